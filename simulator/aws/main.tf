@@ -11,6 +11,13 @@ module "vpc" {
   region = var.region
 }
 
+# EC2 Module - compute instances
+module "ec2" {
+  source             = "./modules/ec2"
+  subnet_id          = module.vpc.public_subnet_id
+  security_group_ids = [module.vpc.security_group_id]
+}
+
 # S3 Module - object storage
 module "s3" {
   source = "./modules/s3"
@@ -0,0 +1,12 @@
+# EC2 Instance - equivalent to google_compute_instance
+resource "aws_instance" "example" {
+  ami                    = var.ami_id
+  instance_type          = "t3.micro"
+  subnet_id              = var.subnet_id
+  vpc_security_group_ids = var.security_group_ids
+
+  tags = {
+    Name        = "example-instance"
+    Environment = "development"
+  }
+}
@@ -0,0 +1,15 @@
+variable "ami_id" {
+  type        = string
+  description = "AMI to launch the instance from"
+  default     = "ami-0c55b159cbfafe1f0" # Mock AMI ID for CI/CD
+}
+
+variable "subnet_id" {
+  type        = string
+  description = "Subnet to launch the instance in"
+}
+
+variable "security_group_ids" {
+  type        = list(string)
+  description = "Security group IDs attached to the instance"
+}
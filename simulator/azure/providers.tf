@@ -15,6 +15,8 @@ terraform {
 
 # Configure the Azure Provider
 provider "azurerm" {
+  subscription_id = var.subscription_id
+
   features {
     resource_group {
       prevent_deletion_if_contains_resources = false
@@ -1,3 +1,9 @@
+variable "subscription_id" {
+  description = "The Azure subscription to deploy into; also used to build import IDs"
+  type        = string
+  default     = "00000000-0000-0000-0000-000000000000"
+}
+
 variable "location" {
   description = "The Azure region where resources will be created"
   type        = string
@@ -0,0 +1,547 @@
+//! # Resource Address Module
+//!
+//! This module parses Terraform resource addresses into a structured form. Addresses
+//! produced by `count` and `for_each` carry an instance key, and resources inside
+//! child modules carry a module path, so treating an address as a plain
+//! `type.name` string loses the information needed to map and import each instance.
+//!
+//! ## Address Grammar
+//!
+//! ```text
+//! address     := { module_call "." } [ "data." ] type "." name [ key ]
+//! module_call := "module." name [ key ]
+//! key         := "[" integer "]" | "[" quoted-string "]"
+//! ```
+//!
+//! ## Examples
+//!
+//! - `aws_s3_bucket.b[0]`
+//! - `module.kms.google_kms_crypto_key.this["app-key"]`
+//! - `module.app["blue"].module.db.aws_db_instance.this`
+//!
+//! ## Relative Addresses
+//!
+//! A plan generated at the root module addresses resources from the root, but when
+//! Terragrunt runs inside a child module the state there addresses them from that
+//! module. `ResourceAddress::strip_module_prefix` rewrites a root address relative to
+//! a module prefix by removing the prefix's module calls:
+//!
+//! - Prefix `module.kms`: `module.kms.google_kms_key_ring.this` becomes `google_kms_key_ring.this`
+//! - Prefix `module.app["blue"].module.db`: `module.app["blue"].module.db.module.replica.aws_db_instance.this`
+//!   becomes `module.replica.aws_db_instance.this`
+//!
+//! Call names and instance keys must match exactly; a prefix call without a key only
+//! matches a call without a key. Addresses outside the prefix have no relative form.
+
+use std::fmt;
+use std::str::FromStr;
+use thiserror::Error;
+
+/// Error returned when an address string can't be parsed
+#[derive(Error, Debug, Clone, PartialEq)]
+pub enum AddressError {
+    /// The address doesn't follow Terraform's address grammar
+    #[error("invalid resource address '{address}': {reason}")]
+    Invalid {
+        /// The address that failed to parse
+        address: String,
+        /// What was wrong with it
+        reason: String,
+    },
+}
+
+/// Instance key of a `count` or `for_each` resource or module call
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub enum InstanceKey {
+    /// Numeric index produced by `count`, e.g. `[0]`
+    Index(u64),
+    /// String key produced by `for_each`, e.g. `["app-key"]`
+    Key(String),
+}
+
+impl fmt::Display for InstanceKey {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            InstanceKey::Index(index) => write!(f, "[{}]", index),
+            InstanceKey::Key(key) => {
+                let quoted = serde_json::to_string(key).map_err(|_| fmt::Error)?;
+                write!(f, "[{}]", quoted)
+            }
+        }
+    }
+}
+
+/// One `module.<name>[key]` segment of a module path
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct ModuleCall {
+    /// Module call name as written in configuration
+    pub name: String,
+    /// Instance key when the module call uses `count` or `for_each`
+    pub key: Option<InstanceKey>,
+}
+
+impl fmt::Display for ModuleCall {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "module.{}", self.name)?;
+        if let Some(key) = &self.key {
+            write!(f, "{}", key)?;
+        }
+        Ok(())
+    }
+}
+
+/// A parsed Terraform resource address
+///
+/// Parsing and formatting round-trip: `address.parse::<ResourceAddress>()?.to_string()`
+/// yields the canonical form of the address.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::address::{InstanceKey, ResourceAddress};
+///
+/// let address: ResourceAddress = r#"module.kms.google_kms_crypto_key.this["app-key"]"#.parse().unwrap();
+/// assert_eq!(address.module_key(), "kms");
+/// assert_eq!(address.resource_type, "google_kms_crypto_key");
+/// assert_eq!(address.name, "this");
+/// assert_eq!(address.key, Some(InstanceKey::Key("app-key".to_string())));
+/// ```
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct ResourceAddress {
+    /// Module calls from the root module down to the resource's module
+    pub module_path: Vec<ModuleCall>,
+    /// True for `data.` sources, which can't be imported
+    pub data: bool,
+    /// Resource type (e.g. "aws_s3_bucket")
+    pub resource_type: String,
+    /// Resource name within its module
+    pub name: String,
+    /// Instance key when the resource uses `count` or `for_each`
+    pub key: Option<InstanceKey>,
+}
+
+impl ResourceAddress {
+    /// Returns the module address (e.g. `module.app["blue"].module.db`), or None at the root
+    pub fn module_address(&self) -> Option<String> {
+        if self.module_path.is_empty() {
+            None
+        } else {
+            Some(format_module_path(&self.module_path))
+        }
+    }
+
+    /// Returns the module key as used in terraform's modules.json (e.g. `app.db`)
+    ///
+    /// Instance keys are dropped because every instance of a module call shares
+    /// the same source directory. The root module's key is the empty string.
+    pub fn module_key(&self) -> String {
+        module_key(&self.module_path)
+    }
+
+    /// Returns the address of the configuration block the instance comes from
+    ///
+    /// Instance keys are dropped from the resource and every module call, since all
+    /// instances share one block in the plan's `configuration`.
+    ///
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::address::ResourceAddress;
+    ///
+    /// let address: ResourceAddress = r#"module.app["blue"].aws_iam_role.this[0]"#.parse().unwrap();
+    /// assert_eq!(address.config_address(), "module.app.aws_iam_role.this");
+    /// ```
+    pub fn config_address(&self) -> String {
+        let mut address = String::new();
+        for call in &self.module_path {
+            address.push_str(&format!("module.{}.", call.name));
+        }
+        if self.data {
+            address.push_str("data.");
+        }
+        address.push_str(&format!("{}.{}", self.resource_type, self.name));
+        address
+    }
+
+    /// Returns the address relative to the module at `prefix`
+    ///
+    /// # Arguments
+    /// * `prefix` - Module path of the execution directory, from the root module
+    ///
+    /// # Returns
+    /// The address with `prefix` removed from its module path, or None if the
+    /// resource isn't inside that module. An empty prefix returns the address unchanged.
+    ///
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::address::{parse_module_address, ResourceAddress};
+    ///
+    /// let address: ResourceAddress = "module.kms.google_kms_key_ring.this".parse().unwrap();
+    /// let prefix = parse_module_address("module.kms").unwrap();
+    /// assert_eq!(address.strip_module_prefix(&prefix).unwrap().to_string(), "google_kms_key_ring.this");
+    /// assert_eq!(address.strip_module_prefix(&parse_module_address("module.storage").unwrap()), None);
+    /// ```
+    pub fn strip_module_prefix(&self, prefix: &[ModuleCall]) -> Option<ResourceAddress> {
+        let module_path = self.module_path.strip_prefix(prefix)?;
+        Some(ResourceAddress {
+            module_path: module_path.to_vec(),
+            ..self.clone()
+        })
+    }
+
+    /// Returns the address with its full module path, given the path of its module
+    ///
+    /// The reverse of `strip_module_prefix`: an address relative to its own module, or
+    /// one missing leading module calls (its module path is a tail of `module_path`),
+    /// gets `module_path` as its module path.
+    ///
+    /// # Returns
+    /// The fully-qualified address, or None if the address names a different module
+    ///
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::address::{parse_module_address, ResourceAddress};
+    ///
+    /// let module_path = parse_module_address("module.a.module.b").unwrap();
+    /// for address in ["google_kms_key_ring.this", "module.b.google_kms_key_ring.this", "module.a.module.b.google_kms_key_ring.this"] {
+    ///     let address: ResourceAddress = address.parse().unwrap();
+    ///     assert_eq!(address.within_module(&module_path).unwrap().to_string(), "module.a.module.b.google_kms_key_ring.this");
+    /// }
+    /// let other: ResourceAddress = "module.c.google_kms_key_ring.this".parse().unwrap();
+    /// assert_eq!(other.within_module(&module_path), None);
+    /// ```
+    pub fn within_module(&self, module_path: &[ModuleCall]) -> Option<ResourceAddress> {
+        if !module_path.ends_with(&self.module_path) {
+            return None;
+        }
+        Some(ResourceAddress {
+            module_path: module_path.to_vec(),
+            ..self.clone()
+        })
+    }
+}
+
+impl fmt::Display for ResourceAddress {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        for call in &self.module_path {
+            write!(f, "{}.", call)?;
+        }
+        if self.data {
+            write!(f, "data.")?;
+        }
+        write!(f, "{}.{}", self.resource_type, self.name)?;
+        if let Some(key) = &self.key {
+            write!(f, "{}", key)?;
+        }
+        Ok(())
+    }
+}
+
+impl FromStr for ResourceAddress {
+    type Err = AddressError;
+
+    fn from_str(address: &str) -> Result<Self, Self::Err> {
+        let mut parser = Parser::new(address);
+        let module_path = parser.module_calls()?;
+
+        let mut data = false;
+        let mut resource_type = parser.name("resource type")?;
+        if resource_type == "data" && parser.peek() == Some('.') {
+            data = true;
+            parser.expect('.')?;
+            resource_type = parser.name("resource type")?;
+        }
+        parser.expect('.')?;
+        let name = parser.name("resource name")?;
+        let key = parser.instance_key()?;
+        parser.end()?;
+
+        Ok(ResourceAddress {
+            module_path,
+            data,
+            resource_type,
+            name,
+            key,
+        })
+    }
+}
+
+/// Parses a module address such as `module.app["blue"].module.db`
+///
+/// # Errors
+/// Returns `AddressError::Invalid` if the string isn't a sequence of module calls
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::address::{module_key, parse_module_address};
+///
+/// let path = parse_module_address(r#"module.app["blue"].module.db"#).unwrap();
+/// assert_eq!(module_key(&path), "app.db");
+/// ```
+pub fn parse_module_address(address: &str) -> Result<Vec<ModuleCall>, AddressError> {
+    let mut parser = Parser::new(address);
+    let mut calls = Vec::new();
+    loop {
+        calls.push(parser.module_call()?);
+        if parser.peek().is_none() {
+            return Ok(calls);
+        }
+        parser.expect('.')?;
+    }
+}
+
+/// Joins module call names with `.` as terraform does for modules.json keys
+pub fn module_key(module_path: &[ModuleCall]) -> String {
+    module_path
+        .iter()
+        .map(|call| call.name.as_str())
+        .collect::<Vec<_>>()
+        .join(".")
+}
+
+/// Formats a module path back into address form
+pub fn format_module_path(module_path: &[ModuleCall]) -> String {
+    module_path
+        .iter()
+        .map(ModuleCall::to_string)
+        .collect::<Vec<_>>()
+        .join(".")
+}
+
+/// Minimal cursor over an address string
+struct Parser<'a> {
+    address: &'a str,
+    position: usize,
+}
+
+impl<'a> Parser<'a> {
+    fn new(address: &'a str) -> Self {
+        Self { address, position: 0 }
+    }
+
+    fn rest(&self) -> &'a str {
+        &self.address[self.position..]
+    }
+
+    fn next(&mut self) -> Option<char> {
+        let c = self.peek()?;
+        self.position += c.len_utf8();
+        Some(c)
+    }
+
+    fn error(&self, reason: impl Into<String>) -> AddressError {
+        AddressError::Invalid {
+            address: self.address.to_string(),
+            reason: reason.into(),
+        }
+    }
+
+    fn peek(&self) -> Option<char> {
+        self.rest().chars().next()
+    }
+
+    fn expect(&mut self, expected: char) -> Result<(), AddressError> {
+        match self.next() {
+            Some(c) if c == expected => Ok(()),
+            Some(c) => Err(self.error(format!("expected '{}' but found '{}'", expected, c))),
+            None => Err(self.error(format!("expected '{}' but reached end of address", expected))),
+        }
+    }
+
+    fn end(&self) -> Result<(), AddressError> {
+        match self.peek() {
+            None => Ok(()),
+            Some(c) => Err(self.error(format!("unexpected '{}' after resource name", c))),
+        }
+    }
+
+    fn name(&mut self, what: &str) -> Result<String, AddressError> {
+        let mut name = String::new();
+        while let Some(c) = self.peek() {
+            if c.is_ascii_alphanumeric() || c == '_' || c == '-' {
+                name.push(c);
+                self.next();
+            } else {
+                break;
+            }
+        }
+        if name.is_empty() {
+            Err(self.error(format!("missing {}", what)))
+        } else {
+            Ok(name)
+        }
+    }
+
+    /// Consumes leading `module.<name>[key].` segments of a resource address
+    fn module_calls(&mut self) -> Result<Vec<ModuleCall>, AddressError> {
+        let mut calls = Vec::new();
+        while self.rest().starts_with("module.") {
+            calls.push(self.module_call()?);
+            self.expect('.')?;
+        }
+        Ok(calls)
+    }
+
+    fn module_call(&mut self) -> Result<ModuleCall, AddressError> {
+        let keyword = self.name("module keyword")?;
+        if keyword != "module" {
+            return Err(self.error(format!("expected 'module' but found '{}'", keyword)));
+        }
+        self.expect('.')?;
+        let name = self.name("module name")?;
+        let key = self.instance_key()?;
+        Ok(ModuleCall { name, key })
+    }
+
+    fn instance_key(&mut self) -> Result<Option<InstanceKey>, AddressError> {
+        if self.peek() != Some('[') {
+            return Ok(None);
+        }
+        self.next();
+
+        let key = if self.peek() == Some('"') {
+            InstanceKey::Key(self.quoted_string()?)
+        } else {
+            let mut digits = String::new();
+            while let Some(c) = self.peek().filter(char::is_ascii_digit) {
+                digits.push(c);
+                self.next();
+            }
+            let index = digits
+                .parse()
+                .map_err(|_| self.error("instance key must be an integer or a quoted string"))?;
+            InstanceKey::Index(index)
+        };
+
+        self.expect(']')?;
+        Ok(Some(key))
+    }
+
+    fn quoted_string(&mut self) -> Result<String, AddressError> {
+        self.expect('"')?;
+        let mut value = String::new();
+        loop {
+            match self.next() {
+                Some('"') => return Ok(value),
+                Some('\\') => match self.next() {
+                    Some('n') => value.push('\n'),
+                    Some('t') => value.push('\t'),
+                    Some(c) => value.push(c),
+                    None => break,
+                },
+                Some(c) => value.push(c),
+                None => break,
+            }
+        }
+        Err(self.error("unterminated instance key string"))
+    }
+}
+
+/// Unit tests for address parsing
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn parse(address: &str) -> ResourceAddress {
+        address.parse().unwrap_or_else(|e| panic!("{}", e))
+    }
+
+    /// **TEST** - Plain root-module resources have no module path or key
+    #[test]
+    fn test_parse_simple_address() {
+        let address = parse("aws_vpc.main");
+        assert!(address.module_path.is_empty());
+        assert_eq!(address.resource_type, "aws_vpc");
+        assert_eq!(address.name, "main");
+        assert_eq!(address.key, None);
+        assert_eq!(address.module_address(), None);
+        assert_eq!(address.module_key(), "");
+    }
+
+    /// **TEST** - `count` indices are parsed as numeric keys
+    #[test]
+    fn test_parse_numeric_index() {
+        let address = parse("aws_s3_bucket.b[0]");
+        assert_eq!(address.key, Some(InstanceKey::Index(0)));
+        assert_eq!(address.to_string(), "aws_s3_bucket.b[0]");
+    }
+
+    /// **TEST** - `for_each` string keys are extracted, including dots and brackets in the key
+    #[test]
+    fn test_parse_string_key() {
+        let address = parse(r#"module.kms.google_kms_crypto_key.this["app-key"]"#);
+        assert_eq!(address.module_key(), "kms");
+        assert_eq!(address.key, Some(InstanceKey::Key("app-key".to_string())));
+
+        let address = parse(r#"google_project_service.apis["run.googleapis.com"]"#);
+        assert_eq!(address.key, Some(InstanceKey::Key("run.googleapis.com".to_string())));
+
+        let address = parse(r#"aws_iam_role.r["a]\"b"]"#);
+        assert_eq!(address.key, Some(InstanceKey::Key("a]\"b".to_string())));
+        assert_eq!(address.to_string(), r#"aws_iam_role.r["a]\"b"]"#);
+    }
+
+    /// **TEST** - Nested and indexed module calls are preserved
+    #[test]
+    fn test_parse_nested_modules() {
+        let raw = r#"module.app["blue"].module.db[1].aws_db_instance.this"#;
+        let address = parse(raw);
+        assert_eq!(address.module_path, vec![
+            ModuleCall { name: "app".to_string(), key: Some(InstanceKey::Key("blue".to_string())) },
+            ModuleCall { name: "db".to_string(), key: Some(InstanceKey::Index(1)) },
+        ]);
+        assert_eq!(address.module_key(), "app.db");
+        assert_eq!(address.module_address().as_deref(), Some(r#"module.app["blue"].module.db[1]"#));
+        assert_eq!(address.to_string(), raw);
+    }
+
+    /// **TEST** - Data sources are flagged
+    #[test]
+    fn test_parse_data_source() {
+        let address = parse("module.net.data.aws_vpc.default");
+        assert!(address.data);
+        assert_eq!(address.resource_type, "aws_vpc");
+        assert_eq!(address.to_string(), "module.net.data.aws_vpc.default");
+    }
+
+    /// **TEST** - Malformed addresses are rejected
+    #[test]
+    fn test_parse_invalid_addresses() {
+        for raw in ["", "aws_vpc", "aws_vpc.", "aws_vpc.main[", "aws_vpc.main[x]", r#"aws_vpc.main["x"#, "aws_vpc.main.extra", "module.kms"] {
+            assert!(raw.parse::<ResourceAddress>().is_err(), "{} should not parse", raw);
+        }
+    }
+
+    /// **TEST** - A single module prefix is stripped; other modules have no relative form
+    #[test]
+    fn test_strip_single_module_prefix() {
+        let prefix = parse_module_address("module.kms").unwrap();
+        let stripped = parse(r#"module.kms.google_kms_crypto_key.this["app-key"]"#).strip_module_prefix(&prefix).unwrap();
+        assert!(stripped.module_path.is_empty());
+        assert_eq!(stripped.to_string(), r#"google_kms_crypto_key.this["app-key"]"#);
+
+        assert_eq!(parse("module.kms_old.google_kms_key_ring.this").strip_module_prefix(&prefix), None);
+        assert_eq!(parse("google_kms_key_ring.this").strip_module_prefix(&prefix), None);
+        assert_eq!(parse("aws_vpc.main").strip_module_prefix(&[]), Some(parse("aws_vpc.main")));
+    }
+
+    /// **TEST** - Nested prefixes are stripped call by call and instance keys must match
+    #[test]
+    fn test_strip_nested_module_prefix() {
+        let prefix = parse_module_address(r#"module.app["blue"].module.db"#).unwrap();
+        let address = parse(r#"module.app["blue"].module.db.module.replica.aws_db_instance.this"#);
+        assert_eq!(address.strip_module_prefix(&prefix).unwrap().to_string(), "module.replica.aws_db_instance.this");
+        assert_eq!(parse(r#"module.app["blue"].module.db.aws_db_instance.this"#).strip_module_prefix(&prefix).unwrap().to_string(), "aws_db_instance.this");
+
+        assert_eq!(parse(r#"module.app["green"].module.db.aws_db_instance.this"#).strip_module_prefix(&prefix), None);
+        assert_eq!(parse("module.app.module.db.aws_db_instance.this").strip_module_prefix(&prefix), None);
+        assert_eq!(parse(r#"module.app["blue"].aws_db_instance.this"#).strip_module_prefix(&prefix), None);
+    }
+
+    /// **TEST** - Module addresses parse into module keys
+    #[test]
+    fn test_parse_module_address() {
+        let path = parse_module_address(r#"module.app["blue"].module.db"#).unwrap();
+        assert_eq!(module_key(&path), "app.db");
+        assert_eq!(format_module_path(&path), r#"module.app["blue"].module.db"#);
+        assert!(parse_module_address("kms").is_err());
+    }
+}
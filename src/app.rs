@@ -7,17 +7,47 @@
 //! ## Supported File Types
 //! 
 //! - **Modules File** (`modules.json`): Generated by terragrunt, contains module metadata
-//! - **Plan File** (`.json`): Generated by terraform plan with `-out` and converted to JSON
+//! - **Plan File** (`.json`): Generated by terraform plan with `-out` and converted to JSON;
+//!   the path `-` reads the plan from stdin instead, and `gs://`, `http(s)://` and
+//!   `file://` inputs are fetched first (see `fetch`). Several plans, or a glob of
+//!   them, are merged into one (see `merge`)
+//! - **Mapping File** (`.json`): Optional explicit import IDs keyed by address or glob
+//! 
+//! ## Library Entry Point
+//! 
+//! `import` runs the whole workflow — load the plan and modules, resolve import IDs,
+//! and execute (or print) the import commands — from a single `ImportConfig`. The CLI's
+//! import mode is a thin wrapper around it, so embedding applications get exactly the
+//! same behaviour and can inspect the returned `Report` directly. `preview` takes the
+//! same config and returns what `import` would do, without running any import, and
+//! `emit_import_blocks` writes those decisions as Terraform `import` blocks, and
+//! `emit_import_script` as a bash script of import commands.
 //! 
 //! ## Error Handling
 //! 
 //! All functions use `anyhow::Result` for comprehensive error reporting with context.
 //! Errors include file path information to help with debugging.
 
-use crate::importer::{ModulesFile, PlanFile};
+use crate::builders::{BuilderFile, ImportIdBuilderRegistry, TemplateBuilder};
+use crate::commands::{CommandRunner, ImportOptions, SystemCommandRunner};
+use crate::directories::DirectoryMap;
+use crate::fetch::{CommandPlanFetcher, PlanFetcher, PlanLocation};
+use crate::import_blocks::write_import_blocks;
+use crate::import_script::write_import_script;
+use crate::importer::{execute_or_print_imports, map_resources_to_modules, plan_imports, ImportDecision, ModulesFile, PlanFile, PlannedImport};
+use crate::logging::Logger;
+use crate::mapping::{ImportIdMappings, MappingFile};
+use crate::merge::merge_plans;
+use crate::preview::Preview;
+use crate::reporting::Report;
+use crate::utils::collect_resources;
 use anyhow::{Context, Result};
 use std::fs;
-use std::path::Path;
+use std::io::{self, Read};
+use std::path::{Path, PathBuf};
+
+/// Plan path that means "read the plan from stdin"
+pub const STDIN_PLAN_PATH: &str = "-";
 
 /// Loads and parses the modules file from the given path
 /// 
@@ -61,18 +91,23 @@ pub fn load_modules<P: AsRef<Path>>(path: P) -> Result<ModulesFile> {
 /// 
 /// The plan file should be a JSON representation of a Terraform plan, typically
 /// generated using `terraform plan -out=plan.tfplan` followed by 
-/// `terraform show -json plan.tfplan > plan.json`.
+/// `terraform show -json plan.tfplan > plan.json`. A path of `-` (`STDIN_PLAN_PATH`)
+/// reads the plan from stdin, so it can be piped straight from `terraform show -json`.
+/// Remote inputs such as `gs://bucket/plan.json` are downloaded with `gcloud` or `curl`
+/// (see `load_plan_with_fetcher`). Files, stdin and downloads are all parsed by `read_plan`.
 /// 
 /// # Arguments
-/// * `path` - Path to the plan JSON file
+/// * `path` - Path or URL of the plan JSON, or `-` for stdin
 /// 
 /// # Returns
 /// Parsed PlanFile structure containing planned resources and provider schemas
 /// 
 /// # Errors
 /// - File not found or not readable
+/// - Remote plan can't be fetched, or uses an unsupported scheme (see `FetchError`)
 /// - Invalid JSON format
 /// - JSON doesn't match expected PlanFile structure
+/// - Unsupported `format_version` (see `PlanFormatVersion`)
 /// 
 /// # Example
 /// ```no_run
@@ -85,16 +120,130 @@ pub fn load_modules<P: AsRef<Path>>(path: P) -> Result<ModulesFile> {
 /// # }
 /// ```
 pub fn load_plan<P: AsRef<Path>>(path: P) -> Result<PlanFile> {
+    load_plan_with_fetcher(path, &CommandPlanFetcher::new(&SystemCommandRunner))
+}
+
+/// Same as `load_plan`, downloading remote plans with `fetcher`
+/// 
+/// Local paths and `-` never reach the fetcher.
+/// 
+/// # Errors
+/// Same as `load_plan`
+/// 
+/// # Example
+/// ```
+/// use terragrunt_import_from_plan::app::load_plan_with_fetcher;
+/// use terragrunt_import_from_plan::fetch::{FetchError, PlanFetcher, RemotePlan};
+/// 
+/// struct FixtureFetcher;
+/// 
+/// impl PlanFetcher for FixtureFetcher {
+///     fn fetch(&self, _plan: &RemotePlan) -> Result<String, FetchError> {
+///         Ok(r#"{"format_version": "1.2", "terraform_version": "1.9.0"}"#.to_string())
+///     }
+/// }
+/// 
+/// let plan = load_plan_with_fetcher("gs://ci-plans/prod/plan.json", &FixtureFetcher).unwrap();
+/// assert_eq!(plan.terraform_version, "1.9.0");
+/// ```
+pub fn load_plan_with_fetcher<P: AsRef<Path>>(path: P, fetcher: &dyn PlanFetcher) -> Result<PlanFile> {
     let path = path.as_ref();
-    let content = fs::read_to_string(path)
-        .with_context(|| format!("Failed to read plan file: {}", path.display()))?;
-    
-    let plan: PlanFile = serde_json::from_str(&content)
-        .with_context(|| format!("Failed to parse plan JSON in file: {}", path.display()))?;
+    if path == Path::new(STDIN_PLAN_PATH) {
+        return read_plan(io::stdin().lock(), "stdin");
+    }
+
+    let location = match path.to_str() {
+        Some(input) => PlanLocation::parse(input)?,
+        None => PlanLocation::Local(path.to_path_buf()),
+    };
+    match location {
+        PlanLocation::Local(path) => {
+            let file = fs::File::open(&path)
+                .with_context(|| format!("Failed to read plan file: {}", path.display()))?;
+            read_plan(file, &format!("file: {}", path.display()))
+        }
+        PlanLocation::Remote(remote) => {
+            let content = fetcher.fetch(&remote)?;
+            read_plan(content.as_bytes(), &remote.to_string())
+        }
+    }
+}
+
+/// Reads and parses a plan from any reader
+/// 
+/// # Arguments
+/// * `reader` - Source of the plan JSON, read to the end
+/// * `source` - Describes the reader in error messages, e.g. `stdin` or `file: plan.json`
+/// 
+/// # Returns
+/// Parsed and normalized PlanFile
+/// 
+/// # Errors
+/// Same as `load_plan`
+/// 
+/// # Example
+/// ```
+/// use terragrunt_import_from_plan::app::read_plan;
+/// 
+/// let json = r#"{"format_version": "1.2", "terraform_version": "1.9.0"}"#;
+/// let plan = read_plan(json.as_bytes(), "inline").unwrap();
+/// assert_eq!(plan.terraform_version, "1.9.0");
+/// ```
+pub fn read_plan<R: Read>(mut reader: R, source: &str) -> Result<PlanFile> {
+    let mut content = String::new();
+    reader.read_to_string(&mut content)
+        .with_context(|| format!("Failed to read plan from {}", source))?;
+
+    let mut plan: PlanFile = serde_json::from_str(&content)
+        .with_context(|| format!("Failed to parse plan JSON in {}", source))?;
+
+    plan.normalize()
+        .with_context(|| format!("Failed to interpret plan from {}", source))?;
     
     Ok(plan)
 }
 
+/// Expands plan inputs that are glob patterns into the plan files they match
+/// 
+/// Patterns (`*`, `?` or `[`) are expanded in sorted order; `-`, URLs and plain paths
+/// are kept as they are, so a missing plain path is still reported by `load_plan`.
+/// 
+/// # Errors
+/// Returns an error if a pattern is invalid or matches no files
+/// 
+/// # Example
+/// ```
+/// use std::path::PathBuf;
+/// use terragrunt_import_from_plan::app::expand_plan_inputs;
+/// 
+/// let inputs = ["tests/fixtures/merge/*.json".to_string(), "-".to_string()];
+/// assert_eq!(expand_plan_inputs(&inputs).unwrap(), [
+///     PathBuf::from("tests/fixtures/merge/kms.json"),
+///     PathBuf::from("tests/fixtures/merge/kms_storage.json"),
+///     PathBuf::from("-"),
+/// ]);
+/// ```
+pub fn expand_plan_inputs(inputs: &[String]) -> Result<Vec<PathBuf>> {
+    let mut paths = Vec::new();
+    for input in inputs {
+        let is_pattern = input.contains(['*', '?', '[']) && matches!(PlanLocation::parse(input), Ok(PlanLocation::Local(_)));
+        if !is_pattern {
+            paths.push(PathBuf::from(input));
+            continue;
+        }
+        let mut matches = glob::glob(input)
+            .with_context(|| format!("Invalid plan pattern: {}", input))?
+            .collect::<std::result::Result<Vec<_>, _>>()
+            .with_context(|| format!("Failed to expand plan pattern: {}", input))?;
+        if matches.is_empty() {
+            anyhow::bail!("No plan files match {}", input);
+        }
+        matches.sort();
+        paths.extend(matches);
+    }
+    Ok(paths)
+}
+
 /// Loads both modules and plan files with proper error context
 /// 
 /// This is a convenience function that loads both required input files for the
@@ -137,6 +286,420 @@ pub fn load_input_files<P1: AsRef<Path>, P2: AsRef<Path>>(
     Ok((modules, plan))
 }
 
+/// Loads an import ID mapping file and checks it against the plan
+/// 
+/// Entries whose address (or glob) matches no resource in the plan are reported
+/// with a warning, since they usually indicate a typo or a stale mapping. Environment
+/// variable references in the IDs are expanded from the process environment (see
+/// `mapping`), so one file can serve several environments.
+/// 
+/// # Arguments
+/// * `path` - Path to the mapping JSON or YAML file
+/// * `plan` - Parsed plan the mappings will be applied to
+/// * `logger` - Warned about entries that match no resource
+/// 
+/// # Returns
+/// Indexed mappings ready for lookup during import ID resolution
+/// 
+/// # Errors
+/// - File not found or not readable
+/// - Invalid JSON (or, for `.yaml` and `.yml` files, YAML) format or structure
+/// - Invalid glob pattern in an entry
+/// - An ID references an unset environment variable without a default
+/// 
+/// # Example
+/// ```no_run
+/// use terragrunt_import_from_plan::app::{load_mappings, load_plan};
+/// use terragrunt_import_from_plan::logging::StdLogger;
+/// 
+/// # fn main() -> Result<(), Box<dyn std::error::Error>> {
+/// let plan = load_plan("plan.json")?;
+/// let mappings = load_mappings("mappings.json", &plan, &StdLogger::default())?;
+/// println!("Override for bucket: {:?}", mappings.lookup("aws_s3_bucket.logs"));
+/// # Ok(())
+/// # }
+/// ```
+pub fn load_mappings<P: AsRef<Path>>(path: P, plan: &PlanFile, logger: &dyn Logger) -> Result<ImportIdMappings> {
+    let mappings = read_mappings(path)?;
+
+    let mut resources = Vec::new();
+    if let Some(planned_values) = &plan.planned_values {
+        collect_resources(&planned_values.root_module, &mut resources);
+    }
+    let addresses: Vec<String> = resources.iter().map(|resource| resource.address.clone()).collect();
+    for unused in mappings.unused_entries(&addresses) {
+        logger.warn(&format!("Mapping '{}' does not match any resource in the plan", unused));
+    }
+
+    Ok(mappings)
+}
+
+/// Loads and indexes a mapping file without checking it against a plan
+/// 
+/// Used when one mapping file is shared by several plans, such as the units of a
+/// `PlanSet`, where an entry matching nothing in one plan is expected. Environment
+/// variables are expanded as by `load_mappings`.
+/// 
+/// # Arguments
+/// * `path` - Path to the mapping JSON or YAML file
+/// 
+/// # Returns
+/// Indexed mappings ready for lookup during import ID resolution
+/// 
+/// # Errors
+/// - File not found or not readable
+/// - Invalid JSON (or, for `.yaml` and `.yml` files, YAML) format or structure
+/// - Invalid glob pattern in an entry
+/// - An ID references an unset environment variable without a default
+pub fn read_mappings<P: AsRef<Path>>(path: P) -> Result<ImportIdMappings> {
+    let path = path.as_ref();
+    let content = fs::read_to_string(path)
+        .with_context(|| format!("Failed to read mapping file: {}", path.display()))?;
+
+    let mut file: MappingFile = if matches!(path.extension().and_then(|extension| extension.to_str()), Some("yaml" | "yml")) {
+        serde_yaml::from_str(&content)
+            .with_context(|| format!("Failed to parse mapping YAML in file: {}", path.display()))?
+    } else {
+        serde_json::from_str(&content)
+            .with_context(|| format!("Failed to parse mapping JSON in file: {}", path.display()))?
+    };
+    file.expand_variables(|name| std::env::var(name).ok())
+        .with_context(|| format!("Invalid mapping file: {}", path.display()))?;
+
+    ImportIdMappings::from_file(file)
+        .with_context(|| format!("Invalid mapping file: {}", path.display()))
+}
+
+/// Loads template builders from a config file into `builders`
+/// 
+/// Each template replaces the built-in builder of its resource type, if there is one;
+/// see `builders::template` for the file format.
+/// 
+/// # Arguments
+/// * `path` - Path to the builder config JSON file
+/// * `builders` - Registry the builders are registered in
+/// 
+/// # Returns
+/// The number of builders loaded
+/// 
+/// # Errors
+/// - File not found or not readable
+/// - Invalid JSON format or structure
+/// - A malformed template, or one without placeholders
+/// 
+/// # Example
+/// ```no_run
+/// use terragrunt_import_from_plan::app::load_builders;
+/// use terragrunt_import_from_plan::builders::ImportIdBuilderRegistry;
+/// 
+/// # fn main() -> Result<(), Box<dyn std::error::Error>> {
+/// let mut builders = ImportIdBuilderRegistry::default();
+/// let count = load_builders("builders.json", &mut builders)?;
+/// println!("Loaded {} builder(s); {} types supported", count, builders.resource_types().len());
+/// # Ok(())
+/// # }
+/// ```
+pub fn load_builders<P: AsRef<Path>>(path: P, builders: &mut ImportIdBuilderRegistry) -> Result<usize> {
+    let path = path.as_ref();
+    let content = fs::read_to_string(path)
+        .with_context(|| format!("Failed to read builder file: {}", path.display()))?;
+
+    let file: BuilderFile = serde_json::from_str(&content)
+        .with_context(|| format!("Failed to parse builder JSON in file: {}", path.display()))?;
+    let count = file.builders.len();
+    for (resource_type, template) in file.builders {
+        let builder = TemplateBuilder::parse(&resource_type, &template)
+            .with_context(|| format!("Invalid builder for {} in file: {}", resource_type, path.display()))?;
+        builders.register(&resource_type, builder);
+    }
+    Ok(count)
+}
+
+/// Everything needed for one import run through `import`
+/// 
+/// # Fields
+/// - `plan_path`: Terraform plan JSON to import from: a path, `-` for stdin, or a `gs://`, `http(s)://` or `file://` URL
+/// - `additional_plan_paths`: Further plans merged into `plan_path`'s (see `merge`)
+/// - `modules_path`: Terragrunt `modules.json` mapping resources to module directories
+/// - `module_root`: Directory the module directories are relative to
+/// - `mapping_path`: Optional mapping file with explicit import IDs
+/// - `directories`: Working directory rules by address prefix, ahead of the mapping file's
+/// - `builders`: Import ID builders; register custom builders before running
+/// - `options`: Execution knobs such as dry-run, filters, retries and workers
+/// - `verbose`: Print per-resource progress
+/// 
+/// # Examples
+/// ```no_run
+/// use terragrunt_import_from_plan::app::{import, ImportConfig};
+/// use terragrunt_import_from_plan::filter::ResourceFilter;
+/// 
+/// # fn main() -> anyhow::Result<()> {
+/// let mut config = ImportConfig::new("plan.json", "modules.json");
+/// config.module_root = "live/prod".into();
+/// config.options.dry_run = true;
+/// config.options.filter = ResourceFilter::new(&["module.kms.*".to_string()], &[])?;
+/// 
+/// let report = import(&config)?;
+/// assert!(report.success);
+/// for command in report.commands() {
+///     println!("{}", command);
+/// }
+/// # Ok(())
+/// # }
+/// ```
+#[derive(Debug)]
+pub struct ImportConfig {
+    /// Terraform plan JSON to import from; remote plans are fetched through the command runner
+    pub plan_path: PathBuf,
+    /// Further plans merged into the first one, in order; the first occurrence of an address wins
+    pub additional_plan_paths: Vec<PathBuf>,
+    /// Terragrunt modules file
+    pub modules_path: PathBuf,
+    /// Base directory for module directories (default: current directory)
+    pub module_root: PathBuf,
+    /// Mapping file with explicit import IDs, checked against the plan
+    pub mapping_path: Option<PathBuf>,
+    /// Working directory rules by address prefix; they take precedence over the mapping
+    /// file's `directories` and over `modules.json` (see `directories`)
+    pub directories: DirectoryMap,
+    /// Import ID builders (default: all built-in builders)
+    pub builders: ImportIdBuilderRegistry,
+    /// Execution options
+    pub options: ImportOptions,
+    /// Print per-resource progress
+    pub verbose: bool,
+}
+
+impl ImportConfig {
+    /// Creates a config for `plan_path` and `modules_path` with default settings
+    pub fn new<P1: AsRef<Path>, P2: AsRef<Path>>(plan_path: P1, modules_path: P2) -> Self {
+        Self {
+            plan_path: plan_path.as_ref().to_path_buf(),
+            additional_plan_paths: Vec::new(),
+            modules_path: modules_path.as_ref().to_path_buf(),
+            module_root: PathBuf::from("."),
+            mapping_path: None,
+            directories: DirectoryMap::default(),
+            builders: ImportIdBuilderRegistry::default(),
+            options: ImportOptions::default(),
+            verbose: false,
+        }
+    }
+}
+
+/// Runs a complete import: loads the inputs, then imports every planned create
+/// 
+/// Terragrunt is invoked through the real `SystemCommandRunner`; use
+/// `import_with_runner` to substitute it.
+/// 
+/// # Arguments
+/// * `config` - Inputs and settings for the run
+/// 
+/// # Returns
+/// Report of every resource's outcome. Failed imports are recorded in the report
+/// (`report.success` is false) rather than returned as an error.
+/// 
+/// # Errors
+/// - The plan, modules or mapping file can't be loaded
+/// - Two addresses resolve to the same import ID (see `RunError::DuplicateImportId`)
+/// - The plan replaces a resource (see `RunError::PlannedReplacement`)
+/// - A state backup fails (see `RunError::StateBackup`)
+/// - The requested workspace doesn't exist (see `RunError::Workspace`)
+/// - The binary or a working directory fails the preflight checks (see `RunError::Preflight`)
+pub fn import(config: &ImportConfig) -> Result<Report> {
+    import_with_runner(config, &SystemCommandRunner)
+}
+
+/// Same as `import`, running imports, state commands and remote plan downloads through `runner`
+/// 
+/// # Errors
+/// Same as `import`
+pub fn import_with_runner(config: &ImportConfig, runner: &dyn CommandRunner) -> Result<Report> {
+    let (modules, plan, mappings) = load_config_inputs(config, runner)?;
+
+    let directories = config_directories(config, &mappings);
+    let mut resource_map = map_resources_to_modules(&modules.modules, &plan, &*config.options.logger);
+    directories.apply(&plan, &mut resource_map);
+    let module_root = config.module_root.to_string_lossy();
+    let report = execute_or_print_imports(
+        &resource_map,
+        &plan,
+        &mappings,
+        &config.builders,
+        &config.options,
+        config.verbose,
+        &module_root,
+        runner,
+    )?;
+    Ok(report)
+}
+
+/// Previews an import: resolves every planned create's import ID and decision, but
+/// runs nothing except the state pre-check
+/// 
+/// Honours the same filters, mappings and options as `import`;
+/// `config.options.dry_run` makes no difference.
+/// 
+/// # Arguments
+/// * `config` - Inputs and settings, as for `import`
+/// 
+/// # Returns
+/// One row per planned create, including filtered-out resources, sorted by address
+/// 
+/// # Errors
+/// - The plan, modules or mapping file can't be loaded
+/// - Two addresses resolve to the same import ID (see `RunError::DuplicateImportId`)
+/// - The plan replaces a resource (see `RunError::PlannedReplacement`)
+/// - The requested workspace doesn't exist (see `RunError::Workspace`)
+pub fn preview(config: &ImportConfig) -> Result<Preview> {
+    preview_with_runner(config, &SystemCommandRunner)
+}
+
+/// Same as `preview`, running state commands (and remote plan downloads) through `runner`
+/// 
+/// # Errors
+/// Same as `preview`
+pub fn preview_with_runner(config: &ImportConfig, runner: &dyn CommandRunner) -> Result<Preview> {
+    Ok(Preview::new(plan_config(config, runner)?))
+}
+
+/// Writes a Terraform `import` block for every resource `import` would import, instead
+/// of importing anything
+/// 
+/// The decisions are the same as for `preview`; see `import_blocks` for the output.
+/// With `config.options.workspace` set, the workspace is selected before state is
+/// checked, and stays selected for the `apply` that adopts the blocks.
+/// 
+/// # Arguments
+/// * `config` - Inputs and settings, as for `import`
+/// * `path` - File the blocks are written to, e.g. `imports.tf`
+/// 
+/// # Returns
+/// The number of import blocks written, and of resources left out for lack of an import ID builder
+/// 
+/// # Errors
+/// - Same as `preview`
+/// - The file can't be written
+pub fn emit_import_blocks(config: &ImportConfig, path: &Path) -> Result<EmitSummary> {
+    emit_import_blocks_with_runner(config, path, &SystemCommandRunner)
+}
+
+/// Same as `emit_import_blocks`, running state commands (and remote plan downloads) through `runner`
+/// 
+/// # Errors
+/// Same as `emit_import_blocks`
+pub fn emit_import_blocks_with_runner(config: &ImportConfig, path: &Path, runner: &dyn CommandRunner) -> Result<EmitSummary> {
+    let planned = plan_config(config, runner)?;
+    let written = write_import_blocks(path, &planned)?;
+    Ok(EmitSummary::new(written, &planned))
+}
+
+/// Writes a bash script with the import command of every resource `import` would
+/// import, instead of importing anything
+/// 
+/// The decisions are the same as for `preview`; see `import_script` for the output.
+/// With `config.options.workspace` set, the workspace is selected before state is
+/// checked, and every line of the script selects it before importing.
+/// 
+/// # Arguments
+/// * `config` - Inputs and settings, as for `import`
+/// * `path` - File the script is written to, e.g. `import.sh`
+/// 
+/// # Returns
+/// The number of import commands written, and of resources left out for lack of an import ID builder
+/// 
+/// # Errors
+/// - Same as `preview`
+/// - The file can't be written
+pub fn emit_import_script(config: &ImportConfig, path: &Path) -> Result<EmitSummary> {
+    emit_import_script_with_runner(config, path, &SystemCommandRunner)
+}
+
+/// Same as `emit_import_script`, running state commands (and remote plan downloads) through `runner`
+/// 
+/// # Errors
+/// Same as `emit_import_script`
+pub fn emit_import_script_with_runner(config: &ImportConfig, path: &Path, runner: &dyn CommandRunner) -> Result<EmitSummary> {
+    let planned = plan_config(config, runner)?;
+    let written = write_import_script(path, &planned, config.options.workspace.as_deref())?;
+    Ok(EmitSummary::new(written, &planned))
+}
+
+/// Counts of what `emit_import_blocks` or `emit_import_script` wrote
+/// 
+/// # Fields
+/// - `written`: Import blocks or commands written to the file
+/// - `unsupported`: Resources left out because no import ID builder covers them
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct EmitSummary {
+    /// Number of import blocks or commands written
+    pub written: usize,
+    /// Number of planned creates without an import ID builder, which got no entry
+    pub unsupported: usize,
+}
+
+impl EmitSummary {
+    /// Summarizes `planned` after `written` of its imports were written
+    fn new(written: usize, planned: &[PlannedImport]) -> Self {
+        let unsupported = planned.iter().filter(|planned| planned.decision == ImportDecision::Unsupported).count();
+        Self { written, unsupported }
+    }
+}
+
+/// Loads the inputs named by `config` and generates every planned create's decision
+fn plan_config(config: &ImportConfig, runner: &dyn CommandRunner) -> Result<Vec<PlannedImport>> {
+    let (modules, plan, mappings) = load_config_inputs(config, runner)?;
+
+    let directories = config_directories(config, &mappings);
+    let mut resource_map = map_resources_to_modules(&modules.modules, &plan, &*config.options.logger);
+    directories.apply(&plan, &mut resource_map);
+    let module_root = config.module_root.to_string_lossy();
+    let planned = plan_imports(
+        &resource_map,
+        &plan,
+        &mappings,
+        &config.builders,
+        &config.options,
+        &module_root,
+        runner,
+    )?;
+    Ok(planned)
+}
+
+/// Returns `config`'s directory rules followed by the mapping file's
+fn config_directories(config: &ImportConfig, mappings: &ImportIdMappings) -> DirectoryMap {
+    let mut directories = config.directories.clone();
+    directories.extend(mappings.directories().rules().cloned());
+    directories
+}
+
+/// Loads the modules file, plan and (optional) mapping file named by `config`, fetching
+/// a remote plan through `runner`
+/// 
+/// Additional plans are loaded the same way and merged into the first.
+fn load_config_inputs(config: &ImportConfig, runner: &dyn CommandRunner) -> Result<(ModulesFile, PlanFile, ImportIdMappings)> {
+    let modules = load_modules(&config.modules_path)
+        .context("Failed to load modules file")
+        .context("Failed to load input files")?;
+    let fetcher = CommandPlanFetcher::new(runner);
+    let mut plans = Vec::new();
+    for path in std::iter::once(&config.plan_path).chain(&config.additional_plan_paths) {
+        let plan = load_plan_with_fetcher(path, &fetcher)
+            .context("Failed to load plan file")
+            .context("Failed to load input files")?;
+        plans.push((path.display().to_string(), plan));
+    }
+    if plans.len() > 1 {
+        config.options.logger.info(&format!("📚 Merging {} plan files", plans.len()));
+    }
+    let plan = merge_plans(plans, &config.builders, &*config.options.logger).expect("at least one plan is loaded");
+    let mappings = match &config.mapping_path {
+        Some(path) => load_mappings(path, &plan, &*config.options.logger)?,
+        None => ImportIdMappings::new(),
+    };
+    Ok((modules, plan, mappings))
+}
+
 /// Unit tests for file loading functionality
 /// 
 /// These tests verify proper loading of modules and plan files, as well as
@@ -240,6 +803,23 @@ mod tests {
         assert!(error_string.contains("Failed to parse plan JSON"));
     }
 
+    /// **TEST** - Verifies that readers of any kind share the file parsing path
+    /// 
+    /// Feeds the GCP fixture through an in-memory cursor, as stdin would be read,
+    /// and checks it parses identically to loading the file by path.
+    #[test]
+    fn test_read_plan_from_reader() {
+        let bytes = fs::read("tests/fixtures/gcp/out.json").unwrap();
+        let from_reader = read_plan(io::Cursor::new(bytes), "stdin").unwrap();
+        let from_file = load_plan("tests/fixtures/gcp/out.json").unwrap();
+
+        assert_eq!(from_reader.format_version, from_file.format_version);
+        assert_eq!(from_reader.resource_changes.as_ref().map(Vec::len), from_file.resource_changes.as_ref().map(Vec::len));
+
+        let err = read_plan(io::Cursor::new(b"not json".to_vec()), "stdin").unwrap_err();
+        assert!(err.to_string().contains("Failed to parse plan JSON in stdin"), "{}", err);
+    }
+
     /// **TEST** - Verifies successful loading of both input files
     /// 
     /// Tests the convenience function that loads both modules and plan files
@@ -283,4 +863,29 @@ mod tests {
         let error_string = result.unwrap_err().to_string();
         assert!(error_string.contains("Failed to load modules file"));
     }
-} 
\ No newline at end of file
+
+    /// **TEST** - Verifies a YAML mapping file reads the same as its JSON equivalent
+    #[test]
+    fn test_read_mappings_yaml() {
+        let json = read_mappings("tests/fixtures/mappings/gcp.json").unwrap();
+        let yaml = read_mappings("tests/fixtures/mappings/gcp.yaml").unwrap();
+        for address in [
+            "module.storage.google_storage_bucket.example",
+            "module.cloud_functions.google_storage_bucket.source",
+            "module.kms.google_kms_key_ring.example",
+            "module.kms.google_kms_crypto_key.example",
+        ] {
+            assert_eq!(yaml.lookup(address), json.lookup(address), "{}", address);
+        }
+    }
+
+    /// **TEST** - Verifies invalid YAML is reported as a YAML error, not a JSON one
+    #[test]
+    fn test_read_mappings_invalid_yaml() {
+        let mut temp_file = tempfile::Builder::new().suffix(".yml").tempfile().unwrap();
+        writeln!(temp_file, "mappings:\n  - address: [aws_s3_bucket.logs").unwrap();
+
+        let error_string = format!("{:#}", read_mappings(temp_file.path()).unwrap_err());
+        assert!(error_string.contains("Failed to parse mapping YAML"), "{}", error_string);
+    }
+}
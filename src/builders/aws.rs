@@ -0,0 +1,74 @@
+//! # AWS Import ID Builders
+//! 
+//! Builders for common AWS resource types. Most AWS resources are imported by a
+//! single identifying attribute, so each builder reads that attribute from the planned
+//! values. Formats follow the "Import" section of each resource's page in the AWS
+//! provider documentation.
+//! 
+//! ## Supported Resource Types
+//! 
+//! - `aws_s3_bucket`: `{bucket}`
+//! - `aws_iam_role`: `{name}`
+//! - `aws_instance`: `{id}`
+//! - `aws_security_group`: `{id}`
+//! 
+//! ## Computed IDs
+//! 
+//! Instance and security group IDs are assigned by AWS, so a plan that creates them
+//! has no `id` yet. Their builders fail with `ImportIdError::MissingAttribute` in that
+//! case; supply the real ID through a mapping file instead.
+
+use serde_json::{Map, Value};
+use super::traits::{required_attribute, ImportIdBuilder, ImportIdError};
+
+/// Builds `{bucket}` for `aws_s3_bucket`
+pub struct AwsS3BucketBuilder;
+
+impl ImportIdBuilder for AwsS3BucketBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        Ok(required_attribute(attributes, "aws_s3_bucket", "bucket")?.to_string())
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{bucket}"]
+    }
+}
+
+/// Builds `{name}` for `aws_iam_role`
+pub struct AwsIamRoleBuilder;
+
+impl ImportIdBuilder for AwsIamRoleBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        Ok(required_attribute(attributes, "aws_iam_role", "name")?.to_string())
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{name}"]
+    }
+}
+
+/// Builds `{id}` (e.g. `i-0abc123`) for `aws_instance`
+pub struct AwsInstanceBuilder;
+
+impl ImportIdBuilder for AwsInstanceBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        Ok(required_attribute(attributes, "aws_instance", "id")?.to_string())
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{id}"]
+    }
+}
+
+/// Builds `{id}` (e.g. `sg-0abc123`) for `aws_security_group`
+pub struct AwsSecurityGroupBuilder;
+
+impl ImportIdBuilder for AwsSecurityGroupBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        Ok(required_attribute(attributes, "aws_security_group", "id")?.to_string())
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{id}"]
+    }
+}
@@ -0,0 +1,107 @@
+//! # Azure Import ID Builders
+//!
+//! Builders for common Azure resource types. Azure resources are imported by their full
+//! Azure Resource Manager ID, which starts with the subscription and resource group the
+//! resource lives in. Formats follow the "Import" section of each resource's page in the
+//! azurerm provider documentation.
+//!
+//! ## Supported Resource Types
+//!
+//! - `azurerm_resource_group`: `/subscriptions/{subscription_id}/resourceGroups/{name}`
+//! - `azurerm_storage_account`: `/subscriptions/{subscription_id}/resourceGroups/{resource_group_name}/providers/Microsoft.Storage/storageAccounts/{name}`
+//! - `azurerm_key_vault`: `/subscriptions/{subscription_id}/resourceGroups/{resource_group_name}/providers/Microsoft.KeyVault/vaults/{name}`
+//!
+//! ## Subscription ID
+//!
+//! None of these resources has a subscription attribute: the provider takes it from its
+//! own configuration. The builders read it from a `subscription_id` attribute, falling
+//! back to the subscription in the resource's `id` if that is already known. The importer
+//! fills in `subscription_id` from the plan's `subscription_id` variable or, with
+//! `resolve_unknown_from_state`, from the IDs of resources already in the module's state.
+//! Without either, the builders fail with `ImportIdError::MissingAttribute`.
+
+use serde_json::{Map, Value};
+use super::traits::{required_attribute, ImportIdBuilder, ImportIdError};
+
+/// Attribute the Azure builders read the subscription ID from
+pub const SUBSCRIPTION_ID_ATTRIBUTE: &str = "subscription_id";
+
+/// Returns the subscription ID of an Azure resource ID
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::builders::azure::subscription_from_resource_id;
+///
+/// let id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example-rg";
+/// assert_eq!(subscription_from_resource_id(id), Some("00000000-0000-0000-0000-000000000000"));
+/// assert_eq!(subscription_from_resource_id("projects/p/buckets/b"), None);
+/// ```
+pub fn subscription_from_resource_id(id: &str) -> Option<&str> {
+    let mut segments = id.strip_prefix("/subscriptions/")?.split('/');
+    segments.next().filter(|subscription| !subscription.is_empty())
+}
+
+/// Reads the subscription ID for an import ID, see the module documentation
+fn subscription_id<'a>(attributes: &'a Map<String, Value>, resource_type: &str) -> Result<&'a str, ImportIdError> {
+    required_attribute(attributes, resource_type, SUBSCRIPTION_ID_ATTRIBUTE).or_else(|error| {
+        attributes
+            .get("id")
+            .and_then(Value::as_str)
+            .and_then(subscription_from_resource_id)
+            .ok_or(error)
+    })
+}
+
+/// Builds `/subscriptions/{subscription_id}/resourceGroups/{resource_group_name}/providers/{provider}/{name}`
+fn resource_group_scoped_id(attributes: &Map<String, Value>, resource_type: &str, provider: &str) -> Result<String, ImportIdError> {
+    Ok(format!(
+        "/subscriptions/{}/resourceGroups/{}/providers/{}/{}",
+        subscription_id(attributes, resource_type)?,
+        required_attribute(attributes, resource_type, "resource_group_name")?,
+        provider,
+        required_attribute(attributes, resource_type, "name")?
+    ))
+}
+
+/// Builds `/subscriptions/{subscription_id}/resourceGroups/{name}` for `azurerm_resource_group`
+pub struct AzureResourceGroupBuilder;
+
+impl ImportIdBuilder for AzureResourceGroupBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        Ok(format!(
+            "/subscriptions/{}/resourceGroups/{}",
+            subscription_id(attributes, "azurerm_resource_group")?,
+            required_attribute(attributes, "azurerm_resource_group", "name")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["/subscriptions/{subscription_id}/resourceGroups/{name}"]
+    }
+}
+
+/// Builds the `Microsoft.Storage/storageAccounts` ID for `azurerm_storage_account`
+pub struct AzureStorageAccountBuilder;
+
+impl ImportIdBuilder for AzureStorageAccountBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        resource_group_scoped_id(attributes, "azurerm_storage_account", "Microsoft.Storage/storageAccounts")
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["/subscriptions/{subscription_id}/resourceGroups/{resource_group_name}/providers/Microsoft.Storage/storageAccounts/{name}"]
+    }
+}
+
+/// Builds the `Microsoft.KeyVault/vaults` ID for `azurerm_key_vault`
+pub struct AzureKeyVaultBuilder;
+
+impl ImportIdBuilder for AzureKeyVaultBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        resource_group_scoped_id(attributes, "azurerm_key_vault", "Microsoft.KeyVault/vaults")
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["/subscriptions/{subscription_id}/resourceGroups/{resource_group_name}/providers/Microsoft.KeyVault/vaults/{name}"]
+    }
+}
@@ -0,0 +1,182 @@
+//! # Google Cloud Import ID Builders
+//! 
+//! Builders for Google Cloud resource types whose import IDs can't be taken from a
+//! single attribute. Formats follow the "Import" section of each resource's page in
+//! the Google provider documentation.
+//! 
+//! ## Supported Resource Types
+//! 
+//! - `google_kms_key_ring`: `projects/{project}/locations/{location}/keyRings/{name}`
+//! - `google_kms_crypto_key`: `{key_ring}/cryptoKeys/{name}`
+//! - `google_storage_bucket`: `{project}/{name}`, or `{name}` when the project is unknown
+//! - `google_storage_bucket_iam_binding`: `b/{bucket} {role}`
+//! - `google_storage_bucket_iam_member`: `b/{bucket} {role} {member}`
+//! - `google_project_iam_binding`: `{project} {role}`
+//! - `google_project_iam_member`: `{project} {role} {member}`
+//! - `google_project_service`: `{project}/{service}`
+//! 
+//! ## Provider Defaults
+//! 
+//! A resource missing `project`, `region` or `zone` (listed in `GOOGLE_PROVIDER_DEFAULTS`)
+//! gets the value its provider block sets, so resources using different aliases of the
+//! `google` provider build IDs in their own project and location.
+
+use serde_json::{Map, Value};
+use super::traits::{required_attribute, ImportIdBuilder, ImportIdError};
+
+/// Attributes taken from the resource's provider block when the resource doesn't set them
+pub const GOOGLE_PROVIDER_DEFAULTS: &[&str] = &["project", "region", "zone"];
+
+/// Builds `projects/{project}/locations/{location}/keyRings/{name}` for `google_kms_key_ring`
+pub struct GoogleKmsKeyRingBuilder;
+
+impl ImportIdBuilder for GoogleKmsKeyRingBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_kms_key_ring";
+        Ok(format!(
+            "projects/{}/locations/{}/keyRings/{}",
+            required_attribute(attributes, resource_type, "project")?,
+            required_attribute(attributes, resource_type, "location")?,
+            required_attribute(attributes, resource_type, "name")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["projects/{project}/locations/{location}/keyRings/{name}"]
+    }
+}
+
+/// Builds `{key_ring}/cryptoKeys/{name}` for `google_kms_crypto_key`
+/// 
+/// The `key_ring` attribute holds the full key ring ID, so the result has the shape
+/// `projects/{project}/locations/{location}/keyRings/{ring}/cryptoKeys/{name}`.
+pub struct GoogleKmsCryptoKeyBuilder;
+
+impl ImportIdBuilder for GoogleKmsCryptoKeyBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_kms_crypto_key";
+        Ok(format!(
+            "{}/cryptoKeys/{}",
+            required_attribute(attributes, resource_type, "key_ring")?,
+            required_attribute(attributes, resource_type, "name")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["projects/{project}/locations/{location}/keyRings/{key_ring}/cryptoKeys/{name}"]
+    }
+}
+
+/// Builds `{project}/{name}` for `google_storage_bucket`
+/// 
+/// Buckets are globally unique, so when the project is left to the provider
+/// default (and is unknown at plan time) the bare bucket name is used instead.
+pub struct GoogleStorageBucketBuilder;
+
+impl ImportIdBuilder for GoogleStorageBucketBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_storage_bucket";
+        let name = required_attribute(attributes, resource_type, "name")?;
+
+        match required_attribute(attributes, resource_type, "project") {
+            Ok(project) => Ok(format!("{}/{}", project, name)),
+            Err(_) => Ok(name.to_string()),
+        }
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{project}/{name}", "{name}"]
+    }
+}
+
+/// Builds `b/{bucket} {role}` for `google_storage_bucket_iam_binding`
+pub struct GoogleStorageBucketIamBindingBuilder;
+
+impl ImportIdBuilder for GoogleStorageBucketIamBindingBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_storage_bucket_iam_binding";
+        Ok(format!(
+            "b/{} {}",
+            required_attribute(attributes, resource_type, "bucket")?,
+            required_attribute(attributes, resource_type, "role")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["b/{bucket} {role}"]
+    }
+}
+
+/// Builds `b/{bucket} {role} {member}` for `google_storage_bucket_iam_member`
+pub struct GoogleStorageBucketIamMemberBuilder;
+
+impl ImportIdBuilder for GoogleStorageBucketIamMemberBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_storage_bucket_iam_member";
+        Ok(format!(
+            "b/{} {} {}",
+            required_attribute(attributes, resource_type, "bucket")?,
+            required_attribute(attributes, resource_type, "role")?,
+            required_attribute(attributes, resource_type, "member")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["b/{bucket} {role} {member}"]
+    }
+}
+
+/// Builds `{project} {role}` for `google_project_iam_binding`
+pub struct GoogleProjectIamBindingBuilder;
+
+impl ImportIdBuilder for GoogleProjectIamBindingBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_project_iam_binding";
+        Ok(format!(
+            "{} {}",
+            required_attribute(attributes, resource_type, "project")?,
+            required_attribute(attributes, resource_type, "role")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{project} {role}"]
+    }
+}
+
+/// Builds `{project} {role} {member}` for `google_project_iam_member`
+pub struct GoogleProjectIamMemberBuilder;
+
+impl ImportIdBuilder for GoogleProjectIamMemberBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_project_iam_member";
+        Ok(format!(
+            "{} {} {}",
+            required_attribute(attributes, resource_type, "project")?,
+            required_attribute(attributes, resource_type, "role")?,
+            required_attribute(attributes, resource_type, "member")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{project} {role} {member}"]
+    }
+}
+
+/// Builds `{project}/{service}` for `google_project_service`
+pub struct GoogleProjectServiceBuilder;
+
+impl ImportIdBuilder for GoogleProjectServiceBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let resource_type = "google_project_service";
+        Ok(format!(
+            "{}/{}",
+            required_attribute(attributes, resource_type, "project")?,
+            required_attribute(attributes, resource_type, "service")?
+        ))
+    }
+
+    fn id_formats(&self) -> &'static [&'static str] {
+        &["{project}/{service}"]
+    }
+}
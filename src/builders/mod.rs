@@ -0,0 +1,388 @@
+//! # Import ID Builders Module
+//! 
+//! This module provides a registry of provider-specific import ID builders, keyed by
+//! terraform resource type. Where a builder is registered for a resource type, its
+//! output is used as the import ID instead of the heuristic inference in the importer.
+//! 
+//! ## Sub-modules
+//! 
+//! - `traits`: The ImportIdBuilder trait and shared helpers
+//! - `gcp`: Builders for Google Cloud resource types
+//! - `aws`: Builders for AWS resource types
+//! - `azure`: Builders for Azure resource types
+//! - `template`: Builders compiled at runtime from ID templates in a config file
+//! 
+//! ## Usage Pattern
+//! 
+//! 1. Start from `ImportIdBuilderRegistry::default()` to get the built-in builders
+//! 2. Register custom builders for additional resource types with `register()`, or
+//!    load template builders with `app::load_builders`
+//! 3. Pass the registry to the import workflow
+
+pub mod aws;
+pub mod azure;
+pub mod gcp;
+pub mod template;
+pub mod traits;
+
+use std::collections::HashMap;
+use serde_json::{Map, Value};
+
+pub use aws::{AwsIamRoleBuilder, AwsInstanceBuilder, AwsS3BucketBuilder, AwsSecurityGroupBuilder};
+pub use azure::{AzureKeyVaultBuilder, AzureResourceGroupBuilder, AzureStorageAccountBuilder};
+pub use gcp::{
+    GoogleKmsCryptoKeyBuilder, GoogleKmsKeyRingBuilder, GoogleProjectIamBindingBuilder,
+    GoogleProjectIamMemberBuilder, GoogleProjectServiceBuilder, GoogleStorageBucketBuilder, GoogleStorageBucketIamBindingBuilder,
+    GoogleStorageBucketIamMemberBuilder,
+};
+pub use template::{BuilderFile, TemplateBuilder, TemplateError};
+pub use traits::{required_attribute, validate_import_id, ImportIdBuilder, ImportIdError};
+
+/// Registry of import ID builders keyed by resource type
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::builders::{ImportIdBuilderRegistry, required_attribute};
+/// use serde_json::json;
+/// 
+/// let mut registry = ImportIdBuilderRegistry::default();
+/// registry.register("google_pubsub_topic", |attributes: &serde_json::Map<String, serde_json::Value>| {
+///     Ok(format!(
+///         "projects/{}/topics/{}",
+///         required_attribute(attributes, "google_pubsub_topic", "project")?,
+///         required_attribute(attributes, "google_pubsub_topic", "name")?
+///     ))
+/// });
+/// 
+/// let attributes = json!({"project": "my-project", "name": "events"});
+/// let id = registry.build("google_pubsub_topic", attributes.as_object().unwrap());
+/// assert_eq!(id, Some(Ok("projects/my-project/topics/events".to_string())));
+/// ```
+pub struct ImportIdBuilderRegistry {
+    /// Registered builders, keyed by terraform resource type
+    builders: HashMap<String, Box<dyn ImportIdBuilder + Send + Sync>>,
+}
+
+impl ImportIdBuilderRegistry {
+    /// Creates an empty registry with no builders registered
+    /// 
+    /// # Returns
+    /// A new, empty ImportIdBuilderRegistry
+    pub fn new() -> Self {
+        Self {
+            builders: HashMap::new(),
+        }
+    }
+
+    /// Registers a builder for a resource type, replacing any existing builder
+    /// 
+    /// # Arguments
+    /// * `resource_type` - Terraform resource type (e.g., "google_kms_key_ring")
+    /// * `builder` - Builder that produces the import ID for that type
+    pub fn register<B>(&mut self, resource_type: &str, builder: B)
+    where
+        B: ImportIdBuilder + Send + Sync + 'static,
+    {
+        self.builders.insert(resource_type.to_string(), Box::new(builder));
+    }
+
+    /// Returns the builder registered for a resource type, if any
+    pub fn get(&self, resource_type: &str) -> Option<&(dyn ImportIdBuilder + Send + Sync)> {
+        self.builders.get(resource_type).map(|builder| builder.as_ref())
+    }
+
+    /// Whether a builder is registered for a resource type
+    pub fn supports(&self, resource_type: &str) -> bool {
+        self.builders.contains_key(resource_type)
+    }
+
+    /// Builds the import ID for a resource using its registered builder
+    /// 
+    /// # Arguments
+    /// * `resource_type` - Terraform resource type
+    /// * `attributes` - The resource's planned `after` values
+    /// 
+    /// # Returns
+    /// `None` if no builder is registered for the type, otherwise the builder's result
+    pub fn build(&self, resource_type: &str, attributes: &Map<String, Value>) -> Option<Result<String, ImportIdError>> {
+        self.get(resource_type).map(|builder| builder.build_id(attributes))
+    }
+
+    /// Checks an import ID against the formats of the builder registered for a resource type
+    /// 
+    /// IDs of types without a builder, or whose builder declares no formats, always pass.
+    /// 
+    /// # Errors
+    /// - `ImportIdError::InvalidId` if the ID matches none of the builder's formats
+    pub fn validate(&self, resource_type: &str, id: &str) -> Result<(), ImportIdError> {
+        let formats = self.get(resource_type).map(|builder| builder.id_formats()).unwrap_or(&[]);
+        validate_import_id(resource_type, id, formats)
+    }
+
+    /// Lists the resource types with a registered builder, sorted alphabetically
+    pub fn resource_types(&self) -> Vec<String> {
+        let mut types: Vec<String> = self.builders.keys().cloned().collect();
+        types.sort();
+        types
+    }
+}
+
+impl Default for ImportIdBuilderRegistry {
+    /// Creates a registry pre-populated with all built-in builders
+    fn default() -> Self {
+        let mut registry = Self::new();
+        registry.register("google_kms_key_ring", GoogleKmsKeyRingBuilder);
+        registry.register("google_kms_crypto_key", GoogleKmsCryptoKeyBuilder);
+        registry.register("google_storage_bucket", GoogleStorageBucketBuilder);
+        registry.register("google_storage_bucket_iam_binding", GoogleStorageBucketIamBindingBuilder);
+        registry.register("google_storage_bucket_iam_member", GoogleStorageBucketIamMemberBuilder);
+        registry.register("google_project_iam_binding", GoogleProjectIamBindingBuilder);
+        registry.register("google_project_iam_member", GoogleProjectIamMemberBuilder);
+        registry.register("google_project_service", GoogleProjectServiceBuilder);
+        registry.register("aws_s3_bucket", AwsS3BucketBuilder);
+        registry.register("aws_iam_role", AwsIamRoleBuilder);
+        registry.register("aws_instance", AwsInstanceBuilder);
+        registry.register("aws_security_group", AwsSecurityGroupBuilder);
+        registry.register("azurerm_resource_group", AzureResourceGroupBuilder);
+        registry.register("azurerm_storage_account", AzureStorageAccountBuilder);
+        registry.register("azurerm_key_vault", AzureKeyVaultBuilder);
+        registry
+    }
+}
+
+impl std::fmt::Debug for ImportIdBuilderRegistry {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.debug_struct("ImportIdBuilderRegistry")
+            .field("resource_types", &self.resource_types())
+            .finish()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn attrs(value: Value) -> Map<String, Value> {
+        value.as_object().unwrap().clone()
+    }
+
+    #[test]
+    fn test_kms_key_ring_id() {
+        let registry = ImportIdBuilderRegistry::default();
+        let id = registry.build("google_kms_key_ring", &attrs(json!({
+            "project": "my-project",
+            "location": "europe-west1",
+            "name": "sim-keyring"
+        })));
+
+        assert_eq!(id, Some(Ok("projects/my-project/locations/europe-west1/keyRings/sim-keyring".to_string())));
+    }
+
+    #[test]
+    fn test_kms_crypto_key_id() {
+        let registry = ImportIdBuilderRegistry::default();
+        let id = registry.build("google_kms_crypto_key", &attrs(json!({
+            "key_ring": "projects/my-project/locations/europe-west1/keyRings/sim-keyring",
+            "name": "sim-key"
+        })));
+
+        assert_eq!(id, Some(Ok("projects/my-project/locations/europe-west1/keyRings/sim-keyring/cryptoKeys/sim-key".to_string())));
+    }
+
+    #[test]
+    fn test_kms_crypto_key_missing_key_ring() {
+        let registry = ImportIdBuilderRegistry::default();
+        let id = registry.build("google_kms_crypto_key", &attrs(json!({"name": "sim-key", "key_ring": null})));
+
+        assert_eq!(id, Some(Err(ImportIdError::MissingAttribute {
+            resource_type: "google_kms_crypto_key".to_string(),
+            attribute: "key_ring".to_string(),
+        })));
+    }
+
+    #[test]
+    fn test_storage_bucket_id_with_and_without_project() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        let with_project = registry.build("google_storage_bucket", &attrs(json!({"project": "my-project", "name": "bucket"})));
+        assert_eq!(with_project, Some(Ok("my-project/bucket".to_string())));
+
+        let without_project = registry.build("google_storage_bucket", &attrs(json!({"name": "bucket"})));
+        assert_eq!(without_project, Some(Ok("bucket".to_string())));
+    }
+
+    #[test]
+    fn test_iam_member_ids() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        let project_member = registry.build("google_project_iam_member", &attrs(json!({
+            "project": "my-project",
+            "role": "roles/owner",
+            "member": "user:jane@example.com"
+        })));
+        assert_eq!(project_member, Some(Ok("my-project roles/owner user:jane@example.com".to_string())));
+
+        let bucket_binding = registry.build("google_storage_bucket_iam_binding", &attrs(json!({
+            "bucket": "bucket",
+            "role": "roles/storage.objectViewer"
+        })));
+        assert_eq!(bucket_binding, Some(Ok("b/bucket roles/storage.objectViewer".to_string())));
+    }
+
+    #[test]
+    fn test_project_service_id_includes_service() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        let service = registry.build("google_project_service", &attrs(json!({
+            "project": "my-project",
+            "service": "run.googleapis.com",
+            "disable_on_destroy": false
+        })));
+        assert_eq!(service, Some(Ok("my-project/run.googleapis.com".to_string())));
+        assert!(registry.validate("google_project_service", "my-project").is_err());
+    }
+
+    #[test]
+    fn test_aws_ids() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        let bucket = registry.build("aws_s3_bucket", &attrs(json!({"bucket": "my-logs", "force_destroy": false})));
+        assert_eq!(bucket, Some(Ok("my-logs".to_string())));
+
+        let role = registry.build("aws_iam_role", &attrs(json!({"name": "example-role", "path": "/"})));
+        assert_eq!(role, Some(Ok("example-role".to_string())));
+
+        let instance = registry.build("aws_instance", &attrs(json!({"id": "i-0abc123", "instance_type": "t3.micro"})));
+        assert_eq!(instance, Some(Ok("i-0abc123".to_string())));
+
+        let security_group = registry.build("aws_security_group", &attrs(json!({"id": "sg-0abc123", "name": "web"})));
+        assert_eq!(security_group, Some(Ok("sg-0abc123".to_string())));
+    }
+
+    #[test]
+    fn test_aws_ids_unknown_at_plan_time() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        // Computed IDs are omitted from planned values until apply
+        let instance = registry.build("aws_instance", &attrs(json!({"instance_type": "t3.micro"})));
+        let err = instance.unwrap().unwrap_err();
+        assert_eq!(err, ImportIdError::MissingAttribute {
+            resource_type: "aws_instance".to_string(),
+            attribute: "id".to_string(),
+        });
+        assert!(err.to_string().contains("unknown at plan time"), "{}", err);
+
+        let bucket = registry.build("aws_s3_bucket", &attrs(json!({"bucket_prefix": "logs-"})));
+        assert!(bucket.unwrap().is_err());
+    }
+
+    #[test]
+    fn test_azure_ids() {
+        let registry = ImportIdBuilderRegistry::default();
+        let subscription = "00000000-0000-0000-0000-000000000000";
+
+        let group = registry.build("azurerm_resource_group", &attrs(json!({"subscription_id": subscription, "name": "example-rg", "location": "eastus"})));
+        assert_eq!(group, Some(Ok(format!("/subscriptions/{}/resourceGroups/example-rg", subscription))));
+
+        let account = registry.build("azurerm_storage_account", &attrs(json!({
+            "subscription_id": subscription,
+            "resource_group_name": "example-rg",
+            "name": "examplestorageacct123"
+        })));
+        let account = account.unwrap().unwrap();
+        assert_eq!(account, format!("/subscriptions/{}/resourceGroups/example-rg/providers/Microsoft.Storage/storageAccounts/examplestorageacct123", subscription));
+        assert!(registry.validate("azurerm_storage_account", &account).is_ok());
+
+        // A known `id` carries the subscription when there is no subscription_id attribute
+        let vault = registry.build("azurerm_key_vault", &attrs(json!({
+            "id": format!("/subscriptions/{}/resourceGroups/example-rg/providers/Microsoft.KeyVault/vaults/old", subscription),
+            "resource_group_name": "example-rg",
+            "name": "example-keyvault-123"
+        })));
+        let vault = vault.unwrap().unwrap();
+        assert!(vault.ends_with("/providers/Microsoft.KeyVault/vaults/example-keyvault-123"), "{}", vault);
+        assert!(registry.validate("azurerm_key_vault", &vault).is_ok());
+    }
+
+    #[test]
+    fn test_azure_ids_without_subscription() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        let group = registry.build("azurerm_resource_group", &attrs(json!({"name": "example-rg", "id": null})));
+        assert_eq!(group, Some(Err(ImportIdError::MissingAttribute {
+            resource_type: "azurerm_resource_group".to_string(),
+            attribute: "subscription_id".to_string(),
+        })));
+
+        let err = registry.validate("azurerm_key_vault", "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/a").unwrap_err();
+        assert!(err.to_string().contains("should be 'Microsoft.KeyVault'"), "{}", err);
+    }
+
+    #[test]
+    fn test_validate_accepts_built_ids() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        assert!(registry.validate("google_kms_key_ring", "projects/p/locations/europe-west1/keyRings/ring").is_ok());
+        assert!(registry.validate("google_kms_crypto_key", "projects/p/locations/l/keyRings/ring/cryptoKeys/key").is_ok());
+        assert!(registry.validate("google_storage_bucket", "my-project/bucket").is_ok());
+        assert!(registry.validate("google_storage_bucket", "bucket").is_ok());
+        assert!(registry.validate("google_storage_bucket_iam_member", "b/bucket roles/storage.admin user:jane@example.com").is_ok());
+        assert!(registry.validate("aws_instance", "i-0abc123").is_ok());
+        assert!(registry.validate("google_pubsub_topic", "anything/at/all").is_ok());
+    }
+
+    #[test]
+    fn test_validate_rejects_malformed_ids() {
+        let registry = ImportIdBuilderRegistry::default();
+
+        let err = registry.validate("google_kms_crypto_key", "sim-keyring/sim-key").unwrap_err();
+        assert_eq!(err.to_string(), "invalid import id for google_kms_crypto_key: expected 8 segments, got 2");
+
+        let err = registry.validate("google_storage_bucket", "a/b/c").unwrap_err();
+        assert_eq!(err.to_string(), "invalid import id for google_storage_bucket: expected 2 or 1 segments, got 3");
+
+        let err = registry.validate("google_kms_key_ring", "projects/p/regions/l/keyRings/ring").unwrap_err();
+        assert_eq!(err.to_string(), "invalid import id for google_kms_key_ring: segment 3 should be 'locations', got 'regions'");
+
+        let err = registry.validate("google_kms_key_ring", "projects//locations/l/keyRings/ring").unwrap_err();
+        assert_eq!(err.to_string(), "invalid import id for google_kms_key_ring: segment 2 ({project}) is empty");
+
+        let err = registry.validate("google_storage_bucket_iam_binding", "bucket roles/storage.admin").unwrap_err();
+        assert_eq!(err.to_string(), "invalid import id for google_storage_bucket_iam_binding: segment 1 should start with 'b/', got 'bucket'");
+    }
+
+    #[test]
+    fn test_unregistered_type() {
+        let registry = ImportIdBuilderRegistry::default();
+        assert!(registry.build("google_pubsub_topic", &Map::new()).is_none());
+        assert!(!registry.supports("google_pubsub_topic"));
+    }
+
+    #[test]
+    fn test_register_custom_builder_overrides_default() {
+        let mut registry = ImportIdBuilderRegistry::default();
+        registry.register("google_storage_bucket", |attributes: &Map<String, Value>| {
+            Ok(format!("gs://{}", required_attribute(attributes, "google_storage_bucket", "name")?))
+        });
+
+        let id = registry.build("google_storage_bucket", &attrs(json!({"project": "my-project", "name": "bucket"})));
+        assert_eq!(id, Some(Ok("gs://bucket".to_string())));
+    }
+
+    #[test]
+    fn test_template_builder_overrides_default() {
+        let mut registry = ImportIdBuilderRegistry::default();
+        registry.register("google_storage_bucket", TemplateBuilder::parse("google_storage_bucket", "{project}/buckets/{name}").unwrap());
+        registry.register("google_pubsub_topic", TemplateBuilder::parse("google_pubsub_topic", "projects/{project}/topics/{name}").unwrap());
+
+        let attributes = attrs(json!({"project": "my-project", "name": "bucket"}));
+        assert_eq!(registry.build("google_storage_bucket", &attributes), Some(Ok("my-project/buckets/bucket".to_string())));
+        assert_eq!(registry.build("google_pubsub_topic", &attributes), Some(Ok("projects/my-project/topics/bucket".to_string())));
+        assert!(registry.validate("google_storage_bucket", "my-project/buckets/bucket").is_ok());
+        assert_eq!(
+            registry.build("google_kms_key_ring", &attributes),
+            Some(Err(ImportIdError::MissingAttribute { resource_type: "google_kms_key_ring".to_string(), attribute: "location".to_string() }))
+        );
+    }
+}
@@ -0,0 +1,232 @@
+//! # Template Import ID Builders
+//!
+//! Builders defined in a config file instead of compiled in, so new resource types can
+//! be supported without a new release. Each entry maps a resource type to a template
+//! whose `{attribute}` placeholders are replaced by the resource's planned values:
+//!
+//! ```json
+//! {
+//!   "builders": {
+//!     "google_pubsub_topic": "projects/{project}/topics/{name}",
+//!     "google_kms_key_ring": "projects/{project}/locations/{location}/keyRings/{name}"
+//!   }
+//! }
+//! ```
+//!
+//! ## Templates
+//!
+//! - A placeholder names one top-level string attribute, e.g. `{project}`; `{{` and `}}`
+//!   stand for literal braces
+//! - A template needs at least one placeholder, since a fixed ID can belong to one resource only
+//! - Attributes are read like the built-in builders read them, so an absent, null,
+//!   empty or unknown attribute is an `ImportIdError::MissingAttribute`, and `google_`
+//!   types get `project`, `region` and `zone` from their provider block
+//!
+//! ## Precedence
+//!
+//! A template replaces the built-in builder of its resource type. Mapping file IDs
+//! still take precedence over both. Template builders declare no ID formats, so
+//! `--validate-ids` accepts whatever they build.
+
+use std::collections::BTreeMap;
+use serde::Deserialize;
+use serde_json::{Map, Value};
+use thiserror::Error;
+use super::traits::{required_attribute, ImportIdBuilder, ImportIdError};
+
+/// Error types for parsing builder templates
+///
+/// # Variants
+/// - `Unterminated`: A `{` has no closing `}`
+/// - `UnmatchedBrace`: A `}` closes no placeholder
+/// - `InvalidPlaceholder`: A placeholder isn't an attribute name
+/// - `NoPlaceholders`: The template references no attribute
+#[derive(Error, Debug, Clone, PartialEq)]
+pub enum TemplateError {
+    /// A placeholder is opened but never closed
+    #[error("unterminated placeholder in template '{template}'")]
+    Unterminated {
+        /// Template being parsed
+        template: String,
+    },
+
+    /// A closing brace without a placeholder (write `}}` for a literal one)
+    #[error("unmatched '}}' in template '{template}' (use '}}}}' for a literal brace)")]
+    UnmatchedBrace {
+        /// Template being parsed
+        template: String,
+    },
+
+    /// A placeholder that isn't a valid attribute name
+    #[error("invalid placeholder '{{{placeholder}}}' in template '{template}' (expected an attribute name)")]
+    InvalidPlaceholder {
+        /// Template being parsed
+        template: String,
+        /// Text between the braces
+        placeholder: String,
+    },
+
+    /// A template without placeholders
+    #[error("template '{template}' references no attribute")]
+    NoPlaceholders {
+        /// Template being parsed
+        template: String,
+    },
+}
+
+/// A config file of template builders, keyed by resource type
+#[derive(Debug, Clone, Default, Deserialize)]
+pub struct BuilderFile {
+    /// Template per resource type, e.g. `projects/{project}/topics/{name}`
+    #[serde(default)]
+    pub builders: BTreeMap<String, String>,
+}
+
+/// One piece of a parsed template
+#[derive(Debug, Clone, PartialEq)]
+enum TemplatePart {
+    Literal(String),
+    Attribute(String),
+}
+
+/// Builds import IDs by filling a template with a resource's planned attributes
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::builders::{ImportIdBuilder, TemplateBuilder};
+/// use serde_json::json;
+///
+/// let builder = TemplateBuilder::parse("google_pubsub_topic", "projects/{project}/topics/{name}").unwrap();
+/// let attributes = json!({"project": "my-project", "name": "events"});
+/// assert_eq!(builder.build_id(attributes.as_object().unwrap()).unwrap(), "projects/my-project/topics/events");
+/// ```
+#[derive(Debug, Clone)]
+pub struct TemplateBuilder {
+    resource_type: String,
+    parts: Vec<TemplatePart>,
+}
+
+impl TemplateBuilder {
+    /// Parses `template` into a builder for `resource_type`
+    ///
+    /// # Errors
+    /// Returns a `TemplateError` if the template is malformed or has no placeholder
+    pub fn parse(resource_type: &str, template: &str) -> Result<Self, TemplateError> {
+        let error_template = || template.to_string();
+        let mut parts = Vec::new();
+        let mut literal = String::new();
+        let mut chars = template.chars().peekable();
+        while let Some(c) = chars.next() {
+            match c {
+                '{' if chars.peek() == Some(&'{') => {
+                    chars.next();
+                    literal.push('{');
+                }
+                '}' if chars.peek() == Some(&'}') => {
+                    chars.next();
+                    literal.push('}');
+                }
+                '}' => return Err(TemplateError::UnmatchedBrace { template: error_template() }),
+                '{' => {
+                    let mut placeholder = String::new();
+                    loop {
+                        match chars.next() {
+                            Some('}') => break,
+                            Some('{') | None => return Err(TemplateError::Unterminated { template: error_template() }),
+                            Some(c) => placeholder.push(c),
+                        }
+                    }
+                    if !is_attribute_name(&placeholder) {
+                        return Err(TemplateError::InvalidPlaceholder { template: error_template(), placeholder });
+                    }
+                    if !literal.is_empty() {
+                        parts.push(TemplatePart::Literal(std::mem::take(&mut literal)));
+                    }
+                    parts.push(TemplatePart::Attribute(placeholder));
+                }
+                c => literal.push(c),
+            }
+        }
+        if !literal.is_empty() {
+            parts.push(TemplatePart::Literal(literal));
+        }
+        if !parts.iter().any(|part| matches!(part, TemplatePart::Attribute(_))) {
+            return Err(TemplateError::NoPlaceholders { template: error_template() });
+        }
+        Ok(Self { resource_type: resource_type.to_string(), parts })
+    }
+}
+
+impl ImportIdBuilder for TemplateBuilder {
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        let mut id = String::new();
+        for part in &self.parts {
+            match part {
+                TemplatePart::Literal(text) => id.push_str(text),
+                TemplatePart::Attribute(name) => id.push_str(required_attribute(attributes, &self.resource_type, name)?),
+            }
+        }
+        Ok(id)
+    }
+}
+
+/// Whether `name` can be a terraform attribute name
+fn is_attribute_name(name: &str) -> bool {
+    let mut chars = name.chars();
+    matches!(chars.next(), Some(c) if c.is_ascii_alphabetic() || c == '_')
+        && chars.all(|c| c.is_ascii_alphanumeric() || c == '_' || c == '-')
+}
+
+/// Unit tests for template parsing and building
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn attrs(value: Value) -> Map<String, Value> {
+        value.as_object().unwrap().clone()
+    }
+
+    /// **TEST** - Templates split into literals and attributes, with escaped braces kept literal
+    #[test]
+    fn test_parse_template() {
+        let builder = TemplateBuilder::parse("google_kms_key_ring", "projects/{project}/locations/{location}/keyRings/{name}").unwrap();
+        assert_eq!(builder.parts, [
+            TemplatePart::Literal("projects/".to_string()),
+            TemplatePart::Attribute("project".to_string()),
+            TemplatePart::Literal("/locations/".to_string()),
+            TemplatePart::Attribute("location".to_string()),
+            TemplatePart::Literal("/keyRings/".to_string()),
+            TemplatePart::Attribute("name".to_string()),
+        ]);
+
+        let builder = TemplateBuilder::parse("example_thing", "{{literal}}:{name}").unwrap();
+        assert_eq!(builder.parts, [TemplatePart::Literal("{literal}:".to_string()), TemplatePart::Attribute("name".to_string())]);
+    }
+
+    /// **TEST** - Malformed templates and templates without placeholders are rejected
+    #[test]
+    fn test_parse_template_errors() {
+        let parse = |template: &str| TemplateBuilder::parse("google_pubsub_topic", template).unwrap_err();
+        assert_eq!(parse("projects/{project/topics"), TemplateError::Unterminated { template: "projects/{project/topics".to_string() });
+        assert!(matches!(parse("projects/{project/topics/{name}"), TemplateError::Unterminated { .. }));
+        assert_eq!(parse("projects/project}/{name}"), TemplateError::UnmatchedBrace { template: "projects/project}/{name}".to_string() });
+        assert_eq!(
+            parse("projects/{}/topics/{name}").to_string(),
+            "invalid placeholder '{}' in template 'projects/{}/topics/{name}' (expected an attribute name)"
+        );
+        assert!(matches!(parse("{project.id}"), TemplateError::InvalidPlaceholder { placeholder, .. } if placeholder == "project.id"));
+        assert_eq!(parse("projects/p/topics/t").to_string(), "template 'projects/p/topics/t' references no attribute");
+    }
+
+    /// **TEST** - A referenced attribute missing from the plan is a MissingAttribute error
+    #[test]
+    fn test_template_missing_attribute() {
+        let builder = TemplateBuilder::parse("google_pubsub_topic", "projects/{project}/topics/{name}").unwrap();
+        assert_eq!(builder.build_id(&attrs(json!({"name": "events", "project": null}))), Err(ImportIdError::MissingAttribute {
+            resource_type: "google_pubsub_topic".to_string(),
+            attribute: "project".to_string(),
+        }));
+        assert_eq!(builder.build_id(&attrs(json!({"project": "p", "name": "events"}))).unwrap(), "projects/p/topics/events");
+    }
+}
@@ -0,0 +1,200 @@
+//! # Import ID Builder Traits
+//! 
+//! The interface every import ID builder implements, and the pieces the provider
+//! builders share. Providers register their builders in `ImportIdBuilderRegistry`;
+//! this module only defines what a builder is and how it fails.
+//! 
+//! ## Key Components
+//! 
+//! - `ImportIdBuilder`: Turns a resource's planned attributes into its import ID
+//! - `ImportIdError`: Why an ID couldn't be built or doesn't fit its type
+//! - `required_attribute`: Reads a string attribute a builder can't do without
+//! - `validate_import_id`: Checks an ID against templates such as `{project}/{name}`
+
+use serde_json::{Map, Value};
+use thiserror::Error;
+
+/// Error types for import ID construction
+/// 
+/// # Variants
+/// - `MissingAttribute`: A required attribute is absent or null in the plan
+/// - `UnsupportedType`: No builder is registered and no ID could be inferred
+/// - `InvalidId`: An ID doesn't match the expected format for its resource type
+#[derive(Error, Debug, Clone, PartialEq)]
+pub enum ImportIdError {
+    /// A required attribute is absent, null, or not a string in the planned values
+    #[error("cannot build import id for {resource_type}: required attribute '{attribute}' is missing or unknown at plan time (set the ID in a mapping file)")]
+    MissingAttribute {
+        /// Resource type the ID was being built for
+        resource_type: String,
+        /// Name of the missing attribute
+        attribute: String,
+    },
+    /// The resource type has no builder and heuristic inference found no ID
+    #[error("no import id builder for resource type '{resource_type}' and no ID could be inferred")]
+    UnsupportedType {
+        /// Resource type that has no builder
+        resource_type: String,
+    },
+    /// The import ID doesn't match any expected format of the resource type
+    #[error("invalid import id for {resource_type}: {reason}")]
+    InvalidId {
+        /// Resource type the ID was validated for
+        resource_type: String,
+        /// What didn't match, e.g. "expected 8 segments, got 2"
+        reason: String,
+    },
+}
+
+/// Trait for building the import ID of a specific resource type
+/// 
+/// Each resource type expects a differently shaped import ID, e.g.
+/// `projects/{project}/locations/{location}/keyRings/{name}` for a KMS key ring
+/// versus `{project}/{name}` for a storage bucket. A builder turns the planned
+/// `after` attributes of a resource into that ID.
+/// 
+/// Closures with the matching signature implement this trait, so custom builders
+/// can be registered without declaring a type.
+pub trait ImportIdBuilder {
+    /// Build the import ID from a resource's planned attributes
+    /// 
+    /// # Arguments
+    /// * `attributes` - The resource's planned `after` values
+    /// 
+    /// # Returns
+    /// The import ID string
+    /// 
+    /// # Errors
+    /// - `ImportIdError::MissingAttribute` when a required attribute is unavailable
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError>;
+
+    /// Formats an import ID for this resource type may take, used by `validate_import_id`
+    /// 
+    /// Defaults to none, which disables validation for the type.
+    fn id_formats(&self) -> &'static [&'static str] {
+        &[]
+    }
+}
+
+impl<F> ImportIdBuilder for F
+where
+    F: Fn(&Map<String, Value>) -> Result<String, ImportIdError>,
+{
+    fn build_id(&self, attributes: &Map<String, Value>) -> Result<String, ImportIdError> {
+        self(attributes)
+    }
+}
+
+/// Reads a required string attribute for use in an import ID
+/// 
+/// # Arguments
+/// * `attributes` - The resource's planned `after` values
+/// * `resource_type` - Resource type, used in the error message
+/// * `attribute` - Name of the attribute to read
+/// 
+/// # Returns
+/// The attribute value
+/// 
+/// # Errors
+/// - `ImportIdError::MissingAttribute` if the attribute is absent, null, empty, or not a string
+pub fn required_attribute<'a>(
+    attributes: &'a Map<String, Value>,
+    resource_type: &str,
+    attribute: &str,
+) -> Result<&'a str, ImportIdError> {
+    attributes
+        .get(attribute)
+        .and_then(|value| value.as_str())
+        .filter(|value| !value.is_empty())
+        .ok_or_else(|| ImportIdError::MissingAttribute {
+            resource_type: resource_type.to_string(),
+            attribute: attribute.to_string(),
+        })
+}
+
+/// Checks an import ID against the expected formats of its resource type
+/// 
+/// A format is a template such as `projects/{project}/locations/{location}/keyRings/{name}`.
+/// Templates containing a space are split into segments on spaces (as in
+/// `{project} {role} {member}`), all others on `/`. An ID matches a template if it has
+/// the same number of segments, every literal segment is equal, and every placeholder
+/// segment (optionally with a literal prefix, as in `b/{bucket}`) is non-empty after the
+/// prefix. An empty list of formats accepts any ID.
+/// 
+/// # Arguments
+/// * `resource_type` - Resource type, used in the error message
+/// * `id` - Import ID to check
+/// * `formats` - Accepted templates, most common first
+/// 
+/// # Errors
+/// - `ImportIdError::InvalidId` describing the first mismatch against the template with the
+///   same segment count, or the accepted segment counts if no template has that count
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::builders::validate_import_id;
+/// 
+/// let formats = &["projects/{project}/locations/{location}/keyRings/{key_ring}/cryptoKeys/{name}"];
+/// assert!(validate_import_id("google_kms_crypto_key", "projects/p/locations/l/keyRings/r/cryptoKeys/k", formats).is_ok());
+/// 
+/// let err = validate_import_id("google_kms_crypto_key", "r/cryptoKeys/k", formats).unwrap_err();
+/// assert_eq!(err.to_string(), "invalid import id for google_kms_crypto_key: expected 8 segments, got 3");
+/// ```
+pub fn validate_import_id(resource_type: &str, id: &str, formats: &[&str]) -> Result<(), ImportIdError> {
+    if formats.is_empty() {
+        return Ok(());
+    }
+    let mut first_mismatch = None;
+    let mut segment_counts: Vec<usize> = Vec::new();
+    for format in formats {
+        let separator = segment_separator(format);
+        let expected: Vec<&str> = format.split(separator).collect();
+        let actual: Vec<&str> = id.split(separator).collect();
+        if !segment_counts.contains(&expected.len()) {
+            segment_counts.push(expected.len());
+        }
+        if expected.len() != actual.len() {
+            continue;
+        }
+        match segment_mismatch(&expected, &actual) {
+            None => return Ok(()),
+            Some(reason) => {
+                first_mismatch.get_or_insert(reason);
+            }
+        }
+    }
+
+    let reason = first_mismatch.unwrap_or_else(|| {
+        let expected: Vec<String> = segment_counts.iter().map(|count| count.to_string()).collect();
+        let actual = id.split(segment_separator(formats[0])).count();
+        format!("expected {} segments, got {}", expected.join(" or "), actual)
+    });
+    Err(ImportIdError::InvalidId {
+        resource_type: resource_type.to_string(),
+        reason,
+    })
+}
+
+/// Space-separated templates are split on spaces, all others on `/`
+fn segment_separator(format: &str) -> char {
+    if format.contains(' ') { ' ' } else { '/' }
+}
+
+/// Describes the first segment of `actual` that doesn't match the template segments
+fn segment_mismatch(expected: &[&str], actual: &[&str]) -> Option<String> {
+    expected.iter().zip(actual).enumerate().find_map(|(index, (expected, actual))| {
+        let position = index + 1;
+        match expected.find('{') {
+            Some(start) if expected.ends_with('}') => {
+                let prefix = &expected[..start];
+                match actual.strip_prefix(prefix) {
+                    Some(value) if !value.is_empty() => None,
+                    Some(_) => Some(format!("segment {} ({}) is empty", position, expected)),
+                    None => Some(format!("segment {} should start with '{}', got '{}'", position, prefix, actual)),
+                }
+            }
+            _ if expected != actual => Some(format!("segment {} should be '{}', got '{}'", position, expected, actual)),
+            _ => None,
+        }
+    })
+}
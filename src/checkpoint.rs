@@ -0,0 +1,281 @@
+//! # Checkpoint Module
+//!
+//! A checkpoint file records every resource an import run has finished with, so a run
+//! that is killed partway can be resumed without re-resolving, re-checking and
+//! re-importing what is already done.
+//!
+//! ## Key Components
+//!
+//! - **CheckpointEntry**: One finished resource: its address and how it finished
+//! - **Checkpoint**: The entries of an existing checkpoint file, read with `--resume`
+//! - **CheckpointWriter**: Appends entries to a checkpoint file as resources finish
+//! - **CheckpointError**: Failure modes when reading or writing a checkpoint
+//!
+//! ## Format
+//!
+//! Newline-delimited JSON, one entry per line, appended and flushed as each resource
+//! finishes:
+//!
+//! ```text
+//! {"address":"module.kms.google_kms_key_ring.example","status":"success"}
+//! {"address":"module.kms.google_kms_crypto_key.example","status":"already_in_state"}
+//! ```
+//!
+//! Only finished resources are recorded: imported, already in state, skipped and
+//! unsupported. Failed, timed-out and cancelled imports are left out so a resumed run
+//! tries them again. A process killed mid-write can leave an incomplete last line;
+//! it is ignored when the checkpoint is read.
+
+use std::collections::BTreeSet;
+use std::fs::{self, File, OpenOptions};
+use std::io::{self, Write};
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, Mutex};
+use serde::{Deserialize, Serialize};
+use thiserror::Error;
+use crate::reporting::ImportStatus;
+
+/// Error types for checkpoint files
+///
+/// # Variants
+/// - `Io`: The checkpoint file could not be read, opened or written
+/// - `InvalidEntry`: A complete line of the checkpoint isn't a valid entry
+#[derive(Error, Debug)]
+pub enum CheckpointError {
+    /// The checkpoint file could not be read, opened or written
+    #[error("Failed to access checkpoint {path}: {source}")]
+    Io {
+        /// Path of the checkpoint file
+        path: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// A line of the checkpoint file isn't a valid entry
+    #[error("Invalid checkpoint entry at {path}:{line}: {source}")]
+    InvalidEntry {
+        /// Path of the checkpoint file
+        path: String,
+        /// One-based line number of the entry
+        line: usize,
+        /// Underlying parse error
+        #[source]
+        source: serde_json::Error,
+    },
+}
+
+/// One finished resource in a checkpoint file
+///
+/// # Fields
+/// - `address`: Full terraform resource address, as in the plan
+/// - `status`: How the resource finished
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct CheckpointEntry {
+    /// Full terraform resource address
+    pub address: String,
+    /// How the resource finished
+    pub status: ImportStatus,
+}
+
+impl CheckpointEntry {
+    /// Returns true if a resource that finished with `status` belongs in a checkpoint
+    pub fn is_finished(status: ImportStatus) -> bool {
+        matches!(
+            status,
+            ImportStatus::Success | ImportStatus::Verified | ImportStatus::AlreadyInState | ImportStatus::Skipped | ImportStatus::Unsupported
+        )
+    }
+}
+
+/// The finished resources recorded by an earlier run
+///
+/// The default checkpoint is empty, so nothing is skipped.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::checkpoint::Checkpoint;
+///
+/// let dir = tempfile::tempdir().unwrap();
+/// let path = dir.path().join("checkpoint.ndjson");
+/// std::fs::write(&path, "{\"address\":\"aws_vpc.main\",\"status\":\"success\"}\n{\"address\":\"aws_sub").unwrap();
+///
+/// let checkpoint = Checkpoint::load(&path).unwrap();
+/// assert!(checkpoint.contains("aws_vpc.main"));
+/// assert_eq!(checkpoint.len(), 1);
+/// ```
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct Checkpoint {
+    entries: Vec<CheckpointEntry>,
+    addresses: BTreeSet<String>,
+}
+
+impl Checkpoint {
+    /// Reads the checkpoint at `path`; a missing file is an empty checkpoint
+    ///
+    /// Blank lines and an incomplete last line (no trailing newline, as left by a
+    /// process killed mid-write) are ignored.
+    ///
+    /// # Errors
+    /// - `CheckpointError::Io` if the file exists but can't be read
+    /// - `CheckpointError::InvalidEntry` if a complete line isn't a valid entry
+    pub fn load(path: &Path) -> Result<Self, CheckpointError> {
+        let content = match fs::read_to_string(path) {
+            Ok(content) => content,
+            Err(e) if e.kind() == io::ErrorKind::NotFound => return Ok(Self::default()),
+            Err(source) => return Err(CheckpointError::Io { path: path.display().to_string(), source }),
+        };
+
+        let mut checkpoint = Self::default();
+        let lines: Vec<&str> = content.split('\n').collect();
+        for (index, line) in lines.iter().enumerate() {
+            if line.trim().is_empty() {
+                continue;
+            }
+            let incomplete = index == lines.len() - 1;
+            match serde_json::from_str::<CheckpointEntry>(line) {
+                Ok(entry) => checkpoint.push(entry),
+                Err(_) if incomplete => {}
+                Err(source) => {
+                    return Err(CheckpointError::InvalidEntry { path: path.display().to_string(), line: index + 1, source });
+                }
+            }
+        }
+        Ok(checkpoint)
+    }
+
+    /// Adds an entry
+    pub fn push(&mut self, entry: CheckpointEntry) {
+        self.addresses.insert(entry.address.clone());
+        self.entries.push(entry);
+    }
+
+    /// Returns true if `address` is recorded as finished
+    pub fn contains(&self, address: &str) -> bool {
+        self.addresses.contains(address)
+    }
+
+    /// Returns the recorded addresses, sorted and without duplicates
+    pub fn addresses(&self) -> impl Iterator<Item = &str> {
+        self.addresses.iter().map(String::as_str)
+    }
+
+    /// Returns the entries in file order
+    pub fn entries(&self) -> &[CheckpointEntry] {
+        &self.entries
+    }
+
+    /// Returns the number of distinct recorded addresses
+    pub fn len(&self) -> usize {
+        self.addresses.len()
+    }
+
+    /// Returns true if no address is recorded
+    pub fn is_empty(&self) -> bool {
+        self.addresses.is_empty()
+    }
+}
+
+/// Appends entries to a checkpoint file; clones share the file
+///
+/// Each entry is written as one line and flushed immediately, so the file is up to
+/// date whenever the process is killed. Workers may record concurrently.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::checkpoint::{Checkpoint, CheckpointWriter};
+/// use terragrunt_import_from_plan::reporting::ImportStatus;
+///
+/// let dir = tempfile::tempdir().unwrap();
+/// let path = dir.path().join("checkpoint.ndjson");
+///
+/// let writer = CheckpointWriter::open(&path).unwrap();
+/// writer.record("aws_vpc.main", ImportStatus::Success).unwrap();
+/// assert!(Checkpoint::load(&path).unwrap().contains("aws_vpc.main"));
+/// ```
+#[derive(Debug, Clone)]
+pub struct CheckpointWriter {
+    path: PathBuf,
+    file: Arc<Mutex<File>>,
+}
+
+impl CheckpointWriter {
+    /// Opens `path` for appending, creating it if needed
+    ///
+    /// # Errors
+    /// Returns `CheckpointError::Io` if the file can't be opened
+    pub fn open(path: &Path) -> Result<Self, CheckpointError> {
+        let file = OpenOptions::new()
+            .create(true)
+            .append(true)
+            .open(path)
+            .map_err(|source| CheckpointError::Io { path: path.display().to_string(), source })?;
+        Ok(Self { path: path.to_path_buf(), file: Arc::new(Mutex::new(file)) })
+    }
+
+    /// Path of the checkpoint file
+    pub fn path(&self) -> &Path {
+        &self.path
+    }
+
+    /// Appends `address` with `status`
+    ///
+    /// # Errors
+    /// Returns `CheckpointError::Io` if the line can't be written
+    pub fn record(&self, address: &str, status: ImportStatus) -> Result<(), CheckpointError> {
+        let entry = CheckpointEntry { address: address.to_string(), status };
+        let mut line = serde_json::to_string(&entry).expect("checkpoint entries always serialize");
+        line.push('\n');
+
+        let mut file = self.file.lock().unwrap();
+        file.write_all(line.as_bytes())
+            .and_then(|_| file.flush())
+            .map_err(|source| CheckpointError::Io { path: self.path.display().to_string(), source })
+    }
+}
+
+/// Unit tests for reading and writing checkpoints
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tempfile::TempDir;
+
+    /// **TEST** - Entries written by one writer are read back, and a missing file is empty
+    #[test]
+    fn test_checkpoint_round_trip() {
+        let dir = TempDir::new().unwrap();
+        let path = dir.path().join("checkpoint.ndjson");
+        assert!(Checkpoint::load(&path).unwrap().is_empty());
+
+        let writer = CheckpointWriter::open(&path).unwrap();
+        writer.record(r#"module.kms.google_kms_crypto_key.this["a"]"#, ImportStatus::Success).unwrap();
+        writer.clone().record("google_storage_bucket.b", ImportStatus::AlreadyInState).unwrap();
+        writer.record("google_storage_bucket.b", ImportStatus::AlreadyInState).unwrap();
+
+        let checkpoint = Checkpoint::load(&path).unwrap();
+        assert_eq!(checkpoint.entries().len(), 3);
+        assert_eq!(checkpoint.addresses().collect::<Vec<_>>(), ["google_storage_bucket.b", r#"module.kms.google_kms_crypto_key.this["a"]"#]);
+        assert_eq!(
+            fs::read_to_string(&path).unwrap().lines().next().unwrap(),
+            r#"{"address":"module.kms.google_kms_crypto_key.this[\"a\"]","status":"success"}"#
+        );
+    }
+
+    /// **TEST** - Only an incomplete last line may be malformed
+    #[test]
+    fn test_checkpoint_rejects_malformed_lines() {
+        let dir = TempDir::new().unwrap();
+        let path = dir.path().join("checkpoint.ndjson");
+
+        fs::write(&path, "{\"address\":\"a.b\",\"status\":\"skipped\"}\n\n{\"addr").unwrap();
+        assert_eq!(Checkpoint::load(&path).unwrap().len(), 1);
+
+        fs::write(&path, "{\"address\":\"a.b\",\"status\":\"skipped\"}\nnot json\n").unwrap();
+        let error = Checkpoint::load(&path).unwrap_err();
+        assert!(matches!(error, CheckpointError::InvalidEntry { line: 2, .. }), "{}", error);
+
+        assert!(CheckpointEntry::is_finished(ImportStatus::Unsupported));
+        assert!(!CheckpointEntry::is_finished(ImportStatus::Failed));
+        assert!(!CheckpointEntry::is_finished(ImportStatus::DryRun));
+    }
+}
@@ -10,6 +10,7 @@
 //! - **Batch Processing**: Generate commands for multiple resources
 //! - **Path Resolution**: Handle module directory path resolution
 //! - **String Formatting**: Generate formatted command strings for display
+//! - **Binary Selection**: Target terragrunt (the default) or plain terraform via `ImportBinary`
 //! 
 //! ## Usage Pattern
 //! 
@@ -17,10 +18,148 @@
 //! 2. Use build_command() for individual resources or build_all_commands() for batches
 //! 3. Commands can be executed via the executor module or displayed as strings
 
+use std::fmt;
 use std::path::{Path, PathBuf};
+use std::str::FromStr;
 use crate::importer::{ModuleMeta, ResourceWithId};
+use crate::utils::shell_quote;
 use super::ImportCommand;
 
+/// The program that runs import and state commands
+/// 
+/// Everything that differs between the two lives here: the program name and how the
+/// module directory is selected. Commands are always executed with the module directory
+/// as the process working directory, so the executed arguments carry no directory flag.
+/// The printed form adds one so it can be pasted into a shell anywhere:
+/// 
+/// - terragrunt: `terragrunt import -config-dir=<dir> <extra args> <address> <id>`
+/// - terraform: `terraform -chdir=<dir> import <extra args> <address> <id>` (`-chdir` is a
+///   global option and must precede the subcommand)
+/// 
+/// Extra arguments (`--extra-arg`) always follow the `import` subcommand and any directory
+/// flag, in the order given, and precede the address and ID: terraform stops reading
+/// options at the first positional argument.
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::commands::builder::ImportBinary;
+/// use std::path::Path;
+/// 
+/// let binary: ImportBinary = "terraform".parse().unwrap();
+/// assert_eq!(binary.program(), "terraform");
+/// assert_eq!(binary.import_args(&[], "aws_vpc.main", "vpc-1"), ["import", "aws_vpc.main", "vpc-1"]);
+/// 
+/// let extra_args = ["-lock-timeout=60s".to_string()];
+/// assert_eq!(
+///     binary.format_import_command(Path::new("modules/vpc"), &extra_args, "aws_vpc.main", "vpc-1"),
+///     "terraform -chdir=modules/vpc import -lock-timeout=60s aws_vpc.main vpc-1"
+/// );
+/// ```
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum ImportBinary {
+    /// Terragrunt wrapping terraform (default)
+    #[default]
+    Terragrunt,
+    /// Plain terraform, for modules not managed by terragrunt
+    Terraform,
+}
+
+impl ImportBinary {
+    /// Name of the executable to run
+    pub fn program(&self) -> &'static str {
+        match self {
+            ImportBinary::Terragrunt => "terragrunt",
+            ImportBinary::Terraform => "terraform",
+        }
+    }
+
+    /// Arguments of an import run with the module directory as working directory
+    pub fn import_args(&self, extra_args: &[String], resource_address: &str, resource_id: &str) -> Vec<String> {
+        let mut args = vec!["import".to_string()];
+        args.extend(extra_args.iter().cloned());
+        args.push(resource_address.to_string());
+        args.push(resource_id.to_string());
+        args
+    }
+
+    /// Formats an import command as a copy-pasteable shell string
+    /// 
+    /// Every argument is shell-quoted, so indexed addresses such as
+    /// `module.kms.google_kms_crypto_key.this["primary"]` are printed in a form
+    /// that can be run as-is.
+    /// 
+    /// # Arguments
+    /// * `working_directory` - Module directory the command runs against
+    /// * `extra_args` - Extra arguments placed after the subcommand and directory flag
+    /// * `resource_address` - Full terraform resource address
+    /// * `resource_id` - Cloud resource ID to import
+    pub fn format_import_command(&self, working_directory: &Path, extra_args: &[String], resource_address: &str, resource_id: &str) -> String {
+        let directory = working_directory.display();
+        let (before, after) = match self {
+            ImportBinary::Terragrunt => (None, Some(format!("-config-dir={}", directory))),
+            ImportBinary::Terraform => (Some(format!("-chdir={}", directory)), None),
+        };
+
+        let mut words = vec![self.program().to_string()];
+        words.extend(before.map(|flag| shell_quote(&flag)));
+        words.push("import".to_string());
+        words.extend(after.map(|flag| shell_quote(&flag)));
+        words.extend(extra_args.iter().map(|arg| shell_quote(arg)));
+        words.push(shell_quote(resource_address));
+        words.push(shell_quote(resource_id));
+        words.join(" ")
+    }
+}
+
+impl fmt::Display for ImportBinary {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.program())
+    }
+}
+
+impl FromStr for ImportBinary {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s {
+            "terragrunt" => Ok(ImportBinary::Terragrunt),
+            "terraform" => Ok(ImportBinary::Terraform),
+            other => Err(format!("unknown binary '{}' (expected terragrunt or terraform)", other)),
+        }
+    }
+}
+
+/// Formats a terragrunt import command as a copy-pasteable shell string
+/// 
+/// Shorthand for `ImportBinary::Terragrunt.format_import_command`.
+/// 
+/// # Arguments
+/// * `working_directory` - Module directory the command runs against
+/// * `resource_address` - Full terraform resource address
+/// * `resource_id` - Cloud resource ID to import
+/// 
+/// # Returns
+/// Formatted terragrunt import command string
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::commands::builder::format_import_command;
+/// use std::path::Path;
+/// 
+/// let command = format_import_command(
+///     Path::new("./modules/kms"),
+///     r#"google_kms_crypto_key.this["primary"]"#,
+///     "projects/p/locations/l/keyRings/r/cryptoKeys/primary",
+/// );
+/// assert_eq!(
+///     command,
+///     r#"terragrunt import -config-dir=./modules/kms 'google_kms_crypto_key.this["primary"]' projects/p/locations/l/keyRings/r/cryptoKeys/primary"#
+/// );
+/// ```
+pub fn format_import_command(working_directory: &Path, resource_address: &str, resource_id: &str) -> String {
+    ImportBinary::Terragrunt.format_import_command(working_directory, &[], resource_address, resource_id)
+}
+
 /// Builder for creating terragrunt import commands
 /// 
 /// This builder provides a convenient interface for constructing terragrunt import
@@ -29,6 +168,7 @@ use super::ImportCommand;
 /// 
 /// # Fields
 /// - `module_root`: Base directory for resolving module paths
+/// - `binary`: Program the built commands run (terragrunt unless set with `with_binary`)
 /// 
 /// # Examples
 /// ```no_run
@@ -40,6 +180,10 @@ use super::ImportCommand;
 pub struct ImportCommandBuilder {
     /// Base directory for resolving relative module paths
     module_root: PathBuf,
+    /// Program the built commands run
+    binary: ImportBinary,
+    /// Extra arguments passed to every built command
+    extra_args: Vec<String>,
 }
 
 impl ImportCommandBuilder {
@@ -65,9 +209,23 @@ impl ImportCommandBuilder {
     pub fn new<P: AsRef<Path>>(module_root: P) -> Self {
         Self {
             module_root: module_root.as_ref().to_path_buf(),
+            binary: ImportBinary::default(),
+            extra_args: Vec::new(),
         }
     }
 
+    /// Makes built commands run `binary` instead of terragrunt
+    pub fn with_binary(mut self, binary: ImportBinary) -> Self {
+        self.binary = binary;
+        self
+    }
+
+    /// Passes `extra_args` to every built command, see `ImportBinary` for their position
+    pub fn with_extra_args(mut self, extra_args: Vec<String>) -> Self {
+        self.extra_args = extra_args;
+        self
+    }
+
     /// Builds a single terragrunt import command for a resource
     /// 
     /// This method constructs a complete ImportCommand object containing all the
@@ -102,6 +260,7 @@ impl ImportCommandBuilder {
 ///     values: None,
 ///     sensitive_values: None,
 ///     depends_on: None,
+///     module_address: None,
 /// };
 /// 
 /// let terraform_resource = TerraformResource {
@@ -120,6 +279,7 @@ impl ImportCommandBuilder {
 /// 
 /// let resource_with_id = ResourceWithId {
 ///     resource: &resource,
+///     address: "module.test.aws_vpc.main".parse().unwrap(),
 ///     terraform_resource,
 ///     id: "vpc-12345".to_string(),
 ///     module_meta: &module_meta,
@@ -135,10 +295,12 @@ impl ImportCommandBuilder {
         
         ImportCommand {
             working_directory: full_path,
-            resource_address: resource.resource.address.clone(),
+            resource_address: resource.address.to_string(),
             resource_id: resource.id.clone(),
             resource_type: resource.resource.r#type.clone(),
             module_name: module.key.clone(),
+            binary: self.binary,
+            extra_args: self.extra_args.clone(),
         }
     }
 
@@ -174,6 +336,7 @@ impl ImportCommandBuilder {
 ///     values: None,
 ///     sensitive_values: None,
 ///     depends_on: None,
+///     module_address: None,
 /// };
 /// 
 /// let terraform_resource1 = TerraformResource {
@@ -192,6 +355,7 @@ impl ImportCommandBuilder {
 /// 
 /// let resource_with_id1 = ResourceWithId {
 ///     resource: &resource1,
+///     address: "module.test.aws_vpc.main".parse().unwrap(),
 ///     terraform_resource: terraform_resource1,
 ///     id: "vpc-12345".to_string(),
 ///     module_meta: &module_meta1,
@@ -244,6 +408,7 @@ impl ImportCommandBuilder {
 ///     values: None,
 ///     sensitive_values: None,
 ///     depends_on: None,
+///     module_address: None,
 /// };
 /// 
 /// let terraform_resource = TerraformResource {
@@ -262,6 +427,7 @@ impl ImportCommandBuilder {
 /// 
 /// let resource_with_id = ResourceWithId {
 ///     resource: &resource,
+///     address: "module.test.aws_vpc.main".parse().unwrap(),
 ///     terraform_resource,
 ///     id: "vpc-12345".to_string(),
 ///     module_meta: &module_meta,
@@ -273,11 +439,6 @@ impl ImportCommandBuilder {
 /// ```
     pub fn build_command_string(&self, resource: &ResourceWithId) -> String {
         let full_path = self.module_root.join(&resource.module_meta.dir);
-        format!(
-            "terragrunt import -config-dir={} {} {}",
-            full_path.display(),
-            resource.resource.address,
-            resource.id
-        )
+        self.binary.format_import_command(&full_path, &self.extra_args, &resource.resource.address, &resource.id)
     }
 } 
\ No newline at end of file
@@ -0,0 +1,91 @@
+//! # Cancellation Module
+//!
+//! Long import runs can hang, e.g. when a state lock is never released. A
+//! `CancellationToken` carried in `ImportOptions` lets the caller stop a run cleanly:
+//! once it is cancelled (explicitly, or because its deadline passed) no further imports
+//! are started and in-flight import processes are killed.
+//!
+//! ## Key Components
+//!
+//! - **CancellationToken**: Cloneable flag with an optional deadline, shared by all workers
+
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::Arc;
+use std::time::{Duration, Instant};
+
+/// Shared cancellation flag with an optional deadline
+///
+/// Clones share the same flag, so a clone can be handed to another thread (or a
+/// signal handler) and cancelled from there. The default token never cancels.
+///
+/// # Examples
+/// ```
+/// use std::time::Duration;
+/// use terragrunt_import_from_plan::commands::CancellationToken;
+///
+/// let token = CancellationToken::new();
+/// let handle = token.clone();
+/// assert!(!token.is_cancelled());
+/// handle.cancel();
+/// assert!(token.is_cancelled());
+///
+/// let expired = CancellationToken::with_timeout(Duration::ZERO);
+/// assert!(expired.is_cancelled());
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct CancellationToken {
+    cancelled: Arc<AtomicBool>,
+    deadline: Option<Instant>,
+}
+
+impl CancellationToken {
+    /// Creates a token that is only cancelled by `cancel`
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Creates a token that is cancelled once `timeout` has elapsed from now
+    pub fn with_timeout(timeout: Duration) -> Self {
+        Self {
+            cancelled: Arc::new(AtomicBool::new(false)),
+            deadline: Some(Instant::now() + timeout),
+        }
+    }
+
+    /// Cancels the token and every clone of it
+    pub fn cancel(&self) {
+        self.cancelled.store(true, Ordering::SeqCst);
+    }
+
+    /// Returns true once `cancel` was called or the deadline has passed
+    pub fn is_cancelled(&self) -> bool {
+        self.cancelled.load(Ordering::SeqCst) || self.deadline.is_some_and(|deadline| Instant::now() >= deadline)
+    }
+}
+
+/// Unit tests for cancellation and deadlines
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// **TEST** - Cancelling a clone cancels every handle; the default never cancels
+    #[test]
+    fn test_cancel_is_shared_between_clones() {
+        let token = CancellationToken::default();
+        let clones: Vec<CancellationToken> = (0..3).map(|_| token.clone()).collect();
+        assert!(clones.iter().all(|clone| !clone.is_cancelled()));
+        clones[1].cancel();
+        assert!(token.is_cancelled());
+        assert!(clones.iter().all(CancellationToken::is_cancelled));
+    }
+
+    /// **TEST** - A timeout cancels the token once its deadline passes
+    #[test]
+    fn test_timeout_cancels_after_deadline() {
+        let token = CancellationToken::with_timeout(Duration::from_millis(30));
+        assert!(!token.is_cancelled());
+        std::thread::sleep(Duration::from_millis(40));
+        assert!(token.is_cancelled());
+        assert!(CancellationToken::with_timeout(Duration::ZERO).is_cancelled());
+    }
+}
@@ -7,17 +7,27 @@
 //! ## Key Features
 //! 
 //! - **Command Execution**: Execute terragrunt import commands with proper error handling
-//! - **Batch Processing**: Execute multiple commands sequentially (required for terraform state)
+//! - **Batch Processing**: Execute multiple commands, sequentially or with a bounded worker pool
 //! - **Dry-Run Support**: Simulate command execution without making changes
 //! - **Performance Tracking**: Track execution times and batch statistics
 //! - **Comprehensive Error Handling**: Detailed error reporting with exit codes and output
 //! 
 //! ## Important Notes
 //! 
-//! - **Sequential Execution Only**: Commands MUST be executed sequentially due to terraform
-//!   state management limitations. Concurrent execution would cause state corruption.
+//! - **Per-Module Serialization**: Commands targeting the same working directory share a
+//!   state file and are always run one after another. With `ImportOptions::workers` above 1,
+//!   different module directories are imported concurrently; this is only safe when each
+//!   directory has its own state, or the backend supports concurrent locking (e.g. S3 with
+//!   DynamoDB, GCS). The default of one worker keeps execution fully sequential.
 //! - **Directory Validation**: Working directories are validated before command execution
-//! - **Output Capture**: Both stdout and stderr are captured for error analysis
+//! - **Output Capture**: Both stdout and stderr are captured into per-process buffers,
+//!   never inherited, so concurrent workers don't interleave; a failure's output ends up
+//!   in its report entry, bounded by `ImportOptions::max_output_bytes`
+//! - **Cancellation**: Once `ImportOptions::cancellation` is cancelled or times out, running
+//!   import processes are killed, along with the terraform processes they started, and no
+//!   new ones are started; both end up as `Interrupted`
+//! - **Per-Import Timeout**: With `ImportOptions::per_import_timeout`, a single import that
+//!   runs too long is killed and reported as `TimedOut` while the run continues
 //! 
 //! ## Usage Pattern
 //! 
@@ -25,10 +35,23 @@
 //! 2. Use ImportExecutor to execute commands individually or in batches
 //! 3. Handle results based on success/failure with detailed error information
 
+use std::collections::HashMap;
 use std::path::PathBuf;
-use std::process::Command;
+use std::sync::atomic::{AtomicBool, AtomicUsize, Ordering};
+use std::sync::Mutex;
+use std::time::Duration;
 use anyhow::Result;
 use thiserror::Error;
+use crate::address::ModuleCall;
+use crate::checkpoint::{Checkpoint, CheckpointWriter};
+use crate::filter::ResourceFilter;
+use crate::logging::SharedLogger;
+use crate::reporting::{print_import_progress, ImportOperation};
+use crate::sensitive::Redactor;
+use super::builder::ImportBinary;
+use super::cancel::CancellationToken;
+use super::retry::RetryConfig;
+use super::runner::{CommandRunner, RunOutcome, SystemCommandRunner};
 
 /// Represents a terragrunt import command ready to be executed
 /// 
@@ -43,10 +66,13 @@ use thiserror::Error;
 /// - `resource_id`: Cloud resource ID to import
 /// - `resource_type`: Terraform resource type (e.g., "aws_vpc")
 /// - `module_name`: Name of the terragrunt module
+/// - `binary`: Program that runs the import (terragrunt or terraform)
+/// - `extra_args`: Extra arguments placed before the address, see `ImportBinary`
 /// 
 /// # Examples
 /// ```no_run
 /// use terragrunt_import_from_plan::commands::executor::ImportCommand;
+/// use terragrunt_import_from_plan::commands::builder::ImportBinary;
 /// use std::path::PathBuf;
 /// 
 /// let command = ImportCommand {
@@ -55,6 +81,8 @@ use thiserror::Error;
 ///     resource_id: "vpc-12345".to_string(),
 ///     resource_type: "aws_vpc".to_string(),
 ///     module_name: "vpc".to_string(),
+///     binary: ImportBinary::Terragrunt,
+///     extra_args: Vec::new(),
 /// };
 /// ```
 #[derive(Debug, Clone)]
@@ -69,6 +97,154 @@ pub struct ImportCommand {
     pub resource_type: String,
     /// Name of the terragrunt module for reference
     pub module_name: String,
+    /// Program that runs the import
+    pub binary: ImportBinary,
+    /// Extra arguments passed through to the import subcommand
+    pub extra_args: Vec<String>,
+}
+
+impl ImportCommand {
+    /// Arguments passed to `binary.program()`, which runs in `working_directory`
+    pub fn args(&self) -> Vec<String> {
+        self.binary.import_args(&self.extra_args, &self.resource_address, &self.resource_id)
+    }
+
+    /// Formats this command as a copy-pasteable shell string
+    /// 
+    /// # Returns
+    /// The import command with the module directory flag and all arguments shell-quoted
+    pub fn command_string(&self) -> String {
+        self.binary.format_import_command(&self.working_directory, &self.extra_args, &self.resource_address, &self.resource_id)
+    }
+}
+
+/// Options controlling how import commands are executed
+/// 
+/// # Fields
+/// - `dry_run`: Print each command instead of executing it
+/// - `skip_state_check`: Attempt imports even for addresses already in state
+/// - `retry`: Retry policy for transient import failures
+/// - `workers`: Maximum number of concurrent imports (0 or 1 runs sequentially)
+/// - `fail_fast`: Stop starting new imports after the first failure
+/// - `max_errors`: Stop starting new imports once this many have failed
+/// - `state_backup_dir`: Back up each module's state here before importing
+/// - `filter`: Include/exclude address patterns applied when generating commands
+/// - `validate_ids`: Check import IDs against their builder's formats before importing
+/// - `binary`: Program used for imports and state commands (terragrunt by default)
+/// - `resolve_unknown_from_state`: Fill plan-unknown builder attributes from pulled state
+/// - `allow_duplicate_ids`: Import even when two addresses resolve to the same import ID
+/// - `skip_replacements`: Skip resources the plan replaces instead of aborting the run
+/// - `logger`: Destination for diagnostic messages
+/// - `cancellation`: Token that stops the run when cancelled or past its deadline
+/// - `per_import_timeout`: Deadline for each individual import attempt
+/// - `strip_module_prefix`: Module the execution directory corresponds to; addresses are made relative to it
+/// - `redactor`: Sensitive values masked in logs, progress output and the report
+/// - `extra_args`: Arguments appended to every import command, e.g. `-lock-timeout=60s`
+/// - `checkpoint`: Append every finished resource to this checkpoint file
+/// - `resume`: Resources finished by an earlier run, skipped without being checked again
+/// - `check_version`: Detect the binary's version before importing and warn if it is too old
+/// - `verify`: Re-check every imported address against state and a targeted plan afterwards
+/// - `workspace`: Workspace selected in every module directory before anything else runs
+/// - `max_output_bytes`: Limit on the captured output kept for each failed import
+/// - `preflight`: Check the binary and working directories before anything runs
+/// - `skip_terragrunt_config_check`: Accept working directories without a `terragrunt.hcl`
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::commands::executor::ImportOptions;
+/// 
+/// let options = ImportOptions { dry_run: true, ..Default::default() };
+/// assert!(options.dry_run);
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct ImportOptions {
+    /// Print the fully-formed commands to stdout and skip execution
+    pub dry_run: bool,
+    /// Don't consult existing state for resources that are already imported
+    pub skip_state_check: bool,
+    /// Retry policy for failed imports (no retries by default)
+    pub retry: RetryConfig,
+    /// Maximum number of imports to run concurrently; commands for the same module
+    /// directory are always serialized
+    pub workers: usize,
+    /// Don't start any further imports once one has failed
+    pub fail_fast: bool,
+    /// Don't start any further imports once this many have failed, counted across all
+    /// workers; None never stops. `fail_fast` is the same as a threshold of 1
+    pub max_errors: Option<usize>,
+    /// Directory for state backups taken before the first import; None disables backups
+    pub state_backup_dir: Option<PathBuf>,
+    /// Selects which plan resources get import commands; the default selects all
+    pub filter: ResourceFilter,
+    /// Fail resources whose import ID doesn't match their builder's formats instead of importing them
+    pub validate_ids: bool,
+    /// Program that runs imports and state commands in each module directory
+    pub binary: ImportBinary,
+    /// When a builder lacks an attribute that is unknown in the plan, look it up in the
+    /// module's current state (`state pull`) before skipping the resource
+    pub resolve_unknown_from_state: bool,
+    /// Only warn, instead of aborting the run, when two addresses resolve to the same
+    /// resource type and import ID
+    pub allow_duplicate_ids: bool,
+    /// Skip resources whose planned action is a replacement, with a warning, instead of
+    /// aborting the run before any import
+    pub skip_replacements: bool,
+    /// Receives diagnostics, including every executed command at debug level
+    pub logger: SharedLogger,
+    /// Kills running imports and stops starting new ones once cancelled; never cancels by default
+    pub cancellation: CancellationToken,
+    /// Kill an import attempt that runs longer than this; the attempt counts as failed
+    /// and is retried if `retry` allows another attempt. None waits indefinitely
+    pub per_import_timeout: Option<Duration>,
+    /// Module path of the execution directory when the plan was generated at the root
+    /// module, e.g. `module.app`. When non-empty, every import runs in the module root
+    /// directory with its address relative to this module, and resources outside it are
+    /// skipped (see `ResourceAddress::strip_module_prefix`). Empty by default
+    pub strip_module_prefix: Vec<ModuleCall>,
+    /// Values shown as `***` in logs, progress output and the report; the import itself
+    /// still uses them. `execute_or_print_imports` adds every value the plan marks as
+    /// sensitive, so this only needs setting for secrets the plan doesn't know about
+    pub redactor: Redactor,
+    /// Passed through to every import, after the `import` subcommand (and terragrunt's
+    /// `-config-dir`) and before the address and ID. Empty by default
+    pub extra_args: Vec<String>,
+    /// Records each imported, already-in-state, skipped or unsupported resource as soon as
+    /// it finishes; nothing is recorded in dry-run mode. None by default
+    pub checkpoint: Option<CheckpointWriter>,
+    /// Addresses recorded by an earlier run's checkpoint; they are skipped before their
+    /// import ID is resolved. Entries that aren't planned creates are ignored with a warning
+    pub resume: Checkpoint,
+    /// Run `<binary> --version` first, record the version in the report and warn if it
+    /// is older than `version::minimum_version`. Off by default
+    pub check_version: bool,
+    /// After the imports, check that every imported address is in state and that a plan
+    /// targeting it no longer creates, replaces or destroys it (see `verify::verify_imports`).
+    /// Costs one plan per module directory; ignored in dry-run mode. Off by default
+    pub verify: bool,
+    /// Workspace to select (`workspace select`) in every module directory before state is
+    /// read or anything is imported; a workspace that doesn't exist aborts the run. In
+    /// dry-run mode it is only checked to exist. None leaves the selected workspace alone
+    pub workspace: Option<String>,
+    /// Keep at most this many bytes (the end) of each failed import's captured output in
+    /// its report entry and error log (see `truncate_output`). None keeps all of it
+    pub max_output_bytes: Option<usize>,
+    /// Before anything runs, check that the binary is on PATH and that every working
+    /// directory exists and holds a configuration (see `preflight::preflight`). Off by default
+    pub preflight: bool,
+    /// With `preflight`, let terragrunt run in working directories that only hold `*.tf`
+    /// files, i.e. plain terraform modules without a `terragrunt.hcl`
+    pub skip_terragrunt_config_check: bool,
+}
+
+impl ImportOptions {
+    /// Skip reason for imports reported as `ImportResult::Cancelled` under these options
+    pub fn cancelled_reason(&self) -> &'static str {
+        if self.fail_fast {
+            FAIL_FAST_SKIP_REASON
+        } else {
+            MAX_ERRORS_SKIP_REASON
+        }
+    }
 }
 
 /// Result of executing a single import command
@@ -81,6 +257,9 @@ pub struct ImportCommand {
 /// - `Success`: Command executed successfully with timing information
 /// - `Failed`: Command failed with detailed error information
 /// - `DryRun`: Dry-run simulation showing command without execution
+/// - `Cancelled`: Command was never started because of `fail_fast` or `max_errors`
+/// - `Interrupted`: Command was killed, or never started, because the run was cancelled or timed out
+/// - `TimedOut`: Command was killed because it exceeded the per-import timeout
 #[derive(Debug)]
 pub enum ImportResult {
     /// Command executed successfully
@@ -102,6 +281,8 @@ pub enum ImportResult {
         stdout: String,
         /// Exit code from the terragrunt process
         exit_code: i32,
+        /// Execution time in milliseconds
+        execution_time_ms: u128,
     },
     /// Dry-run result showing command without execution
     DryRun {
@@ -110,6 +291,87 @@ pub enum ImportResult {
         /// Full command string that would be executed
         command_string: String,
     },
+    /// Command was not started because of `fail_fast`, or `max_errors` was reached
+    Cancelled {
+        /// Resource address that was not imported
+        address: String,
+    },
+    /// Command was killed or not started because the run's cancellation token fired
+    Interrupted {
+        /// Resource address that was not (or not completely) imported
+        address: String,
+        /// Time the command ran before it was killed; 0 if it never started
+        execution_time_ms: u128,
+    },
+    /// Command was killed after exceeding `ImportOptions::per_import_timeout` on every attempt
+    TimedOut {
+        /// Resource address that timed out
+        address: String,
+        /// Description of the timeout, including the attempt count after retries
+        error: String,
+        /// Execution time in milliseconds, across all attempts
+        execution_time_ms: u128,
+    },
+}
+
+impl ImportResult {
+    /// Returns the resource address this result refers to
+    pub fn address(&self) -> &str {
+        match self {
+            ImportResult::Success { address, .. }
+            | ImportResult::Failed { address, .. }
+            | ImportResult::DryRun { address, .. }
+            | ImportResult::Cancelled { address }
+            | ImportResult::Interrupted { address, .. }
+            | ImportResult::TimedOut { address, .. } => address,
+        }
+    }
+
+    /// Returns a failure's short status line: its exit code, or the timeout
+    /// 
+    /// The captured output is left out, so limits such as `truncate_output` applied to
+    /// `captured_output` aren't bypassed. Returns None for results that aren't failures.
+    pub fn failure_message(&self) -> Option<String> {
+        match self {
+            ImportResult::Failed { error, .. } | ImportResult::TimedOut { error, .. } => Some(error.clone()),
+            ImportResult::Success { .. }
+            | ImportResult::DryRun { .. }
+            | ImportResult::Cancelled { .. }
+            | ImportResult::Interrupted { .. } => None,
+        }
+    }
+
+    /// Returns a failed command's captured stdout followed by its stderr
+    /// 
+    /// Returns None for results that aren't failures, or captured nothing.
+    pub fn captured_output(&self) -> Option<String> {
+        let ImportResult::Failed { stderr, stdout, .. } = self else { return None };
+        let output: Vec<&str> = [stdout.trim_end(), stderr.trim_end()].into_iter().filter(|part| !part.trim().is_empty()).collect();
+        (!output.is_empty()).then(|| output.join("\n"))
+    }
+}
+
+/// Shortens `output` to at most `max_bytes` bytes of its end, marking what was cut
+/// 
+/// Terraform prints its diagnostics last, so the end is kept. The cut never splits a
+/// character; the marker line is not counted against `max_bytes`.
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::commands::executor::truncate_output;
+/// 
+/// assert_eq!(truncate_output("Error: bucket not found", 100), "Error: bucket not found");
+/// assert_eq!(truncate_output("Initializing...\nError: bucket not found", 23), "[... 16 bytes truncated]\nError: bucket not found");
+/// ```
+pub fn truncate_output(output: &str, max_bytes: usize) -> String {
+    if output.len() <= max_bytes {
+        return output.to_string();
+    }
+    let mut start = output.len() - max_bytes;
+    while !output.is_char_boundary(start) {
+        start += 1;
+    }
+    format!("[... {} bytes truncated]\n{}", start, &output[start..])
 }
 
 /// Result of executing a batch of import commands
@@ -120,6 +382,9 @@ pub enum ImportResult {
 /// # Fields
 /// - `successful`: Vector of successful import results
 /// - `failed`: Vector of failed import results  
+/// - `dry_run`: Vector of dry-run results (only populated in dry-run mode)
+/// - `cancelled`: Vector of results for commands skipped after a fail-fast failure or interrupted by cancellation
+/// - `commands`: Command strings in the order they were run (or would have been run)
 /// - `total_executed`: Total number of commands processed
 /// - `total_duration_ms`: Total time taken for the entire batch
 /// 
@@ -140,6 +405,13 @@ pub struct BatchResult {
     pub successful: Vec<ImportResult>,
     /// Vector of failed import results with error details
     pub failed: Vec<ImportResult>,
+    /// Vector of dry-run results, one per command, when executed in dry-run mode
+    pub dry_run: Vec<ImportResult>,
+    /// Vector of commands that were never started because of `fail_fast`, or were
+    /// interrupted because the run was cancelled
+    pub cancelled: Vec<ImportResult>,
+    /// Fully-formed command strings in execution order
+    pub commands: Vec<String>,
     /// Total number of commands that were processed
     pub total_executed: usize,
     /// Total duration for the entire batch operation in milliseconds
@@ -198,6 +470,7 @@ pub enum ImportExecutionError {
 /// # Examples
 /// ```no_run
 /// use terragrunt_import_from_plan::commands::executor::{ImportExecutor, ImportCommand, ImportResult};
+/// use terragrunt_import_from_plan::commands::builder::ImportBinary;
 /// use std::path::PathBuf;
 /// 
 /// # fn main() -> Result<(), Box<dyn std::error::Error>> {
@@ -207,6 +480,8 @@ pub enum ImportExecutionError {
 ///     resource_id: "vpc-12345".to_string(),
 ///     resource_type: "aws_vpc".to_string(),
 ///     module_name: "vpc".to_string(),
+///     binary: ImportBinary::Terragrunt,
+///     extra_args: Vec::new(),
 /// };
 /// let executor = ImportExecutor;
 /// let result = executor.execute_command(&command)?;
@@ -239,7 +514,7 @@ impl ImportExecutor {
     /// 
     /// # Process
     /// 1. Validates working directory exists
-    /// 2. Executes `terragrunt import {resource_address} {resource_id}`
+    /// 2. Executes `{binary} import {resource_address} {resource_id}` with the directory as working directory
     /// 3. Captures stdout, stderr, and exit code
     /// 4. Measures execution time
     /// 5. Returns structured result based on success/failure
@@ -251,6 +526,7 @@ impl ImportExecutor {
     /// # Examples
     /// ```no_run
     /// use terragrunt_import_from_plan::commands::executor::{ImportExecutor, ImportCommand, ImportResult};
+    /// use terragrunt_import_from_plan::commands::builder::ImportBinary;
     /// use std::path::PathBuf;
     /// 
     /// # fn main() -> Result<(), Box<dyn std::error::Error>> {
@@ -260,6 +536,8 @@ impl ImportExecutor {
     ///     resource_id: "vpc-12345".to_string(),
     ///     resource_type: "aws_vpc".to_string(),
     ///     module_name: "vpc".to_string(),
+    ///     binary: ImportBinary::Terragrunt,
+    ///     extra_args: Vec::new(),
     /// };
     /// let executor = ImportExecutor;
     /// match executor.execute_command(&command)? {
@@ -270,12 +548,30 @@ impl ImportExecutor {
     ///         eprintln!("❌ Failed to import {}: {}", address, error);
     ///         eprintln!("Error details: {}", stderr);
     ///     }
-    ///     _ => unreachable!(),
+    ///     ImportResult::TimedOut { address, error, .. } => {
+    ///         eprintln!("⏱️ Failed to import {}: {}", address, error);
+    ///     }
+    ///     ImportResult::DryRun { .. } | ImportResult::Cancelled { .. } | ImportResult::Interrupted { .. } => {}
     /// }
     /// # Ok(())
     /// # }
     /// ```
     pub fn execute_command(&self, command: &ImportCommand) -> Result<ImportResult, ImportExecutionError> {
+        self.execute_until_cancelled(command, &SystemCommandRunner, &CancellationToken::default(), None)
+    }
+
+    /// Executes a command like `execute_command` through `runner`, killing it once `cancellation` fires
+    /// 
+    /// A killed process yields `ImportResult::Interrupted` and its partial output is
+    /// discarded. A process still running after `timeout` is killed the same way and
+    /// yields `ImportResult::TimedOut`.
+    fn execute_until_cancelled(
+        &self,
+        command: &ImportCommand,
+        runner: &dyn CommandRunner,
+        cancellation: &CancellationToken,
+        timeout: Option<Duration>,
+    ) -> Result<ImportResult, ImportExecutionError> {
         if !command.working_directory.exists() {
             return Err(ImportExecutionError::DirectoryNotFound {
                 path: command.working_directory.display().to_string(),
@@ -283,35 +579,34 @@ impl ImportExecutor {
         }
 
         let start_time = std::time::Instant::now();
-
-        let output = Command::new("terragrunt")
-            .arg("import")
-            .arg(&command.resource_address)
-            .arg(&command.resource_id)
-            .current_dir(&command.working_directory)
-            .output()
+        let args = command.args();
+        let args: Vec<&str> = args.iter().map(String::as_str).collect();
+        let outcome = runner
+            .run_until(command.binary.program(), &args, &command.working_directory, cancellation, timeout)
             .map_err(|source| ImportExecutionError::CommandFailed { source })?;
-
+        let address = command.resource_address.clone();
         let execution_time_ms = start_time.elapsed().as_millis();
 
-        if output.status.success() {
-            Ok(ImportResult::Success {
-                address: command.resource_address.clone(),
+        Ok(match outcome {
+            RunOutcome::Finished(output) if output.success() => ImportResult::Success { address, execution_time_ms },
+            RunOutcome::Finished(output) => {
+                let exit_code = output.exit_code.unwrap_or(-1);
+                ImportResult::Failed {
+                    address,
+                    error: format!("Import failed with exit code {}", exit_code),
+                    stderr: output.stderr,
+                    stdout: output.stdout,
+                    exit_code,
+                    execution_time_ms,
+                }
+            }
+            RunOutcome::TimedOut => ImportResult::TimedOut {
+                address,
+                error: format!("Import timed out after {:?}", timeout.unwrap_or_default()),
                 execution_time_ms,
-            })
-        } else {
-            let stderr = String::from_utf8_lossy(&output.stderr).to_string();
-            let stdout = String::from_utf8_lossy(&output.stdout).to_string();
-            let exit_code = output.status.code().unwrap_or(-1);
-
-            Ok(ImportResult::Failed {
-                address: command.resource_address.clone(),
-                error: format!("Import failed with exit code {}", exit_code),
-                stderr,
-                stdout,
-                exit_code,
-            })
-        }
+            },
+            RunOutcome::Cancelled => ImportResult::Interrupted { address, execution_time_ms },
+        })
     }
 
     /// Executes a batch of import commands sequentially
@@ -336,6 +631,7 @@ impl ImportExecutor {
     /// # Examples
     /// ```no_run
     /// use terragrunt_import_from_plan::commands::executor::{ImportExecutor, ImportCommand, ImportResult};
+    /// use terragrunt_import_from_plan::commands::builder::ImportBinary;
     /// use std::path::PathBuf;
     /// 
     /// let commands = vec![ImportCommand {
@@ -344,6 +640,8 @@ impl ImportExecutor {
     ///     resource_id: "vpc-12345".to_string(),
     ///     resource_type: "aws_vpc".to_string(),
     ///     module_name: "vpc".to_string(),
+    ///     binary: ImportBinary::Terragrunt,
+    ///     extra_args: Vec::new(),
     /// }];
     /// let executor = ImportExecutor;
     /// let result = executor.execute_batch(&commands);
@@ -364,15 +662,23 @@ impl ImportExecutor {
         let start_time = std::time::Instant::now();
         let mut successful = Vec::new();
         let mut failed = Vec::new();
+        let mut dry_run = Vec::new();
+        let mut cancelled = Vec::new();
 
         for command in commands {
             match self.execute_command(command) {
                 Ok(result @ ImportResult::Success { .. }) => {
                     successful.push(result);
                 }
-                Ok(result @ ImportResult::Failed { .. }) => {
+                Ok(result @ (ImportResult::Failed { .. } | ImportResult::TimedOut { .. })) => {
                     failed.push(result);
                 }
+                Ok(result @ ImportResult::DryRun { .. }) => {
+                    dry_run.push(result);
+                }
+                Ok(result @ (ImportResult::Cancelled { .. } | ImportResult::Interrupted { .. })) => {
+                    cancelled.push(result);
+                }
                 Err(err) => {
                     failed.push(ImportResult::Failed {
                         address: command.resource_address.clone(),
@@ -380,9 +686,9 @@ impl ImportExecutor {
                         stderr: String::new(),
                         stdout: String::new(),
                         exit_code: -1,
+                        execution_time_ms: 0,
                     });
                 }
-                _ => unreachable!(),
             }
         }
 
@@ -392,10 +698,283 @@ impl ImportExecutor {
             total_executed: commands.len(),
             successful,
             failed,
+            dry_run,
+            cancelled,
+            commands: commands.iter().map(|command| command.command_string()).collect(),
             total_duration_ms,
         }
     }
 
+    /// Runs a single command according to the given options
+    /// 
+    /// In dry-run mode the command is only formatted; otherwise it is executed
+    /// as `execute_command` would, retrying failures whose output matches
+    /// `options.retry`. When more than one attempt was made, the error of the
+    /// final failure records the attempt count. Execution time covers all attempts,
+    /// including the delays between them.
+    /// 
+    /// If `options.cancellation` fires before or during an attempt, the result is
+    /// `Interrupted` and no further attempts are made. An attempt killed by
+    /// `options.per_import_timeout` counts as a failed attempt and is always retried
+    /// while attempts remain.
+    /// 
+    /// # Arguments
+    /// * `command` - ImportCommand to run
+    /// * `options` - Execution options
+    /// * `runner` - Runs the import process
+    /// 
+    /// # Returns
+    /// The ImportResult for the command
+    /// 
+    /// # Errors
+    /// Same as `execute_command` when not in dry-run mode
+    pub fn run_command(&self, command: &ImportCommand, options: &ImportOptions, runner: &dyn CommandRunner) -> Result<ImportResult, ImportExecutionError> {
+        if options.dry_run {
+            return Ok(self.dry_run_command(command));
+        }
+        if options.cancellation.is_cancelled() {
+            return Ok(ImportResult::Interrupted { address: command.resource_address.clone(), execution_time_ms: 0 });
+        }
+
+        options.logger.debug(&format!(
+            "Executing in {}: {}",
+            command.working_directory.display(),
+            options.redactor.redact(&command.command_string())
+        ));
+        let start_time = std::time::Instant::now();
+        let (result, attempts) = options.retry.retry(
+            || {
+                if options.cancellation.is_cancelled() {
+                    return Ok(ImportResult::Interrupted { address: command.resource_address.clone(), execution_time_ms: 0 });
+                }
+                self.execute_until_cancelled(command, runner, &options.cancellation, options.per_import_timeout)
+            },
+            |result| match result {
+                Ok(ImportResult::Failed { stderr, stdout, .. }) => {
+                    options.retry.is_retryable(&format!("{}\n{}", stderr, stdout))
+                }
+                Ok(ImportResult::TimedOut { .. }) => true,
+                _ => false,
+            },
+            |attempt, delay| {
+                options.logger.warn(&format!(
+                    "🔁 Retrying {} in {}ms (attempt {}/{})",
+                    command.resource_address,
+                    delay.as_millis(),
+                    attempt,
+                    options.retry.max_attempts
+                ));
+            },
+        );
+
+        let execution_time_ms = start_time.elapsed().as_millis();
+        match result {
+            Ok(ImportResult::Success { address, .. }) => Ok(ImportResult::Success { address, execution_time_ms }),
+            Ok(ImportResult::Failed { address, error, stderr, stdout, exit_code, .. }) => {
+                let error = if attempts > 1 {
+                    format!("{} after {} attempts", error, attempts)
+                } else {
+                    error
+                };
+                Ok(ImportResult::Failed { address, error, stderr, stdout, exit_code, execution_time_ms })
+            }
+            Ok(ImportResult::TimedOut { address, error, .. }) => {
+                let error = if attempts > 1 {
+                    format!("{} after {} attempts", error, attempts)
+                } else {
+                    error
+                };
+                Ok(ImportResult::TimedOut { address, error, execution_time_ms })
+            }
+            Ok(ImportResult::Interrupted { address, .. }) => Ok(ImportResult::Interrupted { address, execution_time_ms }),
+            other => other,
+        }
+    }
+
+    /// Executes or prints a batch of import commands according to the given options
+    /// 
+    /// This is the main entry point for running imports. With `dry_run` set, each
+    /// fully-formed command is printed to stdout and nothing is executed. Either way
+    /// the returned BatchResult lists the command strings so callers can inspect
+    /// exactly what was (or would have been) run.
+    /// 
+    /// Otherwise up to `options.workers` imports run concurrently (see the module docs for
+    /// the locking caveat). Progress is printed as each import finishes, but the results in
+    /// the BatchResult are always in the order of `commands`. A failure doesn't affect other
+    /// imports unless `options.fail_fast` is set, or `options.max_errors` imports have failed,
+    /// in which case commands that haven't started yet are reported as `Cancelled`. Once `options.cancellation` fires, running imports are
+    /// killed and the remaining ones are not started; all of them are reported as `Interrupted`.
+    /// 
+    /// # Arguments
+    /// * `commands` - Slice of ImportCommand objects to run
+    /// * `options` - Execution options
+    /// 
+    /// # Returns
+    /// BatchResult containing categorized results and the command strings
+    /// 
+    /// # Examples
+    /// ```no_run
+    /// use terragrunt_import_from_plan::commands::executor::{ImportExecutor, ImportCommand, ImportOptions};
+    /// use terragrunt_import_from_plan::commands::builder::ImportBinary;
+    /// use std::path::PathBuf;
+    /// 
+    /// let commands = vec![ImportCommand {
+    ///     working_directory: PathBuf::from("./modules/kms"),
+    ///     resource_address: r#"google_kms_crypto_key.this["primary"]"#.to_string(),
+    ///     resource_id: "projects/p/locations/l/keyRings/r/cryptoKeys/primary".to_string(),
+    ///     resource_type: "google_kms_crypto_key".to_string(),
+    ///     module_name: "kms".to_string(),
+    ///     binary: ImportBinary::Terragrunt,
+    ///     extra_args: Vec::new(),
+    /// }];
+    /// let executor = ImportExecutor;
+    /// let result = executor.execute_imports(&commands, &ImportOptions { dry_run: true, ..Default::default() });
+    /// 
+    /// for command in &result.commands {
+    ///     println!("{}", command);
+    /// }
+    /// ```
+    pub fn execute_imports(&self, commands: &[ImportCommand], options: &ImportOptions) -> BatchResult {
+        self.execute_imports_with(commands, options, &SystemCommandRunner, &|_, _| {})
+    }
+
+    /// `execute_imports`, running imports through `runner` and calling `on_finished` with
+    /// each command's index and result
+    /// 
+    /// `on_finished` is called as soon as a command finishes (or is cancelled), from the
+    /// worker that ran it, so results arrive in completion order rather than input order.
+    pub fn execute_imports_with(
+        &self,
+        commands: &[ImportCommand],
+        options: &ImportOptions,
+        runner: &dyn CommandRunner,
+        on_finished: &(dyn Fn(usize, &ImportResult) + Sync),
+    ) -> BatchResult {
+        if !options.dry_run {
+            let start_time = std::time::Instant::now();
+            let mut successful = Vec::new();
+            let mut failed = Vec::new();
+            let mut cancelled = Vec::new();
+
+            for result in self.run_pool(commands, options, runner, on_finished) {
+                match result {
+                    ImportResult::Success { .. } => successful.push(result),
+                    ImportResult::Cancelled { .. } | ImportResult::Interrupted { .. } => cancelled.push(result),
+                    _ => failed.push(result),
+                }
+            }
+
+            return BatchResult {
+                total_executed: commands.len(),
+                successful,
+                failed,
+                dry_run: Vec::new(),
+                cancelled,
+                commands: commands.iter().map(|command| command.command_string()).collect(),
+                total_duration_ms: start_time.elapsed().as_millis(),
+            };
+        }
+
+        let dry_run = self.dry_run_batch(commands);
+        for (index, result) in dry_run.iter().enumerate() {
+            if let ImportResult::DryRun { address, command_string } = result {
+                print_import_progress(address, ImportOperation::DryRun { command: options.redactor.redact(command_string) });
+            }
+            on_finished(index, result);
+        }
+
+        BatchResult {
+            total_executed: commands.len(),
+            successful: Vec::new(),
+            failed: Vec::new(),
+            commands: commands.iter().map(|command| command.command_string()).collect(),
+            dry_run,
+            cancelled: Vec::new(),
+            total_duration_ms: 0,
+        }
+    }
+
+    /// Runs commands on a pool of worker threads and returns results in input order
+    /// 
+    /// Commands are grouped by working directory and each group is handled by a single
+    /// worker, so imports into the same state never overlap. With one worker, all commands
+    /// form one group and run strictly in order.
+    fn run_pool(
+        &self,
+        commands: &[ImportCommand],
+        options: &ImportOptions,
+        runner: &dyn CommandRunner,
+        on_finished: &(dyn Fn(usize, &ImportResult) + Sync),
+    ) -> Vec<ImportResult> {
+        let groups = group_by_working_directory(commands, options.workers > 1);
+        let next_group = AtomicUsize::new(0);
+        let stop = AtomicBool::new(false);
+        let failures = AtomicUsize::new(0);
+        let successes = AtomicUsize::new(0);
+        let results: Mutex<Vec<Option<ImportResult>>> = Mutex::new((0..commands.len()).map(|_| None).collect());
+
+        let worker = || loop {
+            let group = next_group.fetch_add(1, Ordering::SeqCst);
+            let Some(indices) = groups.get(group) else { break };
+
+            for &index in indices {
+                let command = &commands[index];
+                let result = if options.cancellation.is_cancelled() {
+                    ImportResult::Interrupted { address: command.resource_address.clone(), execution_time_ms: 0 }
+                } else if stop.load(Ordering::SeqCst) {
+                    ImportResult::Cancelled { address: command.resource_address.clone() }
+                } else {
+                    let result = self.run_command(command, options, runner).unwrap_or_else(|err| ImportResult::Failed {
+                        address: command.resource_address.clone(),
+                        error: err.to_string(),
+                        stderr: String::new(),
+                        stdout: String::new(),
+                        exit_code: -1,
+                        execution_time_ms: 0,
+                    });
+                    match result {
+                        ImportResult::Success { .. } => {
+                            successes.fetch_add(1, Ordering::SeqCst);
+                        }
+                        ImportResult::Failed { .. } | ImportResult::TimedOut { .. } => {
+                            let failed = failures.fetch_add(1, Ordering::SeqCst) + 1;
+                            if options.fail_fast {
+                                stop.store(true, Ordering::SeqCst);
+                            } else if let Some(max_errors) = options.max_errors.filter(|max_errors| failed == *max_errors) {
+                                stop.store(true, Ordering::SeqCst);
+                                options.logger.warn(&format!(
+                                    "⛔ Not starting further imports: {} failed (--max-errors {}), {} succeeded so far",
+                                    failed,
+                                    max_errors,
+                                    successes.load(Ordering::SeqCst)
+                                ));
+                            }
+                        }
+                        _ => {}
+                    }
+                    result
+                };
+                report_progress(&result, options);
+                on_finished(index, &result);
+                results.lock().unwrap()[index] = Some(result);
+            }
+        };
+
+        let workers = options.workers.clamp(1, groups.len().max(1));
+        std::thread::scope(|scope| {
+            for _ in 0..workers {
+                scope.spawn(worker);
+            }
+        });
+
+        results
+            .into_inner()
+            .unwrap()
+            .into_iter()
+            .map(|result| result.expect("every command is assigned to exactly one worker"))
+            .collect()
+    }
+
     /// Creates a dry-run result without executing the command
     /// 
     /// This method simulates command execution by generating the exact command string
@@ -411,6 +990,7 @@ impl ImportExecutor {
     /// # Examples
     /// ```no_run
     /// use terragrunt_import_from_plan::commands::executor::{ImportExecutor, ImportCommand, ImportResult};
+    /// use terragrunt_import_from_plan::commands::builder::ImportBinary;
     /// use std::path::PathBuf;
     /// 
     /// let command = ImportCommand {
@@ -419,6 +999,8 @@ impl ImportExecutor {
     ///     resource_id: "vpc-12345".to_string(),
     ///     resource_type: "aws_vpc".to_string(),
     ///     module_name: "vpc".to_string(),
+    ///     binary: ImportBinary::Terragrunt,
+    ///     extra_args: Vec::new(),
     /// };
     /// let executor = ImportExecutor;
     /// let result = executor.dry_run_command(&command);
@@ -429,16 +1011,9 @@ impl ImportExecutor {
     /// }
     /// ```
     pub fn dry_run_command(&self, command: &ImportCommand) -> ImportResult {
-        let command_string = format!(
-            "terragrunt import -config-dir={} {} {}",
-            command.working_directory.display(),
-            command.resource_address,
-            command.resource_id
-        );
-
         ImportResult::DryRun {
             address: command.resource_address.clone(),
-            command_string,
+            command_string: command.command_string(),
         }
     }
 
@@ -457,6 +1032,7 @@ impl ImportExecutor {
     /// # Examples
     /// ```no_run
     /// use terragrunt_import_from_plan::commands::executor::{ImportExecutor, ImportCommand, ImportResult};
+    /// use terragrunt_import_from_plan::commands::builder::ImportBinary;
     /// use std::path::PathBuf;
     /// 
     /// let commands = vec![ImportCommand {
@@ -465,6 +1041,8 @@ impl ImportExecutor {
     ///     resource_id: "vpc-12345".to_string(),
     ///     resource_type: "aws_vpc".to_string(),
     ///     module_name: "vpc".to_string(),
+    ///     binary: ImportBinary::Terragrunt,
+    ///     extra_args: Vec::new(),
     /// }];
     /// let executor = ImportExecutor;
     /// let dry_run_results = executor.dry_run_batch(&commands);
@@ -482,4 +1060,52 @@ impl ImportExecutor {
             .map(|command| self.dry_run_command(command))
             .collect()
     }
-} 
\ No newline at end of file
+} 
+
+/// Skip reason for imports that were cancelled by `fail_fast`
+pub const FAIL_FAST_SKIP_REASON: &str = "not started because an earlier import failed (fail-fast)";
+
+/// Skip reason for imports that were cancelled because `max_errors` was reached
+pub const MAX_ERRORS_SKIP_REASON: &str = "not started because too many imports failed (max-errors)";
+
+/// Error recorded for imports that were interrupted by cancellation or `--timeout`
+pub const INTERRUPTED_ERROR: &str = "cancelled before completion (run was cancelled or timed out)";
+
+/// Splits command indices into groups that must run sequentially
+/// 
+/// With `by_directory` set, each working directory forms one group, in order of first
+/// appearance; otherwise all commands form a single group.
+fn group_by_working_directory(commands: &[ImportCommand], by_directory: bool) -> Vec<Vec<usize>> {
+    if !by_directory {
+        return vec![(0..commands.len()).collect()];
+    }
+
+    let mut groups: Vec<Vec<usize>> = Vec::new();
+    let mut group_for_directory: HashMap<&PathBuf, usize> = HashMap::new();
+    for (index, command) in commands.iter().enumerate() {
+        let group = *group_for_directory.entry(&command.working_directory).or_insert_with(|| {
+            groups.push(Vec::new());
+            groups.len() - 1
+        });
+        groups[group].push(index);
+    }
+    groups
+}
+
+/// Prints the progress line for a finished (or cancelled) import
+fn report_progress(result: &ImportResult, options: &ImportOptions) {
+    let redactor = &options.redactor;
+    match result {
+        ImportResult::Success { address, .. } => print_import_progress(address, ImportOperation::Success),
+        ImportResult::Failed { address, .. } | ImportResult::TimedOut { address, .. } => print_import_progress(address, ImportOperation::Failed {
+            error: redactor.redact(&result.failure_message().unwrap_or_default()),
+        }),
+        ImportResult::DryRun { address, command_string } => print_import_progress(address, ImportOperation::DryRun {
+            command: redactor.redact(command_string),
+        }),
+        ImportResult::Cancelled { address } => print_import_progress(address, ImportOperation::Skipped {
+            reason: options.cancelled_reason().to_string(),
+        }),
+        ImportResult::Interrupted { address, .. } => print_import_progress(address, ImportOperation::Cancelled),
+    }
+}
@@ -1,5 +1,11 @@
 pub mod builder;
+pub mod cancel;
 pub mod executor;
+pub mod retry;
+pub mod runner;
 
-pub use builder::ImportCommandBuilder;
-pub use executor::{ImportExecutor, ImportCommand, ImportResult, BatchResult}; 
\ No newline at end of file
+pub use builder::{ImportBinary, ImportCommandBuilder};
+pub use cancel::CancellationToken;
+pub use executor::{ImportExecutor, ImportCommand, ImportOptions, ImportResult, BatchResult};
+pub use retry::RetryConfig;
+pub use runner::{CommandOutput, CommandRunner, RunOutcome, SystemCommandRunner};
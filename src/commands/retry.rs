@@ -0,0 +1,263 @@
+//! # Import Retry Module
+//!
+//! Cloud APIs intermittently reject imports with 503s and rate-limit errors. This module
+//! decides which failures are worth retrying and how long to wait between attempts,
+//! mirroring Terratest's `WithDefaultRetryableErrors` concept: a list of error patterns
+//! that are known to be transient, plus exponential backoff with jitter.
+//!
+//! ## Key Components
+//!
+//! - **RetryConfig**: Attempt limit, backoff parameters and retryable error patterns
+//! - **DEFAULT_RETRYABLE_ERRORS**: Patterns for transient provider and backend errors
+//! - **DEFAULT_NON_RETRYABLE_ERRORS**: Patterns that must fail fast even if they also match above
+//!
+//! ## Pattern Matching
+//!
+//! Patterns are regular expressions matched against the combined stderr and stdout of
+//! the failed command. A pattern that isn't a valid regex is matched as a plain substring.
+
+use std::collections::hash_map::RandomState;
+use std::hash::{BuildHasher, Hasher};
+use std::time::Duration;
+use regex::Regex;
+
+/// Error patterns that indicate a transient failure worth retrying
+pub const DEFAULT_RETRYABLE_ERRORS: &[&str] = &[
+    r"\b503\b",
+    r"\b429\b",
+    r"(?i)service unavailable",
+    r"(?i)rate ?limit",
+    r"(?i)rate exceeded",
+    r"(?i)too many requests",
+    r"RESOURCE_EXHAUSTED",
+    r"(?i)quota exceeded",
+    r"(?i)connection reset by peer",
+    r"(?i)TLS handshake timeout",
+    r"(?i)i/o timeout",
+    r"(?i)error acquiring the state lock",
+];
+
+/// Error patterns that are never retried because another attempt can't succeed
+pub const DEFAULT_NON_RETRYABLE_ERRORS: &[&str] = &[
+    r"(?i)resource already managed by terraform",
+    r"(?i)cannot import non-existent remote object",
+    r"(?i)\bnot found\b",
+];
+
+/// Retry and backoff settings for import execution
+///
+/// The delay before retry `n` (1-based) is `base_delay * 2^(n-1)`, capped at
+/// `max_delay`, then randomly adjusted by up to `jitter` (a fraction, e.g. `0.2`
+/// for ±20%) so parallel runs don't retry in lockstep.
+///
+/// The default configuration makes a single attempt and never retries; use
+/// `with_default_retryable_errors` to opt in to retries for known transient errors.
+///
+/// # Examples
+/// ```
+/// use std::time::Duration;
+/// use terragrunt_import_from_plan::commands::retry::RetryConfig;
+///
+/// let config = RetryConfig {
+///     max_attempts: 5,
+///     ..RetryConfig::with_default_retryable_errors()
+/// };
+/// assert!(config.is_retryable("googleapi: Error 503: The service is currently unavailable"));
+/// assert!(!config.is_retryable("Error: Resource already managed by Terraform"));
+/// assert_eq!(config.backoff_delay(2), Duration::from_secs(2));
+/// ```
+#[derive(Debug, Clone, PartialEq)]
+pub struct RetryConfig {
+    /// Total attempts per import, including the first (1 disables retries)
+    pub max_attempts: u32,
+    /// Delay before the first retry
+    pub base_delay: Duration,
+    /// Upper bound for any single delay, before jitter
+    pub max_delay: Duration,
+    /// Fraction of the delay to randomly add or subtract (0.0 disables jitter)
+    pub jitter: f64,
+    /// Regex patterns identifying retryable failures
+    pub retryable_errors: Vec<String>,
+    /// Regex patterns that always fail fast, taking precedence over `retryable_errors`
+    pub non_retryable_errors: Vec<String>,
+}
+
+impl Default for RetryConfig {
+    fn default() -> Self {
+        Self {
+            max_attempts: 1,
+            base_delay: Duration::from_secs(1),
+            max_delay: Duration::from_secs(30),
+            jitter: 0.0,
+            retryable_errors: Vec::new(),
+            non_retryable_errors: Vec::new(),
+        }
+    }
+}
+
+impl RetryConfig {
+    /// Creates a configuration that retries known transient errors up to 3 times in total
+    pub fn with_default_retryable_errors() -> Self {
+        Self {
+            max_attempts: 3,
+            jitter: 0.2,
+            retryable_errors: DEFAULT_RETRYABLE_ERRORS.iter().map(|p| p.to_string()).collect(),
+            non_retryable_errors: DEFAULT_NON_RETRYABLE_ERRORS.iter().map(|p| p.to_string()).collect(),
+            ..Self::default()
+        }
+    }
+
+    /// Returns true if a failure with this output should be retried
+    ///
+    /// # Arguments
+    /// * `output` - Combined error output of the failed command
+    pub fn is_retryable(&self, output: &str) -> bool {
+        if matches_any(&self.non_retryable_errors, output) {
+            return false;
+        }
+        matches_any(&self.retryable_errors, output)
+    }
+
+    /// Returns the delay before retry number `retry` (1-based), without jitter
+    pub fn backoff_delay(&self, retry: u32) -> Duration {
+        let factor = 2u32.saturating_pow(retry.saturating_sub(1));
+        self.base_delay.saturating_mul(factor).min(self.max_delay)
+    }
+
+    /// Returns the delay before retry number `retry` (1-based), with jitter applied
+    pub fn delay_for(&self, retry: u32) -> Duration {
+        let delay = self.backoff_delay(retry);
+        if self.jitter <= 0.0 {
+            return delay;
+        }
+        let jitter = self.jitter.min(1.0);
+        let unit = (RandomState::new().build_hasher().finish() as f64) / (u64::MAX as f64);
+        delay.mul_f64(1.0 - jitter + 2.0 * jitter * unit)
+    }
+
+    /// Runs `operation` until it succeeds, isn't retryable, or attempts run out
+    ///
+    /// # Arguments
+    /// * `operation` - Performs one attempt
+    /// * `should_retry` - Decides from an attempt's result whether to try again
+    /// * `on_retry` - Called before sleeping with the next attempt number and the delay
+    ///
+    /// # Returns
+    /// Tuple of (result of the last attempt, number of attempts made)
+    pub fn retry<T>(
+        &self,
+        mut operation: impl FnMut() -> T,
+        mut should_retry: impl FnMut(&T) -> bool,
+        mut on_retry: impl FnMut(u32, Duration),
+    ) -> (T, u32) {
+        let max_attempts = self.max_attempts.max(1);
+        let mut attempt = 1;
+        loop {
+            let result = operation();
+            if attempt >= max_attempts || !should_retry(&result) {
+                return (result, attempt);
+            }
+            let delay = self.delay_for(attempt);
+            on_retry(attempt + 1, delay);
+            std::thread::sleep(delay);
+            attempt += 1;
+        }
+    }
+}
+
+/// Returns true if any pattern matches `output`
+fn matches_any(patterns: &[String], output: &str) -> bool {
+    patterns.iter().any(|pattern| match Regex::new(pattern) {
+        Ok(re) => re.is_match(output),
+        Err(_) => output.contains(pattern.as_str()),
+    })
+}
+
+/// Unit tests for retry decisions and backoff
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn instant_config(max_attempts: u32) -> RetryConfig {
+        RetryConfig {
+            max_attempts,
+            base_delay: Duration::ZERO,
+            jitter: 0.0,
+            ..RetryConfig::with_default_retryable_errors()
+        }
+    }
+
+    /// **TEST** - Transient errors are retryable, permanent ones fail fast
+    #[test]
+    fn test_default_retryable_errors() {
+        let config = RetryConfig::with_default_retryable_errors();
+        assert!(config.is_retryable("Error 429: Quota exceeded for quota metric"));
+        assert!(config.is_retryable("rpc error: code = Unavailable desc = 503 Service Unavailable"));
+        assert!(config.is_retryable("Error: RESOURCE_EXHAUSTED"));
+        assert!(!config.is_retryable("Error: Resource already managed by Terraform"));
+        assert!(!config.is_retryable("Error 404: KeyRing not found"));
+        assert!(!config.is_retryable("Error: Cannot import non-existent remote object"));
+        assert!(!config.is_retryable("Error: Invalid resource address"));
+        assert!(!RetryConfig::default().is_retryable("503 Service Unavailable"));
+    }
+
+    /// **TEST** - Backoff doubles from the base delay and is capped
+    #[test]
+    fn test_backoff_delay() {
+        let config = RetryConfig {
+            base_delay: Duration::from_millis(500),
+            max_delay: Duration::from_secs(3),
+            ..RetryConfig::default()
+        };
+        assert_eq!(config.backoff_delay(1), Duration::from_millis(500));
+        assert_eq!(config.backoff_delay(2), Duration::from_secs(1));
+        assert_eq!(config.backoff_delay(3), Duration::from_secs(2));
+        assert_eq!(config.backoff_delay(4), Duration::from_secs(3));
+        assert_eq!(config.backoff_delay(40), Duration::from_secs(3));
+    }
+
+    /// **TEST** - Jitter keeps delays within the configured fraction
+    #[test]
+    fn test_delay_jitter_bounds() {
+        let config = RetryConfig {
+            base_delay: Duration::from_secs(10),
+            max_delay: Duration::from_secs(10),
+            jitter: 0.5,
+            ..RetryConfig::default()
+        };
+        for _ in 0..50 {
+            let delay = config.delay_for(1);
+            assert!(delay >= Duration::from_secs(5) && delay <= Duration::from_secs(15), "{:?}", delay);
+        }
+    }
+
+    /// **TEST** - Retries stop at max attempts and report the attempt count
+    #[test]
+    fn test_retry_exhausts_attempts() {
+        let config = instant_config(3);
+        let mut calls = 0;
+        let mut retries = Vec::new();
+        let (result, attempts) = config.retry(
+            || { calls += 1; "503 Service Unavailable" },
+            |output| config.is_retryable(output),
+            |attempt, _| retries.push(attempt),
+        );
+        assert_eq!(result, "503 Service Unavailable");
+        assert_eq!(attempts, 3);
+        assert_eq!(calls, 3);
+        assert_eq!(retries, vec![2, 3]);
+    }
+
+    /// **TEST** - Non-retryable results and successes return immediately
+    #[test]
+    fn test_retry_stops_early() {
+        let config = instant_config(5);
+        let (_, attempts) = config.retry(|| "Resource already managed by Terraform", |o| config.is_retryable(o), |_, _| {});
+        assert_eq!(attempts, 1);
+
+        let mut outputs = vec!["ok", "rate limit exceeded"];
+        let (result, attempts) = config.retry(|| outputs.pop().unwrap(), |o| config.is_retryable(o), |_, _| {});
+        assert_eq!(result, "ok");
+        assert_eq!(attempts, 2);
+    }
+}
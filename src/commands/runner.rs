@@ -0,0 +1,249 @@
+//! # Command Runner Module
+//!
+//! This module abstracts process execution behind the `CommandRunner` trait so that
+//! code which shells out to terragrunt (imports, state inspection, backups) can be
+//! exercised against a fake runner in tests instead of a real binary.
+//!
+//! ## Key Components
+//!
+//! - **CommandRunner**: Trait for running a program with arguments in a directory
+//! - **CommandOutput**: Captured exit code, stdout and stderr of a finished process
+//! - **RunOutcome**: How a process run with a cancellation token and timeout ended
+//! - **SystemCommandRunner**: Default implementation backed by `std::process::Command`
+
+use std::io::{self, Read};
+use std::path::Path;
+use std::process::{Child, Command, Stdio};
+use std::time::{Duration, Instant};
+use super::cancel::CancellationToken;
+
+/// Captured result of a finished process
+///
+/// # Fields
+/// - `exit_code`: Process exit code, or `None` if it was terminated by a signal
+/// - `stdout`: Everything written to standard output, lossily decoded as UTF-8
+/// - `stderr`: Everything written to standard error, lossily decoded as UTF-8
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct CommandOutput {
+    /// Process exit code, `None` when terminated by a signal
+    pub exit_code: Option<i32>,
+    /// Captured standard output
+    pub stdout: String,
+    /// Captured standard error
+    pub stderr: String,
+}
+
+impl CommandOutput {
+    /// Returns true if the process exited with code 0
+    pub fn success(&self) -> bool {
+        self.exit_code == Some(0)
+    }
+}
+
+/// How a process started by `CommandRunner::run_until` ended
+///
+/// # Variants
+/// - `Finished`: The process exited on its own
+/// - `Cancelled`: The process was killed, or never started, because the token was cancelled
+/// - `TimedOut`: The process was killed after running longer than the timeout
+#[derive(Debug, Clone, PartialEq)]
+pub enum RunOutcome {
+    /// The process exited on its own, successfully or not
+    Finished(CommandOutput),
+    /// The process was killed or not started because the cancellation token fired
+    Cancelled,
+    /// The process was killed after exceeding the timeout
+    TimedOut,
+}
+
+/// Runs external programs and captures their output
+///
+/// Implementations must run `program` with `args` in `working_directory` and wait
+/// for it to finish. A non-zero exit is not an error at this level; it is reported
+/// through `CommandOutput::exit_code` so callers can decide how to handle it.
+///
+/// Runners are shared by the import workers, so they must be `Sync`.
+///
+/// # Examples
+/// ```
+/// use std::io;
+/// use std::path::Path;
+/// use terragrunt_import_from_plan::commands::runner::{CommandOutput, CommandRunner};
+///
+/// struct FakeRunner;
+///
+/// impl CommandRunner for FakeRunner {
+///     fn run(&self, _program: &str, _args: &[&str], _working_directory: &Path) -> io::Result<CommandOutput> {
+///         Ok(CommandOutput { exit_code: Some(0), stdout: "aws_vpc.main\n".to_string(), stderr: String::new() })
+///     }
+/// }
+///
+/// let output = FakeRunner.run("terragrunt", &["state", "list"], Path::new(".")).unwrap();
+/// assert!(output.success());
+/// ```
+pub trait CommandRunner: Sync {
+    /// Runs `program` with `args` in `working_directory` and returns its captured output
+    ///
+    /// # Errors
+    /// Returns an error if the process could not be started
+    fn run(&self, program: &str, args: &[&str], working_directory: &Path) -> io::Result<CommandOutput>;
+
+    /// Runs a command like `run`, killing it once `cancellation` fires or it has run
+    /// longer than `timeout`
+    ///
+    /// The default checks `cancellation` before starting and then waits for `run`, so
+    /// runners that don't start real processes only need to implement `run`.
+    ///
+    /// # Errors
+    /// Returns an error if the process could not be started
+    fn run_until(
+        &self,
+        program: &str,
+        args: &[&str],
+        working_directory: &Path,
+        cancellation: &CancellationToken,
+        _timeout: Option<Duration>,
+    ) -> io::Result<RunOutcome> {
+        if cancellation.is_cancelled() {
+            return Ok(RunOutcome::Cancelled);
+        }
+        self.run(program, args, working_directory).map(RunOutcome::Finished)
+    }
+}
+
+/// How often a running process is checked for completion, cancellation or timeout
+const POLL_INTERVAL: Duration = Duration::from_millis(20);
+
+/// Default CommandRunner that spawns real processes
+#[derive(Debug, Clone, Copy, Default)]
+pub struct SystemCommandRunner;
+
+impl CommandRunner for SystemCommandRunner {
+    fn run(&self, program: &str, args: &[&str], working_directory: &Path) -> io::Result<CommandOutput> {
+        let output = Command::new(program)
+            .args(args)
+            .current_dir(working_directory)
+            .output()?;
+
+        Ok(CommandOutput {
+            exit_code: output.status.code(),
+            stdout: String::from_utf8_lossy(&output.stdout).to_string(),
+            stderr: String::from_utf8_lossy(&output.stderr).to_string(),
+        })
+    }
+
+    /// Polls the process every `POLL_INTERVAL`; a killed process's partial output is discarded
+    fn run_until(
+        &self,
+        program: &str,
+        args: &[&str],
+        working_directory: &Path,
+        cancellation: &CancellationToken,
+        timeout: Option<Duration>,
+    ) -> io::Result<RunOutcome> {
+        if cancellation.is_cancelled() {
+            return Ok(RunOutcome::Cancelled);
+        }
+        let start_time = Instant::now();
+        let mut command = Command::new(program);
+        command
+            .args(args)
+            .current_dir(working_directory)
+            .stdout(Stdio::piped())
+            .stderr(Stdio::piped());
+        // Terragrunt runs terraform as a child; its own group lets both be killed together
+        #[cfg(unix)]
+        std::os::unix::process::CommandExt::process_group(&mut command, 0);
+        let mut child = command.spawn()?;
+        let stdout_reader = drain_pipe(child.stdout.take());
+        let stderr_reader = drain_pipe(child.stderr.take());
+
+        let status = loop {
+            if let Some(status) = child.try_wait()? {
+                break status;
+            }
+            let timed_out = timeout.is_some_and(|timeout| start_time.elapsed() >= timeout);
+            if cancellation.is_cancelled() || timed_out {
+                kill_process_tree(&mut child);
+                let _ = child.wait();
+                // The readers are left detached: a grandchild may still hold the pipes open
+                return Ok(if cancellation.is_cancelled() { RunOutcome::Cancelled } else { RunOutcome::TimedOut });
+            }
+            std::thread::sleep(POLL_INTERVAL);
+        };
+
+        Ok(RunOutcome::Finished(CommandOutput {
+            exit_code: status.code(),
+            stdout: stdout_reader.join().unwrap_or_default(),
+            stderr: stderr_reader.join().unwrap_or_default(),
+        }))
+    }
+}
+
+/// Kills `child` together with every process it started
+///
+/// On unix the child leads its own process group (see `SystemCommandRunner::run_until`),
+/// so the whole group is signalled: killing only terragrunt would leave its terraform
+/// child running, possibly holding the state lock or completing the import.
+fn kill_process_tree(child: &mut Child) {
+    #[cfg(unix)]
+    if let Ok(pgid) = libc::pid_t::try_from(child.id()) {
+        // SAFETY: kill only sends a signal; a negative pid addresses the child's process group
+        if unsafe { libc::kill(-pgid, libc::SIGKILL) } == 0 {
+            return;
+        }
+    }
+    let _ = child.kill();
+}
+
+/// Reads a child's output pipe to the end on a separate thread
+///
+/// Both pipes must be drained concurrently while the child runs, or a child writing
+/// more than a pipe buffer's worth of output would block forever.
+fn drain_pipe<R: Read + Send + 'static>(pipe: Option<R>) -> std::thread::JoinHandle<String> {
+    std::thread::spawn(move || {
+        let mut buffer = Vec::new();
+        if let Some(mut pipe) = pipe {
+            let _ = pipe.read_to_end(&mut buffer);
+        }
+        String::from_utf8_lossy(&buffer).to_string()
+    })
+}
+
+/// Unit tests for the system runner
+#[cfg(all(test, unix))]
+mod tests {
+    use super::*;
+
+    /// **TEST** - A timed-out process is killed with the children it started
+    ///
+    /// The shell starts a background child that outlives it and writes a marker after
+    /// half a second; killing only the shell would let the marker appear.
+    #[test]
+    fn test_timeout_kills_process_group() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let marker = temp_dir.path().join("marker");
+        let script = format!("(sleep 0.5; touch '{}') & wait", marker.display());
+
+        let outcome = SystemCommandRunner
+            .run_until("sh", &["-c", &script], temp_dir.path(), &CancellationToken::default(), Some(Duration::from_millis(100)))
+            .unwrap();
+        assert_eq!(outcome, RunOutcome::TimedOut);
+        std::thread::sleep(Duration::from_millis(800));
+        assert!(!marker.exists(), "the background child survived the timeout");
+    }
+
+    /// **TEST** - Cancellation kills a running process; a finished one keeps its output
+    #[test]
+    fn test_run_until_cancellation_and_output() {
+        let temp_dir = tempfile::tempdir().unwrap();
+        let cancellation = CancellationToken::with_timeout(Duration::from_millis(100));
+        let outcome = SystemCommandRunner.run_until("sleep", &["30"], temp_dir.path(), &cancellation, None).unwrap();
+        assert_eq!(outcome, RunOutcome::Cancelled);
+
+        let outcome = SystemCommandRunner
+            .run_until("sh", &["-c", "echo out; echo err >&2; exit 3"], temp_dir.path(), &CancellationToken::default(), None)
+            .unwrap();
+        assert_eq!(outcome, RunOutcome::Finished(CommandOutput { exit_code: Some(3), stdout: "out\n".to_string(), stderr: "err\n".to_string() }));
+    }
+}
@@ -0,0 +1,198 @@
+//! # Builder Coverage Module
+//!
+//! A coverage check lists every distinct managed resource type in a plan and whether an
+//! import ID builder is registered for it. It is the unsupported-type detection of an
+//! import run as a standalone check, so CI can fail a change that introduces a type
+//! nothing knows how to import before anyone tries to import it.
+//!
+//! ## Scope
+//!
+//! Every managed resource in the plan's planned values counts, whatever its planned
+//! action: a type that is only updated today may be created in the next environment.
+//! Data sources are never imported and are left out.
+//!
+//! ## Output Formats
+//!
+//! - **Table**: Aligned columns for humans
+//! - **JSON**: The same rows for tooling
+//!
+//! Rows are sorted by resource type in both formats.
+
+use std::collections::BTreeMap;
+use anyhow::{Context, Result};
+use serde::Serialize;
+use crate::builders::ImportIdBuilderRegistry;
+use crate::importer::PlanFile;
+use crate::utils::collect_resources;
+
+/// One resource type's row in a Coverage
+///
+/// # Fields
+/// - `resource_type`: Terraform resource type
+/// - `resources`: Number of resources of the type in the plan
+/// - `has_builder`: Whether an import ID builder is registered for the type
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct TypeCoverage {
+    /// Terraform resource type
+    pub resource_type: String,
+    /// Number of resources of the type in the plan
+    pub resources: usize,
+    /// Whether an import ID builder is registered for the type
+    pub has_builder: bool,
+}
+
+/// Builder coverage of every managed resource type in a plan, sorted by type
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::builders::ImportIdBuilderRegistry;
+/// use terragrunt_import_from_plan::coverage::Coverage;
+///
+/// let plan = serde_json::from_value(serde_json::json!({
+///     "format_version": "1.2",
+///     "terraform_version": "1.9.0",
+///     "planned_values": {"root_module": {"resources": [
+///         {"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs"},
+///         {"address": "acme_widget.w", "mode": "managed", "type": "acme_widget", "name": "w"}
+///     ]}}
+/// })).unwrap();
+///
+/// let coverage = Coverage::new(&plan, &ImportIdBuilderRegistry::default());
+/// assert_eq!(coverage.missing(), vec!["acme_widget"]);
+/// assert!(!coverage.is_complete());
+/// ```
+#[derive(Debug, Clone, Default, PartialEq, Serialize)]
+pub struct Coverage {
+    /// One row per resource type, sorted by type
+    pub resource_types: Vec<TypeCoverage>,
+}
+
+impl Coverage {
+    /// Checks the managed resource types of `plan` against `builders`
+    pub fn new(plan: &PlanFile, builders: &ImportIdBuilderRegistry) -> Self {
+        let mut resources = Vec::new();
+        if let Some(planned_values) = &plan.planned_values {
+            collect_resources(&planned_values.root_module, &mut resources);
+        }
+
+        let mut counts: BTreeMap<&str, usize> = BTreeMap::new();
+        for resource in resources.iter().filter(|resource| resource.mode == "managed") {
+            *counts.entry(resource.r#type.as_str()).or_default() += 1;
+        }
+
+        let resource_types = counts
+            .into_iter()
+            .map(|(resource_type, resources)| TypeCoverage {
+                resource_type: resource_type.to_string(),
+                resources,
+                has_builder: builders.supports(resource_type),
+            })
+            .collect();
+        Self { resource_types }
+    }
+
+    /// Returns the resource types without a builder, sorted
+    pub fn missing(&self) -> Vec<&str> {
+        self.resource_types
+            .iter()
+            .filter(|entry| !entry.has_builder)
+            .map(|entry| entry.resource_type.as_str())
+            .collect()
+    }
+
+    /// Returns true if every resource type has a builder
+    pub fn is_complete(&self) -> bool {
+        self.resource_types.iter().all(|entry| entry.has_builder)
+    }
+
+    /// Formats the coverage as an aligned table followed by a summary line
+    ///
+    /// Columns are TYPE, RESOURCES and BUILDER (`yes` or `missing`), separated by two
+    /// spaces. Lines carry no trailing whitespace.
+    pub fn to_table(&self) -> String {
+        let type_width = self
+            .resource_types
+            .iter()
+            .map(|entry| entry.resource_type.chars().count())
+            .chain(["TYPE".len()])
+            .max()
+            .unwrap_or_default();
+
+        let mut lines = vec![
+            format!("{:<width$}  RESOURCES  BUILDER", "TYPE", width = type_width),
+            format!("{}  ---------  -------", "-".repeat(type_width)),
+        ];
+        for entry in &self.resource_types {
+            let builder = if entry.has_builder { "yes" } else { "missing" };
+            lines.push(format!("{:<width$}  {:>9}  {}", entry.resource_type, entry.resources, builder, width = type_width));
+        }
+        lines.push(format!(
+            "\n{} of {} resource type(s) have an import ID builder",
+            self.resource_types.len() - self.missing().len(),
+            self.resource_types.len()
+        ));
+        lines.join("\n") + "\n"
+    }
+
+    /// Serializes the coverage as pretty-printed JSON
+    ///
+    /// # Errors
+    /// Returns an error if serialization fails
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).context("Failed to serialize builder coverage")
+    }
+}
+
+/// Unit tests for coverage counting and formatting
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn plan() -> PlanFile {
+        serde_json::from_value(json!({
+            "format_version": "1.2",
+            "terraform_version": "1.9.0",
+            "planned_values": {"root_module": {
+                "resources": [
+                    {"address": "google_storage_bucket.a", "mode": "managed", "type": "google_storage_bucket", "name": "a"},
+                    {"address": "data.google_project.p", "mode": "data", "type": "google_project", "name": "p"}
+                ],
+                "child_modules": [{"address": "module.x", "resources": [
+                    {"address": "module.x.google_storage_bucket.b", "mode": "managed", "type": "google_storage_bucket", "name": "b"},
+                    {"address": "module.x.acme_widget.w", "mode": "managed", "type": "acme_widget", "name": "w"}
+                ]}]
+            }}
+        }))
+        .unwrap()
+    }
+
+    /// **TEST** - Types are counted across modules, sorted, and data sources ignored
+    #[test]
+    fn test_coverage_counts_managed_types() {
+        let coverage = Coverage::new(&plan(), &ImportIdBuilderRegistry::default());
+
+        assert_eq!(coverage.resource_types, vec![
+            TypeCoverage { resource_type: "acme_widget".to_string(), resources: 1, has_builder: false },
+            TypeCoverage { resource_type: "google_storage_bucket".to_string(), resources: 2, has_builder: true },
+        ]);
+        assert_eq!(coverage.missing(), vec!["acme_widget"]);
+        assert!(Coverage::default().is_complete());
+    }
+
+    /// **TEST** - The table aligns columns and ends with a summary line
+    #[test]
+    fn test_coverage_table() {
+        let coverage = Coverage::new(&plan(), &ImportIdBuilderRegistry::default());
+
+        assert_eq!(
+            coverage.to_table(),
+            "TYPE                   RESOURCES  BUILDER\n\
+             ---------------------  ---------  -------\n\
+             acme_widget                    1  missing\n\
+             google_storage_bucket          2  yes\n\
+             \n\
+             1 of 2 resource type(s) have an import ID builder\n"
+        );
+    }
+}
@@ -0,0 +1,186 @@
+//! # Working Directory Rules Module
+//!
+//! In a monorepo one plan can hold resources of several Terragrunt units, each living
+//! in its own directory. `modules.json` only knows the module each resource belongs to,
+//! so this module lets users route resources to directories by address prefix, e.g.
+//! everything under `module.network` to `units/network`.
+//!
+//! ## Key Components
+//!
+//! - **DirectoryRule**: One address prefix and the directory its resources import in
+//! - **DirectoryMap**: Ordered rules, looked up by address and applied to a resource map
+//!
+//! ## Sources
+//!
+//! Rules come from `--dir-map PREFIX=DIR` (repeatable) and from the `directories` list
+//! of a mapping file (see `mapping`):
+//!
+//! ```json
+//! {
+//!   "directories": [
+//!     { "address": "module.network", "dir": "units/network" }
+//!   ]
+//! }
+//! ```
+//!
+//! ## Matching Rules
+//!
+//! - A prefix matches the address itself and anything below it: `module.network`
+//!   matches `module.network.google_compute_network.vpc` and `module.network["eu"].x`,
+//!   but not `module.network_peering.x`
+//! - The longest matching prefix wins; among equal prefixes, the first rule wins, so
+//!   `--dir-map` rules take precedence over the mapping file's
+//! - Directories are relative to the module root, like the `Dir` of `modules.json`
+//! - A resource matching no rule keeps the directory `modules.json` gives it
+
+use std::collections::HashMap;
+use std::str::FromStr;
+use serde::Deserialize;
+use crate::importer::{ModuleMeta, PlanFile};
+use crate::utils::collect_resources;
+
+/// One address prefix and the working directory for the resources under it
+#[derive(Debug, Clone, PartialEq, Deserialize)]
+pub struct DirectoryRule {
+    /// Resource or module address prefix, e.g. `module.network`
+    pub address: String,
+    /// Working directory for matching resources, relative to the module root
+    pub dir: String,
+}
+
+impl DirectoryRule {
+    /// Whether `address` is the rule's prefix or lies below it
+    pub fn matches(&self, address: &str) -> bool {
+        match address.strip_prefix(&self.address) {
+            Some(rest) => rest.is_empty() || rest.starts_with('.') || rest.starts_with('['),
+            None => false,
+        }
+    }
+}
+
+impl FromStr for DirectoryRule {
+    type Err = String;
+
+    /// Parses `PREFIX=DIR`, e.g. `module.network=units/network`
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.split_once('=') {
+            Some((address, dir)) if !address.trim().is_empty() && !dir.trim().is_empty() => Ok(Self {
+                address: address.trim().to_string(),
+                dir: dir.trim().to_string(),
+            }),
+            _ => Err(format!("invalid directory rule '{}' (expected ADDRESS_PREFIX=DIR)", s)),
+        }
+    }
+}
+
+/// Ordered working directory rules
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::directories::DirectoryMap;
+///
+/// let directories = DirectoryMap::new(vec![
+///     "module.network=units/network".parse().unwrap(),
+///     "module.network.module.firewall=units/firewall".parse().unwrap(),
+/// ]);
+/// assert_eq!(directories.lookup("module.network.google_compute_network.vpc"), Some("units/network"));
+/// assert_eq!(directories.lookup("module.network.module.firewall.google_compute_firewall.ssh"), Some("units/firewall"));
+/// assert_eq!(directories.lookup("module.network_peering.google_compute_network_peering.eu"), None);
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct DirectoryMap {
+    rules: Vec<(DirectoryRule, ModuleMeta)>,
+}
+
+impl DirectoryMap {
+    /// Creates a map from `rules`, in order of precedence
+    pub fn new(rules: Vec<DirectoryRule>) -> Self {
+        let mut directories = Self::default();
+        directories.extend(rules);
+        directories
+    }
+
+    /// Appends `rules`, which take precedence only over rules with shorter prefixes
+    pub fn extend<I: IntoIterator<Item = DirectoryRule>>(&mut self, rules: I) {
+        for rule in rules {
+            let module = ModuleMeta { key: rule.address.clone(), source: String::new(), dir: rule.dir.clone() };
+            self.rules.push((rule, module));
+        }
+    }
+
+    /// Returns the rules in order of precedence
+    pub fn rules(&self) -> impl Iterator<Item = &DirectoryRule> {
+        self.rules.iter().map(|(rule, _)| rule)
+    }
+
+    /// Returns the rule and module entry that decide `address`'s directory, if any
+    fn find(&self, address: &str) -> Option<&(DirectoryRule, ModuleMeta)> {
+        self.rules
+            .iter()
+            .filter(|(rule, _)| rule.matches(address))
+            .fold(None, |best: Option<&(DirectoryRule, ModuleMeta)>, candidate| match best {
+                Some(best) if best.0.address.len() >= candidate.0.address.len() => Some(best),
+                _ => Some(candidate),
+            })
+    }
+
+    /// Returns the working directory for `address`, or None if no rule matches
+    pub fn lookup(&self, address: &str) -> Option<&str> {
+        self.find(address).map(|(rule, _)| rule.dir.as_str())
+    }
+
+    /// Routes every planned resource matching a rule to the rule's directory
+    ///
+    /// Entries from `modules.json` are replaced, and resources it doesn't cover are
+    /// added, with a module entry whose key is the rule's prefix. Other resources are
+    /// left as they are.
+    ///
+    /// # Arguments
+    /// * `plan` - Plan whose planned resources are routed
+    /// * `resource_map` - Resource to module mapping, e.g. from `map_resources_to_modules`
+    pub fn apply<'a>(&'a self, plan: &PlanFile, resource_map: &mut HashMap<String, &'a ModuleMeta>) {
+        if self.rules.is_empty() {
+            return;
+        }
+        let Some(planned_values) = &plan.planned_values else { return };
+        let mut resources = Vec::new();
+        collect_resources(&planned_values.root_module, &mut resources);
+        for resource in resources {
+            if let Some((_, module)) = self.find(&resource.address) {
+                resource_map.insert(resource.address.clone(), module);
+            }
+        }
+    }
+}
+
+/// Unit tests for directory rule parsing and matching
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// **TEST** - Rules parse from `PREFIX=DIR` and reject anything else
+    #[test]
+    fn test_parse_directory_rule() {
+        let rule: DirectoryRule = "module.kms = units/kms".parse().unwrap();
+        assert_eq!(rule, DirectoryRule { address: "module.kms".to_string(), dir: "units/kms".to_string() });
+        assert!("module.kms".parse::<DirectoryRule>().unwrap_err().contains("expected ADDRESS_PREFIX=DIR"));
+        assert!("=units/kms".parse::<DirectoryRule>().is_err());
+        assert!("module.kms=".parse::<DirectoryRule>().is_err());
+    }
+
+    /// **TEST** - Prefixes match at address boundaries and the longest, then first, rule wins
+    #[test]
+    fn test_directory_lookup_precedence() {
+        let directories = DirectoryMap::new(vec![
+            "module.app=units/app".parse().unwrap(),
+            "module.app=units/ignored".parse().unwrap(),
+            "module.app.google_storage_bucket.assets=units/assets".parse().unwrap(),
+        ]);
+        assert_eq!(directories.lookup("module.app[\"blue\"].google_pubsub_topic.events"), Some("units/app"));
+        assert_eq!(directories.lookup("module.app.google_storage_bucket.logs"), Some("units/app"));
+        assert_eq!(directories.lookup("module.app.google_storage_bucket.assets"), Some("units/assets"));
+        assert_eq!(directories.lookup("module.app.google_storage_bucket.assets_backup"), Some("units/app"));
+        assert_eq!(directories.lookup("module.application.google_pubsub_topic.events"), None);
+        assert_eq!(directories.lookup("google_pubsub_topic.events"), None);
+    }
+}
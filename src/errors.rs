@@ -1 +1,66 @@
+//! # Error Types Module
+//!
+//! This module defines error types shared across the import workflow that don't
+//! belong to a single component. Component-specific errors (command execution,
+//! schema generation) live alongside the code that produces them.
 
+use thiserror::Error;
+use crate::preflight::PreflightError;
+use crate::state::StateError;
+use crate::workspace::WorkspaceError;
+
+/// Error types for Terraform plan processing
+///
+/// Represents failure modes when interpreting the JSON output of
+/// `terraform show -json`, beyond plain JSON syntax errors.
+///
+/// # Variants
+/// - `UnsupportedFormatVersion`: The plan's `format_version` is not one we can parse
+#[derive(Error, Debug)]
+pub enum PlanError {
+    /// The plan declares a `format_version` this tool doesn't understand
+    #[error("unsupported plan format version: {0}")]
+    UnsupportedFormatVersion(String),
+}
+
+/// Error types that abort an entire import run
+///
+/// Individual import failures are reported per resource in the `Report`; these
+/// errors stop the run before (further) imports are attempted.
+///
+/// # Variants
+/// - `StateBackup`: State could not be backed up before importing
+/// - `DuplicateImportId`: Two addresses would import the same cloud resource
+/// - `PlannedReplacement`: The plan destroys and recreates resources that would be imported
+/// - `Workspace`: The requested workspace could not be selected
+/// - `Preflight`: The binary or a working directory failed the preflight checks
+#[derive(Error, Debug)]
+pub enum RunError {
+    /// Backing up a module's state failed, so no imports were run
+    #[error("aborting before any imports: state backup failed: {0}")]
+    StateBackup(StateError),
+    /// Two distinct addresses resolved to the same resource type and import ID
+    #[error("aborting before any imports: {first} and {second} both resolve to {resource_type} import id '{import_id}' (pass --allow-duplicate-ids to import anyway)")]
+    DuplicateImportId {
+        /// Resource type both addresses share
+        resource_type: String,
+        /// Import ID both addresses resolved to
+        import_id: String,
+        /// Address that produced the ID first, in plan order
+        first: String,
+        /// Address that produced the same ID again
+        second: String,
+    },
+    /// The plan replaces (destroys and recreates) resources the run would otherwise import
+    #[error("aborting before any imports: the plan replaces {}; importing would adopt objects Terraform is about to destroy (pass --skip-replacements to skip them)", addresses.join(", "))]
+    PlannedReplacement {
+        /// Addresses planned for replacement, in plan order
+        addresses: Vec<String>,
+    },
+    /// The requested workspace doesn't exist or could not be selected in a module
+    #[error("aborting before any imports: {0}")]
+    Workspace(WorkspaceError),
+    /// The binary isn't on PATH or a working directory isn't usable
+    #[error("aborting before any imports: {0}")]
+    Preflight(PreflightError),
+}
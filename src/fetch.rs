@@ -0,0 +1,289 @@
+//! # Remote Plan Fetching Module
+//!
+//! Plans are often produced by one CI job and consumed by another, so the plan input
+//! may name an object store path or URL instead of a local file. This module decides
+//! where a plan lives and fetches remote plans before they are parsed.
+//!
+//! ## Key Components
+//!
+//! - **PlanLocation**: A plan input parsed into a local path or a `RemotePlan`
+//! - **RemotePlan**: A plan in Google Cloud Storage or behind an HTTP(S) URL
+//! - **PlanFetcher**: Trait for downloading a remote plan, so tests can use a fake
+//! - **CommandPlanFetcher**: Default fetcher that shells out through a `CommandRunner`
+//! - **FetchError**: Failure modes when locating or fetching a plan
+//!
+//! ## Supported Inputs
+//!
+//! - `gs://bucket/path/plan.json`: fetched with `gcloud storage cat`
+//! - `http://...` and `https://...`: fetched with `curl`
+//! - `file:///path/plan.json`: read locally, like a plain path
+//! - Anything without a `scheme://` prefix: a local path, unchanged
+//!
+//! Credentials are never handled here: `gcloud` and `curl` use whatever the
+//! environment is already authenticated with. Other schemes are rejected rather
+//! than read as a local path.
+
+use std::fmt;
+use std::io;
+use std::path::{Path, PathBuf};
+use thiserror::Error;
+use crate::commands::runner::CommandRunner;
+
+/// Error types for locating and fetching plans
+///
+/// # Variants
+/// - `UnsupportedScheme`: The input has a `scheme://` prefix this module doesn't know
+/// - `InvalidLocation`: A supported scheme with a malformed remainder, e.g. no object name
+/// - `CommandFailed`: The download program could not be started
+/// - `NonZeroExit`: The download program ran but reported an error
+#[derive(Error, Debug)]
+pub enum FetchError {
+    /// The plan input uses a scheme that isn't supported
+    #[error("unsupported plan location {location}: scheme '{scheme}' is not one of gs, http, https or file")]
+    UnsupportedScheme {
+        /// The plan input as given
+        location: String,
+        /// Its scheme
+        scheme: String,
+    },
+
+    /// The plan input is malformed for its scheme
+    #[error("invalid plan location {location}: {reason}")]
+    InvalidLocation {
+        /// The plan input as given
+        location: String,
+        /// What is wrong with it
+        reason: String,
+    },
+
+    /// The download program could not be started
+    #[error("Failed to run {program} to fetch {location}: {source}")]
+    CommandFailed {
+        /// Program that was run
+        program: String,
+        /// Plan that was fetched
+        location: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// The download program exited with a non-zero code
+    #[error("{program} failed to fetch {location} with exit code {exit_code}: {stderr}")]
+    NonZeroExit {
+        /// Program that was run
+        program: String,
+        /// Plan that was fetched
+        location: String,
+        /// Process exit code (-1 if terminated by a signal)
+        exit_code: i32,
+        /// Captured standard error
+        stderr: String,
+    },
+}
+
+/// A plan stored outside the local filesystem
+///
+/// `Display` gives the location in URL form, e.g. `gs://bucket/plans/plan.json`.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum RemotePlan {
+    /// An object in a Google Cloud Storage bucket
+    Gcs {
+        /// Bucket name
+        bucket: String,
+        /// Object name within the bucket
+        object: String,
+    },
+    /// An `http://` or `https://` URL
+    Http(String),
+}
+
+impl fmt::Display for RemotePlan {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            RemotePlan::Gcs { bucket, object } => write!(f, "gs://{}/{}", bucket, object),
+            RemotePlan::Http(url) => f.write_str(url),
+        }
+    }
+}
+
+/// Where a plan input points
+///
+/// # Examples
+/// ```
+/// use std::path::PathBuf;
+/// use terragrunt_import_from_plan::fetch::{PlanLocation, RemotePlan};
+///
+/// assert_eq!(
+///     PlanLocation::parse("gs://ci-plans/prod/plan.json").unwrap(),
+///     PlanLocation::Remote(RemotePlan::Gcs { bucket: "ci-plans".to_string(), object: "prod/plan.json".to_string() })
+/// );
+/// assert_eq!(PlanLocation::parse("file:///tmp/plan.json").unwrap(), PlanLocation::Local(PathBuf::from("/tmp/plan.json")));
+/// assert_eq!(PlanLocation::parse("plans/plan.json").unwrap(), PlanLocation::Local(PathBuf::from("plans/plan.json")));
+/// assert!(PlanLocation::parse("s3://bucket/plan.json").is_err());
+/// ```
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum PlanLocation {
+    /// A file on the local filesystem
+    Local(PathBuf),
+    /// A plan that has to be fetched first
+    Remote(RemotePlan),
+}
+
+impl PlanLocation {
+    /// Parses a plan input, see the module documentation for the supported forms
+    ///
+    /// # Errors
+    /// - `FetchError::UnsupportedScheme` for any other `scheme://` prefix
+    /// - `FetchError::InvalidLocation` for a `gs://` input without a bucket or object
+    pub fn parse(input: &str) -> Result<Self, FetchError> {
+        let Some((scheme, rest)) = input.split_once("://") else {
+            return Ok(PlanLocation::Local(PathBuf::from(input)));
+        };
+        let is_scheme = !scheme.is_empty()
+            && scheme.chars().all(|c| c.is_ascii_alphanumeric() || matches!(c, '+' | '-' | '.'));
+        if !is_scheme {
+            return Ok(PlanLocation::Local(PathBuf::from(input)));
+        }
+
+        match scheme.to_ascii_lowercase().as_str() {
+            "file" => Ok(PlanLocation::Local(PathBuf::from(rest))),
+            "http" | "https" => Ok(PlanLocation::Remote(RemotePlan::Http(input.to_string()))),
+            "gs" => match rest.split_once('/') {
+                Some((bucket, object)) if !bucket.is_empty() && !object.is_empty() => Ok(PlanLocation::Remote(RemotePlan::Gcs {
+                    bucket: bucket.to_string(),
+                    object: object.to_string(),
+                })),
+                _ => Err(FetchError::InvalidLocation {
+                    location: input.to_string(),
+                    reason: "expected gs://<bucket>/<object>".to_string(),
+                }),
+            },
+            _ => Err(FetchError::UnsupportedScheme { location: input.to_string(), scheme: scheme.to_string() }),
+        }
+    }
+}
+
+/// Downloads remote plans
+///
+/// Implementations return the plan JSON as text; parsing is left to the caller.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::fetch::{FetchError, PlanFetcher, RemotePlan};
+///
+/// struct FakeFetcher;
+///
+/// impl PlanFetcher for FakeFetcher {
+///     fn fetch(&self, plan: &RemotePlan) -> Result<String, FetchError> {
+///         Ok(format!(r#"{{"format_version": "1.2", "terraform_version": "1.9.0", "source": "{}"}}"#, plan))
+///     }
+/// }
+///
+/// let plan = RemotePlan::Http("https://plans.example.com/plan.json".to_string());
+/// assert!(FakeFetcher.fetch(&plan).unwrap().contains("https://plans.example.com/plan.json"));
+/// ```
+pub trait PlanFetcher {
+    /// Returns the content of `plan`
+    ///
+    /// # Errors
+    /// Returns a `FetchError` if the plan can't be downloaded
+    fn fetch(&self, plan: &RemotePlan) -> Result<String, FetchError>;
+}
+
+/// PlanFetcher running `gcloud storage cat` for GCS and `curl` for HTTP(S)
+///
+/// Both programs run in the current directory through `runner` and must be on PATH.
+pub struct CommandPlanFetcher<'a> {
+    runner: &'a dyn CommandRunner,
+}
+
+impl<'a> CommandPlanFetcher<'a> {
+    /// Creates a fetcher that runs its download commands through `runner`
+    pub fn new(runner: &'a dyn CommandRunner) -> Self {
+        Self { runner }
+    }
+}
+
+impl PlanFetcher for CommandPlanFetcher<'_> {
+    fn fetch(&self, plan: &RemotePlan) -> Result<String, FetchError> {
+        let location = plan.to_string();
+        let (program, args): (&str, Vec<&str>) = match plan {
+            RemotePlan::Gcs { .. } => ("gcloud", vec!["storage", "cat", &location]),
+            RemotePlan::Http(url) => ("curl", vec!["--fail", "--silent", "--show-error", "--location", url]),
+        };
+
+        let output = self
+            .runner
+            .run(program, &args, Path::new("."))
+            .map_err(|source| FetchError::CommandFailed { program: program.to_string(), location: location.clone(), source })?;
+        if !output.success() {
+            return Err(FetchError::NonZeroExit {
+                program: program.to_string(),
+                location,
+                exit_code: output.exit_code.unwrap_or(-1),
+                stderr: output.stderr.trim().to_string(),
+            });
+        }
+        Ok(output.stdout)
+    }
+}
+
+/// Unit tests for plan location parsing and command-based fetching
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::Mutex;
+    use crate::commands::runner::CommandOutput;
+
+    /// Runner that records each command and answers with a fixed output
+    struct RecordingRunner {
+        output: CommandOutput,
+        commands: Mutex<Vec<String>>,
+    }
+
+    impl CommandRunner for RecordingRunner {
+        fn run(&self, program: &str, args: &[&str], _working_directory: &Path) -> io::Result<CommandOutput> {
+            self.commands.lock().unwrap().push(format!("{} {}", program, args.join(" ")));
+            Ok(self.output.clone())
+        }
+    }
+
+    /// **TEST** - Schemes map to locations, and bad or unknown ones are rejected
+    #[test]
+    fn test_parse_plan_location() {
+        assert_eq!(
+            PlanLocation::parse("HTTPS://plans.example.com/plan.json").unwrap(),
+            PlanLocation::Remote(RemotePlan::Http("HTTPS://plans.example.com/plan.json".to_string()))
+        );
+        assert_eq!(PlanLocation::parse("file://plan.json").unwrap(), PlanLocation::Local(PathBuf::from("plan.json")));
+        assert_eq!(PlanLocation::parse("odd dir://plan.json").unwrap(), PlanLocation::Local(PathBuf::from("odd dir://plan.json")));
+        assert!(matches!(PlanLocation::parse("gs://bucket-only"), Err(FetchError::InvalidLocation { .. })));
+        assert!(matches!(PlanLocation::parse("gs:///plan.json"), Err(FetchError::InvalidLocation { .. })));
+        assert!(matches!(PlanLocation::parse("s3://b/plan.json"), Err(FetchError::UnsupportedScheme { scheme, .. }) if scheme == "s3"));
+    }
+
+    /// **TEST** - GCS and HTTP plans are fetched with gcloud and curl; failures carry stderr
+    #[test]
+    fn test_command_plan_fetcher() {
+        let runner = RecordingRunner {
+            output: CommandOutput { exit_code: Some(0), stdout: "{}".to_string(), stderr: String::new() },
+            commands: Mutex::new(Vec::new()),
+        };
+        let fetcher = CommandPlanFetcher::new(&runner);
+        let gcs = RemotePlan::Gcs { bucket: "ci-plans".to_string(), object: "prod/plan.json".to_string() };
+        assert_eq!(fetcher.fetch(&gcs).unwrap(), "{}");
+        fetcher.fetch(&RemotePlan::Http("https://plans.example.com/plan.json".to_string())).unwrap();
+        assert_eq!(*runner.commands.lock().unwrap(), [
+            "gcloud storage cat gs://ci-plans/prod/plan.json",
+            "curl --fail --silent --show-error --location https://plans.example.com/plan.json",
+        ]);
+
+        let runner = RecordingRunner {
+            output: CommandOutput { exit_code: Some(1), stdout: String::new(), stderr: "ERROR: 404 No such object\n".to_string() },
+            commands: Mutex::new(Vec::new()),
+        };
+        let error = CommandPlanFetcher::new(&runner).fetch(&gcs).unwrap_err();
+        assert_eq!(error.to_string(), "gcloud failed to fetch gs://ci-plans/prod/plan.json with exit code 1: ERROR: 404 No such object");
+    }
+}
@@ -0,0 +1,142 @@
+//! # Resource Filter Module
+//!
+//! Large plans often contain far more resources than a user wants to import in one
+//! run. This module selects a subset by matching glob patterns against each
+//! resource's full address before import commands are generated.
+//!
+//! ## Matching Rules
+//!
+//! - Patterns are matched against the full address, e.g. `module.kms.google_kms_key_ring.example`,
+//!   so `google_kms_*` only selects root-module resources; use `*google_kms_*` to select
+//!   them in any module.
+//! - With no include patterns every resource is included; otherwise a resource must
+//!   match at least one of them.
+//! - A resource matching any exclude pattern is dropped, even if it is also included.
+
+use anyhow::{Context, Result};
+use glob::Pattern;
+
+/// Include and exclude patterns selecting which resources to import
+///
+/// The default filter has no patterns and selects every resource.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::filter::ResourceFilter;
+///
+/// let filter = ResourceFilter::new(
+///     &["module.kms.*".to_string()],
+///     &["*.google_kms_crypto_key.*".to_string()],
+/// ).unwrap();
+///
+/// assert!(filter.matches("module.kms.google_kms_key_ring.example"));
+/// assert!(!filter.matches("module.kms.google_kms_crypto_key.example"));
+/// assert!(!filter.matches("module.storage.google_storage_bucket.example"));
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct ResourceFilter {
+    include: Vec<Pattern>,
+    exclude: Vec<Pattern>,
+}
+
+impl ResourceFilter {
+    /// Compiles include and exclude glob patterns into a filter
+    ///
+    /// # Arguments
+    /// * `include` - Patterns of addresses to import; empty means all
+    /// * `exclude` - Patterns of addresses never to import
+    ///
+    /// # Errors
+    /// Returns an error if any pattern isn't a valid glob
+    pub fn new(include: &[String], exclude: &[String]) -> Result<Self> {
+        Ok(Self {
+            include: compile_patterns(include, "include")?,
+            exclude: compile_patterns(exclude, "exclude")?,
+        })
+    }
+
+    /// Returns true if the resource at `address` should be imported
+    pub fn matches(&self, address: &str) -> bool {
+        if self.exclude.iter().any(|pattern| pattern.matches(address)) {
+            return false;
+        }
+        self.include.is_empty() || self.include.iter().any(|pattern| pattern.matches(address))
+    }
+
+    /// Returns true if the filter has no patterns and selects every resource
+    pub fn is_empty(&self) -> bool {
+        self.include.is_empty() && self.exclude.is_empty()
+    }
+}
+
+/// Compiles each pattern, naming the offending flag in the error
+fn compile_patterns(patterns: &[String], kind: &str) -> Result<Vec<Pattern>> {
+    patterns
+        .iter()
+        .map(|pattern| Pattern::new(pattern).with_context(|| format!("Invalid {} pattern: {}", kind, pattern)))
+        .collect()
+}
+
+/// Unit tests for include/exclude matching
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn build(include: &[&str], exclude: &[&str]) -> ResourceFilter {
+        let to_strings = |patterns: &[&str]| patterns.iter().map(|p| p.to_string()).collect::<Vec<_>>();
+        ResourceFilter::new(&to_strings(include), &to_strings(exclude)).unwrap()
+    }
+
+    /// **TEST** - An empty filter selects everything
+    #[test]
+    fn test_empty_filter_matches_all() {
+        let filter = ResourceFilter::default();
+        assert!(filter.is_empty());
+        assert!(filter.matches("aws_vpc.main"));
+        assert!(filter.matches("module.kms.google_kms_key_ring.example"));
+    }
+
+    /// **TEST** - Module-scoped include selects only that module's resources
+    #[test]
+    fn test_include_module_pattern() {
+        let filter = build(&["module.kms.*"], &[]);
+        assert!(filter.matches("module.kms.google_kms_key_ring.example"));
+        assert!(filter.matches("module.kms.google_kms_crypto_key.example"));
+        assert!(!filter.matches("module.storage.google_storage_bucket.example"));
+        assert!(!filter.matches("module.kms_legacy.google_kms_key_ring.example"));
+    }
+
+    /// **TEST** - Type patterns match the full address, so nesting needs a leading `*`
+    #[test]
+    fn test_include_type_pattern() {
+        let root_only = build(&["google_kms_*"], &[]);
+        assert!(root_only.matches("google_kms_key_ring.example"));
+        assert!(!root_only.matches("module.kms.google_kms_key_ring.example"));
+
+        let any_module = build(&["*google_kms_*"], &[]);
+        assert!(any_module.matches("module.kms.google_kms_key_ring.example"));
+        assert!(any_module.matches("google_kms_crypto_key.example"));
+        assert!(!any_module.matches("module.storage.google_storage_bucket.example"));
+    }
+
+    /// **TEST** - Multiple includes are OR-combined and excludes take precedence
+    #[test]
+    fn test_exclude_overrides_include() {
+        let filter = build(&["module.kms.*", "module.storage.*"], &["*.google_kms_crypto_key.*"]);
+        assert!(filter.matches("module.kms.google_kms_key_ring.example"));
+        assert!(filter.matches("module.storage.google_storage_bucket.example"));
+        assert!(!filter.matches("module.kms.google_kms_crypto_key.example"));
+        assert!(!filter.matches("module.pubsub.google_pubsub_topic.example"));
+
+        let exclude_only = build(&[], &["module.kms.*"]);
+        assert!(!exclude_only.matches("module.kms.google_kms_key_ring.example"));
+        assert!(exclude_only.matches("module.storage.google_storage_bucket.example"));
+    }
+
+    /// **TEST** - Invalid patterns are rejected with the flag name
+    #[test]
+    fn test_invalid_pattern() {
+        let err = ResourceFilter::new(&[], &["module.[kms".to_string()]).unwrap_err();
+        assert!(err.to_string().contains("Invalid exclude pattern: module.[kms"));
+    }
+}
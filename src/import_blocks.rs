@@ -0,0 +1,243 @@
+//! # Import Blocks Module
+//!
+//! Terraform 1.5 added declarative `import` blocks: instead of running `terraform import`
+//! per resource, the blocks are committed next to the configuration and the next
+//! `terraform apply` adopts the resources. This module renders the resolved address and
+//! import ID of every resource an import run would import as such blocks.
+//!
+//! ## Output
+//!
+//! ```hcl
+//! import {
+//!   to = module.kms.google_kms_crypto_key.this["app-key"]
+//!   id = "projects/p/locations/l/keyRings/r/cryptoKeys/app-key"
+//! }
+//! ```
+//!
+//! `to` is a resource address expression, so only instance keys are quoted; `id` is a
+//! quoted string. Both use HCL's escapes, including `$${` and `%%{` so that IDs are never
+//! read as template interpolations. Blocks are sorted by address for stable diffs.
+
+use std::fs;
+use std::path::Path;
+use anyhow::{Context, Result};
+use crate::address::{InstanceKey, ResourceAddress};
+use crate::importer::{ImportDecision, PlannedImport};
+
+/// Formats `value` as a quoted HCL string literal
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::import_blocks::hcl_string;
+///
+/// assert_eq!(hcl_string(r#"a "b" ${c}"#), r#""a \"b\" $${c}""#);
+/// ```
+pub fn hcl_string(value: &str) -> String {
+    let mut quoted = String::with_capacity(value.len() + 2);
+    quoted.push('"');
+    let mut chars = value.chars().peekable();
+    while let Some(c) = chars.next() {
+        match c {
+            '"' => quoted.push_str("\\\""),
+            '\\' => quoted.push_str("\\\\"),
+            '\n' => quoted.push_str("\\n"),
+            '\r' => quoted.push_str("\\r"),
+            '\t' => quoted.push_str("\\t"),
+            '$' | '%' if chars.peek() == Some(&'{') => {
+                quoted.push(c);
+                quoted.push(c);
+            }
+            c if c.is_control() => quoted.push_str(&format!("\\u{:04x}", c as u32)),
+            c => quoted.push(c),
+        }
+    }
+    quoted.push('"');
+    quoted
+}
+
+/// Formats a resource address as an HCL reference expression for `to`
+fn hcl_address(address: &ResourceAddress) -> String {
+    let key = |key: &Option<InstanceKey>| match key {
+        Some(InstanceKey::Index(index)) => format!("[{}]", index),
+        Some(InstanceKey::Key(key)) => format!("[{}]", hcl_string(key)),
+        None => String::new(),
+    };
+
+    let mut expression = String::new();
+    for call in &address.module_path {
+        expression.push_str(&format!("module.{}{}.", call.name, key(&call.key)));
+    }
+    expression.push_str(&format!("{}.{}{}", address.resource_type, address.name, key(&address.key)));
+    expression
+}
+
+/// Renders an `import` block for every resource with `ImportDecision::Import`
+///
+/// The target address is the one the import command would use, so it honours
+/// `ImportOptions::strip_module_prefix`.
+///
+/// # Arguments
+/// * `planned` - Decisions from `importer::plan_imports`
+///
+/// # Returns
+/// The blocks sorted by address and separated by blank lines; empty if nothing would be imported
+///
+/// # Errors
+/// Returns an error if an import command's address can't be parsed
+pub fn render_import_blocks(planned: &[PlannedImport]) -> Result<String> {
+    let mut targets = Vec::new();
+    for entry in planned.iter().filter(|entry| entry.decision == ImportDecision::Import) {
+        let Some(command) = &entry.command else { continue };
+        let address: ResourceAddress = command
+            .resource_address
+            .parse()
+            .with_context(|| format!("Failed to render import block for {}", entry.address))?;
+        targets.push((command.resource_address.as_str(), hcl_address(&address), &command.resource_id));
+    }
+    targets.sort_by(|a, b| a.0.cmp(b.0));
+
+    let blocks: Vec<String> = targets
+        .into_iter()
+        .map(|(_, to, id)| format!("import {{\n  to = {}\n  id = {}\n}}\n", to, hcl_string(id)))
+        .collect();
+    Ok(blocks.join("\n"))
+}
+
+/// Writes the import blocks for `planned` to `path`
+///
+/// # Returns
+/// The number of blocks written
+///
+/// # Errors
+/// Returns an error if a block can't be rendered or the file can't be written
+pub fn write_import_blocks(path: &Path, planned: &[PlannedImport]) -> Result<usize> {
+    let rendered = render_import_blocks(planned)?;
+    fs::write(path, &rendered).with_context(|| format!("Failed to write import blocks to {}", path.display()))?;
+    Ok(rendered.matches("import {\n").count())
+}
+
+/// Unit tests for HCL quoting and block rendering
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::PathBuf;
+    use crate::commands::{ImportBinary, ImportCommand};
+
+    fn planned(address: &str, id: &str, decision: ImportDecision) -> PlannedImport {
+        PlannedImport {
+            address: address.to_string(),
+            resource_type: String::new(),
+            import_id: Some(id.to_string()),
+            decision,
+            reason: None,
+            command: Some(ImportCommand {
+                working_directory: PathBuf::from("modules"),
+                resource_address: address.to_string(),
+                resource_id: id.to_string(),
+                resource_type: String::new(),
+                module_name: String::new(),
+                binary: ImportBinary::Terraform,
+                extra_args: Vec::new(),
+            }),
+            sensitive_values: Vec::new(),
+        }
+    }
+
+    /// Reads a quoted HCL string literal at the start of `input`, returning it unescaped
+    /// and the rest of the input
+    fn parse_hcl_string(input: &str) -> (String, &str) {
+        let mut chars = input.strip_prefix('"').expect("string literal must start with a quote").char_indices();
+        let mut value = String::new();
+        while let Some((i, c)) = chars.next() {
+            match c {
+                '"' => return (value, &input[i + 2..]),
+                '\\' => match chars.next().map(|(_, c)| c) {
+                    Some('"') => value.push('"'),
+                    Some('\\') => value.push('\\'),
+                    Some('n') => value.push('\n'),
+                    Some('r') => value.push('\r'),
+                    Some('t') => value.push('\t'),
+                    Some('u') => {
+                        let hex: String = (0..4).map(|_| chars.next().unwrap().1).collect();
+                        value.push(char::from_u32(u32::from_str_radix(&hex, 16).unwrap()).unwrap());
+                    }
+                    other => panic!("invalid escape {:?}", other),
+                },
+                '$' | '%' => {
+                    let rest = &input[i + 1..];
+                    if rest[1..].starts_with(c) && rest[2..].starts_with('{') {
+                        chars.next();
+                    } else {
+                        assert!(!rest[1..].starts_with('{'), "unescaped template sequence");
+                    }
+                    value.push(c);
+                }
+                c if c.is_control() => panic!("raw control character in string literal"),
+                c => value.push(c),
+            }
+        }
+        panic!("unterminated string literal")
+    }
+
+    /// Parses rendered import blocks back into (to, id) pairs, with instance keys unescaped
+    ///
+    /// No HCL parser is among the dependencies, so this is a strict parser of exactly the
+    /// subset `render_import_blocks` emits: anything else fails the test.
+    fn parse_import_blocks(hcl: &str) -> Vec<(String, String)> {
+        let mut blocks = Vec::new();
+        let mut rest = hcl;
+        while !rest.is_empty() {
+            rest = rest.strip_prefix("import {\n  to = ").expect("block header");
+            let mut to = String::new();
+            while !rest.starts_with('\n') {
+                if rest.starts_with('"') {
+                    let (key, after) = parse_hcl_string(rest);
+                    to.push_str(&serde_json::to_string(&key).unwrap());
+                    rest = after;
+                } else {
+                    let c = rest.chars().next().unwrap();
+                    assert!(c.is_ascii_alphanumeric() || "_-.[]".contains(c), "unexpected {:?} in address", c);
+                    to.push(c);
+                    rest = &rest[c.len_utf8()..];
+                }
+            }
+            rest = rest.strip_prefix("\n  id = ").expect("id attribute");
+            let (id, after) = parse_hcl_string(rest);
+            rest = after.strip_prefix("\n}\n").expect("block end");
+            rest = rest.strip_prefix('\n').unwrap_or(rest);
+            blocks.push((to, id));
+        }
+        blocks
+    }
+
+    /// **TEST** - Blocks round-trip through a parser, including keyed addresses and tricky IDs
+    #[test]
+    fn test_import_blocks_round_trip() {
+        let tricky_id = "a \"quoted\" \\path\\ with ${var} and %{if} and\nnewline\u{1}";
+        let keyed = r#"module.app["blue"].module.db[1].aws_iam_role.this["a]\"${b}"]"#;
+        let rendered = render_import_blocks(&[
+            planned("module.kms.google_kms_key_ring.example", "projects/p/locations/l/keyRings/r", ImportDecision::Import),
+            planned(keyed, tricky_id, ImportDecision::Import),
+            planned("aws_vpc.skipped", "vpc-1", ImportDecision::SkipAlreadyInState),
+        ])
+        .unwrap();
+
+        assert!(rendered.contains("  to = module.app[\"blue\"].module.db[1].aws_iam_role.this[\"a]\\\"$${b}\"]\n"), "{}", rendered);
+        assert!(!rendered.contains("aws_vpc.skipped"));
+        assert_eq!(parse_import_blocks(&rendered), vec![
+            (keyed.to_string(), tricky_id.to_string()),
+            ("module.kms.google_kms_key_ring.example".to_string(), "projects/p/locations/l/keyRings/r".to_string()),
+        ]);
+    }
+
+    /// **TEST** - HCL strings escape quotes, backslashes, control characters and template sequences
+    #[test]
+    fn test_hcl_string_escapes() {
+        assert_eq!(hcl_string("plain/id-1"), r#""plain/id-1""#);
+        assert_eq!(hcl_string("tab\there"), r#""tab\there""#);
+        assert_eq!(hcl_string("$5 and 100%"), r#""$5 and 100%""#);
+        assert_eq!(hcl_string("%{x}${y}"), r#""%%{x}$${y}""#);
+        assert_eq!(hcl_string("\u{7}"), r#""\u0007""#);
+        assert_eq!(render_import_blocks(&[]).unwrap(), "");
+    }
+}
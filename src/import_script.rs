@@ -0,0 +1,151 @@
+//! # Import Script Module
+//!
+//! Some teams won't let a tool run `import` against their state; they review the
+//! commands and run them themselves. This module renders the imports a run would make
+//! as an executable bash script instead, one line per resource.
+//!
+//! ## Output
+//!
+//! ```bash
+//! #!/usr/bin/env bash
+//! # Generated by terragrunt_import_from_plan; review before running.
+//! # Working directories are relative to the directory the tool was run in.
+//! set -euo pipefail
+//!
+//! (cd live/modules/kms && terragrunt import 'module.kms.google_kms_crypto_key.this["app-key"]' projects/p/locations/l/keyRings/r/cryptoKeys/app-key)
+//! ```
+//!
+//! Every line changes into its resource's working directory in a subshell, so lines
+//! can be run, skipped or reordered on their own. Directories, extra arguments,
+//! addresses and IDs are shell-quoted with `utils::shell_quote`. Lines keep the
+//! dependency order the run would import in, and the script stops at the first failure.
+//!
+//! With a workspace, every line selects it before importing, e.g.
+//! `(cd live/modules/kms && terragrunt workspace select staging && terragrunt import ...)`,
+//! so the imports land in the same state the run checked.
+
+use std::fs;
+use std::path::Path;
+use anyhow::{Context, Result};
+use crate::importer::{ImportDecision, PlannedImport};
+use crate::utils::shell_quote;
+
+/// Lines every script starts with
+const SCRIPT_HEADER: &str = "#!/usr/bin/env bash
+# Generated by terragrunt_import_from_plan; review before running.
+# Working directories are relative to the directory the tool was run in.
+set -euo pipefail
+";
+
+/// Renders a script with an import line for every resource with `ImportDecision::Import`
+///
+/// # Arguments
+/// * `planned` - Decisions from `importer::plan_imports`
+/// * `workspace` - Workspace every line selects before importing, if any
+///
+/// # Returns
+/// The script; it only holds the header if nothing would be imported
+pub fn render_import_script(planned: &[PlannedImport], workspace: Option<&str>) -> String {
+    let mut script = SCRIPT_HEADER.to_string();
+    let commands = planned
+        .iter()
+        .filter(|entry| entry.decision == ImportDecision::Import)
+        .filter_map(|entry| entry.command.as_ref());
+    for (index, command) in commands.enumerate() {
+        if index == 0 {
+            script.push('\n');
+        }
+        let directory = command.working_directory.display().to_string();
+        let directory = if directory.is_empty() { ".".to_string() } else { directory };
+        let program = command.binary.program();
+        let mut words = vec![program.to_string()];
+        words.extend(
+            command
+                .binary
+                .import_args(&command.extra_args, &command.resource_address, &command.resource_id)
+                .iter()
+                .map(|arg| shell_quote(arg)),
+        );
+        let select = match workspace {
+            Some(workspace) => format!("{} workspace select {} && ", program, shell_quote(workspace)),
+            None => String::new(),
+        };
+        script.push_str(&format!("(cd {} && {}{})\n", shell_quote(&directory), select, words.join(" ")));
+    }
+    script
+}
+
+/// Writes the import script for `planned`, selecting `workspace` if given, to `path`
+/// and makes it executable
+///
+/// # Returns
+/// The number of import lines written
+///
+/// # Errors
+/// Returns an error if the file can't be written or made executable
+pub fn write_import_script(path: &Path, planned: &[PlannedImport], workspace: Option<&str>) -> Result<usize> {
+    let script = render_import_script(planned, workspace);
+    fs::write(path, &script).with_context(|| format!("Failed to write import script to {}", path.display()))?;
+    #[cfg(unix)]
+    {
+        use std::os::unix::fs::PermissionsExt;
+        fs::set_permissions(path, fs::Permissions::from_mode(0o755))
+            .with_context(|| format!("Failed to make import script {} executable", path.display()))?;
+    }
+    Ok(script.lines().filter(|line| line.starts_with("(cd ")).count())
+}
+
+/// Unit tests for import script rendering
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::path::PathBuf;
+    use crate::commands::{ImportBinary, ImportCommand};
+
+    fn planned(directory: &str, address: &str, id: &str, decision: ImportDecision) -> PlannedImport {
+        PlannedImport {
+            address: address.to_string(),
+            resource_type: String::new(),
+            import_id: Some(id.to_string()),
+            decision,
+            reason: None,
+            command: Some(ImportCommand {
+                working_directory: PathBuf::from(directory),
+                resource_address: address.to_string(),
+                resource_id: id.to_string(),
+                resource_type: String::new(),
+                module_name: String::new(),
+                binary: ImportBinary::Terragrunt,
+                extra_args: vec!["-lock-timeout=60s".to_string()],
+            }),
+            sensitive_values: Vec::new(),
+        }
+    }
+
+    /// **TEST** - Lines keep plan order, quote what the shell would misread and skip non-imports
+    #[test]
+    fn test_render_import_script() {
+        let script = render_import_script(&[
+            planned("units/app one", r#"module.app["blue"].aws_s3_bucket.this[0]"#, "it's-a-bucket", ImportDecision::Import),
+            planned("units/vpc", "aws_vpc.main", "vpc-1", ImportDecision::SkipAlreadyInState),
+            planned("", "aws_iam_role.ci", "ci", ImportDecision::Import),
+        ], None);
+        assert_eq!(script, format!(
+            "{}\n{}\n{}\n",
+            SCRIPT_HEADER,
+            r#"(cd 'units/app one' && terragrunt import -lock-timeout=60s 'module.app["blue"].aws_s3_bucket.this[0]' 'it'\''s-a-bucket')"#,
+            "(cd . && terragrunt import -lock-timeout=60s aws_iam_role.ci ci)",
+        ));
+        assert_eq!(render_import_script(&[], None), SCRIPT_HEADER);
+    }
+
+    /// **TEST** - With a workspace, every line selects it before importing
+    #[test]
+    fn test_render_import_script_with_workspace() {
+        let script = render_import_script(&[planned("units/vpc", "aws_vpc.main", "vpc-1", ImportDecision::Import)], Some("staging"));
+        assert_eq!(script, format!(
+            "{}\n(cd units/vpc && terragrunt workspace select staging && terragrunt import -lock-timeout=60s aws_vpc.main vpc-1)\n",
+            SCRIPT_HEADER,
+        ));
+    }
+}
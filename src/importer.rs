@@ -10,10 +10,29 @@ use std::process::Command;
 use std::io;
 use serde::{Deserialize, Serialize};
 use serde_json::{Map, Value};
+use crate::address::{format_module_path, module_key, parse_module_address, ResourceAddress};
+use crate::builders::azure::{subscription_from_resource_id, SUBSCRIPTION_ID_ATTRIBUTE};
+use crate::builders::gcp::GOOGLE_PROVIDER_DEFAULTS;
+use crate::builders::{ImportIdBuilderRegistry, ImportIdError};
+use crate::checkpoint::CheckpointEntry;
+use crate::mapping::ImportIdMappings;
+use crate::ordering::sort_by_dependencies;
+use crate::commands::builder::{format_import_command, ImportBinary};
+use crate::commands::executor::{truncate_output, INTERRUPTED_ERROR};
+use crate::commands::{CommandRunner, ImportCommand, ImportExecutor, ImportOptions, ImportResult};
+use crate::errors::{PlanError, RunError};
+use crate::logging::Logger;
 use crate::plan::TerraformResource;
-use crate::reporting::{ImportStats, ImportOperation, print_import_progress, print_import_summary};
+use crate::preflight::preflight;
+use crate::providers::{ProviderConfig, ProviderConfigs};
+use crate::reporting::{ImportStats, ImportStatus, ImportOperation, Report, ReportEntry, print_import_progress, print_import_summary};
 use crate::utils::collect_resources;
 use crate::schema::SchemaManager;
+use crate::state::StateCache;
+use crate::verify::{verify_imports, VerificationFailure};
+use crate::version::{detect_version, minimum_version};
+use crate::workspace::select_workspace;
+use crate::sensitive::{sensitive_values, RedactingLogger};
 
 /// Represents a resource that has been processed and has an inferred ID
 /// 
@@ -24,6 +43,8 @@ use crate::schema::SchemaManager;
 pub struct ResourceWithId<'a> {
     /// Reference to the original resource from the plan file
     pub resource: &'a Resource,
+    /// Structured form of the resource address, including module path and instance key
+    pub address: ResourceAddress,
     /// Terraform resource representation for internal processing
     pub terraform_resource: TerraformResource,
     /// The inferred ID that will be used for the import command
@@ -37,11 +58,20 @@ pub struct ResourceWithId<'a> {
 /// Result of processing a single resource
 /// 
 /// Represents the outcome when attempting to process a resource for import.
-/// A resource can either be ready for import or skipped for various reasons.
+/// A resource can either be ready for import, unsupported, or skipped for various reasons.
 #[derive(Debug)]
 enum ResourceProcessingResult<'a> {
     /// Resource is ready for import with all required information
     ReadyForImport(ResourceWithId<'a>),
+    /// No import ID builder exists for the resource's type
+    Unsupported {
+        /// The resource address that couldn't be imported
+        address: String,
+        /// The resource type without a builder
+        resource_type: String,
+        /// Human-readable explanation for the report
+        reason: String,
+    },
     /// Resource was skipped with an explanation
     Skipped { 
         /// The resource address that was skipped
@@ -51,29 +81,6 @@ enum ResourceProcessingResult<'a> {
     },
 }
 
-/// Result of executing an import operation
-/// 
-/// Represents the outcome of attempting to execute a terragrunt import command.
-#[derive(Debug)]
-enum ImportExecutionResult {
-    /// Import command executed successfully
-    Success(String), // resource address
-    /// Import command failed with error details
-    Failed { 
-        /// The resource address that failed
-        address: String, 
-        /// Error message describing the failure
-        error: String 
-    },
-    /// Dry run mode - command was generated but not executed
-    DryRun { 
-        /// The resource address for the dry run
-        address: String, 
-        /// The command string that would be executed
-        command: String 
-    },
-}
-
 /// Represents a Terraform plan file structure
 /// 
 /// This is the top-level structure when parsing a Terraform plan JSON file.
@@ -90,6 +97,312 @@ pub struct PlanFile {
     pub planned_values: Option<PlannedValues>,
     /// Provider schema information
     pub provider_schemas: Option<ProviderSchemas>,
+    /// Per-resource change records (actions, before/after values)
+    pub resource_changes: Option<Vec<ResourceChange>>,
+    /// The configuration the plan was made from, used to order imports by dependency
+    pub configuration: Option<Value>,
+}
+
+/// Plan JSON format versions this tool knows how to read
+///
+/// Terraform stamps every `terraform show -json` document with a `format_version`.
+/// The layout of `resource_changes` has shifted between versions, so the parser
+/// detects the version up front and normalizes older layouts to the current one.
+///
+/// # Variants
+/// - `V0_1`: Terraform 0.12 - 0.14, no sensitivity markers on changes
+/// - `V0_2`: Terraform 0.15, adds `before_sensitive` / `after_sensitive`
+/// - `V1_0`: Terraform 1.0 - 1.1
+/// - `V1_1`: Terraform 1.2 - 1.4, adds `replace_paths`
+/// - `V1_2`: Terraform 1.5+, adds `importing` and checks
+///
+/// Within a major version Terraform only adds fields, so later `1.x` versions are
+/// read with the `V1_2` layout; only a different major version is rejected.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord)]
+pub enum PlanFormatVersion {
+    V0_1,
+    V0_2,
+    V1_0,
+    V1_1,
+    V1_2,
+}
+
+impl PlanFormatVersion {
+    /// Parses a `format_version` string from a plan file
+    ///
+    /// # Arguments
+    /// * `version` - Raw `format_version` value (e.g., "1.2")
+    ///
+    /// # Returns
+    /// The matching PlanFormatVersion, or `V1_2` for a later `1.x` version
+    ///
+    /// # Errors
+    /// - `PlanError::UnsupportedFormatVersion` for an unlisted `0.x` version, any other
+    ///   major version, or a value that isn't a version at all
+    ///
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::importer::PlanFormatVersion;
+    ///
+    /// assert_eq!(PlanFormatVersion::parse("1.2").unwrap(), PlanFormatVersion::V1_2);
+    /// assert_eq!(PlanFormatVersion::parse("1.3").unwrap(), PlanFormatVersion::V1_2);
+    /// assert!(PlanFormatVersion::parse("2.0").is_err());
+    /// ```
+    pub fn parse(version: &str) -> Result<Self, PlanError> {
+        match version.trim() {
+            "0.1" => Ok(Self::V0_1),
+            "0.2" => Ok(Self::V0_2),
+            "1.0" => Ok(Self::V1_0),
+            "1.1" => Ok(Self::V1_1),
+            "1.2" => Ok(Self::V1_2),
+            other => match other.split_once('.') {
+                Some(("1", minor)) if minor.parse::<u32>().is_ok() => Ok(Self::V1_2),
+                _ => Err(PlanError::UnsupportedFormatVersion(other.to_string())),
+            },
+        }
+    }
+
+    /// Whether `version` is newer than every version listed above but still readable
+    ///
+    /// Such plans are read with the `V1_2` layout, so callers should warn that fields
+    /// added since may be ignored.
+    pub fn is_newer_than_known(version: &str) -> bool {
+        version.trim() != "1.2" && matches!(Self::parse(version), Ok(Self::V1_2))
+    }
+
+    /// Whether this format records sensitivity markers on resource changes
+    pub fn has_sensitive_values(&self) -> bool {
+        *self >= Self::V0_2
+    }
+}
+
+/// A single entry from the plan's `resource_changes` array
+///
+/// Unlike `planned_values`, resource changes record what Terraform intends to do
+/// with each resource instance, which is what decides whether it needs importing.
+#[derive(Debug, Deserialize)]
+pub struct ResourceChange {
+    /// Full resource instance address (e.g., "module.kms.google_kms_crypto_key.this["app"]")
+    pub address: String,
+    /// Address of the containing module, absent for root module resources
+    pub module_address: Option<String>,
+    /// Resource mode - "managed" or "data"
+    pub mode: String,
+    /// Resource type (e.g., "google_kms_key_ring")
+    #[serde(rename = "type")]
+    pub r#type: String,
+    /// Resource name within the module
+    pub name: String,
+    /// Instance key for count / for_each resources
+    pub index: Option<Value>,
+    /// Provider that manages this resource
+    pub provider_name: Option<String>,
+    /// The planned change for this resource
+    pub change: Change,
+}
+
+/// The change block of a resource change entry
+#[derive(Debug, Deserialize)]
+pub struct Change {
+    /// Planned actions (e.g., ["create"], ["delete", "create"])
+    pub actions: Vec<String>,
+    /// Values before the change, null for creates
+    pub before: Option<Value>,
+    /// Values after the change, null for deletes
+    pub after: Option<Value>,
+    /// Attributes whose values are only known after apply
+    pub after_unknown: Option<Value>,
+    /// Sensitivity markers for `before` (format 0.2+)
+    pub before_sensitive: Option<Value>,
+    /// Sensitivity markers for `after` (format 0.2+)
+    pub after_sensitive: Option<Value>,
+}
+
+impl Change {
+    /// Whether the change is a pure create (`["create"]`)
+    ///
+    /// Replacements (`["delete", "create"]` or `["create", "delete"]`) are not pure
+    /// creates: the existing object is already managed and importing it would fail.
+    pub fn is_create(&self) -> bool {
+        self.actions.len() == 1 && self.actions[0] == "create"
+    }
+
+    /// Whether the change is a replacement (`["delete", "create"]` or, with
+    /// `create_before_destroy`, `["create", "delete"]`)
+    pub fn is_replace(&self) -> bool {
+        matches!(self.actions.as_slice(), [first, second] if (first == "delete" && second == "create") || (first == "create" && second == "delete"))
+    }
+
+    /// Whether the top-level `attribute` is only known after apply
+    pub fn is_unknown_after(&self, attribute: &str) -> bool {
+        self.after_unknown
+            .as_ref()
+            .and_then(|unknown| unknown.get(attribute))
+            .is_some_and(|unknown| unknown == &Value::Bool(true))
+    }
+}
+
+impl PlanFile {
+    /// Detects the plan's format version and normalizes version-specific layouts
+    ///
+    /// After normalization every resource change carries an object-shaped
+    /// `after_unknown` (or `true` when the whole value is unknown) and explicit
+    /// sensitivity markers, regardless of which Terraform version wrote the plan.
+    /// Planned resources and resource changes of nested modules carry their full
+    /// address, and planned resources their module's `module_address`.
+    ///
+    /// # Returns
+    /// The detected PlanFormatVersion
+    ///
+    /// # Errors
+    /// - `PlanError::UnsupportedFormatVersion` if the version isn't recognized
+    pub fn normalize(&mut self) -> Result<PlanFormatVersion, PlanError> {
+        let version = PlanFormatVersion::parse(&self.format_version)?;
+
+        if let Some(changes) = self.resource_changes.as_mut() {
+            for resource_change in changes.iter_mut() {
+                let change = &mut resource_change.change;
+
+                // Older formats omit after_unknown when nothing is unknown
+                match &change.after_unknown {
+                    None | Some(Value::Null) | Some(Value::Bool(false)) => {
+                        change.after_unknown = Some(Value::Object(Map::new()));
+                    }
+                    _ => {}
+                }
+
+                // Sensitivity markers were only introduced in format 0.2
+                if !version.has_sensitive_values() {
+                    change.before_sensitive.get_or_insert(Value::Bool(false));
+                    change.after_sensitive.get_or_insert(Value::Bool(false));
+                }
+
+                if let Some(module_address) = &resource_change.module_address {
+                    resource_change.address = qualify_address(module_address, &resource_change.address);
+                }
+            }
+        }
+        if let Some(planned_values) = self.planned_values.as_mut() {
+            qualify_module_addresses(&mut planned_values.root_module, None);
+        }
+
+        Ok(version)
+    }
+
+    /// Returns the addresses of all resource changes in plan order
+    ///
+    /// # Returns
+    /// Vector of resource instance addresses, empty if the plan has no changes
+    pub fn resource_change_addresses(&self) -> Vec<String> {
+        self.resource_changes
+            .as_ref()
+            .map(|changes| changes.iter().map(|rc| rc.address.clone()).collect())
+            .unwrap_or_default()
+    }
+
+    /// Indexes the resource changes by instance address
+    ///
+    /// Build this once when looking up changes for many resources; it is empty if the
+    /// plan has no `resource_changes`.
+    pub fn resource_changes_by_address(&self) -> HashMap<&str, &ResourceChange> {
+        self.resource_changes
+            .iter()
+            .flatten()
+            .map(|rc| (rc.address.as_str(), rc))
+            .collect()
+    }
+
+    /// Returns the addresses of resources Terraform plans to create
+    ///
+    /// # Returns
+    /// Addresses whose planned action is a pure create, or None if the plan has no
+    /// `resource_changes` and actions are therefore unknown
+    ///
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::importer::PlanFile;
+    ///
+    /// let plan: PlanFile = serde_json::from_value(serde_json::json!({
+    ///     "format_version": "1.2",
+    ///     "terraform_version": "1.9.0",
+    ///     "resource_changes": [
+    ///         { "address": "aws_vpc.new", "mode": "managed", "type": "aws_vpc", "name": "new",
+    ///           "change": { "actions": ["create"] } },
+    ///         { "address": "aws_vpc.old", "mode": "managed", "type": "aws_vpc", "name": "old",
+    ///           "change": { "actions": ["update"] } }
+    ///     ]
+    /// })).unwrap();
+    ///
+    /// let creates = plan.create_only_addresses().unwrap();
+    /// assert!(creates.contains("aws_vpc.new"));
+    /// assert!(!creates.contains("aws_vpc.old"));
+    /// ```
+    pub fn create_only_addresses(&self) -> Option<HashSet<&str>> {
+        self.resource_changes.as_ref().map(|changes| {
+            changes
+                .iter()
+                .filter(|rc| rc.change.is_create())
+                .map(|rc| rc.address.as_str())
+                .collect()
+        })
+    }
+
+    /// Returns the resource changes Terraform plans to replace, in plan order
+    ///
+    /// Importing the create side of a replacement would adopt an object Terraform is
+    /// about to destroy, so these are never imported (see `RunError::PlannedReplacement`).
+    pub fn replacements(&self) -> Vec<&ResourceChange> {
+        self.resource_changes
+            .iter()
+            .flatten()
+            .filter(|rc| rc.mode == "managed" && rc.change.is_replace())
+            .collect()
+    }
+}
+
+/// Returns `address` with the full module path of `module_address`
+/// 
+/// Addresses that already have it, belong to another module, or don't parse are
+/// returned as they are (see `ResourceAddress::within_module`).
+fn qualify_address(module_address: &str, address: &str) -> String {
+    let (Ok(module_path), Ok(parsed)) = (parse_module_address(module_address), address.parse::<ResourceAddress>()) else {
+        return address.to_string();
+    };
+    match parsed.within_module(&module_path) {
+        Some(qualified) if qualified.module_path != parsed.module_path => qualified.to_string(),
+        _ => address.to_string(),
+    }
+}
+
+/// Gives every module and resource below `module` its full address
+/// 
+/// Terraform writes full addresses, e.g. `module.a.module.b.google_kms_crypto_key.this`,
+/// but some tools writing plan JSON leave out the leading module calls of nested
+/// modules and their resources. Those are completed from the parent module's address,
+/// so imports target the nested resource and not a same-named one higher up. Each
+/// resource records its module's full address as `module_address`.
+/// 
+/// # Arguments
+/// * `module` - Module whose address, resources and children are qualified
+/// * `parent` - Full address of the parent module, None for the root module
+fn qualify_module_addresses(module: &mut PlannedModule, parent: Option<&str>) {
+    if let (Some(parent), Some(address)) = (parent, module.address.as_mut()) {
+        if let (Ok(parent_path), Ok(module_path)) = (parse_module_address(parent), parse_module_address(address)) {
+            if !module_path.starts_with(&parent_path) {
+                *address = format_module_path(&[parent_path, module_path].concat());
+            }
+        }
+    }
+    let module_address = module.address.clone();
+    if let Some(module_address) = &module_address {
+        for resource in module.resources.iter_mut().flatten() {
+            resource.address = qualify_address(module_address, &resource.address);
+            resource.module_address = Some(module_address.clone());
+        }
+    }
+    for child in module.child_modules.iter_mut().flatten() {
+        qualify_module_addresses(child, module_address.as_deref());
+    }
 }
 
 /// Provider schema information from a plan file
@@ -119,6 +432,30 @@ pub struct Variables {
     pub project_id: Option<ValueWrapper>,
     /// Cloud region variable
     pub region: Option<ValueWrapper>,
+    /// Azure subscription ID variable, used by the Azure import ID builders
+    pub subscription_id: Option<ValueWrapper>,
+    /// Every other variable by name, as its raw `{"value": ...}` wrapper
+    #[serde(flatten)]
+    pub other: HashMap<String, Value>,
+}
+
+impl Variables {
+    /// Returns the value of the string variable `name`
+    /// 
+    /// # Arguments
+    /// * `name` - Variable name, without the `var.` prefix
+    /// 
+    /// # Returns
+    /// The variable's value, or None if it isn't set or isn't a string
+    pub fn value(&self, name: &str) -> Option<&str> {
+        let wrapper = match name {
+            "project_id" => self.project_id.as_ref(),
+            "region" => self.region.as_ref(),
+            "subscription_id" => self.subscription_id.as_ref(),
+            _ => return self.other.get(name).and_then(|wrapper| wrapper.get("value")).and_then(Value::as_str),
+        };
+        wrapper.map(|wrapper| wrapper.value.as_str())
+    }
 }
 
 /// Wrapper for variable values in the plan file
@@ -177,13 +514,16 @@ pub struct Resource {
     pub sensitive_values: Option<serde_json::Value>,
     /// Resources this resource depends on
     pub depends_on: Option<Vec<String>>,
+    /// Address of the containing module (e.g., "module.app.module.db"), absent for root
+    /// module resources; filled in from the module by `PlanFile::normalize`
+    pub module_address: Option<String>,
 }
 
 /// Metadata about a Terragrunt module
 /// 
 /// This comes from the modules.json file generated by terragrunt and contains
 /// information about where each module is located and what it contains.
-#[derive(Debug, Deserialize, Serialize)]
+#[derive(Debug, Clone, Deserialize, Serialize)]
 pub struct ModuleMeta {
     /// Unique key identifying this module
     #[serde(rename = "Key")]
@@ -244,12 +584,14 @@ pub fn validate_module_dirs<P: AsRef<Path>>(
 /// # Arguments
 /// * `modules` - Array of module metadata from modules.json
 /// * `plan` - Parsed Terraform plan file
+/// * `logger` - Warned about module addresses that can't be parsed or have no metadata
 /// 
 /// # Returns
 /// HashMap mapping resource addresses to their module metadata
 pub fn map_resources_to_modules<'a>(
     modules: &'a [ModuleMeta],
     plan: &'a PlanFile,
+    logger: &dyn Logger,
 ) -> HashMap<String, &'a ModuleMeta> {
     let mut mapping = HashMap::new();
 
@@ -259,28 +601,35 @@ pub fn map_resources_to_modules<'a>(
             modules: &'a [ModuleMeta],
             module: &'a PlannedModule,
             mapping: &mut HashMap<String, &'a ModuleMeta>,
+            logger: &dyn Logger,
         ) {
             if let Some(resources) = &module.resources {
                 if let Some(address) = &module.address {
-                    let prefix = address.strip_prefix("module.").unwrap_or("");
-                    if let Some(module_meta) = modules.iter().find(|m| m.key == prefix) {
+                    let key = match parse_module_address(address) {
+                        Ok(module_path) => module_key(&module_path),
+                        Err(e) => {
+                            logger.warn(&format!("{} - skipping resources in this module", e));
+                            return;
+                        }
+                    };
+                    if let Some(module_meta) = modules.iter().find(|m| m.key == key) {
                         for resource in resources {
                             mapping.insert(resource.address.clone(), module_meta);
                         }
                     } else {
-                        eprintln!("⚠️ Warning: Unmatched module address '{}' - skipping resources in this module", address);
+                        logger.warn(&format!("Unmatched module address '{}' - skipping resources in this module", address));
                         // Continue processing instead of crashing
                     }
                 }
             }
             if let Some(children) = &module.child_modules {
                 for child in children {
-                    recurse_modules(modules, child, mapping);
+                    recurse_modules(modules, child, mapping, logger);
                 }
             }
         }
 
-        recurse_modules(modules, &planned_values.root_module, &mut mapping);
+        recurse_modules(modules, &planned_values.root_module, &mut mapping, logger);
     }
 
     mapping
@@ -295,7 +644,7 @@ pub fn map_resources_to_modules<'a>(
 /// * `resource_map` - Mapping of resources to their modules
 /// * `plan` - Terraform plan file
 /// * `module_root` - Root directory for resolving module paths
-/// * `verbose` - Whether to print verbose output during processing
+/// * `logger` - Receives inference details and resources without an ID at debug level
 /// 
 /// # Returns
 /// Vector of terragrunt import command strings
@@ -304,13 +653,15 @@ pub fn generate_import_commands(
     resource_map: &HashMap<String, &ModuleMeta>,
     plan: &PlanFile,
     module_root: &str,
-    verbose: bool,
+    logger: &dyn Logger,
 ) -> Vec<String> {
     let mut commands = vec![];
+    let builders = ImportIdBuilderRegistry::default();
 
     if let Some(planned_values) = &plan.planned_values {
         let mut all_resources = vec![];
         collect_resources(&planned_values.root_module, &mut all_resources);
+        retain_planned_creates(plan, &mut all_resources);
 
         for resource in all_resources {
             let terraform_resource = TerraformResource {
@@ -329,27 +680,14 @@ pub fn generate_import_commands(
                 .cloned()
                 .unwrap_or_default();
 
-            let inferred_id = infer_resource_id(
-                &terraform_resource,
-                None, // Will be updated when we integrate SchemaManager properly
-                verbose,
-            );
+            let import_id = resolve_import_id(&terraform_resource, &ImportIdMappings::new(), &builders, logger);
 
             if let Some(module_meta) = resource_map.get(&resource.address) {
-                if let Some(ref id) = inferred_id {
+                if let Ok(ref id) = import_id {
                     let full_path = PathBuf::from(module_root).join(&module_meta.dir);
-                    let cmd = format!(
-                        "terragrunt import -config-dir={} {} {}",
-                        full_path.display(),
-                        resource.address,
-                        id
-                    );
-                    commands.push(cmd);
-                } else if verbose {
-                    println!(
-                        "⚠️ Could not infer ID for resource {}",
-                        resource.address
-                    );
+                    commands.push(format_import_command(&full_path, &resource.address, id));
+                } else if let Err(e) = import_id {
+                    logger.debug(&format!("Could not infer ID for resource {}: {}", resource.address, e));
                 }
             }
         }
@@ -391,14 +729,14 @@ pub fn extract_id_candidate_fields_from_values(values: &Map<String, Value>) -> H
 /// # Arguments
 /// * `resource` - The terraform resource to analyze
 /// * `schema_manager` - Optional schema manager for advanced analysis
-/// * `verbose` - Whether to print debug information about the selection process
+/// * `logger` - Receives the ranked candidates at debug level
 /// 
 /// # Returns
 /// The inferred ID string if one could be determined, None otherwise
 pub fn infer_resource_id(
     resource: &TerraformResource,
     schema_manager: Option<&SchemaManager>,
-    verbose: bool,
+    logger: &dyn Logger,
 ) -> Option<String> {
     let values = resource.values.as_ref()?.as_object()?;
     
@@ -423,13 +761,11 @@ pub fn infer_resource_id(
         }
     }
 
-    if verbose {
-        println!(
-            "🔍 [{}] Ranked ID candidates: {:?}",
-            resource.address,
-            ranked_candidates.iter().map(|(k, _)| *k).collect::<Vec<_>>()
-        );
-    }
+    logger.debug(&format!(
+        "🔍 [{}] Ranked ID candidates: {:?}",
+        resource.address,
+        ranked_candidates.iter().map(|(k, _)| *k).collect::<Vec<_>>()
+    ));
 
     for (_, val) in ranked_candidates {
         if let Some(s) = val.as_str() {
@@ -440,36 +776,214 @@ pub fn infer_resource_id(
     None
 }
 
-/// Executes an import operation for a resource (either dry-run or real execution)
-/// 
-/// This internal function handles the actual execution or simulation of a terragrunt
-/// import command for a single resource.
+/// Builds the ImportCommand for a resource that is ready for import
 /// 
 /// # Arguments
 /// * `resource_with_id` - Resource with all information needed for import
-/// * `dry_run` - If true, only generate the command without executing it
+/// * `binary` - Program that runs the import
+/// * `extra_args` - Arguments passed through to the import subcommand
 /// 
 /// # Returns
-/// Result indicating success, failure, or dry-run status
-fn execute_import_for_resource(resource_with_id: &ResourceWithId, dry_run: bool) -> ImportExecutionResult {
-    if dry_run {
-        let command = format!(
-            "terragrunt import -config-dir={} {} {}",
-            resource_with_id.module_path.display(),
-            resource_with_id.resource.address,
-            resource_with_id.id
-        );
-        ImportExecutionResult::DryRun {
-            address: resource_with_id.resource.address.clone(),
-            command,
+/// ImportCommand targeting the resource's module directory
+fn import_command_for(resource_with_id: &ResourceWithId, binary: ImportBinary, extra_args: &[String]) -> ImportCommand {
+    ImportCommand {
+        working_directory: resource_with_id.module_path.clone(),
+        resource_address: resource_with_id.address.to_string(),
+        resource_id: resource_with_id.id.clone(),
+        resource_type: resource_with_id.resource.r#type.clone(),
+        module_name: resource_with_id.module_meta.key.clone(),
+        binary,
+        extra_args: extra_args.to_vec(),
+    }
+}
+
+/// Determines the import ID for a resource
+/// 
+/// An explicit entry in `mappings` always wins. Otherwise, if a builder is registered
+/// for the resource type it is authoritative: its ID is used, and if it can't build one
+/// (e.g. a required attribute is unknown at plan time) the resource is not imported
+/// rather than guessing. Types without a builder fall back to heuristic inference via
+/// `infer_resource_id`.
+/// 
+/// # Arguments
+/// * `resource` - The terraform resource to resolve an ID for
+/// * `mappings` - Explicit import IDs from a mapping file
+/// * `builders` - Registry of resource-type specific import ID builders
+/// * `logger` - Receives mapped IDs and inference details at debug level
+/// 
+/// # Returns
+/// The import ID
+/// 
+/// # Errors
+/// - `ImportIdError::MissingAttribute` if the registered builder lacks an attribute
+/// - `ImportIdError::UnsupportedType` if there is no builder and inference found nothing
+pub fn resolve_import_id(
+    resource: &TerraformResource,
+    mappings: &ImportIdMappings,
+    builders: &ImportIdBuilderRegistry,
+    logger: &dyn Logger,
+) -> Result<String, ImportIdError> {
+    if let Some(id) = mappings.lookup(&resource.address) {
+        logger.debug(&format!("🗺️ Using mapped import ID for {}: {}", resource.address, id));
+        return Ok(id.to_string());
+    }
+
+    let empty = Map::new();
+    let attributes = resource
+        .values
+        .as_ref()
+        .and_then(|values| values.as_object())
+        .unwrap_or(&empty);
+
+    match builders.build(&resource.r#type, attributes) {
+        Some(Ok(id)) => Ok(id),
+        Some(result) => result,
+        None => infer_resource_id(resource, None, logger).ok_or_else(|| ImportIdError::UnsupportedType {
+            resource_type: resource.r#type.clone(),
+        }),
+    }
+}
+
+/// Retries a resource's builder with plan-unknown attributes taken from prior state
+/// 
+/// Some IDs depend on attributes that are only known after apply (e.g. a generated
+/// suffix), yet the object already exists and is in state. Each time the builder reports
+/// a missing attribute that `change` marks as unknown, the value is copied from the
+/// resource's entry in the pulled state of `module_path` and the builder is run again.
+/// Every value taken from state is logged at info level; attributes the builder reads
+/// from the plan are used as they are.
+/// 
+/// # Arguments
+/// * `resource` - The resource whose builder failed
+/// * `change` - The resource's planned change
+/// * `builders` - Registry of import ID builders
+/// * `prior_state` - Cache of pulled state
+/// * `module_path` - Module directory whose state holds the resource
+/// * `logger` - Receives resolution and state read failures
+/// 
+/// # Errors
+/// The builder's `ImportIdError::MissingAttribute` for the first attribute that is known
+/// in the plan, or can't be found in state
+fn resolve_unknowns_from_state(
+    resource: &TerraformResource,
+    change: Option<&Change>,
+    builders: &ImportIdBuilderRegistry,
+    prior_state: &mut StateCache,
+    module_path: &Path,
+    logger: &dyn Logger,
+) -> Result<String, ImportIdError> {
+    let mut attributes = resource
+        .values
+        .as_ref()
+        .and_then(|values| values.as_object())
+        .cloned()
+        .unwrap_or_default();
+    let mut from_state = HashSet::new();
+
+    loop {
+        let error = match builders.build(&resource.r#type, &attributes) {
+            Some(Err(error)) => error,
+            Some(result) => return result,
+            None => return Err(ImportIdError::UnsupportedType { resource_type: resource.r#type.clone() }),
+        };
+        let ImportIdError::MissingAttribute { attribute, .. } = &error else {
+            return Err(error);
+        };
+        if from_state.contains(attribute) || !change.is_some_and(|change| change.is_unknown_after(attribute)) {
+            return Err(error);
         }
-    } else {
-        match run_terragrunt_import(&resource_with_id.module_path, &resource_with_id.resource.address, resource_with_id.id.clone()) {
-            Ok(_) => ImportExecutionResult::Success(resource_with_id.resource.address.clone()),
-            Err(e) => ImportExecutionResult::Failed {
-                address: resource_with_id.resource.address.clone(),
-                error: format!("Import failed: {}", e),
-            },
+
+        let value = match prior_state.attributes(module_path, &resource.address) {
+            Ok(prior) => prior.and_then(|prior| prior.get(attribute)).filter(|value| !value.is_null()).cloned(),
+            Err(e) => {
+                logger.warn(&format!("Could not read state to resolve unknown attributes: {}", e));
+                None
+            }
+        };
+        let Some(value) = value else {
+            return Err(error);
+        };
+
+        logger.info(&format!("ℹ️ Resolved {} of {} from state (unknown in plan): {}", attribute, resource.address, value));
+        attributes.insert(attribute.clone(), value);
+        from_state.insert(attribute.clone());
+    }
+}
+
+/// Sets the `subscription_id` an Azure builder needs on a resource that doesn't have one
+/// 
+/// The subscription is taken from the plan's `subscription_id` variable or, failing
+/// that, from the first resource ID (by address) in the pulled state of `module_path`
+/// that names a subscription. Resources that already have a `subscription_id` are left
+/// alone; if neither source has one, the builder reports the attribute as missing.
+/// 
+/// # Arguments
+/// * `resource` - The `azurerm_` resource whose values are passed to the builder
+/// * `plan_subscription` - The plan's `subscription_id` variable, if set
+/// * `prior_state` - Cache of pulled state, if looking up unknowns in state is enabled
+/// * `module_path` - Module directory whose state holds the module's resources
+/// * `logger` - Receives the subscription taken from state and state read failures
+fn fill_azure_subscription(
+    resource: &mut TerraformResource,
+    plan_subscription: Option<&str>,
+    prior_state: Option<&mut StateCache>,
+    module_path: Option<&Path>,
+    logger: &dyn Logger,
+) {
+    let Some(Value::Object(values)) = &mut resource.values else { return };
+    if values.get(SUBSCRIPTION_ID_ATTRIBUTE).is_some_and(|value| !value.is_null()) {
+        return;
+    }
+
+    let subscription = match (plan_subscription, prior_state, module_path) {
+        (Some(subscription), ..) => Some(subscription.to_string()),
+        (None, Some(prior_state), Some(module_path)) => match prior_state.resources(module_path) {
+            Ok(resources) => {
+                let mut found: Vec<(&String, &str)> = resources
+                    .iter()
+                    .filter_map(|(address, attributes)| {
+                        let id = attributes.get("id").and_then(Value::as_str)?;
+                        Some((address, subscription_from_resource_id(id)?))
+                    })
+                    .collect();
+                found.sort();
+                found.first().map(|(address, subscription)| {
+                    logger.info(&format!("ℹ️ Resolved subscription_id of {} from the state of {}: {}", resource.address, address, subscription));
+                    subscription.to_string()
+                })
+            }
+            Err(e) => {
+                logger.warn(&format!("Could not read state to resolve the Azure subscription: {}", e));
+                None
+            }
+        },
+        _ => None,
+    };
+    if let Some(subscription) = subscription {
+        values.insert(SUBSCRIPTION_ID_ATTRIBUTE.to_string(), Value::String(subscription));
+    }
+}
+
+/// Sets the `project`, `region` and `zone` a GCP builder needs from the resource's provider
+/// 
+/// Resources using a provider alias get these defaults from the aliased block, so two
+/// otherwise identical resources can resolve to different import IDs. Attributes the
+/// resource already has are left alone, as are settings the provider doesn't resolve.
+/// 
+/// # Arguments
+/// * `resource` - The `google_` resource whose values are passed to the builder
+/// * `provider` - The provider block the resource uses, if the configuration names one
+/// * `logger` - Receives each attribute taken from the provider at debug level
+fn fill_google_provider_defaults(resource: &mut TerraformResource, provider: Option<&ProviderConfig>, logger: &dyn Logger) {
+    let Some(provider) = provider else { return };
+    let Some(Value::Object(values)) = &mut resource.values else { return };
+    for attribute in GOOGLE_PROVIDER_DEFAULTS {
+        if values.get(*attribute).is_some_and(|value| !value.is_null()) {
+            continue;
+        }
+        if let Some(value) = provider.setting(attribute) {
+            logger.debug(&format!("Using {} of {} from provider {}: {}", attribute, resource.address, provider.display_name(), value));
+            values.insert(attribute.to_string(), Value::String(value.to_string()));
         }
     }
 }
@@ -478,13 +992,21 @@ fn execute_import_for_resource(resource_with_id: &ResourceWithId, dry_run: bool)
 /// 
 /// This internal function analyzes a resource to determine if it can be imported.
 /// It checks for module mapping, attempts ID inference, and returns the appropriate result.
+/// Resources whose type has no builder and no inferable ID are reported as unsupported.
 /// 
 /// # Arguments
 /// * `resource` - The resource to process
 /// * `resource_map` - Mapping of resources to modules
 /// * `_schema_map` - Provider schema information (currently unused)
+/// * `mappings` - Explicit import IDs from a mapping file
+/// * `builders` - Registry of resource-type specific import ID builders
 /// * `module_root` - Root directory for module paths
-/// * `verbose` - Whether to print verbose output
+/// * `logger` - Receives the parsed address, inference details and built import ID at debug level
+/// * `prior_state` - If given, attributes unknown in `change` are looked up here when a
+///   builder is missing them (see `resolve_unknowns_from_state`)
+/// * `change` - The resource's planned change, telling which attributes are unknown
+/// * `subscription_id` - The plan's Azure subscription ID (see `fill_azure_subscription`)
+/// * `provider` - The provider block the resource uses (see `fill_google_provider_defaults`)
 /// 
 /// # Returns
 /// Processing result indicating if the resource is ready for import or should be skipped
@@ -492,41 +1014,96 @@ fn process_single_resource<'a>(
     resource: &'a Resource,
     resource_map: &HashMap<String, &'a ModuleMeta>,
     _schema_map: &HashMap<String, Value>,
+    mappings: &ImportIdMappings,
+    builders: &ImportIdBuilderRegistry,
     module_root: &str,
-    verbose: bool,
+    logger: &dyn Logger,
+    mut prior_state: Option<&mut StateCache>,
+    change: Option<&Change>,
+    subscription_id: Option<&str>,
+    provider: Option<&ProviderConfig>,
 ) -> ResourceProcessingResult<'a> {
-    let terraform_resource = TerraformResource {
+    let mut terraform_resource = TerraformResource {
         address: resource.address.clone(),
         mode: resource.mode.clone(),
         r#type: resource.r#type.clone(),
         name: resource.name.clone(),
         values: resource.values.clone(),
     };
+    if resource.r#type.starts_with("azurerm_") {
+        let module_path = resource_map.get(&resource.address).map(|module_meta| PathBuf::from(module_root).join(&module_meta.dir));
+        fill_azure_subscription(&mut terraform_resource, subscription_id, prior_state.as_deref_mut(), module_path.as_deref(), logger);
+    }
+    if resource.r#type.starts_with("google_") {
+        fill_google_provider_defaults(&mut terraform_resource, provider, logger);
+    }
 
-    let inferred_id = infer_resource_id(
-        &terraform_resource,
-        None, // Will be updated when we integrate SchemaManager properly
-        verbose,
-    );
+    let address = match resource.address.parse::<ResourceAddress>() {
+        Ok(address) if address.data => {
+            return ResourceProcessingResult::Skipped {
+                address: resource.address.clone(),
+                reason: "data sources cannot be imported".to_string(),
+            };
+        }
+        Ok(address) => address,
+        Err(e) => {
+            logger.debug(&format!("Could not parse address {}: {}", resource.address, e));
+            return ResourceProcessingResult::Skipped {
+                address: resource.address.clone(),
+                reason: e.to_string(),
+            };
+        }
+    };
+
+    logger.debug(&format!(
+        "Parsed address {}: module={} type={} name={} key={}",
+        resource.address,
+        address.module_address().unwrap_or_else(|| "<root>".to_string()),
+        address.resource_type,
+        address.name,
+        address.key.as_ref().map_or_else(|| "<none>".to_string(), |key| key.to_string())
+    ));
+
+    let import_id = match (resolve_import_id(&terraform_resource, mappings, builders, logger), prior_state, resource_map.get(&resource.address)) {
+        (Err(ImportIdError::MissingAttribute { .. }), Some(prior_state), Some(module_meta)) => {
+            let module_path = PathBuf::from(module_root).join(&module_meta.dir);
+            resolve_unknowns_from_state(&terraform_resource, change, builders, prior_state, &module_path, logger)
+        }
+        (import_id, ..) => import_id,
+    };
+    match &import_id {
+        Ok(id) => logger.debug(&format!("Built import ID for {}: {}", resource.address, id)),
+        Err(e) => logger.debug(&format!("No import ID for {}: {}", resource.address, e)),
+    }
 
     match resource_map.get(&resource.address) {
-        Some(module_meta) => {
-            if let Some(id) = inferred_id {
+        Some(module_meta) => match import_id {
+            Ok(id) => {
                 let module_path = PathBuf::from(module_root).join(&module_meta.dir);
                 ResourceProcessingResult::ReadyForImport(ResourceWithId {
                     resource,
+                    address,
                     terraform_resource,
                     id,
                     module_meta,
                     module_path,
                 })
-            } else {
-                ResourceProcessingResult::Skipped {
-                    address: resource.address.clone(),
-                    reason: "no ID could be inferred".to_string(),
+            }
+            Err(e) => {
+                let reason = e.to_string();
+                match e {
+                    ImportIdError::UnsupportedType { resource_type } => ResourceProcessingResult::Unsupported {
+                        address: resource.address.clone(),
+                        resource_type,
+                        reason,
+                    },
+                    _ => ResourceProcessingResult::Skipped {
+                        address: resource.address.clone(),
+                        reason,
+                    },
                 }
             }
-        }
+        },
         None => ResourceProcessingResult::Skipped {
             address: resource.address.clone(),
             reason: "no matching module mapping found".to_string(),
@@ -534,10 +1111,52 @@ fn process_single_resource<'a>(
     }
 }
 
+/// Drops resources whose planned action isn't a pure create
+/// 
+/// Only resources Terraform is about to create can be imported; anything it updates,
+/// replaces or leaves unchanged is already managed. Plans without `resource_changes`
+/// carry no actions, so their resources are all kept.
+/// 
+/// # Arguments
+/// * `plan` - The Terraform plan the resources were collected from
+/// * `resources` - Resources collected from the plan's planned values
+fn retain_planned_creates(plan: &PlanFile, resources: &mut Vec<&Resource>) {
+    if let Some(creates) = plan.create_only_addresses() {
+        resources.retain(|resource| creates.contains(resource.address.as_str()));
+    }
+}
+
+/// Collects the values of a planned resource that the plan marks as sensitive
+/// 
+/// Both the planned resource's `sensitive_values` and the change's `after_sensitive`
+/// are honoured, so it doesn't matter which of the two a plan format fills in.
+/// 
+/// # Arguments
+/// * `resource` - The planned resource
+/// * `change` - The resource's change block, if the plan has `resource_changes`
+/// 
+/// # Returns
+/// The sensitive values, for `sensitive::Redactor`
+fn plan_sensitive_values(resource: &Resource, change: Option<&Change>) -> Vec<String> {
+    let mut found = Vec::new();
+    if let (Some(values), Some(mask)) = (&resource.values, &resource.sensitive_values) {
+        found.extend(sensitive_values(values, mask));
+    }
+    if let Some(change) = change {
+        let values = change.after.as_ref().or(resource.values.as_ref());
+        if let (Some(values), Some(mask)) = (values, &change.after_sensitive) {
+            found.extend(sensitive_values(values, mask));
+        }
+    }
+    found.sort();
+    found.dedup();
+    found
+}
+
 /// Collects all resources from a plan and prepares the provider schema map
 /// 
-/// This internal helper function extracts all resources from a plan file and
-/// prepares the provider schema information for use in processing.
+/// This internal helper function extracts the resources Terraform plans to create
+/// and prepares the provider schema information for use in processing.
 /// 
 /// # Arguments
 /// * `plan` - The Terraform plan file to process
@@ -549,6 +1168,7 @@ fn collect_and_prepare_resources(plan: &PlanFile) -> (Vec<&Resource>, HashMap<St
     if let Some(planned_values) = &plan.planned_values {
         collect_resources(&planned_values.root_module, &mut all_resources);
     }
+    retain_planned_creates(plan, &mut all_resources);
 
     let schema_map = plan
         .provider_schemas
@@ -561,68 +1181,661 @@ fn collect_and_prepare_resources(plan: &PlanFile) -> (Vec<&Resource>, HashMap<St
     (all_resources, schema_map)
 }
 
+/// Decision the generator made for one planned resource
+/// 
+/// Serialized in kebab-case (e.g. `skip-already-in-state`), which is also the `Display` form.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "kebab-case")]
+pub enum ImportDecision {
+    /// An import command is generated for the resource
+    Import,
+    /// The resource is already present in its module's state
+    SkipAlreadyInState,
+    /// No import ID builder exists for the type and no ID could be inferred
+    Unsupported,
+    /// The address doesn't pass the include/exclude filter
+    FilteredOut,
+    /// The resource can't be imported, e.g. no module mapping or an ID attribute unknown at plan time
+    Skip,
+    /// The import ID doesn't match its builder's formats (only with `validate_ids`)
+    InvalidId,
+}
+
+impl std::fmt::Display for ImportDecision {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        let name = match self {
+            ImportDecision::Import => "import",
+            ImportDecision::SkipAlreadyInState => "skip-already-in-state",
+            ImportDecision::Unsupported => "unsupported",
+            ImportDecision::FilteredOut => "filtered-out",
+            ImportDecision::Skip => "skip",
+            ImportDecision::InvalidId => "invalid-id",
+        };
+        f.write_str(name)
+    }
+}
+
+/// Outcome of generating the import for one planned resource, before anything runs
+/// 
+/// # Fields
+/// - `address`: Full terraform resource address
+/// - `resource_type`: Terraform resource type
+/// - `import_id`: Resolved import ID, if one could be determined
+/// - `decision`: What will happen to the resource
+/// - `reason`: Why the resource won't be imported, for skips, unsupported types and invalid IDs
+/// - `command`: The import command, for `ImportDecision::Import`
+/// - `sensitive_values`: Values of the resource the plan marks as sensitive
+#[derive(Debug, Clone)]
+pub struct PlannedImport {
+    /// Full terraform resource address
+    pub address: String,
+    /// Terraform resource type
+    pub resource_type: String,
+    /// Resolved import ID
+    pub import_id: Option<String>,
+    /// What will happen to the resource
+    pub decision: ImportDecision,
+    /// Why the resource won't be imported
+    pub reason: Option<String>,
+    /// The import command to run
+    pub command: Option<ImportCommand>,
+    /// Values from `after_sensitive` / `sensitive_values` that may appear in the import
+    /// ID; anything shown to users masks them (see `sensitive::Redactor`)
+    pub sensitive_values: Vec<String>,
+}
+
+/// Generates the import decision for every planned create without executing anything
+/// 
+/// This is the first half of `execute_or_print_imports`: it resolves import IDs, applies
+/// the filter, ID validation and duplicate check, and consults existing state, producing
+/// one `PlannedImport` per planned create in dependency order (see `ordering`), so parents
+/// come before the resources that reference them. Resources rejected by
+/// `options.filter` are included with `ImportDecision::FilteredOut`. See
+/// `execute_or_print_imports` for how each option affects the decisions.
+/// 
+/// With `options.workspace` set, the workspace is selected first, as by
+/// `execute_or_print_imports`, so the state check reads that workspace's state.
+/// 
+/// # Arguments
+/// Same as `execute_or_print_imports` without `verbose`; `runner` is only used for workspace
+/// and state commands
+/// 
+/// # Returns
+/// The decision for each planned create; empty if the plan has no planned values
+/// 
+/// # Errors
+/// - `RunError::DuplicateImportId` if two addresses resolve to the same import ID
+///   and `options.allow_duplicate_ids` isn't set
+/// - `RunError::PlannedReplacement` if the plan replaces a resource passing the filter
+///   and `options.skip_replacements` isn't set
+/// - `RunError::Workspace` if the workspace doesn't exist or can't be selected
+pub fn plan_imports(
+    resource_map: &HashMap<String, &ModuleMeta>,
+    plan: &PlanFile,
+    mappings: &ImportIdMappings,
+    builders: &ImportIdBuilderRegistry,
+    options: &ImportOptions,
+    module_root: &str,
+    runner: &dyn CommandRunner,
+) -> Result<Vec<PlannedImport>, RunError> {
+    if plan.planned_values.is_some() {
+        select_workspaces(resource_map, options, module_root, runner)?;
+    }
+    let mut state = StateCache::new(runner, options.binary);
+    plan_imports_with_state(resource_map, plan, mappings, builders, options, module_root, &mut state)
+}
+
+/// Selects `options.workspace`, if set, in every mapped module directory (or, with
+/// `options.strip_module_prefix`, the module root); in dry-run mode it is only checked
+fn select_workspaces(
+    resource_map: &HashMap<String, &ModuleMeta>,
+    options: &ImportOptions,
+    module_root: &str,
+    runner: &dyn CommandRunner,
+) -> Result<(), RunError> {
+    let Some(workspace) = &options.workspace else { return Ok(()) };
+    let mut directories: Vec<PathBuf> = if options.strip_module_prefix.is_empty() {
+        resource_map.values().map(|module_meta| PathBuf::from(module_root).join(&module_meta.dir)).collect()
+    } else {
+        vec![PathBuf::from(module_root)]
+    };
+    directories.sort();
+    directories.dedup();
+    for directory in &directories {
+        select_workspace(runner, options.binary, directory, workspace, options.dry_run).map_err(RunError::Workspace)?;
+        options.logger.info(&format!("🗂️ Using workspace {} in {}", workspace, directory.display()));
+    }
+    Ok(())
+}
+
+/// Skip reason for resources an earlier run's checkpoint records as finished
+pub const CHECKPOINT_SKIP_REASON: &str = "already finished according to the checkpoint (--resume)";
+
+/// Skip reason for resources the plan replaces, with `skip_replacements` set
+pub const REPLACEMENT_SKIP_REASON: &str = "the plan replaces it (destroy and recreate), so importing would adopt an object Terraform is about to delete";
+
+/// `plan_imports` reading existing state through `state`, so the caller can reuse its pulls
+fn plan_imports_with_state(
+    resource_map: &HashMap<String, &ModuleMeta>,
+    plan: &PlanFile,
+    mappings: &ImportIdMappings,
+    builders: &ImportIdBuilderRegistry,
+    options: &ImportOptions,
+    module_root: &str,
+    state: &mut StateCache,
+) -> Result<Vec<PlannedImport>, RunError> {
+    if PlanFormatVersion::is_newer_than_known(&plan.format_version) {
+        options.logger.warn(&format!(
+            "⚠️ Plan format version {} is newer than 1.2; reading it as 1.2, so newer fields are ignored",
+            plan.format_version.trim()
+        ));
+    }
+    let mut planned = Vec::new();
+    if plan.planned_values.is_none() {
+        return Ok(planned);
+    }
+
+    let (mut all_resources, schema_map) = collect_and_prepare_resources(plan);
+    if let Err(cycle) = sort_by_dependencies(plan, &mut all_resources) {
+        options.logger.warn(&format!("⚠️ Importing in plan order: {}", cycle));
+    }
+    let replacements: Vec<&ResourceChange> = plan
+        .replacements()
+        .into_iter()
+        .filter(|rc| options.filter.matches(&rc.address))
+        .filter(|rc| {
+            options.strip_module_prefix.is_empty()
+                || rc.address.parse::<ResourceAddress>().is_ok_and(|address| address.strip_module_prefix(&options.strip_module_prefix).is_some())
+        })
+        .collect();
+    if !replacements.is_empty() && !options.skip_replacements {
+        return Err(RunError::PlannedReplacement { addresses: replacements.iter().map(|rc| rc.address.clone()).collect() });
+    }
+    for rc in &replacements {
+        options.logger.warn(&format!("⚠️ Skipping {}: {}", rc.address, REPLACEMENT_SKIP_REASON));
+        planned.push(PlannedImport {
+            address: rc.address.clone(),
+            resource_type: rc.r#type.clone(),
+            import_id: None,
+            decision: ImportDecision::Skip,
+            reason: Some(REPLACEMENT_SKIP_REASON.to_string()),
+            command: None,
+            sensitive_values: Vec::new(),
+        });
+    }
+    if !options.resume.is_empty() {
+        let mut planned_addresses: HashSet<&str> = all_resources.iter().map(|resource| resource.address.as_str()).collect();
+        planned_addresses.extend(replacements.iter().map(|rc| rc.address.as_str()));
+        for address in options.resume.addresses().filter(|address| !planned_addresses.contains(address)) {
+            options.logger.warn(&format!("⚠️ Ignoring checkpoint entry for {}: not a planned create in this plan", address));
+        }
+    }
+    let mut addresses_by_id: HashMap<(String, String), String> = HashMap::new();
+    let changes = plan.resource_changes_by_address();
+    let subscription_id = plan.variables.as_ref().and_then(|variables| variables.subscription_id.as_ref()).map(|variable| variable.value.as_str());
+    let providers = ProviderConfigs::from_plan(plan);
+
+    for resource in all_resources {
+        let mut entry = PlannedImport {
+            address: resource.address.clone(),
+            resource_type: resource.r#type.clone(),
+            import_id: None,
+            decision: ImportDecision::FilteredOut,
+            reason: None,
+            command: None,
+            sensitive_values: Vec::new(),
+        };
+        if !options.filter.matches(&resource.address) {
+            planned.push(entry);
+            continue;
+        }
+        if options.resume.contains(&resource.address) {
+            entry.decision = ImportDecision::Skip;
+            entry.reason = Some(CHECKPOINT_SKIP_REASON.to_string());
+            planned.push(entry);
+            continue;
+        }
+
+        let change = changes.get(resource.address.as_str()).map(|rc| &rc.change);
+        entry.sensitive_values = plan_sensitive_values(resource, change);
+        let mut redactor = options.redactor.clone();
+        redactor.extend(entry.sensitive_values.iter().cloned());
+        let logger = RedactingLogger::new(&*options.logger, &redactor);
+        let result = process_single_resource(
+            resource,
+            resource_map,
+            &schema_map,
+            mappings,
+            builders,
+            module_root,
+            &logger,
+            options.resolve_unknown_from_state.then_some(&mut *state),
+            change,
+            subscription_id,
+            resource.address.parse::<ResourceAddress>().ok().and_then(|address| providers.for_resource(&address)),
+        );
+
+        match result {
+            ResourceProcessingResult::ReadyForImport(mut resource_with_id) => {
+                entry.import_id = Some(resource_with_id.id.clone());
+                if !options.strip_module_prefix.is_empty() {
+                    match resource_with_id.address.strip_module_prefix(&options.strip_module_prefix) {
+                        Some(relative) => {
+                            resource_with_id.address = relative;
+                            resource_with_id.module_path = PathBuf::from(module_root);
+                        }
+                        None => {
+                            entry.decision = ImportDecision::Skip;
+                            entry.reason = Some(format!("not inside {}", format_module_path(&options.strip_module_prefix)));
+                            planned.push(entry);
+                            continue;
+                        }
+                    }
+                }
+
+                let validation = if options.validate_ids {
+                    builders.validate(&resource_with_id.resource.r#type, &resource_with_id.id)
+                } else {
+                    Ok(())
+                };
+                if let Err(e) = validation {
+                    entry.decision = ImportDecision::InvalidId;
+                    entry.reason = Some(e.to_string());
+                    planned.push(entry);
+                    continue;
+                }
+
+                let key = (resource_with_id.resource.r#type.clone(), resource_with_id.id.clone());
+                match addresses_by_id.get(&key) {
+                    Some(first) if *first != resource_with_id.resource.address => {
+                        if !options.allow_duplicate_ids {
+                            return Err(RunError::DuplicateImportId {
+                                resource_type: key.0,
+                                import_id: redactor.redact(&key.1),
+                                first: first.clone(),
+                                second: resource_with_id.resource.address.clone(),
+                            });
+                        }
+                        logger.warn(&format!(
+                            "{} and {} both resolve to {} import id '{}'",
+                            first, resource_with_id.resource.address, key.0, key.1
+                        ));
+                    }
+                    Some(_) => {}
+                    None => {
+                        addresses_by_id.insert(key, resource_with_id.resource.address.clone());
+                    }
+                }
+
+                if !options.skip_state_check {
+                    match state.contains(&resource_with_id.module_path, &resource_with_id.address.to_string()) {
+                        Ok(true) => {
+                            entry.decision = ImportDecision::SkipAlreadyInState;
+                            planned.push(entry);
+                            continue;
+                        }
+                        Ok(false) => {}
+                        Err(e) => options.logger.warn(&format!("Could not check existing state, importing without it: {}", e)),
+                    }
+                }
+
+                entry.decision = ImportDecision::Import;
+                entry.command = Some(import_command_for(&resource_with_id, options.binary, &options.extra_args));
+            }
+            ResourceProcessingResult::Unsupported { address, resource_type, reason } => {
+                entry.address = address;
+                entry.resource_type = resource_type;
+                entry.decision = ImportDecision::Unsupported;
+                entry.reason = Some(reason);
+            }
+            ResourceProcessingResult::Skipped { address, reason } => {
+                entry.address = address;
+                entry.decision = ImportDecision::Skip;
+                entry.reason = Some(reason);
+            }
+        }
+        planned.push(entry);
+    }
+
+    Ok(planned)
+}
+
 /// **MAIN FUNCTION** - Executes or prints terragrunt import commands for all resources
 /// 
 /// This is the primary function used by the application to process a full Terraform plan
 /// and either execute import commands or print them in dry-run mode. It handles the complete
-/// workflow from resource discovery to import execution/simulation.
+/// workflow from resource discovery to import execution/simulation. The import
+/// decisions come from `plan_imports`; this function reports them and runs the imports.
+/// 
+/// Only resources whose planned action is a pure create are considered. Resources
+/// whose address doesn't pass `options.filter` are left out entirely, as if they
+/// weren't in the plan.
+/// 
+/// Unless `options.skip_state_check` is set, resources already present in their module
+/// directory's state are skipped, so the tool can safely be re-run after a partial
+/// failure. If a directory's state can't be read a warning is printed and its resources
+/// are attempted as usual.
+/// 
+/// With `options.resolve_unknown_from_state` set, a builder that lacks an attribute the
+/// plan marks as unknown gets the value from the module's pulled state instead, for
+/// resources that already exist out-of-band.
+/// 
+/// Each module directory's state is pulled (`terragrunt state pull`) at most once per
+/// run into a `StateCache` shared by the state check, the unknown-attribute fallback
+/// and the state backups below.
+/// 
+/// With `options.validate_ids` set, each import ID (including mapped ones) is checked
+/// against the formats of its type's builder, and resources with a malformed ID are
+/// recorded as failed without running a command.
+/// 
+/// With `options.strip_module_prefix` set, the plan is taken to come from the root module
+/// while Terragrunt runs in `module_root`, the directory of that module: every import
+/// runs there with its address relative to the module (also for the state check), and
+/// resources outside the module are skipped. The report keeps the plan addresses.
+/// 
+/// Two distinct addresses resolving to the same resource type and import ID would make
+/// the second import adopt a resource the first already manages, so the run is aborted
+/// before anything is imported, unless `options.allow_duplicate_ids` is set, in which
+/// case a warning is logged.
+/// 
+/// Resources the plan replaces (`["delete", "create"]` or `["create", "delete"]`) are
+/// never imported: the object being imported is the one Terraform is about to destroy.
+/// Any replacement passing the filter aborts the run before anything is imported, unless
+/// `options.skip_replacements` is set, in which case each is skipped with a warning.
+/// 
+/// With `options.checkpoint` set, every resource that is imported, already in state,
+/// skipped or unsupported is appended to the checkpoint as soon as it finishes (outside
+/// dry-run mode). With `options.verify` set, imports are only recorded once verified, so
+/// one that fails verification is retried by the next run. Addresses in `options.resume`
+/// are skipped before anything else is done for them, and are not recorded again.
+/// 
+/// With `options.workspace` set, the workspace is selected in every mapped module
+/// directory (or, with `options.strip_module_prefix`, the module root) before state is
+/// read, and recorded in the report. In dry-run mode it is only checked to exist.
+/// 
+/// With `options.preflight` set, the binary and the working directories of every
+/// resource passing the filter are checked before anything runs (see
+/// `preflight::preflight`); `options.skip_terragrunt_config_check` accepts directories
+/// without a `terragrunt.hcl`.
+/// 
+/// With `options.check_version` set, `<binary> --version` is run before any state command. The version is
+/// recorded in the report, and a warning is logged if it is older than the known-good
+/// minimum or can't be determined; the run continues either way.
+/// 
+/// Values the plan marks as sensitive are still passed to the import commands as-is,
+/// but are masked as `***` in progress output, debug logs and the report.
+/// 
+/// With `options.verify` set (outside dry-run mode), the successful imports of each
+/// module directory are checked once the batch is done (see `verify::verify_imports`).
+/// Resources that pass are reported as `Verified`; the others as `Failed`, with the
+/// reason as their error.
+/// 
+/// A failed import's stdout and stderr are logged as an error and kept in its report
+/// entry's `output`, redacted and cut to the last `options.max_output_bytes` bytes.
+/// 
+/// If `options.state_backup_dir` is set, the state of every module directory that is
+/// about to receive imports is written to a timestamped backup first; a directory with
+/// no state yet has nothing to back up, which is logged and not an error. The remaining
+/// commands are then handed to `ImportExecutor::execute_imports` as one batch, so
+/// `options.workers`, `options.fail_fast`, `options.max_errors` and `options.retry` apply. Commands are in
+/// dependency order; workers keep that order within each module directory, and with a
+/// single worker it holds across directories too.
 /// 
 /// # Arguments
 /// * `resource_map` - Mapping of resource addresses to their module metadata
 /// * `plan` - Terraform plan file containing resources to import
-/// * `dry_run` - If true, only print commands without executing them
+/// * `mappings` - Explicit import IDs that override the builders (see `app::load_mappings`)
+/// * `builders` - Registry of import ID builders; register custom builders before calling
+/// * `options` - Execution options; with `dry_run` set, commands are only printed
 /// * `verbose` - Whether to print detailed progress information
 /// * `module_root` - Root directory for resolving module paths
+/// * `runner` - Command runner used to inspect existing state
+/// 
+/// # Returns
+/// Report of every resource's outcome; `Report::commands()` gives the fully-formed
+/// command strings that were run (or, in dry-run mode, would have been run)
+/// 
+/// # Errors
+/// - `RunError::DuplicateImportId` if two addresses resolve to the same import ID
+/// - `RunError::StateBackup` if a state backup fails
+/// - `RunError::Workspace` if the workspace doesn't exist or can't be selected
+/// - `RunError::Preflight` if the binary or a working directory fails the preflight checks
+/// 
+/// Nothing is imported in any of these cases.
 pub fn execute_or_print_imports(
     resource_map: &HashMap<String, &ModuleMeta>,
     plan: &PlanFile,
-    dry_run: bool,
+    mappings: &ImportIdMappings,
+    builders: &ImportIdBuilderRegistry,
+    options: &ImportOptions,
     verbose: bool,
     module_root: &str,
-) {
-    if let Some(_planned_values) = &plan.planned_values {
-        let (all_resources, schema_map) = collect_and_prepare_resources(plan);
+    runner: &dyn CommandRunner,
+) -> Result<Report, RunError> {
+    let mut report = Report::new();
+
+    if options.preflight {
+        let mut directories: Vec<PathBuf> = if options.strip_module_prefix.is_empty() {
+            resource_map
+                .iter()
+                .filter(|(address, _)| options.filter.matches(address))
+                .map(|(_, module_meta)| PathBuf::from(module_root).join(&module_meta.dir))
+                .collect()
+        } else {
+            vec![PathBuf::from(module_root)]
+        };
+        directories.sort();
+        directories.dedup();
+        let search_path = std::env::var_os("PATH").unwrap_or_default();
+        let program = preflight(options.binary, &directories, !options.skip_terragrunt_config_check, &search_path).map_err(RunError::Preflight)?;
+        options.logger.info(&format!("🛫 Preflight passed: {} and {} working director{}", program.display(), directories.len(), if directories.len() == 1 { "y" } else { "ies" }));
+    }
+
+    if options.check_version {
+        let program = options.binary.program();
+        match detect_version(runner, options.binary) {
+            Ok(version) => {
+                report.tool_version = Some(format!("{} {}", program, version));
+                let minimum = minimum_version(options.binary);
+                if version < minimum {
+                    options.logger.warn(&format!(
+                        "⚠️ {} {} is older than {}, the oldest version known to work; plan parsing and import IDs may not match",
+                        program, version, minimum
+                    ));
+                } else {
+                    options.logger.info(&format!("🔧 Using {} {}", program, version));
+                }
+            }
+            Err(e) => options.logger.warn(&format!("⚠️ Could not determine the {} version: {}", program, e)),
+        }
+    }
+
+    if plan.planned_values.is_some() {
+        select_workspaces(resource_map, options, module_root, runner)?;
+        report.workspace = options.workspace.clone();
+
+        let mut state = StateCache::new(runner, options.binary);
+        let planned = plan_imports_with_state(resource_map, plan, mappings, builders, options, module_root, &mut state)?;
+        let mut redactor = options.redactor.clone();
+        redactor.extend(planned.iter().flat_map(|entry| entry.sensitive_values.iter().cloned()));
+        let options = &ImportOptions { redactor, ..options.clone() };
+        let redact = |text: &str| options.redactor.redact(text);
+        let record_checkpoint = |address: &str, status: ImportStatus| {
+            let Some(writer) = &options.checkpoint else { return };
+            if options.dry_run || !CheckpointEntry::is_finished(status) || options.resume.contains(address) {
+                return;
+            }
+            if let Err(e) = writer.record(address, status) {
+                options.logger.warn(&format!("⚠️ Could not update checkpoint: {}", e));
+            }
+        };
         let mut stats = ImportStats::new();
+        let mut import_commands = Vec::new();
+        let mut plan_addresses = Vec::new();
 
-        for resource in all_resources {
+        for entry in planned {
+            if entry.decision == ImportDecision::FilteredOut {
+                continue;
+            }
             if verbose {
-                print_import_progress(&resource.address, ImportOperation::Checking);
+                print_import_progress(&entry.address, ImportOperation::Checking);
             }
 
-            let result = process_single_resource(resource, resource_map, &schema_map, module_root, verbose);
+            let status = match entry.decision {
+                ImportDecision::Import => {
+                    if let Some(command) = entry.command {
+                        if verbose {
+                            print_import_progress(&entry.address, ImportOperation::Importing { id: redact(&command.resource_id) });
+                        }
+                        import_commands.push(command);
+                        plan_addresses.push(entry.address);
+                    }
+                    continue;
+                }
+                ImportDecision::InvalidId => {
+                    print_import_progress(&entry.address, ImportOperation::Failed { error: redact(entry.reason.as_deref().unwrap_or_default()) });
+                    stats.increment_failed();
+                    ImportStatus::Failed
+                }
+                ImportDecision::SkipAlreadyInState => {
+                    print_import_progress(&entry.address, ImportOperation::AlreadyInState);
+                    stats.increment_already_in_state();
+                    ImportStatus::AlreadyInState
+                }
+                ImportDecision::Unsupported => {
+                    print_import_progress(&entry.address, ImportOperation::Unsupported { resource_type: entry.resource_type.clone() });
+                    stats.increment_unsupported(entry.resource_type.clone());
+                    ImportStatus::Unsupported
+                }
+                ImportDecision::Skip => {
+                    print_import_progress(&entry.address, ImportOperation::Skipped { reason: redact(entry.reason.as_deref().unwrap_or_default()) });
+                    stats.increment_skipped();
+                    ImportStatus::Skipped
+                }
+                ImportDecision::FilteredOut => continue,
+            };
+            record_checkpoint(&entry.address, status);
+            report.record(ReportEntry {
+                address: entry.address,
+                import_id: entry.import_id.as_deref().map(redact),
+                status,
+                error: entry.reason.as_deref().map(redact),
+                duration_ms: None,
+                command: None,
+                output: None,
+            });
+        }
 
-            match result {
-                ResourceProcessingResult::ReadyForImport(resource_with_id) => {
-                    if verbose {
-                        print_import_progress(&resource_with_id.resource.address, ImportOperation::Importing { id: resource_with_id.id.clone() });
+        if let (Some(backup_dir), false) = (&options.state_backup_dir, options.dry_run) {
+            let mut backed_up = HashSet::new();
+            for command in &import_commands {
+                if backed_up.insert(&command.working_directory) {
+                    match state.backup(&command.working_directory, backup_dir).map_err(RunError::StateBackup)? {
+                        Some(backup) => options.logger.info(&format!("💾 Backed up state of {} to {}", command.working_directory.display(), backup.display())),
+                        None => options.logger.info(&format!("💾 No state to back up in {} yet", command.working_directory.display())),
                     }
-                    
-                    let execution_result = execute_import_for_resource(&resource_with_id, dry_run);
+                }
+            }
+        }
 
-                    match execution_result {
-                        ImportExecutionResult::Success(address) => {
-                            print_import_progress(&address, ImportOperation::Success);
-                            stats.increment_imported(address.clone());
-                        }
-                        ImportExecutionResult::Failed { address, error } => {
-                            print_import_progress(&address, ImportOperation::Failed { error });
-                            stats.increment_failed();
-                        }
-                        ImportExecutionResult::DryRun { address, command } => {
-                            print_import_progress(&address, ImportOperation::DryRun { command });
-                            stats.increment_imported(address.clone());
-                        }
+        let verifying = options.verify && !options.dry_run;
+        let batch = ImportExecutor.execute_imports_with(&import_commands, options, runner, &|index, result| {
+            if let (ImportResult::Success { .. }, false) = (result, verifying) {
+                record_checkpoint(&plan_addresses[index], ImportStatus::Success);
+            }
+        });
+        let results = batch.successful.iter().chain(&batch.failed).chain(&batch.dry_run).chain(&batch.cancelled);
+        let results_by_address: HashMap<&str, &ImportResult> = results.map(|result| (result.address(), result)).collect();
+
+        let mut verification: HashMap<&str, Result<(), VerificationFailure>> = HashMap::new();
+        if verifying {
+            let mut by_directory: Vec<(&Path, Vec<&str>)> = Vec::new();
+            for command in &import_commands {
+                if !matches!(results_by_address.get(command.resource_address.as_str()), Some(ImportResult::Success { .. })) {
+                    continue;
+                }
+                match by_directory.iter_mut().find(|(directory, _)| *directory == command.working_directory.as_path()) {
+                    Some((_, addresses)) => addresses.push(&command.resource_address),
+                    None => by_directory.push((&command.working_directory, vec![&command.resource_address])),
+                }
+            }
+            for (directory, addresses) in by_directory {
+                options.logger.info(&format!("🔎 Verifying {} import(s) in {}", addresses.len(), directory.display()));
+                let results = verify_imports(runner, options.binary, &mut state, directory, &addresses);
+                verification.extend(addresses.into_iter().zip(results));
+            }
+        }
+
+        for (command, address) in import_commands.iter().zip(plan_addresses) {
+            let Some(result) = results_by_address.get(command.resource_address.as_str()) else { continue };
+            let (status, error, duration_ms) = match result {
+                ImportResult::Success { execution_time_ms, .. } => match verification.remove(command.resource_address.as_str()) {
+                    Some(Err(failure)) => {
+                        options.logger.warn(&format!("⚠️ {}: {}", address, failure));
+                        stats.increment_failed();
+                        (ImportStatus::Failed, Some(failure.to_string()), Some(*execution_time_ms))
+                    }
+                    verified => {
+                        stats.increment_imported(address.clone());
+                        let status = if verified.is_some() {
+                            stats.increment_verified();
+                            ImportStatus::Verified
+                        } else {
+                            ImportStatus::Success
+                        };
+                        (status, None, Some(*execution_time_ms))
                     }
+                },
+                ImportResult::Failed { execution_time_ms, .. } => {
+                    stats.increment_failed();
+                    (ImportStatus::Failed, result.failure_message(), Some(*execution_time_ms))
+                }
+                ImportResult::TimedOut { execution_time_ms, .. } => {
+                    stats.increment_failed();
+                    (ImportStatus::TimedOut, result.failure_message(), Some(*execution_time_ms))
                 }
-                ResourceProcessingResult::Skipped { address, reason } => {
-                    print_import_progress(&address, ImportOperation::Skipped { reason });
+                ImportResult::DryRun { .. } => {
+                    stats.increment_imported(address.clone());
+                    (ImportStatus::DryRun, None, None)
+                }
+                ImportResult::Cancelled { .. } => {
                     stats.increment_skipped();
+                    (ImportStatus::Skipped, Some(options.cancelled_reason().to_string()), None)
+                }
+                ImportResult::Interrupted { execution_time_ms, .. } => {
+                    stats.increment_cancelled();
+                    let duration_ms = (*execution_time_ms > 0).then_some(*execution_time_ms);
+                    (ImportStatus::Cancelled, Some(INTERRUPTED_ERROR.to_string()), duration_ms)
                 }
+            };
+            if let (ImportResult::Success { .. }, true) = (result, verifying) {
+                record_checkpoint(&address, status);
+            }
+            let output = result.captured_output().map(|output| {
+                let output = redact(&output);
+                match options.max_output_bytes {
+                    Some(max_bytes) => truncate_output(&output, max_bytes),
+                    None => output,
+                }
+            });
+            if let Some(output) = &output {
+                options.logger.error(&format!("{} failed, captured output:\n{}", address, output));
             }
+            report.record(ReportEntry {
+                address,
+                import_id: Some(redact(&command.resource_id)),
+                status,
+                error: error.as_deref().map(redact),
+                duration_ms,
+                command: Some(redact(&command.command_string())),
+                output,
+            });
         }
 
         print_import_summary(&stats);
     }
+
+    Ok(report)
 }
 
 /// Executes a terragrunt import command for a single resource
@@ -693,12 +1906,12 @@ pub fn run_terragrunt_import(
 /// # Arguments
 /// * `module` - The module to check
 /// * `found` - Mutable reference to track if any IDs were found
-/// * `verbose` - Whether to print verbose output
+/// * `logger` - Receives inferred IDs at debug level
 /// * `_schema_map` - Schema information (currently unused)
 fn check(
     module: &PlannedModule,
     found: &mut bool,
-    verbose: bool,
+    logger: &dyn Logger,
     _schema_map: &HashMap<String, Value>,
 ) {
     if let Some(resources) = &module.resources {
@@ -711,8 +1924,8 @@ fn check(
                 values: resource.values.clone(),
             };
 
-            if let Some(id) = infer_resource_id(&terraform_resource, None, verbose) {
-                println!("Inferred ID for {}: {}", terraform_resource.address, id);
+            if let Some(id) = infer_resource_id(&terraform_resource, None, logger) {
+                logger.debug(&format!("Inferred ID for {}: {}", terraform_resource.address, id));
                 *found = true;
                 return;
             }
@@ -721,7 +1934,7 @@ fn check(
 
     if let Some(children) = &module.child_modules {
         for child in children {
-            check(child, found, verbose, _schema_map);
+            check(child, found, logger, _schema_map);
         }
     }
 }
@@ -1,20 +1,56 @@
 
+pub mod address;
 pub mod app;
+pub mod builders;
+pub mod checkpoint;
 pub mod commands;
+pub mod coverage;
+pub mod directories;
 pub mod errors;
-
+pub mod fetch;
+pub mod filter;
+pub mod import_blocks;
+pub mod import_script;
 pub mod importer;
+pub mod logging;
+pub mod mapping;
+pub mod merge;
+pub mod ordering;
 pub mod plan;
+pub mod planset;
+pub mod preflight;
+pub mod preview;
+pub mod providers;
 pub mod reporting;
 pub mod schema;
 pub mod scoring;
+pub mod sensitive;
+pub mod state;
 pub mod utils;
+pub mod verify;
+pub mod version;
+pub mod workspace;
 
 // Re-export specific items to avoid ambiguity
-pub use commands::{ImportCommandBuilder, ImportExecutor, ImportCommand, ImportResult, BatchResult};
-pub use importer::{PlannedModule, Resource, PlanFile};
+pub use address::{AddressError, InstanceKey, ResourceAddress};
+pub use app::{emit_import_blocks, emit_import_blocks_with_runner, emit_import_script, emit_import_script_with_runner, import, import_with_runner, preview, preview_with_runner, EmitSummary, ImportConfig};
+pub use builders::{ImportIdBuilder, ImportIdBuilderRegistry, ImportIdError};
+pub use checkpoint::{Checkpoint, CheckpointEntry, CheckpointError, CheckpointWriter};
+pub use coverage::{Coverage, TypeCoverage};
+pub use commands::{ImportBinary, ImportCommandBuilder, ImportExecutor, ImportCommand, ImportOptions, ImportResult, BatchResult};
+pub use importer::{ImportDecision, PlannedImport, PlannedModule, Resource, PlanFile};
+pub use reporting::{ImportStatus, Report, ReportEntry};
+pub use mapping::ImportIdMappings;
+pub use fetch::{PlanFetcher, PlanLocation, RemotePlan};
+pub use filter::ResourceFilter;
+pub use logging::{LogLevel, Logger, SharedLogger, StdLogger};
+pub use planset::{PlanSet, PlanSetReport, PlanUnit};
+pub use preview::{Preview, PreviewEntry};
 pub use plan::{get_id_candidate_fields, score_attributes_for_id};
 pub use schema::{write_provider_schema, SchemaManager, AttributeMetadata, ResourceAttributeMap};
 pub use scoring::{IdScoringStrategy, ProviderType, GoogleCloudScoringStrategy, AzureScoringStrategy, DefaultScoringStrategy};
+pub use sensitive::{RedactingLogger, Redactor};
+pub use state::{StateCache, StateError};
+pub use version::{ToolVersion, VersionError};
 pub use utils::{collect_resources, extract_id_candidate_fields, run_terragrunt_init};
 
@@ -0,0 +1,241 @@
+//! # Logging Module
+//!
+//! Diagnostic output of the import workflow goes through the `Logger` trait so that
+//! verbosity can be controlled and output can be redirected, e.g. into a log
+//! aggregation pipeline. User-facing progress lines (see `reporting`) are unaffected.
+//!
+//! ## Key Components
+//!
+//! - **LogLevel**: Debug, Info, Warn and Error, in increasing severity
+//! - **Logger**: Minimal trait with a single required `log` method
+//! - **StdLogger**: Default implementation writing to stdout/stderr above a minimum level
+//! - **SharedLogger**: Cloneable handle carried in `ImportOptions`
+//!
+//! ## Debug Output
+//!
+//! At debug level the importer logs every parsed resource address, every import ID
+//! it builds, and every command the executor runs.
+
+use std::fmt;
+use std::ops::Deref;
+use std::str::FromStr;
+use std::sync::Arc;
+
+/// Severity of a log message
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Default)]
+pub enum LogLevel {
+    /// Detailed tracing of addresses, IDs and commands
+    Debug,
+    /// Normal operational messages
+    #[default]
+    Info,
+    /// Something unexpected that doesn't stop the run
+    Warn,
+    /// A failure
+    Error,
+}
+
+impl fmt::Display for LogLevel {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let name = match self {
+            LogLevel::Debug => "debug",
+            LogLevel::Info => "info",
+            LogLevel::Warn => "warn",
+            LogLevel::Error => "error",
+        };
+        f.write_str(name)
+    }
+}
+
+impl FromStr for LogLevel {
+    type Err = String;
+
+    /// Parses a level name case-insensitively; "warning" is accepted for `Warn`
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        match s.to_ascii_lowercase().as_str() {
+            "debug" => Ok(LogLevel::Debug),
+            "info" => Ok(LogLevel::Info),
+            "warn" | "warning" => Ok(LogLevel::Warn),
+            "error" => Ok(LogLevel::Error),
+            other => Err(format!("unknown log level '{}' (expected debug, info, warn or error)", other)),
+        }
+    }
+}
+
+/// Destination for diagnostic messages
+///
+/// Only `log` must be implemented, which keeps adapters to other logging libraries
+/// to a few lines. Implementations decide themselves which levels to keep.
+///
+/// # Examples
+/// ```
+/// use std::sync::Mutex;
+/// use terragrunt_import_from_plan::logging::{LogLevel, Logger};
+///
+/// #[derive(Default)]
+/// struct MemoryLogger(Mutex<Vec<String>>);
+///
+/// impl Logger for MemoryLogger {
+///     fn log(&self, level: LogLevel, message: &str) {
+///         self.0.lock().unwrap().push(format!("{}: {}", level, message));
+///     }
+/// }
+///
+/// let logger = MemoryLogger::default();
+/// logger.warn("state lock held");
+/// assert_eq!(logger.0.lock().unwrap()[0], "warn: state lock held");
+/// ```
+pub trait Logger: Send + Sync {
+    /// Records `message` at `level`
+    fn log(&self, level: LogLevel, message: &str);
+
+    /// Records a debug message
+    fn debug(&self, message: &str) {
+        self.log(LogLevel::Debug, message);
+    }
+
+    /// Records an informational message
+    fn info(&self, message: &str) {
+        self.log(LogLevel::Info, message);
+    }
+
+    /// Records a warning
+    fn warn(&self, message: &str) {
+        self.log(LogLevel::Warn, message);
+    }
+
+    /// Records an error
+    fn error(&self, message: &str) {
+        self.log(LogLevel::Error, message);
+    }
+}
+
+/// Default Logger writing messages at or above `level` to the standard streams
+///
+/// Debug and info messages go to stdout, warnings and errors to stderr.
+#[derive(Debug, Clone, Copy, Default)]
+pub struct StdLogger {
+    /// Minimum level that is written
+    pub level: LogLevel,
+}
+
+impl StdLogger {
+    /// Creates a logger that writes messages at or above `level`
+    pub fn new(level: LogLevel) -> Self {
+        Self { level }
+    }
+}
+
+impl Logger for StdLogger {
+    fn log(&self, level: LogLevel, message: &str) {
+        if level < self.level {
+            return;
+        }
+        match level {
+            LogLevel::Debug => println!("🐛 {}", message),
+            LogLevel::Info => println!("{}", message),
+            LogLevel::Warn => eprintln!("⚠️ {}", message),
+            LogLevel::Error => eprintln!("❌ {}", message),
+        }
+    }
+}
+
+/// Cloneable, shareable handle to a Logger
+///
+/// Defaults to a `StdLogger` at info level.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::commands::ImportOptions;
+/// use terragrunt_import_from_plan::logging::{LogLevel, SharedLogger, StdLogger};
+///
+/// let options = ImportOptions {
+///     logger: SharedLogger::new(StdLogger::new(LogLevel::Debug)),
+///     ..Default::default()
+/// };
+/// options.logger.debug("parsed 3 resources");
+/// ```
+#[derive(Clone)]
+pub struct SharedLogger(Arc<dyn Logger>);
+
+impl SharedLogger {
+    /// Wraps `logger` so it can be shared between threads and options
+    pub fn new<L: Logger + 'static>(logger: L) -> Self {
+        Self(Arc::new(logger))
+    }
+
+    /// Wraps an already shared logger, e.g. one the caller keeps a handle to
+    pub fn from_arc(logger: Arc<dyn Logger>) -> Self {
+        Self(logger)
+    }
+}
+
+impl Default for SharedLogger {
+    fn default() -> Self {
+        Self::new(StdLogger::default())
+    }
+}
+
+impl fmt::Debug for SharedLogger {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str("SharedLogger")
+    }
+}
+
+impl Deref for SharedLogger {
+    type Target = dyn Logger;
+
+    fn deref(&self) -> &Self::Target {
+        &*self.0
+    }
+}
+
+/// Unit tests for level parsing and filtering
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::Mutex;
+
+    #[derive(Default)]
+    struct RecordingLogger(Mutex<Vec<(LogLevel, String)>>);
+
+    impl Logger for RecordingLogger {
+        fn log(&self, level: LogLevel, message: &str) {
+            self.0.lock().unwrap().push((level, message.to_string()));
+        }
+    }
+
+    /// **TEST** - Level names parse case-insensitively and round-trip through Display
+    #[test]
+    fn test_log_level_from_str() {
+        assert_eq!("DEBUG".parse::<LogLevel>(), Ok(LogLevel::Debug));
+        assert_eq!("warning".parse::<LogLevel>(), Ok(LogLevel::Warn));
+        for level in [LogLevel::Debug, LogLevel::Info, LogLevel::Warn, LogLevel::Error] {
+            assert_eq!(level.to_string().parse::<LogLevel>(), Ok(level));
+        }
+        assert!("verbose".parse::<LogLevel>().unwrap_err().contains("unknown log level 'verbose'"));
+        assert!(LogLevel::Debug < LogLevel::Info && LogLevel::Warn < LogLevel::Error);
+    }
+
+    /// **TEST** - Convenience methods forward with the matching level
+    #[test]
+    fn test_logger_convenience_methods() {
+        let logger = RecordingLogger::default();
+        logger.debug("d");
+        logger.info("i");
+        logger.warn("w");
+        logger.error("e");
+        let levels: Vec<LogLevel> = logger.0.lock().unwrap().iter().map(|(level, _)| *level).collect();
+        assert_eq!(levels, vec![LogLevel::Debug, LogLevel::Info, LogLevel::Warn, LogLevel::Error]);
+    }
+
+    /// **TEST** - A shared logger forwards to the wrapped logger
+    #[test]
+    fn test_shared_logger_forwards() {
+        let recording = Arc::new(RecordingLogger::default());
+        let shared = SharedLogger::from_arc(recording.clone());
+        shared.clone().warn("lock held");
+        assert_eq!(recording.0.lock().unwrap()[0], (LogLevel::Warn, "lock held".to_string()));
+        assert_eq!(format!("{:?}", shared), "SharedLogger");
+    }
+}
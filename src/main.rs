@@ -17,26 +17,61 @@
 //! The tool can be used in two modes:
 //! 
 //! 1. **Command Mode** (Recommended): Use specific subcommands for different operations
-//! 2. **Legacy Mode**: Provide --plan and --modules arguments for import functionality
+//! 2. **Legacy Mode**: Provide --plan and --modules arguments for import functionality,
+//!    or --plan-dir to import a directory of per-unit plans
 
 #![feature(let_chains)]
 
+mod address;
 mod app;
+mod builders;
+mod checkpoint;
 mod commands;
+mod coverage;
+mod directories;
 mod errors;
+mod fetch;
+mod filter;
+mod import_blocks;
+mod import_script;
 mod importer;
+mod logging;
+mod mapping;
+mod merge;
+mod ordering;
 mod plan;
+mod planset;
+mod preflight;
+mod preview;
+mod providers;
 mod reporting;
 mod schema;
 mod scoring;
+mod sensitive;
+mod state;
 mod utils;
+mod verify;
+mod version;
+mod workspace;
 
-use crate::app::load_input_files;
-use crate::importer::{execute_or_print_imports, map_resources_to_modules};
+use crate::address::{parse_module_address, ModuleCall};
+use crate::directories::{DirectoryMap, DirectoryRule};
+use crate::app::{emit_import_blocks, emit_import_script, expand_plan_inputs, import, load_builders, load_plan, preview, read_mappings, ImportConfig};
+use crate::checkpoint::{Checkpoint, CheckpointWriter};
+use crate::coverage::Coverage;
+use crate::builders::ImportIdBuilderRegistry;
+use crate::mapping::ImportIdMappings;
+use crate::filter::ResourceFilter;
+use crate::logging::{LogLevel, SharedLogger, StdLogger};
+use crate::planset::PlanSet;
+use crate::reporting::{EXIT_IMPORT_FAILURES, EXIT_SUCCESS, EXIT_USAGE_ERROR};
+use crate::commands::{CancellationToken, ImportBinary, ImportOptions, RetryConfig, SystemCommandRunner};
 use crate::utils::{run_terragrunt_init, write_provider_schema, generate_fixtures, clean_workspace, extract_id_candidate_fields, validate_terraform_format, validate_terraform_config, format_terraform_files, init_terragrunt, plan_terragrunt, apply_terragrunt, destroy_terragrunt};
 use anyhow::{Context, Result};
 use clap::{Parser, Subcommand};
-use std::path::Path;
+use std::path::{Path, PathBuf};
+use std::process::ExitCode;
+use std::time::Duration;
 
 /// Main CLI structure for the terragrunt import tool
 /// 
@@ -52,23 +87,175 @@ struct Args {
     command: Option<Commands>,
 
     // Legacy arguments for backwards compatibility  
-    /// Path to Terraform plan JSON file (legacy mode)
+    /// Path to Terraform plan JSON file, - to read it from stdin, or a gs://, http(s):// or file:// URL to fetch it from; repeat it or pass a glob to merge several plans into one run (legacy mode)
     #[arg(long)]
-    plan: Option<String>,
+    plan: Vec<String>,
 
     /// Path to modules.json file (legacy mode)
     #[arg(long)]
     modules: Option<String>,
 
-    /// Root directory for module resolution (legacy mode)
+    /// Directory of per-unit plan JSON files named by unit path; imports every unit (legacy mode)
+    #[arg(long, conflicts_with_all = ["plan", "modules"])]
+    plan_dir: Option<String>,
+
+    /// Directory the unit paths of --plan-dir are relative to (legacy mode)
     #[arg(long)]
+    units_root: Option<String>,
+
+    /// Root directory for module resolution; --plan-dir resolves units against --units-root instead (legacy mode)
+    #[arg(long, conflicts_with = "plan_dir")]
     module_root: Option<String>,
 
     /// Run in dry-run mode (show commands without executing) (legacy mode)
     #[arg(long, default_value_t = false)]
     dry_run: bool,
 
-    /// Enable verbose output (legacy mode)
+    /// Skip the check for resources already present in terraform state (legacy mode)
+    #[arg(long, default_value_t = false)]
+    skip_state_check: bool,
+
+    /// Maximum attempts per import, retrying transient errors such as 503s and rate limits (legacy mode)
+    #[arg(long, default_value_t = 3)]
+    retry_attempts: u32,
+
+    /// Delay before the first retry in milliseconds, doubled on each further retry (legacy mode)
+    #[arg(long, default_value_t = 1000)]
+    retry_base_delay_ms: u64,
+
+    /// Upper bound for the delay between retries in milliseconds (legacy mode)
+    #[arg(long, default_value_t = 30000)]
+    retry_max_delay_ms: u64,
+
+    /// Number of imports to run concurrently; imports into the same module are always serialized (legacy mode)
+    #[arg(long, default_value_t = 1)]
+    workers: usize,
+
+    /// Stop starting new imports after the first failure; this is the default unless --continue-on-error is given (legacy mode)
+    #[arg(long, default_value_t = false, conflicts_with = "continue_on_error")]
+    fail_fast: bool,
+
+    /// Keep importing after failures, but don't start any further imports once this many have failed; implies --continue-on-error (legacy mode)
+    #[arg(long, conflicts_with = "fail_fast")]
+    max_errors: Option<usize>,
+
+    /// Keep importing after a failure and exit with status 2 if any import failed (legacy mode)
+    #[arg(long, default_value_t = false)]
+    continue_on_error: bool,
+
+    /// Select this workspace in every module directory before reading state or importing; fails if it doesn't exist (legacy mode)
+    #[arg(long)]
+    workspace: Option<String>,
+
+    /// After importing, check each imported address is in state and a plan targeting it no longer creates or replaces it; costs one plan per module (legacy mode)
+    #[arg(long, default_value_t = false)]
+    verify: bool,
+
+    /// Back up each module's state (terragrunt state pull) into this directory before importing (legacy mode)
+    #[arg(long)]
+    state_backup_dir: Option<String>,
+
+    /// JSON or YAML file of explicit import IDs by resource address or glob, overriding inferred IDs (legacy mode)
+    #[arg(long)]
+    mapping: Option<String>,
+
+    /// JSON file of import ID templates by resource type, e.g. projects/{project}/topics/{name}; they replace the built-in builders of their types (legacy mode)
+    #[arg(long)]
+    builders: Option<String>,
+
+    /// Run the imports of resources under an address prefix in a directory relative to --module-root, as PREFIX=DIR, e.g. module.network=units/network; repeatable, the longest matching prefix wins (legacy mode)
+    #[arg(long, conflicts_with = "plan_dir")]
+    dir_map: Vec<DirectoryRule>,
+
+    /// Only import resources whose address matches one of these globs; repeatable (legacy mode)
+    #[arg(long)]
+    include: Vec<String>,
+
+    /// Never import resources whose address matches one of these globs, even if included; repeatable (legacy mode)
+    #[arg(long)]
+    exclude: Vec<String>,
+
+    /// Exit with an error if any planned resource has no import ID builder (legacy mode)
+    #[arg(long, default_value_t = false)]
+    strict: bool,
+
+    /// Fail resources whose import ID doesn't match the expected format for their type instead of importing them
+    #[arg(long, default_value_t = false)]
+    validate_ids: bool,
+
+    /// Program that runs imports and state commands: terragrunt, or terraform for plain Terraform modules (legacy mode)
+    #[arg(long, default_value_t = ImportBinary::Terragrunt)]
+    binary: ImportBinary,
+
+    /// Extra argument passed to every import after the import subcommand and before the address, e.g. --extra-arg=-lock-timeout=60s; repeatable, kept in order (legacy mode)
+    #[arg(long = "extra-arg", allow_hyphen_values = true)]
+    extra_args: Vec<String>,
+
+    /// Look up attributes that are unknown in the plan in each module's current state when an import ID needs them (legacy mode)
+    #[arg(long, default_value_t = false)]
+    resolve_unknown_from_state: bool,
+
+    /// Import even if two plan addresses resolve to the same resource type and import ID (legacy mode)
+    #[arg(long, default_value_t = false)]
+    allow_duplicate_ids: bool,
+
+    /// Before running anything, check that the binary is on PATH and that every working directory exists and holds a terragrunt.hcl (terraform: *.tf files) (legacy mode)
+    #[arg(long, default_value_t = false)]
+    preflight: bool,
+
+    /// With --preflight, accept working directories that only hold *.tf files, for plain terraform modules run through terragrunt (legacy mode)
+    #[arg(long, default_value_t = false, requires = "preflight")]
+    skip_terragrunt_config_check: bool,
+
+    /// Skip resources the plan replaces (destroys and recreates) with a warning, instead of aborting before any import (legacy mode)
+    #[arg(long, default_value_t = false)]
+    skip_replacements: bool,
+
+    /// Kill any single import attempt that runs longer than this many seconds; it counts as a failed attempt (legacy mode)
+    #[arg(long)]
+    per_import_timeout: Option<u64>,
+
+    /// Module address that --module-root corresponds to when the plan was generated at the root module, e.g. module.app; imports run in --module-root with addresses relative to it and resources outside it are skipped (legacy mode)
+    #[arg(long)]
+    strip_module_prefix: Option<String>,
+
+    /// Write a Terraform 1.5+ `import` block for every resolved address and ID to this file instead of running any import (legacy mode)
+    #[arg(long, conflicts_with = "plan_dir")]
+    emit_import_blocks: Option<String>,
+
+    /// Write an executable bash script with the import command of every resolved address and ID to this file instead of running any import (legacy mode)
+    #[arg(long, conflicts_with_all = ["emit_import_blocks", "plan_dir"])]
+    emit_script: Option<String>,
+
+    /// Cancel the whole run after this many seconds, killing running imports and reporting the rest as cancelled (legacy mode)
+    #[arg(long)]
+    timeout: Option<u64>,
+
+    /// Append every finished resource to this newline-delimited JSON checkpoint as it finishes (legacy mode)
+    #[arg(long, conflicts_with = "plan_dir")]
+    checkpoint: Option<String>,
+
+    /// Skip resources recorded as finished in this checkpoint, and keep appending to it unless --checkpoint is given (legacy mode)
+    #[arg(long, conflicts_with = "plan_dir")]
+    resume: Option<String>,
+
+    /// Keep at most this many bytes (the end) of a failed import's output in the report and error log; 0 keeps all of it (legacy mode)
+    #[arg(long, default_value_t = 4096)]
+    max_output_bytes: usize,
+
+    /// Write a JSON report of every resource's import result to this path (legacy mode)
+    #[arg(long)]
+    report_json: Option<String>,
+
+    /// Minimum level of diagnostic messages: debug, info, warn or error (legacy mode)
+    #[arg(long, default_value_t = LogLevel::Info)]
+    log_level: LogLevel,
+
+    /// Don't print the summary table of results by status at the end of the run (legacy mode)
+    #[arg(long, default_value_t = false)]
+    quiet: bool,
+
+    /// Enable verbose output; implies --log-level debug (legacy mode)
     #[arg(long, default_value_t = false)]
     verbose: bool,
 
@@ -178,6 +365,63 @@ enum Commands {
         #[arg(long)]
         safe: bool,
     },
+    /// Show what an import run would import or skip, without running any import
+    Preview {
+        /// Path to terraform plan JSON file, or - to read it from stdin
+        #[arg(long)]
+        plan: String,
+        /// Path to terragrunt modules.json file
+        #[arg(long)]
+        modules: String,
+        /// Root directory for module paths
+        #[arg(long)]
+        module_root: Option<String>,
+        /// Module address that --module-root corresponds to, e.g. module.app; addresses are shown relative to it
+        #[arg(long)]
+        strip_module_prefix: Option<String>,
+        /// JSON or YAML file of explicit import IDs by resource address or glob
+        #[arg(long)]
+        mapping: Option<String>,
+        /// JSON file of import ID templates by resource type; they replace the built-in builders of their types
+        #[arg(long)]
+        builders: Option<String>,
+        /// Only import resources whose address matches one of these globs; repeatable
+        #[arg(long)]
+        include: Vec<String>,
+        /// Never import resources whose address matches one of these globs; repeatable
+        #[arg(long)]
+        exclude: Vec<String>,
+        /// Don't consult existing state, so nothing is reported as already in state
+        #[arg(long)]
+        skip_state_check: bool,
+        /// Report import IDs that don't match the expected format for their type
+        #[arg(long)]
+        validate_ids: bool,
+        /// Program that runs state commands: terragrunt or terraform
+        #[arg(long, default_value_t = ImportBinary::Terragrunt)]
+        binary: ImportBinary,
+        /// Output format (table, json)
+        #[arg(long, value_parser = ["table", "json"], default_value = "table")]
+        format: String,
+        /// Write the preview to this file instead of stdout
+        #[arg(long)]
+        output: Option<String>,
+    },
+    /// List the plan's resource types and whether each has an import ID builder
+    Coverage {
+        /// Path to terraform plan JSON file, or - to read it from stdin
+        #[arg(long)]
+        plan: String,
+        /// Output format (table, json)
+        #[arg(long, value_parser = ["table", "json"], default_value = "table")]
+        format: String,
+        /// Exit with status 2 if any resource type has no builder
+        #[arg(long)]
+        strict: bool,
+        /// JSON file of import ID templates by resource type; their types count as covered
+        #[arg(long)]
+        builders: Option<String>,
+    },
 }
 
 /// Sets up provider schema for import operations (legacy mode helper)
@@ -223,14 +467,41 @@ fn setup_provider_schema(working_directory: Option<&str>) -> Result<()> {
 /// Terraform plan files and generate/execute import commands.
 /// 
 /// # Returns
-/// Result indicating success or failure of the operation
+/// The exit status from `run`: 0 on success, 2 if any import failed, and 3 for
+/// invalid arguments or any other error, which is printed to stderr
+fn main() -> ExitCode {
+    let args = match Args::try_parse() {
+        Ok(args) => args,
+        Err(e) if !e.use_stderr() => e.exit(),
+        Err(e) => {
+            let _ = e.print();
+            return exit_code(EXIT_USAGE_ERROR);
+        }
+    };
+
+    match run(args) {
+        Ok(code) => exit_code(code),
+        Err(e) => {
+            eprintln!("Error: {:?}", e);
+            exit_code(EXIT_USAGE_ERROR)
+        }
+    }
+}
+
+/// Converts one of the `reporting` exit status constants into an `ExitCode`
+fn exit_code(code: i32) -> ExitCode {
+    ExitCode::from(u8::try_from(code).unwrap_or(1))
+}
+
+/// Runs the selected subcommand, or an import in legacy mode
+/// 
+/// # Returns
+/// The process exit status; import runs take it from their aggregated report
 /// 
 /// # Errors
 /// Returns errors for invalid arguments, missing files, or operation failures
-fn main() -> Result<()> {
-    let args = Args::parse();
-
-    match args.command {
+fn run(args: Args) -> Result<i32> {
+    let outcome = match args.command {
         Some(Commands::GenerateFixtures { provider }) => {
             println!("🔧 Generating fixtures for {} provider...", provider);
             generate_fixtures(&provider)
@@ -277,24 +548,250 @@ fn main() -> Result<()> {
         Some(Commands::Destroy { provider, env, auto_approve, safe }) => {
             destroy_terragrunt(&provider, &env, auto_approve, safe)
         }
-        None => {
-            // Legacy mode - require plan and modules arguments
-            let plan = args.plan.ok_or_else(|| anyhow::anyhow!("--plan argument is required when not using subcommands"))?;
-            let modules = args.modules.ok_or_else(|| anyhow::anyhow!("--modules argument is required when not using subcommands"))?;
-            
-            let (modules_file, plan_file) = load_input_files(&modules, &plan)
-                .context("Failed to load input files")?;
-            let module_root = args.module_root.clone().unwrap_or_else(|| ".".to_string());
+        Some(Commands::Preview { plan, modules, module_root, strip_module_prefix, mapping, builders, include, exclude, skip_state_check, validate_ids, binary, format, output }) => {
+            let mut config = ImportConfig::new(&plan, &modules);
+            if let Some(module_root) = module_root {
+                config.module_root = PathBuf::from(module_root);
+            }
+            config.mapping_path = mapping.map(PathBuf::from);
+            config.builders = import_builders(builders.as_deref())?;
+            config.options = ImportOptions {
+                skip_state_check,
+                validate_ids,
+                binary,
+                filter: ResourceFilter::new(&include, &exclude)?,
+                strip_module_prefix: parse_strip_module_prefix(strip_module_prefix.as_deref())?,
+                ..Default::default()
+            };
+            let preview = preview(&config)?;
+            let rendered = if format == "json" { preview.to_json()? + "\n" } else { preview.to_table() };
 
-            // 🌐 Try to extract provider schema if possible
-            setup_provider_schema(args.working_directory.as_deref())?;
-            
-            let mapping = map_resources_to_modules(&modules_file.modules, &plan_file);
-            execute_or_print_imports(&mapping, &plan_file, args.dry_run, args.verbose, &module_root);
-            
+            match output {
+                Some(path) => {
+                    std::fs::write(&path, rendered).with_context(|| format!("Failed to write preview to {}", path))?;
+                    println!("📝 Import preview written to {}", path);
+                }
+                None => print!("{}", rendered),
+            }
+            Ok(())
+        }
+        Some(Commands::Coverage { plan, format, strict, builders }) => {
+            let coverage = Coverage::new(&load_plan(&plan)?, &import_builders(builders.as_deref())?);
+            let rendered = if format == "json" { coverage.to_json()? + "\n" } else { coverage.to_table() };
+            print!("{}", rendered);
+
+            if strict && !coverage.is_complete() {
+                eprintln!("❌ No import ID builder for: {}", coverage.missing().join(", "));
+                return Ok(EXIT_IMPORT_FAILURES);
+            }
             Ok(())
         }
+        None => return run_import(&args),
+    };
+    outcome.map(|()| EXIT_SUCCESS)
+}
+
+/// Runs a legacy-mode import of `--plan`, or of every plan in `--plan-dir`
+/// 
+/// Unless `--continue-on-error` is set, no further imports are started after the
+/// first failure. With `--emit-import-blocks` or `--emit-script`, the import blocks or
+/// import script are written instead and nothing is imported. With `--strict`, resources
+/// without an import ID builder also make the run fail, including when only writing
+/// blocks or a script, which then leave those resources out. A summary table of the results is printed at the end unless `--quiet`.
+/// 
+/// # Returns
+/// The report's exit status: `EXIT_SUCCESS` or `EXIT_IMPORT_FAILURES`
+/// 
+/// # Errors
+/// Returns an error if arguments are missing or invalid, or inputs can't be read
+fn run_import(args: &Args) -> Result<i32> {
+    if let Some(plan_dir) = &args.plan_dir {
+        return run_plan_set(args, Path::new(plan_dir));
+    }
+
+    // Legacy mode - require plan and modules arguments
+    let mut plans = expand_plan_inputs(&args.plan)?.into_iter();
+    let plan = plans.next().ok_or_else(|| anyhow::anyhow!("--plan argument is required when not using subcommands"))?;
+    let modules = args.modules.clone().ok_or_else(|| anyhow::anyhow!("--modules argument is required when not using subcommands"))?;
+    
+    let mut config = ImportConfig::new(&plan, &modules);
+    config.additional_plan_paths = plans.collect();
+    if let Some(module_root) = &args.module_root {
+        config.module_root = PathBuf::from(module_root);
+    }
+    config.mapping_path = args.mapping.as_ref().map(PathBuf::from);
+    config.directories = DirectoryMap::new(args.dir_map.clone());
+    config.builders = import_builders(args.builders.as_deref())?;
+    config.options = import_options(args)?;
+    config.verbose = args.verbose;
+    if let Some(path) = &args.resume {
+        println!("⏩ Resuming from {}: {} resource(s) already finished", path, config.options.resume.len());
+    }
+
+    if let Some(path) = &args.emit_import_blocks {
+        let summary = emit_import_blocks(&config, Path::new(path))?;
+        println!("📝 Wrote {} import block(s) to {}", summary.written, path);
+        return Ok(strict_exit_code(args, summary.unsupported, EXIT_SUCCESS));
+    }
+
+    if let Some(path) = &args.emit_script {
+        let summary = emit_import_script(&config, Path::new(path))?;
+        println!("📝 Wrote {} import command(s) to {}", summary.written, path);
+        return Ok(strict_exit_code(args, summary.unsupported, EXIT_SUCCESS));
+    }
+
+    // 🌐 Try to extract provider schema if possible
+    setup_provider_schema(args.working_directory.as_deref())?;
+
+    let report = import(&config)?;
+    if !args.quiet {
+        print!("\n{}", report.summary_table());
+    }
+
+    if let Some(report_path) = &args.report_json {
+        report.write_json(Path::new(report_path))?;
+        println!("📝 Import report written to {}", report_path);
+    }
+
+    Ok(strict_exit_code(args, report.unsupported, report.exit_code))
+}
+
+/// Returns `EXIT_IMPORT_FAILURES` with `--strict` when resources had no import ID builder, `exit_code` otherwise
+fn strict_exit_code(args: &Args, unsupported: usize, exit_code: i32) -> i32 {
+    if args.strict && unsupported > 0 {
+        eprintln!("❌ {} resource(s) have no import ID builder (--strict)", unsupported);
+        return EXIT_IMPORT_FAILURES;
+    }
+    exit_code
+}
+
+/// Builds the import execution options from the legacy-mode arguments
+/// 
+/// Diagnostics go to a `StdLogger` at `--log-level`, or debug with `--verbose`. The
+/// `--timeout` deadline starts counting when the options are built. The checkpoint is
+/// only opened for writing outside dry-run mode.
+/// 
+/// # Errors
+/// Returns an error if an `--include` or `--exclude` pattern or `--strip-module-prefix` is
+/// invalid, `--max-errors` is 0, or the `--resume` or `--checkpoint` file can't be read or opened
+fn import_options(args: &Args) -> Result<ImportOptions> {
+    if args.max_errors == Some(0) {
+        return Err(anyhow::anyhow!("--max-errors must be at least 1"));
+    }
+    let resume = match &args.resume {
+        Some(path) => Checkpoint::load(Path::new(path))?,
+        None => Checkpoint::default(),
+    };
+    let checkpoint = match args.checkpoint.as_ref().or(args.resume.as_ref()) {
+        Some(path) if !args.dry_run => Some(CheckpointWriter::open(Path::new(path))?),
+        _ => None,
+    };
+
+    Ok(ImportOptions {
+        dry_run: args.dry_run,
+        skip_state_check: args.skip_state_check,
+        retry: RetryConfig {
+            max_attempts: args.retry_attempts,
+            base_delay: Duration::from_millis(args.retry_base_delay_ms),
+            max_delay: Duration::from_millis(args.retry_max_delay_ms),
+            ..RetryConfig::with_default_retryable_errors()
+        },
+        workers: args.workers,
+        fail_fast: args.fail_fast || (!args.continue_on_error && args.max_errors.is_none()),
+        max_errors: args.max_errors,
+        state_backup_dir: args.state_backup_dir.as_ref().map(PathBuf::from),
+        filter: ResourceFilter::new(&args.include, &args.exclude)?,
+        validate_ids: args.validate_ids,
+        binary: args.binary,
+        resolve_unknown_from_state: args.resolve_unknown_from_state,
+        allow_duplicate_ids: args.allow_duplicate_ids,
+        skip_replacements: args.skip_replacements,
+        logger: SharedLogger::new(StdLogger::new(if args.verbose { LogLevel::Debug } else { args.log_level })),
+        cancellation: args
+            .timeout
+            .map(|seconds| CancellationToken::with_timeout(Duration::from_secs(seconds)))
+            .unwrap_or_default(),
+        per_import_timeout: args.per_import_timeout.map(Duration::from_secs),
+        max_output_bytes: (args.max_output_bytes > 0).then_some(args.max_output_bytes),
+        preflight: args.preflight,
+        skip_terragrunt_config_check: args.skip_terragrunt_config_check,
+        strip_module_prefix: parse_strip_module_prefix(args.strip_module_prefix.as_deref())?,
+        extra_args: args.extra_args.clone(),
+        checkpoint,
+        resume,
+        check_version: true,
+        verify: args.verify,
+        workspace: args.workspace.clone(),
+        ..Default::default()
+    })
+}
+
+/// Returns the built-in builders, with the templates of a `--builders` file registered over them
+/// 
+/// # Errors
+/// Returns an error if the builder file can't be read or holds an invalid template
+fn import_builders(path: Option<&str>) -> Result<ImportIdBuilderRegistry> {
+    let mut builders = ImportIdBuilderRegistry::default();
+    if let Some(path) = path {
+        let count = load_builders(path, &mut builders)?;
+        eprintln!("🧩 Loaded {} import ID template(s) from {}", count, path);
+    }
+    Ok(builders)
+}
+
+/// Parses a `--strip-module-prefix` module address; None means no prefix
+/// 
+/// # Errors
+/// Returns an error if the value isn't a module address such as `module.app["blue"]`
+fn parse_strip_module_prefix(prefix: Option<&str>) -> Result<Vec<ModuleCall>> {
+    match prefix {
+        Some(prefix) => parse_module_address(prefix).with_context(|| format!("Invalid --strip-module-prefix: {}", prefix)),
+        None => Ok(Vec::new()),
+    }
+}
+
+/// Imports every unit plan in `plan_dir`, running terragrunt in each unit's directory
+/// 
+/// Unit directories are resolved against `--units-root` (default: current directory).
+/// A failed unit stops the remaining ones unless `--continue-on-error` is set; either
+/// way the exit status is `EXIT_IMPORT_FAILURES` once any unit has failed. With
+/// `--strict`, resources without an import ID builder in any unit also make the run fail.
+/// 
+/// # Returns
+/// The plan set report's exit status
+/// 
+/// # Errors
+/// - The plan directory can't be read or contains no plans
+/// - The mapping file or report can't be read or written
+fn run_plan_set(args: &Args, plan_dir: &Path) -> Result<i32> {
+    let units_root = args.units_root.as_deref().unwrap_or(".");
+    let plan_set = PlanSet::from_dir(plan_dir, Path::new(units_root))?;
+    println!("📂 Found {} unit plan(s) in {}", plan_set.units.len(), plan_dir.display());
+
+    let options = import_options(args)?;
+    let mappings = match &args.mapping {
+        Some(path) => read_mappings(path)?,
+        None => ImportIdMappings::new(),
+    };
+    let builders = import_builders(args.builders.as_deref())?;
+    let report = plan_set.run(&mappings, &builders, &options, args.verbose, &SystemCommandRunner);
+    if !args.quiet {
+        for unit in &report.units {
+            if let Some(unit_report) = &unit.report {
+                print!("\n📦 {}\n{}", unit.unit, unit_report.summary_table());
+            }
+        }
+    }
+
+    if let Some(report_path) = &args.report_json {
+        report.write_json(Path::new(report_path))?;
+        println!("📝 Import report written to {}", report_path);
+    }
+
+    if !report.success {
+        eprintln!("❌ {} of {} unit(s) failed", report.failed, report.units.len());
     }
+    Ok(strict_exit_code(args, report.unsupported(), report.exit_code))
 }
 
 /// Unit tests for the main application functionality
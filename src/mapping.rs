@@ -0,0 +1,328 @@
+//! # Import ID Mapping Module
+//!
+//! Some resources have import IDs that can't be derived from plan attributes, such as
+//! legacy resources whose real ID differs from the computed name. A mapping file lets
+//! users pin explicit IDs, which take precedence over the automatic builders.
+//!
+//! ## File Format
+//!
+//! Mapping files are JSON, like `modules.json`, or YAML when the file name ends in
+//! `.yaml` or `.yml`:
+//!
+//! ```json
+//! {
+//!   "mappings": [
+//!     { "address": "module.kms.google_kms_key_ring.legacy", "id": "projects/p/locations/l/keyRings/old" },
+//!     { "address": "module.storage.google_storage_bucket.archive_*", "id": "archive-bucket" }
+//!   ]
+//! }
+//! ```
+//!
+//! ```yaml
+//! mappings:
+//!   - address: module.kms.google_kms_key_ring.legacy
+//!     id: projects/p/locations/l/keyRings/old
+//!   - address: module.storage.google_storage_bucket.archive_*
+//!     id: archive-bucket
+//! ```
+//!
+//! An optional `directories` list routes resources to working directories by address
+//! prefix (see `directories`).
+//!
+//! ## Matching Rules
+//!
+//! - An address containing `*` or `?` is a glob; anything else must match exactly. This
+//!   keeps indexed addresses such as `aws_s3_bucket.b["x"]` usable as exact entries even
+//!   though `[` has a meaning in globs (use `[[]` to match a literal `[` inside a glob).
+//! - Exact entries always win over globs; among globs, the first matching entry wins.
+//!
+//! ## Environment Variables
+//!
+//! IDs may reference environment variables, so one mapping file serves every
+//! environment: `${PROJECT_ID}` or `$PROJECT_ID` is replaced by the variable's value,
+//! and `${REGION:-europe-west1}` falls back to `europe-west1` when `REGION` is unset or
+//! empty. A variable that is unset and has no default is an error rather than an empty
+//! string, which would silently produce a wrong ID. `$$` stands for a literal `$`.
+
+use std::collections::HashMap;
+use anyhow::{Context, Result};
+use glob::Pattern;
+use serde::Deserialize;
+use thiserror::Error;
+use crate::directories::{DirectoryMap, DirectoryRule};
+
+/// One entry of a mapping file
+#[derive(Debug, Clone, PartialEq, Deserialize)]
+pub struct MappingEntry {
+    /// Exact resource address or glob pattern
+    pub address: String,
+    /// Import ID to use for matching resources
+    pub id: String,
+}
+
+/// Top-level structure of a mapping file
+#[derive(Debug, Clone, Default, PartialEq, Deserialize)]
+pub struct MappingFile {
+    /// Mapping entries in file order
+    #[serde(default)]
+    pub mappings: Vec<MappingEntry>,
+    /// Working directory rules by address prefix, in order of precedence
+    #[serde(default)]
+    pub directories: Vec<DirectoryRule>,
+}
+
+impl MappingFile {
+    /// Expands the environment variable references in every entry's ID
+    ///
+    /// # Arguments
+    /// * `lookup` - Returns a variable's value, e.g. `|name| std::env::var(name).ok()`
+    ///
+    /// # Errors
+    /// Returns an error naming the entry if a reference can't be expanded
+    pub fn expand_variables<F: Fn(&str) -> Option<String>>(&mut self, lookup: F) -> Result<()> {
+        for entry in &mut self.mappings {
+            entry.id = expand_variables(&entry.id, &lookup)
+                .with_context(|| format!("Invalid import ID for mapping address: {}", entry.address))?;
+        }
+        Ok(())
+    }
+}
+
+/// Error types for expanding environment variable references
+///
+/// # Variants
+/// - `Unset`: A referenced variable is unset and the reference has no default
+/// - `Unterminated`: A `${` reference has no closing `}`
+/// - `InvalidName`: A `${...}` reference doesn't start with a variable name
+#[derive(Error, Debug, Clone, PartialEq)]
+pub enum ExpansionError {
+    /// A referenced variable is unset and has no default
+    #[error("environment variable {name} is not set (use ${{{name}:-default}} to give it a default)")]
+    Unset {
+        /// Variable name
+        name: String,
+    },
+
+    /// A `${` reference is never closed
+    #[error("unterminated variable reference in '{text}'")]
+    Unterminated {
+        /// Text containing the reference
+        text: String,
+    },
+
+    /// A `${...}` reference doesn't name a variable
+    #[error("invalid variable reference '${{{reference}}}'")]
+    InvalidName {
+        /// Contents of the braces
+        reference: String,
+    },
+}
+
+/// Replaces `${NAME}`, `${NAME:-default}` and `$NAME` in `text` with values from `lookup`
+///
+/// A default applies when the variable is unset or empty, and is taken literally.
+/// `$$` gives a literal `$`, as does a `$` not followed by a name or `{`.
+///
+/// # Errors
+/// See `ExpansionError`
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::mapping::{expand_variables, ExpansionError};
+///
+/// let lookup = |name: &str| (name == "PROJECT_ID").then(|| "sim-project".to_string());
+/// assert_eq!(
+///     expand_variables("projects/${PROJECT_ID}/locations/${REGION:-europe-west1}", lookup).unwrap(),
+///     "projects/sim-project/locations/europe-west1"
+/// );
+/// assert_eq!(
+///     expand_variables("$REGION", lookup),
+///     Err(ExpansionError::Unset { name: "REGION".to_string() })
+/// );
+/// ```
+pub fn expand_variables<F: Fn(&str) -> Option<String>>(text: &str, lookup: F) -> Result<String, ExpansionError> {
+    let is_name_char = |c: char| c.is_ascii_alphanumeric() || c == '_';
+    let is_name = |name: &str| name.starts_with(|c: char| c.is_ascii_alphabetic() || c == '_') && name.chars().all(is_name_char);
+
+    let mut expanded = String::with_capacity(text.len());
+    let mut rest = text;
+    while let Some(index) = rest.find('$') {
+        expanded.push_str(&rest[..index]);
+        rest = &rest[index + 1..];
+
+        let (name, default) = if let Some(braced) = rest.strip_prefix('{') {
+            let end = braced.find('}').ok_or_else(|| ExpansionError::Unterminated { text: text.to_string() })?;
+            let reference = &braced[..end];
+            rest = &braced[end + 1..];
+            let (name, default) = match reference.split_once(":-") {
+                Some((name, default)) => (name, Some(default)),
+                None => (reference, None),
+            };
+            if !is_name(name) {
+                return Err(ExpansionError::InvalidName { reference: reference.to_string() });
+            }
+            (name, default)
+        } else if let Some(escaped) = rest.strip_prefix('$') {
+            expanded.push('$');
+            rest = escaped;
+            continue;
+        } else {
+            let end = rest.find(|c: char| !is_name_char(c)).unwrap_or(rest.len());
+            let name = &rest[..end];
+            if !is_name(name) {
+                expanded.push('$');
+                continue;
+            }
+            rest = &rest[end..];
+            (name, None)
+        };
+
+        match (lookup(name).filter(|value| !value.is_empty() || default.is_none()), default) {
+            (Some(value), _) => expanded.push_str(&value),
+            (None, Some(default)) => expanded.push_str(default),
+            (None, None) => return Err(ExpansionError::Unset { name: name.to_string() }),
+        }
+    }
+    expanded.push_str(rest);
+    Ok(expanded)
+}
+
+/// Explicit import ID overrides, indexed for lookup
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::mapping::{ImportIdMappings, MappingEntry, MappingFile};
+///
+/// let mappings = ImportIdMappings::from_file(MappingFile {
+///     mappings: vec![
+///         MappingEntry { address: "aws_s3_bucket.*".to_string(), id: "shared".to_string() },
+///         MappingEntry { address: "aws_s3_bucket.logs".to_string(), id: "legacy-logs".to_string() },
+///     ],
+///     ..Default::default()
+/// }).unwrap();
+///
+/// assert_eq!(mappings.lookup("aws_s3_bucket.logs"), Some("legacy-logs"));
+/// assert_eq!(mappings.lookup("aws_s3_bucket.data"), Some("shared"));
+/// assert_eq!(mappings.lookup("aws_vpc.main"), None);
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct ImportIdMappings {
+    exact: HashMap<String, String>,
+    globs: Vec<(Pattern, String)>,
+    directories: DirectoryMap,
+}
+
+impl ImportIdMappings {
+    /// Creates an empty set of mappings that never matches
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Indexes the entries of a parsed mapping file
+    ///
+    /// # Errors
+    /// Returns an error if a glob entry isn't a valid pattern
+    pub fn from_file(file: MappingFile) -> Result<Self> {
+        let mut mappings = Self::new();
+        mappings.directories = DirectoryMap::new(file.directories);
+        for entry in file.mappings {
+            if is_glob(&entry.address) {
+                let pattern = Pattern::new(&entry.address)
+                    .with_context(|| format!("Invalid glob in mapping address: {}", entry.address))?;
+                mappings.globs.push((pattern, entry.id));
+            } else {
+                mappings.exact.insert(entry.address, entry.id);
+            }
+        }
+        Ok(mappings)
+    }
+
+    /// Returns the explicit import ID for `address`, if any entry matches
+    pub fn lookup(&self, address: &str) -> Option<&str> {
+        if let Some(id) = self.exact.get(address) {
+            return Some(id);
+        }
+        self.globs
+            .iter()
+            .find(|(pattern, _)| pattern.matches(address))
+            .map(|(_, id)| id.as_str())
+    }
+
+    /// Returns the file's working directory rules
+    pub fn directories(&self) -> &DirectoryMap {
+        &self.directories
+    }
+
+    /// Returns true if there are no entries
+    pub fn is_empty(&self) -> bool {
+        self.exact.is_empty() && self.globs.is_empty()
+    }
+
+    /// Returns the entry addresses that match none of `addresses`, sorted
+    ///
+    /// Used to warn about mappings that refer to resources not present in the plan.
+    pub fn unused_entries(&self, addresses: &[String]) -> Vec<String> {
+        let mut unused: Vec<String> = self
+            .exact
+            .keys()
+            .filter(|address| !addresses.contains(address))
+            .cloned()
+            .collect();
+        unused.extend(
+            self.globs
+                .iter()
+                .filter(|(pattern, _)| !addresses.iter().any(|address| pattern.matches(address)))
+                .map(|(pattern, _)| pattern.as_str().to_string()),
+        );
+        unused.sort();
+        unused
+    }
+}
+
+/// Returns true if a mapping address should be treated as a glob
+fn is_glob(address: &str) -> bool {
+    address.contains('*') || address.contains('?')
+}
+
+/// Unit tests for environment variable expansion
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn lookup(name: &str) -> Option<String> {
+        match name {
+            "PROJECT_ID" => Some("sim-project".to_string()),
+            "EMPTY" => Some(String::new()),
+            _ => None,
+        }
+    }
+
+    /// **TEST** - Braced, bare and defaulted references expand; `$$` and stray `$` stay literal
+    #[test]
+    fn test_expand_variables() {
+        assert_eq!(expand_variables("projects/$PROJECT_ID/topics/t", lookup).unwrap(), "projects/sim-project/topics/t");
+        assert_eq!(expand_variables("${PROJECT_ID}-logs", lookup).unwrap(), "sim-project-logs");
+        assert_eq!(expand_variables("${REGION:-us-central1}/${PROJECT_ID:-unused}", lookup).unwrap(), "us-central1/sim-project");
+        assert_eq!(expand_variables("${EMPTY:-fallback}|$EMPTY|", lookup).unwrap(), "fallback||");
+        assert_eq!(expand_variables("${REGION:-}", lookup).unwrap(), "");
+        assert_eq!(expand_variables("cost$$ 5$ $1", lookup).unwrap(), "cost$ 5$ $1");
+        assert_eq!(expand_variables("no references", lookup).unwrap(), "no references");
+    }
+
+    /// **TEST** - Unset variables without a default and malformed references are errors
+    #[test]
+    fn test_expand_variables_errors() {
+        assert_eq!(expand_variables("projects/${REGION}", lookup), Err(ExpansionError::Unset { name: "REGION".to_string() }));
+        assert_eq!(expand_variables("$REGION", lookup), Err(ExpansionError::Unset { name: "REGION".to_string() }));
+        assert_eq!(expand_variables("${PROJECT_ID", lookup), Err(ExpansionError::Unterminated { text: "${PROJECT_ID".to_string() }));
+        assert_eq!(expand_variables("${1X}", lookup), Err(ExpansionError::InvalidName { reference: "1X".to_string() }));
+        assert_eq!(
+            ExpansionError::Unset { name: "REGION".to_string() }.to_string(),
+            "environment variable REGION is not set (use ${REGION:-default} to give it a default)"
+        );
+
+        let mut file = MappingFile { mappings: vec![MappingEntry { address: "aws_s3_bucket.logs".to_string(), id: "$BUCKET".to_string() }], ..Default::default() };
+        let error = file.expand_variables(lookup).unwrap_err();
+        assert_eq!(format!("{:#}", error), "Invalid import ID for mapping address: aws_s3_bucket.logs: environment variable BUCKET is not set (use ${BUCKET:-default} to give it a default)");
+    }
+}
@@ -0,0 +1,234 @@
+//! # Plan Merging Module
+//!
+//! A large configuration is sometimes planned in several parts, e.g. one plan per
+//! `-target` group, while the imports should still run as one batch against one
+//! working directory. This module merges such plans into a single `PlanFile` that the
+//! rest of the tool treats exactly like one plan.
+//!
+//! ## Merging
+//!
+//! Plans are merged in the order given, and the first occurrence of anything wins:
+//!
+//! - **Resources**: Planned resources and resource changes are deduplicated by address.
+//!   A duplicate whose import ID differs from the first one's is logged as a warning,
+//!   since at most one of them can be right; matching duplicates are dropped silently
+//! - **Modules**: Child modules with the same address are merged into one
+//! - **Configuration**: Objects are merged key by key and `resources` lists by
+//!   address, so dependency ordering and provider resolution see every plan's blocks
+//! - **Variables and provider schemas**: Entries missing from earlier plans are added
+//!
+//! The merged plan keeps the first plan's format and Terraform version.
+
+use std::collections::HashMap;
+use serde_json::Value;
+use crate::builders::ImportIdBuilderRegistry;
+use crate::importer::{resolve_import_id, PlanFile, PlannedModule, Resource, Variables};
+use crate::logging::Logger;
+use crate::mapping::ImportIdMappings;
+use crate::plan::TerraformResource;
+
+/// Where an address was first seen and the import ID it resolved to there
+struct FirstSeen {
+    source: String,
+    import_id: Option<String>,
+}
+
+/// Merges `plans` into one, see the module documentation
+///
+/// # Arguments
+/// * `plans` - Plans with a description of where each came from (e.g. its path), in order
+/// * `builders` - Builders used to compare the import IDs of duplicate addresses
+/// * `logger` - Receives a warning per conflicting duplicate, and debug messages for the rest
+///
+/// # Returns
+/// The merged plan, or None if `plans` is empty
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::builders::ImportIdBuilderRegistry;
+/// use terragrunt_import_from_plan::importer::PlanFile;
+/// use terragrunt_import_from_plan::logging::StdLogger;
+/// use terragrunt_import_from_plan::merge::merge_plans;
+///
+/// let plan = |names: &[&str]| -> PlanFile {
+///     let resources: Vec<_> = names.iter().map(|name| serde_json::json!({
+///         "address": format!("aws_s3_bucket.{}", name), "mode": "managed", "type": "aws_s3_bucket", "name": name,
+///         "values": {"bucket": name}
+///     })).collect();
+///     serde_json::from_value(serde_json::json!({
+///         "format_version": "1.2",
+///         "terraform_version": "1.9.0",
+///         "planned_values": {"root_module": {"resources": resources}}
+///     })).unwrap()
+/// };
+///
+/// let merged = merge_plans(
+///     vec![("a.json".to_string(), plan(&["logs", "data"])), ("b.json".to_string(), plan(&["data", "assets"]))],
+///     &ImportIdBuilderRegistry::default(),
+///     &StdLogger::default(),
+/// ).unwrap();
+/// let resources = merged.planned_values.unwrap().root_module.resources.unwrap();
+/// let addresses: Vec<&str> = resources.iter().map(|resource| resource.address.as_str()).collect();
+/// assert_eq!(addresses, ["aws_s3_bucket.logs", "aws_s3_bucket.data", "aws_s3_bucket.assets"]);
+/// ```
+pub fn merge_plans(plans: Vec<(String, PlanFile)>, builders: &ImportIdBuilderRegistry, logger: &dyn Logger) -> Option<PlanFile> {
+    let mut plans = plans.into_iter();
+    let (first_source, mut merged) = plans.next()?;
+
+    let mut seen: HashMap<String, FirstSeen> = HashMap::new();
+    if let Some(planned_values) = &merged.planned_values {
+        record_module(&planned_values.root_module, &first_source, builders, logger, &mut seen);
+    }
+
+    for (source, plan) in plans {
+        if let Some(planned_values) = plan.planned_values {
+            match &mut merged.planned_values {
+                Some(target) => merge_module(&mut target.root_module, planned_values.root_module, &source, builders, logger, &mut seen),
+                None => {
+                    record_module(&planned_values.root_module, &source, builders, logger, &mut seen);
+                    merged.planned_values = Some(planned_values);
+                }
+            }
+        }
+
+        if let Some(changes) = plan.resource_changes {
+            let target = merged.resource_changes.get_or_insert_with(Vec::new);
+            for change in changes {
+                if !target.iter().any(|existing| existing.address == change.address) {
+                    target.push(change);
+                }
+            }
+        }
+
+        match (&mut merged.configuration, plan.configuration) {
+            (Some(target), Some(configuration)) => merge_json(target, configuration),
+            (target @ None, configuration) => *target = configuration,
+            (Some(_), None) => {}
+        }
+
+        match (&mut merged.variables, plan.variables) {
+            (Some(target), Some(variables)) => merge_variables(target, variables),
+            (target @ None, variables) => *target = variables,
+            (Some(_), None) => {}
+        }
+
+        match (&mut merged.provider_schemas, plan.provider_schemas) {
+            (Some(target), Some(schemas)) => {
+                for (provider, schema) in schemas.provider_schemas {
+                    target.provider_schemas.entry(provider).or_insert(schema);
+                }
+            }
+            (target @ None, schemas) => *target = schemas,
+            (Some(_), None) => {}
+        }
+    }
+    Some(merged)
+}
+
+/// Returns the import ID a resource resolves to without a mapping file, if any
+fn import_id(resource: &Resource, builders: &ImportIdBuilderRegistry, logger: &dyn Logger) -> Option<String> {
+    let resource = TerraformResource {
+        address: resource.address.clone(),
+        mode: resource.mode.clone(),
+        r#type: resource.r#type.clone(),
+        name: resource.name.clone(),
+        values: resource.values.clone(),
+    };
+    resolve_import_id(&resource, &ImportIdMappings::new(), builders, logger).ok()
+}
+
+/// Records every resource of `module` and its children as first seen in `source`
+fn record_module(module: &PlannedModule, source: &str, builders: &ImportIdBuilderRegistry, logger: &dyn Logger, seen: &mut HashMap<String, FirstSeen>) {
+    for resource in module.resources.iter().flatten() {
+        seen.entry(resource.address.clone())
+            .or_insert_with(|| FirstSeen { source: source.to_string(), import_id: import_id(resource, builders, logger) });
+    }
+    for child in module.child_modules.iter().flatten() {
+        record_module(child, source, builders, logger, seen);
+    }
+}
+
+/// Adds the resources of `module` that `target` doesn't have yet, module by module
+fn merge_module(
+    target: &mut PlannedModule,
+    module: PlannedModule,
+    source: &str,
+    builders: &ImportIdBuilderRegistry,
+    logger: &dyn Logger,
+    seen: &mut HashMap<String, FirstSeen>,
+) {
+    for resource in module.resources.into_iter().flatten() {
+        let id = import_id(&resource, builders, logger);
+        match seen.get(&resource.address) {
+            Some(first) if first.import_id != id => logger.warn(&format!(
+                "{} is in both {} and {} with different import IDs ({} vs {}); using the one from {}",
+                resource.address,
+                first.source,
+                source,
+                first.import_id.as_deref().unwrap_or("none"),
+                id.as_deref().unwrap_or("none"),
+                first.source
+            )),
+            Some(first) => logger.debug(&format!("Dropping duplicate {} from {} (also in {})", resource.address, source, first.source)),
+            None => {
+                seen.insert(resource.address.clone(), FirstSeen { source: source.to_string(), import_id: id });
+                target.resources.get_or_insert_with(Vec::new).push(resource);
+            }
+        }
+    }
+
+    for child in module.child_modules.into_iter().flatten() {
+        let children = target.child_modules.get_or_insert_with(Vec::new);
+        let index = match children.iter().position(|existing| existing.address == child.address) {
+            Some(index) => index,
+            None => {
+                children.push(PlannedModule { resources: None, child_modules: None, address: child.address.clone() });
+                children.len() - 1
+            }
+        };
+        merge_module(&mut children[index], child, source, builders, logger, seen);
+    }
+}
+
+/// Merges `value` into `target`: objects key by key, `resources` arrays by address
+///
+/// Anything else already in `target` is kept as it is.
+fn merge_json(target: &mut Value, value: Value) {
+    match (target, value) {
+        (Value::Object(target), Value::Object(object)) => {
+            for (key, value) in object {
+                match target.get_mut(&key) {
+                    Some(existing) => merge_json(existing, value),
+                    None => {
+                        target.insert(key, value);
+                    }
+                }
+            }
+        }
+        (Value::Array(target), Value::Array(items)) => {
+            for item in items {
+                let Some(address) = item.get("address").and_then(Value::as_str) else { continue };
+                if !target.iter().any(|existing| existing.get("address").and_then(Value::as_str) == Some(address)) {
+                    target.push(item);
+                }
+            }
+        }
+        _ => {}
+    }
+}
+
+/// Adds the variables of `variables` that `target` doesn't set
+fn merge_variables(target: &mut Variables, variables: Variables) {
+    if target.project_id.is_none() {
+        target.project_id = variables.project_id;
+    }
+    if target.region.is_none() {
+        target.region = variables.region;
+    }
+    if target.subscription_id.is_none() {
+        target.subscription_id = variables.subscription_id;
+    }
+    for (name, value) in variables.other {
+        target.other.entry(name).or_insert(value);
+    }
+}
@@ -0,0 +1,328 @@
+//! # Import Ordering Module
+//!
+//! Importing a child resource before its parent is in state can fail, e.g. a KMS crypto
+//! key whose key ring hasn't been imported yet. This module orders the resources of a
+//! plan so that every resource comes after the resources it depends on.
+//!
+//! ## Dependencies
+//!
+//! Dependencies are read from the plan's `configuration`:
+//!
+//! - **References**: Every `references` entry in a resource's expressions, e.g.
+//!   `google_kms_key_ring.example.id`, resolved in the resource's own module
+//! - **depends_on**: Explicit `depends_on` entries of the resource
+//! - **Module calls**: References and `depends_on` of a module call apply to every
+//!   resource inside the module, and a reference to `module.x` depends on every resource
+//!   inside `module.x`
+//!
+//! A planned resource's own `depends_on` is used as well. Dependencies are followed
+//! through resources that aren't being ordered, so a dependency on a data source or
+//! an unchanged resource still orders what lies behind it.
+//!
+//! ## Ordering
+//!
+//! The sort is stable: resources without a dependency between them keep their plan
+//! order, and instances of the same block are never ordered against each other. If the
+//! dependencies form a cycle, `DependencyCycle` names the resources involved and the
+//! caller keeps the original order.
+
+use std::collections::{BTreeSet, HashMap, HashSet};
+use serde_json::Value;
+use thiserror::Error;
+use crate::address::ResourceAddress;
+use crate::importer::{PlanFile, Resource};
+use crate::utils::collect_resources;
+
+/// Error returned when the dependencies between resources form a cycle
+#[derive(Error, Debug, Clone, PartialEq)]
+#[error("dependency cycle between {}", addresses.join(", "))]
+pub struct DependencyCycle {
+    /// Addresses of the resources on or behind the cycle, in plan order
+    pub addresses: Vec<String>,
+}
+
+/// What a reference in the configuration points at
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+enum Target {
+    /// A resource block, by configuration address
+    Resource(String),
+    /// Every resource inside a module call, by module address prefix (ending in `.`)
+    Module(String),
+}
+
+/// Removes the `[...]` index parts of a reference, keeping quoted keys intact
+fn strip_index_keys(reference: &str) -> String {
+    let mut stripped = String::with_capacity(reference.len());
+    let mut depth = 0;
+    let mut quoted = false;
+    let mut escaped = false;
+    for c in reference.chars() {
+        match c {
+            _ if escaped => escaped = false,
+            '\\' if quoted => escaped = true,
+            '"' if depth > 0 => quoted = !quoted,
+            '[' if !quoted => depth += 1,
+            ']' if !quoted && depth > 0 => depth -= 1,
+            c if depth == 0 => stripped.push(c),
+            _ => {}
+        }
+    }
+    stripped
+}
+
+/// Resolves a configuration reference in the module at `scope` (e.g. `module.kms.`)
+///
+/// References to variables, locals and other non-resource objects resolve to None.
+fn parse_reference(reference: &str, scope: &str) -> Option<Target> {
+    let stripped = strip_index_keys(reference);
+    let segments: Vec<&str> = stripped.split('.').collect();
+    match segments.as_slice() {
+        ["var" | "local" | "each" | "count" | "path" | "terraform" | "self", ..] => None,
+        ["module", name, ..] => Some(Target::Module(format!("{}module.{}.", scope, name))),
+        ["data", resource_type, name, ..] => Some(Target::Resource(format!("{}data.{}.{}", scope, resource_type, name))),
+        [resource_type, name, ..] => Some(Target::Resource(format!("{}{}.{}", scope, resource_type, name))),
+        _ => None,
+    }
+}
+
+/// Collects the targets of every `references` and `depends_on` entry beneath `value`
+fn collect_targets(value: &Value, scope: &str, targets: &mut Vec<Target>) {
+    match value {
+        Value::Object(object) => {
+            for (key, value) in object {
+                match (key.as_str(), value) {
+                    ("references" | "depends_on", Value::Array(references)) => {
+                        targets.extend(references.iter().filter_map(Value::as_str).filter_map(|reference| parse_reference(reference, scope)));
+                    }
+                    // Nested modules are walked separately, with their own scope
+                    ("module", _) => {}
+                    _ => collect_targets(value, scope, targets),
+                }
+            }
+        }
+        Value::Array(values) => values.iter().for_each(|value| collect_targets(value, scope, targets)),
+        _ => {}
+    }
+}
+
+/// Records the targets of every resource in a configuration module and its module calls
+fn walk_module(module: &Value, scope: &str, inherited: &[Target], dependencies: &mut HashMap<String, Vec<Target>>) {
+    for resource in module.get("resources").and_then(Value::as_array).into_iter().flatten() {
+        let Some(address) = resource.get("address").and_then(Value::as_str) else { continue };
+        let mut targets = inherited.to_vec();
+        collect_targets(resource, scope, &mut targets);
+        dependencies.entry(format!("{}{}", scope, address)).or_default().extend(targets);
+    }
+
+    for (name, call) in module.get("module_calls").and_then(Value::as_object).into_iter().flatten() {
+        let mut targets = inherited.to_vec();
+        collect_targets(call, scope, &mut targets);
+        if let Some(child) = call.get("module") {
+            walk_module(child, &format!("{}module.{}.", scope, name), &targets, dependencies);
+        }
+    }
+}
+
+/// Returns the direct dependencies of every resource block in the plan
+///
+/// # Arguments
+/// * `plan` - The Terraform plan
+///
+/// # Returns
+/// Configuration addresses (see `ResourceAddress::config_address`) mapped to the
+/// configuration addresses they depend on; blocks without dependencies may be missing
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::ordering::resource_dependencies;
+///
+/// let plan = serde_json::from_value(serde_json::json!({
+///     "format_version": "1.2",
+///     "terraform_version": "1.9.0",
+///     "configuration": {"root_module": {"module_calls": {"kms": {"module": {"resources": [
+///         {"address": "google_kms_crypto_key.key", "expressions": {"key_ring": {"references": ["google_kms_key_ring.ring.id", "google_kms_key_ring.ring"]}}},
+///         {"address": "google_kms_key_ring.ring", "expressions": {"project": {"references": ["var.project_id"]}}}
+///     ]}}}}}
+/// })).unwrap();
+///
+/// let dependencies = resource_dependencies(&plan);
+/// assert!(dependencies["module.kms.google_kms_crypto_key.key"].contains("module.kms.google_kms_key_ring.ring"));
+/// assert!(dependencies.get("module.kms.google_kms_key_ring.ring").map_or(true, |deps| deps.is_empty()));
+/// ```
+pub fn resource_dependencies(plan: &PlanFile) -> HashMap<String, HashSet<String>> {
+    let mut targets_by_address = HashMap::new();
+    if let Some(root_module) = plan.configuration.as_ref().and_then(|configuration| configuration.get("root_module")) {
+        walk_module(root_module, "", &[], &mut targets_by_address);
+    }
+    let mut addresses: Vec<String> = targets_by_address.keys().cloned().collect();
+
+    let mut planned_depends_on = Vec::new();
+    if let Some(planned_values) = &plan.planned_values {
+        let mut resources = Vec::new();
+        collect_resources(&planned_values.root_module, &mut resources);
+        for resource in resources {
+            let Ok(address) = resource.address.parse::<ResourceAddress>() else { continue };
+            let targets = resource
+                .depends_on
+                .iter()
+                .flatten()
+                .filter_map(|dependency| parse_reference(dependency, ""))
+                .collect::<Vec<_>>();
+            addresses.push(address.config_address());
+            planned_depends_on.push((address.config_address(), targets));
+        }
+    }
+    for (address, targets) in planned_depends_on {
+        targets_by_address.entry(address).or_default().extend(targets);
+    }
+
+    let mut dependencies = HashMap::new();
+    for (address, targets) in targets_by_address {
+        let resolved: HashSet<String> = targets
+            .into_iter()
+            .flat_map(|target| match target {
+                Target::Resource(dependency) => vec![dependency],
+                Target::Module(prefix) => addresses.iter().filter(|address| address.starts_with(&prefix)).cloned().collect(),
+            })
+            .filter(|dependency| *dependency != address)
+            .collect();
+        dependencies.insert(address, resolved);
+    }
+    dependencies
+}
+
+/// Returns every configuration address reachable from `address` through `dependencies`
+fn reachable<'a>(address: &'a str, dependencies: &'a HashMap<String, HashSet<String>>) -> HashSet<&'a str> {
+    let mut seen = HashSet::new();
+    let mut pending = vec![address];
+    while let Some(next) = pending.pop() {
+        for dependency in dependencies.get(next).into_iter().flatten() {
+            if seen.insert(dependency.as_str()) {
+                pending.push(dependency);
+            }
+        }
+    }
+    seen
+}
+
+/// Returns the order in which to process `addresses` so dependencies come first
+///
+/// # Arguments
+/// * `addresses` - Resource instance addresses in plan order
+/// * `dependencies` - Output of `resource_dependencies`
+///
+/// # Returns
+/// Indices into `addresses`, dependencies before dependents and plan order otherwise
+///
+/// # Errors
+/// - `DependencyCycle` if the resources depend on each other in a cycle
+pub fn dependency_order(addresses: &[&str], dependencies: &HashMap<String, HashSet<String>>) -> Result<Vec<usize>, DependencyCycle> {
+    let config_addresses: Vec<Option<String>> = addresses
+        .iter()
+        .map(|address| address.parse::<ResourceAddress>().ok().map(|address| address.config_address()))
+        .collect();
+
+    let mut prerequisites: Vec<HashSet<usize>> = vec![HashSet::new(); addresses.len()];
+    let mut dependents: Vec<Vec<usize>> = vec![Vec::new(); addresses.len()];
+    for (index, config_address) in config_addresses.iter().enumerate() {
+        let Some(config_address) = config_address else { continue };
+        let reachable = reachable(config_address, dependencies);
+        for (other, other_address) in config_addresses.iter().enumerate() {
+            if other_address.as_deref().is_some_and(|other_address| other_address != config_address && reachable.contains(other_address)) {
+                prerequisites[index].insert(other);
+                dependents[other].push(index);
+            }
+        }
+    }
+
+    let mut ready: BTreeSet<usize> = (0..addresses.len()).filter(|&index| prerequisites[index].is_empty()).collect();
+    let mut order = Vec::with_capacity(addresses.len());
+    while let Some(index) = ready.pop_first() {
+        order.push(index);
+        for &dependent in &dependents[index] {
+            prerequisites[dependent].remove(&index);
+            if prerequisites[dependent].is_empty() {
+                ready.insert(dependent);
+            }
+        }
+    }
+
+    if order.len() < addresses.len() {
+        let ordered: HashSet<usize> = order.into_iter().collect();
+        return Err(DependencyCycle {
+            addresses: (0..addresses.len()).filter(|index| !ordered.contains(index)).map(|index| addresses[index].to_string()).collect(),
+        });
+    }
+    Ok(order)
+}
+
+/// Sorts planned resources so that every resource follows the resources it depends on
+///
+/// # Arguments
+/// * `plan` - The plan the resources were collected from
+/// * `resources` - Resources in plan order; left unchanged if there is a cycle
+///
+/// # Errors
+/// - `DependencyCycle` if the resources depend on each other in a cycle
+pub fn sort_by_dependencies(plan: &PlanFile, resources: &mut Vec<&Resource>) -> Result<(), DependencyCycle> {
+    let dependencies = resource_dependencies(plan);
+    let addresses: Vec<&str> = resources.iter().map(|resource| resource.address.as_str()).collect();
+    let order = dependency_order(&addresses, &dependencies)?;
+    let sorted = order.into_iter().map(|index| resources[index]).collect();
+    *resources = sorted;
+    Ok(())
+}
+
+/// Unit tests for reference parsing and dependency ordering
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn dependencies(edges: &[(&str, &[&str])]) -> HashMap<String, HashSet<String>> {
+        edges
+            .iter()
+            .map(|(address, deps)| (address.to_string(), deps.iter().map(|dep| dep.to_string()).collect()))
+            .collect()
+    }
+
+    /// **TEST** - References resolve in their module scope; index keys and non-resources are handled
+    #[test]
+    fn test_parse_reference() {
+        let resource = |address: &str| Some(Target::Resource(address.to_string()));
+        assert_eq!(parse_reference("google_kms_key_ring.example.id", "module.kms."), resource("module.kms.google_kms_key_ring.example"));
+        assert_eq!(parse_reference(r#"aws_iam_role.this["a.b"].arn"#, ""), resource("aws_iam_role.this"));
+        assert_eq!(parse_reference("data.google_project.p.number", ""), resource("data.google_project.p"));
+        assert_eq!(parse_reference("module.network[0].subnet_id", "module.app."), Some(Target::Module("module.app.module.network.".to_string())));
+        assert_eq!(parse_reference("var.project_id", ""), None);
+        assert_eq!(parse_reference("each.value", ""), None);
+    }
+
+    /// **TEST** - Dependencies come first, transitively, and unrelated resources keep plan order
+    #[test]
+    fn test_dependency_order_is_stable() {
+        let deps = dependencies(&[
+            ("module.kms.google_kms_crypto_key.key", &["module.kms.google_kms_key_ring.ring"]),
+            ("module.kms.google_kms_key_ring.ring", &["data.google_project.p"]),
+            ("data.google_project.p", &["google_project_service.kms"]),
+        ]);
+        let addresses = [
+            "module.kms.google_kms_crypto_key.key[\"a\"]",
+            "google_storage_bucket.logs",
+            "module.kms.google_kms_crypto_key.key[\"b\"]",
+            "module.kms.google_kms_key_ring.ring",
+            "google_project_service.kms",
+        ];
+
+        let order = dependency_order(&addresses, &deps).unwrap();
+        assert_eq!(order, vec![1, 4, 3, 0, 2]);
+    }
+
+    /// **TEST** - A cycle is reported with the resources involved
+    #[test]
+    fn test_dependency_cycle() {
+        let deps = dependencies(&[("a_thing.one", &["a_thing.two"]), ("a_thing.two", &["a_thing.one"])]);
+        let err = dependency_order(&["a_thing.one", "b_thing.free", "a_thing.two"], &deps).unwrap_err();
+        assert_eq!(err.addresses, vec!["a_thing.one", "a_thing.two"]);
+        assert_eq!(err.to_string(), "dependency cycle between a_thing.one, a_thing.two");
+    }
+}
@@ -0,0 +1,375 @@
+//! # Plan Set Module
+//!
+//! Infrastructure split into many Terragrunt units is usually planned unit by unit,
+//! producing one plan JSON per unit. This module treats a directory of such plans as
+//! a single run, the import equivalent of `terragrunt run-all`: every plan is mapped to
+//! its unit's working directory and imported there, one unit after another.
+//!
+//! ## Layout
+//!
+//! Plan files are named by the path of their unit relative to the units root, with a
+//! `.json` extension. With plans in `plans/` and units under `live/`:
+//!
+//! ```text
+//! plans/prod/vpc.json        ->  live/prod/vpc
+//! plans/prod/kms/keys.json   ->  live/prod/kms/keys
+//! ```
+//!
+//! ## Failure Handling
+//!
+//! A unit fails if its plan can't be loaded, its run aborts (e.g. a failed state
+//! backup) or any of its imports fail. Failures are recorded in the `PlanSetReport`
+//! and the remaining units still run, unless `ImportOptions::fail_fast` is set.
+
+use std::collections::HashMap;
+use std::fs;
+use std::path::{Path, PathBuf};
+use anyhow::{bail, Context, Result};
+use serde::Serialize;
+use crate::app::load_plan;
+use crate::builders::ImportIdBuilderRegistry;
+use crate::commands::{CommandRunner, ImportOptions};
+use crate::importer::{execute_or_print_imports, ModuleMeta};
+use crate::mapping::ImportIdMappings;
+use crate::reporting::{Report, EXIT_IMPORT_FAILURES, EXIT_SUCCESS};
+use crate::utils::collect_resources;
+
+/// Reason recorded for units that weren't run because an earlier unit failed
+pub const FAIL_FAST_UNIT_SKIP_REASON: &str = "not run: an earlier unit failed and fail-fast is enabled";
+
+/// One Terragrunt unit and the plan generated for it
+#[derive(Debug, Clone, PartialEq)]
+pub struct PlanUnit {
+    /// Unit path relative to the units root, using `/` separators (e.g. "prod/vpc")
+    pub name: String,
+    /// Path of the unit's plan JSON file
+    pub plan_path: PathBuf,
+    /// Directory `terragrunt` is run in for this unit
+    pub working_directory: PathBuf,
+}
+
+/// A set of per-unit plans imported as one run
+///
+/// # Examples
+/// ```no_run
+/// use std::path::Path;
+/// use terragrunt_import_from_plan::builders::ImportIdBuilderRegistry;
+/// use terragrunt_import_from_plan::commands::{ImportOptions, SystemCommandRunner};
+/// use terragrunt_import_from_plan::mapping::ImportIdMappings;
+/// use terragrunt_import_from_plan::planset::PlanSet;
+///
+/// let plan_set = PlanSet::from_dir(Path::new("plans"), Path::new("live")).unwrap();
+/// let options = ImportOptions { dry_run: true, ..Default::default() };
+/// let report = plan_set.run(&ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, false, &SystemCommandRunner);
+/// println!("{} of {} units succeeded", report.units.len() - report.failed, report.units.len());
+/// ```
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct PlanSet {
+    /// Units in the order they are run, sorted by name
+    pub units: Vec<PlanUnit>,
+}
+
+impl PlanSet {
+    /// Discovers every `*.json` plan below `plan_dir`, mapping each to a unit under `units_root`
+    ///
+    /// # Arguments
+    /// * `plan_dir` - Directory containing one plan JSON per unit, possibly nested
+    /// * `units_root` - Directory the unit paths are relative to
+    ///
+    /// # Errors
+    /// - `plan_dir` or one of its subdirectories can't be read
+    /// - No plan files were found
+    pub fn from_dir(plan_dir: &Path, units_root: &Path) -> Result<Self> {
+        let mut plan_paths = Vec::new();
+        find_plan_files(plan_dir, &mut plan_paths)?;
+        if plan_paths.is_empty() {
+            bail!("No plan JSON files found in {}", plan_dir.display());
+        }
+
+        let mut units: Vec<PlanUnit> = plan_paths
+            .into_iter()
+            .map(|plan_path| {
+                let relative = plan_path.strip_prefix(plan_dir).unwrap_or(&plan_path).with_extension("");
+                let name = relative
+                    .components()
+                    .map(|component| component.as_os_str().to_string_lossy())
+                    .collect::<Vec<_>>()
+                    .join("/");
+                PlanUnit { working_directory: units_root.join(&relative), name, plan_path }
+            })
+            .collect();
+        units.sort_by(|a, b| a.name.cmp(&b.name));
+
+        Ok(Self { units })
+    }
+
+    /// Imports each unit's plan in its working directory, one unit at a time
+    ///
+    /// Every resource in a unit's plan is imported in that unit's working directory,
+    /// so no modules.json is needed. All `options` apply within each unit; with
+    /// `fail_fast` set, units after the first failed one are recorded as skipped.
+    ///
+    /// # Arguments
+    /// * `mappings` - Explicit import IDs, shared by all units
+    /// * `builders` - Registry of import ID builders
+    /// * `options` - Execution options applied to every unit
+    /// * `verbose` - Whether to print detailed progress information
+    /// * `runner` - Command runner used to inspect and back up state
+    ///
+    /// # Returns
+    /// Per-unit outcomes in run order
+    pub fn run(
+        &self,
+        mappings: &ImportIdMappings,
+        builders: &ImportIdBuilderRegistry,
+        options: &ImportOptions,
+        verbose: bool,
+        runner: &dyn CommandRunner,
+    ) -> PlanSetReport {
+        let mut report = PlanSetReport::new();
+
+        for unit in &self.units {
+            if options.fail_fast && report.failed > 0 {
+                report.record(UnitReport::skipped(unit, FAIL_FAST_UNIT_SKIP_REASON));
+                continue;
+            }
+
+            println!("\n📂 Unit {} ({})", unit.name, unit.working_directory.display());
+            let outcome = run_unit(unit, mappings, builders, options, verbose, runner);
+            if let Err(e) = &outcome {
+                options.logger.error(&format!("Unit {} failed: {:#}", unit.name, e));
+            }
+            report.record(UnitReport::from_outcome(unit, outcome));
+        }
+
+        report
+    }
+}
+
+/// Loads a unit's plan and runs its imports in the unit's working directory
+fn run_unit(
+    unit: &PlanUnit,
+    mappings: &ImportIdMappings,
+    builders: &ImportIdBuilderRegistry,
+    options: &ImportOptions,
+    verbose: bool,
+    runner: &dyn CommandRunner,
+) -> Result<Report> {
+    let plan = load_plan(&unit.plan_path)?;
+
+    let unit_module = ModuleMeta {
+        key: unit.name.clone(),
+        source: unit.name.clone(),
+        dir: unit.working_directory.to_string_lossy().to_string(),
+    };
+    let mut resources = Vec::new();
+    if let Some(planned_values) = &plan.planned_values {
+        collect_resources(&planned_values.root_module, &mut resources);
+    }
+    let resource_map: HashMap<String, &ModuleMeta> = resources
+        .iter()
+        .map(|resource| (resource.address.clone(), &unit_module))
+        .collect();
+
+    let report = execute_or_print_imports(&resource_map, &plan, mappings, builders, options, verbose, "", runner)?;
+    Ok(report)
+}
+
+/// Recursively collects `*.json` files below `dir`
+fn find_plan_files(dir: &Path, plan_paths: &mut Vec<PathBuf>) -> Result<()> {
+    let entries = fs::read_dir(dir)
+        .with_context(|| format!("Failed to read plan directory: {}", dir.display()))?;
+    for entry in entries {
+        let path = entry
+            .with_context(|| format!("Failed to read plan directory: {}", dir.display()))?
+            .path();
+        if path.is_dir() {
+            find_plan_files(&path, plan_paths)?;
+        } else if path.extension().is_some_and(|extension| extension == "json") {
+            plan_paths.push(path);
+        }
+    }
+    Ok(())
+}
+
+/// Outcome of a single unit in a PlanSetReport
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "snake_case")]
+pub enum UnitStatus {
+    /// The unit ran and none of its imports failed
+    Success,
+    /// The unit's plan couldn't be processed or an import failed
+    Failed,
+    /// The unit wasn't run
+    Skipped,
+}
+
+/// One unit's entry in a PlanSetReport
+///
+/// # Fields
+/// - `unit`: Unit path relative to the units root
+/// - `working_directory`: Directory terragrunt was run in
+/// - `status`: Outcome for this unit
+/// - `error`: Why the unit failed or was skipped, if it didn't finish its run
+/// - `report`: Per-resource results, if the unit's run finished
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct UnitReport {
+    /// Unit path relative to the units root
+    pub unit: String,
+    /// Directory terragrunt was run in
+    pub working_directory: String,
+    /// Outcome for this unit
+    pub status: UnitStatus,
+    /// Reason the unit's run didn't finish
+    pub error: Option<String>,
+    /// Per-resource results of the unit's run
+    pub report: Option<Report>,
+}
+
+impl UnitReport {
+    /// Builds the entry for a unit from the result of its run
+    fn from_outcome(unit: &PlanUnit, outcome: Result<Report>) -> Self {
+        let (status, error, report) = match outcome {
+            Ok(report) if report.success => (UnitStatus::Success, None, Some(report)),
+            Ok(report) => (UnitStatus::Failed, None, Some(report)),
+            Err(e) => (UnitStatus::Failed, Some(format!("{:#}", e)), None),
+        };
+        Self {
+            unit: unit.name.clone(),
+            working_directory: unit.working_directory.display().to_string(),
+            status,
+            error,
+            report,
+        }
+    }
+
+    /// Builds the entry for a unit that wasn't run
+    fn skipped(unit: &PlanUnit, reason: &str) -> Self {
+        Self {
+            unit: unit.name.clone(),
+            working_directory: unit.working_directory.display().to_string(),
+            status: UnitStatus::Skipped,
+            error: Some(reason.to_string()),
+            report: None,
+        }
+    }
+}
+
+/// Machine-readable results of a plan set run
+///
+/// `success` is false and `exit_code` is `EXIT_IMPORT_FAILURES` if any unit failed.
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct PlanSetReport {
+    /// True if no unit failed
+    pub success: bool,
+    /// Process exit status corresponding to `success`
+    pub exit_code: i32,
+    /// Number of units that failed
+    pub failed: usize,
+    /// Number of units that weren't run
+    pub skipped: usize,
+    /// Per-unit results in run order
+    pub units: Vec<UnitReport>,
+}
+
+impl Default for PlanSetReport {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl PlanSetReport {
+    /// Creates an empty, successful report
+    pub fn new() -> Self {
+        Self { success: true, exit_code: EXIT_SUCCESS, failed: 0, skipped: 0, units: Vec::new() }
+    }
+
+    /// Adds a unit's result and updates the counts and overall status
+    pub fn record(&mut self, entry: UnitReport) {
+        match entry.status {
+            UnitStatus::Success => {}
+            UnitStatus::Failed => self.failed += 1,
+            UnitStatus::Skipped => self.skipped += 1,
+        }
+        self.success = self.failed == 0;
+        self.exit_code = if self.success { EXIT_SUCCESS } else { EXIT_IMPORT_FAILURES };
+        self.units.push(entry);
+    }
+
+    /// Returns the number of resources without an import ID builder, across every unit that ran
+    pub fn unsupported(&self) -> usize {
+        self.units.iter().filter_map(|unit| unit.report.as_ref()).map(|report| report.unsupported).sum()
+    }
+
+    /// Serializes the report as pretty-printed JSON
+    ///
+    /// # Errors
+    /// Returns an error if serialization fails
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).context("Failed to serialize plan set report")
+    }
+
+    /// Writes the report as JSON to `path`
+    ///
+    /// # Errors
+    /// Returns an error if the file can't be written
+    pub fn write_json(&self, path: &Path) -> Result<()> {
+        let json = self.to_json()?;
+        fs::write(path, json)
+            .with_context(|| format!("Failed to write plan set report to {}", path.display()))
+    }
+}
+
+/// Unit tests for plan discovery and report bookkeeping
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// **TEST** - Plans are discovered recursively and named by their relative path
+    #[test]
+    fn test_from_dir_maps_plans_to_units() {
+        let plans = tempfile::tempdir().unwrap();
+        fs::create_dir_all(plans.path().join("prod/kms")).unwrap();
+        fs::write(plans.path().join("prod/vpc.json"), "{}").unwrap();
+        fs::write(plans.path().join("prod/kms/keys.json"), "{}").unwrap();
+        fs::write(plans.path().join("prod/README.md"), "not a plan").unwrap();
+
+        let plan_set = PlanSet::from_dir(plans.path(), Path::new("live")).unwrap();
+
+        let names: Vec<&str> = plan_set.units.iter().map(|unit| unit.name.as_str()).collect();
+        assert_eq!(names, vec!["prod/kms/keys", "prod/vpc"]);
+        assert_eq!(plan_set.units[0].working_directory, Path::new("live/prod/kms/keys"));
+        assert_eq!(plan_set.units[1].plan_path, plans.path().join("prod/vpc.json"));
+    }
+
+    /// **TEST** - A directory without plans is an error
+    #[test]
+    fn test_from_dir_requires_plans() {
+        let plans = tempfile::tempdir().unwrap();
+        let err = PlanSet::from_dir(plans.path(), Path::new("live")).unwrap_err();
+        assert!(err.to_string().contains("No plan JSON files found"));
+        assert!(PlanSet::from_dir(&plans.path().join("missing"), Path::new("live")).is_err());
+    }
+
+    /// **TEST** - Failed units make the report unsuccessful, skipped ones are counted
+    #[test]
+    fn test_report_records_units() {
+        let unit = PlanUnit {
+            name: "prod/vpc".to_string(),
+            plan_path: PathBuf::from("plans/prod/vpc.json"),
+            working_directory: PathBuf::from("live/prod/vpc"),
+        };
+        let mut report = PlanSetReport::new();
+        report.record(UnitReport::from_outcome(&unit, Ok(Report::new())));
+        assert!(report.success);
+
+        report.record(UnitReport::from_outcome(&unit, Err(anyhow::anyhow!("Failed to read plan file"))));
+        report.record(UnitReport::skipped(&unit, FAIL_FAST_UNIT_SKIP_REASON));
+        assert_eq!((report.failed, report.skipped, report.exit_code), (1, 1, EXIT_IMPORT_FAILURES));
+
+        let json: serde_json::Value = serde_json::from_str(&report.to_json().unwrap()).unwrap();
+        assert_eq!(json["units"][1]["status"], "failed");
+        assert_eq!(json["units"][1]["error"], "Failed to read plan file");
+        assert_eq!(json["units"][2]["status"], "skipped");
+    }
+}
@@ -0,0 +1,263 @@
+//! # Preflight Module
+//!
+//! The import commands run a user-chosen binary in directories built from user-provided
+//! paths. A typo in either only shows up once the first import fails, as a bare "No such
+//! file or directory", possibly after other modules were already imported. This module
+//! checks both once, before anything runs.
+//!
+//! ## Checks
+//!
+//! - **Binary**: The program (`terragrunt` or `terraform`) is an executable file in a
+//!   `PATH` directory
+//! - **Working directories**: Each exists, is a directory, and holds a configuration for
+//!   the binary: a `terragrunt.hcl` for terragrunt, or a `*.tf` / `*.tf.json` file for
+//!   terraform
+//!
+//! Terragrunt can also run in a plain terraform module without a `terragrunt.hcl`; with
+//! `require_terragrunt_config` unset such directories pass as long as they hold `*.tf`
+//! files.
+
+use std::ffi::OsStr;
+use std::fs;
+use std::io;
+use std::path::{Path, PathBuf};
+use thiserror::Error;
+use crate::commands::builder::ImportBinary;
+
+/// Terragrunt's configuration file, expected in every working directory by default
+pub const TERRAGRUNT_CONFIG: &str = "terragrunt.hcl";
+
+/// Error types for the checks run before any command
+///
+/// # Variants
+/// - `BinaryNotFound`: The binary isn't an executable file on `PATH`
+/// - `MissingDirectory`: A working directory doesn't exist
+/// - `NotADirectory`: A working directory path is something other than a directory
+/// - `UnreadableDirectory`: A working directory's entries can't be listed
+/// - `MissingTerragruntConfig`: A working directory has no `terragrunt.hcl`
+/// - `MissingTerraformFiles`: A working directory has no terraform configuration files
+#[derive(Error, Debug)]
+pub enum PreflightError {
+    /// The binary isn't an executable file in any `PATH` directory
+    #[error("{program} was not found on PATH (install it, or pick the other one with --binary)")]
+    BinaryNotFound {
+        /// Program that was looked up
+        program: String,
+    },
+
+    /// A working directory doesn't exist
+    #[error("working directory {path} does not exist (check --module-root, --dir-map and modules.json)")]
+    MissingDirectory {
+        /// Directory that was checked
+        path: String,
+    },
+
+    /// A working directory path exists but isn't a directory
+    #[error("working directory {path} is not a directory")]
+    NotADirectory {
+        /// Path that was checked
+        path: String,
+    },
+
+    /// A working directory's entries could not be listed
+    #[error("failed to read working directory {path}: {source}")]
+    UnreadableDirectory {
+        /// Directory that was checked
+        path: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// A working directory has no `terragrunt.hcl`
+    #[error("working directory {path} has no terragrunt.hcl (pass --skip-terragrunt-config-check for plain terraform modules)")]
+    MissingTerragruntConfig {
+        /// Directory that was checked
+        path: String,
+    },
+
+    /// A working directory has neither `*.tf` nor `*.tf.json` files
+    #[error("working directory {path} has no terraform configuration (*.tf or *.tf.json)")]
+    MissingTerraformFiles {
+        /// Directory that was checked
+        path: String,
+    },
+}
+
+/// Finds `program` in the directories of `search_path`, like a shell would
+///
+/// A program containing a path separator is checked as given instead.
+///
+/// # Arguments
+/// * `program` - Program name, e.g. "terragrunt"
+/// * `search_path` - Colon-separated directory list, usually the `PATH` variable
+///
+/// # Returns
+/// The path of the first executable file found, or None
+pub fn find_program(program: &str, search_path: &OsStr) -> Option<PathBuf> {
+    if program.contains(std::path::MAIN_SEPARATOR) {
+        let path = PathBuf::from(program);
+        return is_executable(&path).then_some(path);
+    }
+    std::env::split_paths(search_path)
+        .map(|directory| directory.join(program))
+        .find(|candidate| is_executable(candidate))
+}
+
+/// Whether `path` is a file the current user may execute
+fn is_executable(path: &Path) -> bool {
+    let Ok(metadata) = fs::metadata(path) else { return false };
+    #[cfg(unix)]
+    {
+        use std::os::unix::fs::PermissionsExt;
+        metadata.is_file() && metadata.permissions().mode() & 0o111 != 0
+    }
+    #[cfg(not(unix))]
+    {
+        metadata.is_file()
+    }
+}
+
+/// Checks that `directory` exists and holds a configuration for `binary`
+fn check_directory(binary: ImportBinary, directory: &Path, require_terragrunt_config: bool) -> Result<(), PreflightError> {
+    let path = directory.display().to_string();
+    match fs::metadata(directory) {
+        Ok(metadata) if metadata.is_dir() => {}
+        Ok(_) => return Err(PreflightError::NotADirectory { path }),
+        Err(e) if e.kind() == io::ErrorKind::NotFound => return Err(PreflightError::MissingDirectory { path }),
+        Err(source) => return Err(PreflightError::UnreadableDirectory { path, source }),
+    }
+
+    let has_terragrunt_config = directory.join(TERRAGRUNT_CONFIG).is_file();
+    if binary == ImportBinary::Terragrunt && require_terragrunt_config {
+        return if has_terragrunt_config { Ok(()) } else { Err(PreflightError::MissingTerragruntConfig { path }) };
+    }
+    if binary == ImportBinary::Terragrunt && has_terragrunt_config {
+        return Ok(());
+    }
+
+    let entries = fs::read_dir(directory).map_err(|source| PreflightError::UnreadableDirectory { path: path.clone(), source })?;
+    let has_terraform_files = entries.flatten().any(|entry| {
+        let name = entry.file_name();
+        let name = name.to_string_lossy();
+        (name.ends_with(".tf") || name.ends_with(".tf.json")) && entry.path().is_file()
+    });
+    if has_terraform_files {
+        Ok(())
+    } else {
+        Err(PreflightError::MissingTerraformFiles { path })
+    }
+}
+
+/// Checks the binary and every working directory before a run starts
+///
+/// Directories are checked in the order given and the first failure is returned, so
+/// the caller can stop before running anything.
+///
+/// # Arguments
+/// * `binary` - Program the run will invoke
+/// * `directories` - Working directories the run will use
+/// * `require_terragrunt_config` - With terragrunt, require a `terragrunt.hcl` rather
+///   than accepting plain terraform modules
+/// * `search_path` - Directories to look for the binary in, usually the `PATH` variable
+///
+/// # Returns
+/// The path of the binary that will run
+///
+/// # Examples
+/// ```
+/// use std::ffi::OsStr;
+/// use std::path::PathBuf;
+/// use terragrunt_import_from_plan::commands::ImportBinary;
+/// use terragrunt_import_from_plan::preflight::preflight;
+///
+/// let error = preflight(ImportBinary::Terraform, &[PathBuf::from(".")], true, OsStr::new("")).unwrap_err();
+/// assert_eq!(error.to_string(), "terraform was not found on PATH (install it, or pick the other one with --binary)");
+/// ```
+pub fn preflight(
+    binary: ImportBinary,
+    directories: &[PathBuf],
+    require_terragrunt_config: bool,
+    search_path: &OsStr,
+) -> Result<PathBuf, PreflightError> {
+    let program = find_program(binary.program(), search_path)
+        .ok_or_else(|| PreflightError::BinaryNotFound { program: binary.program().to_string() })?;
+    for directory in directories {
+        check_directory(binary, directory, require_terragrunt_config)?;
+    }
+    Ok(program)
+}
+
+/// Unit tests for the preflight checks
+#[cfg(all(test, unix))]
+mod tests {
+    use super::*;
+    use std::ffi::OsString;
+    use std::os::unix::fs::PermissionsExt;
+    use tempfile::TempDir;
+
+    /// Creates `bin/<program>` in `root`, executable or not, and returns `bin` as a search path
+    fn install_program(root: &Path, program: &str, executable: bool) -> OsString {
+        let bin = root.join("bin");
+        fs::create_dir_all(&bin).unwrap();
+        let path = bin.join(program);
+        fs::write(&path, "#!/bin/sh\n").unwrap();
+        fs::set_permissions(&path, fs::Permissions::from_mode(if executable { 0o755 } else { 0o644 })).unwrap();
+        bin.into_os_string()
+    }
+
+    /// Creates directory `name` in `root` holding `files`
+    fn module_dir(root: &Path, name: &str, files: &[&str]) -> PathBuf {
+        let directory = root.join(name);
+        fs::create_dir_all(&directory).unwrap();
+        for file in files {
+            fs::write(directory.join(file), "").unwrap();
+        }
+        directory
+    }
+
+    /// **TEST** - The binary must be an executable file in one of the search path's directories
+    #[test]
+    fn test_binary_lookup() {
+        let temp_dir = TempDir::new().unwrap();
+        let search_path = install_program(temp_dir.path(), "terragrunt", true);
+        let unit = module_dir(temp_dir.path(), "unit", &[TERRAGRUNT_CONFIG]);
+
+        let found = preflight(ImportBinary::Terragrunt, &[unit.clone()], true, &search_path).unwrap();
+        assert_eq!(found, temp_dir.path().join("bin/terragrunt"));
+        let error = preflight(ImportBinary::Terraform, &[unit.clone()], true, &search_path).unwrap_err();
+        assert!(matches!(error, PreflightError::BinaryNotFound { ref program } if program == "terraform"), "{}", error);
+
+        let other = TempDir::new().unwrap();
+        let search_path = install_program(other.path(), "terragrunt", false);
+        assert!(matches!(preflight(ImportBinary::Terragrunt, &[unit], true, &search_path), Err(PreflightError::BinaryNotFound { .. })));
+    }
+
+    /// **TEST** - Working directories must exist and hold a configuration for the binary
+    #[test]
+    fn test_working_directory_checks() {
+        let temp_dir = TempDir::new().unwrap();
+        let root = temp_dir.path();
+        let search_path = install_program(root, "terragrunt", true);
+        install_program(root, "terraform", true);
+        let unit = module_dir(root, "unit", &[TERRAGRUNT_CONFIG]);
+        let module = module_dir(root, "module", &["main.tf", "README.md"]);
+        let json_module = module_dir(root, "json_module", &["main.tf.json"]);
+        let empty = module_dir(root, "empty", &["README.md"]);
+        let file = root.join("unit").join(TERRAGRUNT_CONFIG);
+        let missing = root.join("missing");
+
+        let check = |binary, directory: &PathBuf, require| preflight(binary, std::slice::from_ref(directory), require, &search_path);
+        assert!(check(ImportBinary::Terragrunt, &unit, true).is_ok());
+        assert!(matches!(check(ImportBinary::Terragrunt, &module, true), Err(PreflightError::MissingTerragruntConfig { .. })));
+        assert!(check(ImportBinary::Terragrunt, &module, false).is_ok());
+        assert!(check(ImportBinary::Terragrunt, &unit, false).is_ok());
+        assert!(check(ImportBinary::Terraform, &json_module, true).is_ok());
+        assert!(matches!(check(ImportBinary::Terraform, &unit, true), Err(PreflightError::MissingTerraformFiles { .. })));
+        assert!(matches!(check(ImportBinary::Terragrunt, &empty, false), Err(PreflightError::MissingTerraformFiles { .. })));
+        assert!(matches!(check(ImportBinary::Terraform, &file, true), Err(PreflightError::NotADirectory { .. })));
+
+        let error = check(ImportBinary::Terragrunt, &missing, true).unwrap_err();
+        assert_eq!(error.to_string(), format!("working directory {} does not exist (check --module-root, --dir-map and modules.json)", missing.display()));
+    }
+}
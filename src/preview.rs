@@ -0,0 +1,200 @@
+//! # Import Preview Module
+//!
+//! A preview shows what an import run would do without running anything: for every
+//! planned create it lists the address, resource type, computed import ID and the
+//! generator's decision. It is built from `importer::plan_imports`, so it reflects the
+//! same filters, ID validation and state pre-check as a real run.
+//!
+//! ## Output Formats
+//!
+//! - **Table**: Aligned columns for humans, e.g. as a reviewable artifact in a PR
+//! - **JSON**: The same rows for tooling
+//!
+//! Rows are sorted by address in both formats, so previews of the same plan and state
+//! are byte-for-byte identical and diff cleanly.
+
+use anyhow::{Context, Result};
+use serde::Serialize;
+use crate::importer::{ImportDecision, PlannedImport};
+use crate::sensitive::Redactor;
+
+/// One resource's row in a Preview
+///
+/// # Fields
+/// - `address`: Full terraform resource address
+/// - `resource_type`: Terraform resource type
+/// - `import_id`: Computed import ID, if one could be determined
+/// - `decision`: What an import run would do with the resource
+/// - `reason`: Why the resource wouldn't be imported, where applicable
+/// - `command`: The import command that would run, for `ImportDecision::Import`
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct PreviewEntry {
+    /// Full terraform resource address
+    pub address: String,
+    /// Terraform resource type
+    pub resource_type: String,
+    /// Computed import ID
+    pub import_id: Option<String>,
+    /// What an import run would do with the resource
+    pub decision: ImportDecision,
+    /// Why the resource wouldn't be imported
+    pub reason: Option<String>,
+    /// The import command that would run
+    pub command: Option<String>,
+}
+
+/// What an import run would do with each planned create, sorted by address
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::importer::{ImportDecision, PlannedImport};
+/// use terragrunt_import_from_plan::preview::Preview;
+///
+/// let preview = Preview::new(vec![PlannedImport {
+///     address: "aws_vpc.main".to_string(),
+///     resource_type: "aws_vpc".to_string(),
+///     import_id: Some("vpc-12345".to_string()),
+///     decision: ImportDecision::SkipAlreadyInState,
+///     reason: None,
+///     command: None,
+///     sensitive_values: Vec::new(),
+/// }]);
+///
+/// assert_eq!(preview.count(ImportDecision::SkipAlreadyInState), 1);
+/// assert_eq!(preview.to_table().lines().nth(2), Some("aws_vpc.main  aws_vpc  vpc-12345  skip-already-in-state"));
+/// ```
+#[derive(Debug, Clone, Default, PartialEq, Serialize)]
+pub struct Preview {
+    /// One row per planned create, sorted by address
+    pub resources: Vec<PreviewEntry>,
+}
+
+impl Preview {
+    /// Builds a preview from the generator's decisions
+    ///
+    /// Values the plan marks as sensitive are masked in import IDs, reasons and commands.
+    pub fn new(planned: Vec<PlannedImport>) -> Self {
+        let mut resources: Vec<PreviewEntry> = planned
+            .into_iter()
+            .map(|entry| {
+                let redactor = Redactor::new(entry.sensitive_values);
+                PreviewEntry {
+                    address: entry.address,
+                    resource_type: entry.resource_type,
+                    import_id: entry.import_id.map(|id| redactor.redact(&id)),
+                    decision: entry.decision,
+                    reason: entry.reason.map(|reason| redactor.redact(&reason)),
+                    command: entry.command.map(|command| redactor.redact(&command.command_string())),
+                }
+            })
+            .collect();
+        resources.sort_by(|a, b| a.address.cmp(&b.address));
+        Self { resources }
+    }
+
+    /// Returns the number of resources with the given decision
+    pub fn count(&self, decision: ImportDecision) -> usize {
+        self.resources.iter().filter(|entry| entry.decision == decision).count()
+    }
+
+    /// Formats the preview as an aligned table with a header and separator line
+    ///
+    /// Columns are ADDRESS, TYPE, IMPORT ID and DECISION, separated by two spaces;
+    /// a missing import ID is shown as `-`. Lines carry no trailing whitespace.
+    pub fn to_table(&self) -> String {
+        let header = ["ADDRESS", "TYPE", "IMPORT ID", "DECISION"].map(String::from);
+        let rows: Vec<[String; 4]> = self
+            .resources
+            .iter()
+            .map(|entry| {
+                [
+                    entry.address.clone(),
+                    entry.resource_type.clone(),
+                    entry.import_id.clone().unwrap_or_else(|| "-".to_string()),
+                    entry.decision.to_string(),
+                ]
+            })
+            .collect();
+
+        let mut widths = header.each_ref().map(|title| title.chars().count());
+        for row in &rows {
+            for (width, cell) in widths.iter_mut().zip(row) {
+                *width = (*width).max(cell.chars().count());
+            }
+        }
+
+        let format_row = |cells: &[String; 4]| {
+            let line = cells
+                .iter()
+                .zip(widths)
+                .map(|(cell, width)| format!("{:<width$}", cell, width = width))
+                .collect::<Vec<_>>()
+                .join("  ");
+            line.trim_end().to_string()
+        };
+
+        let separator = widths.map(|width| "-".repeat(width));
+        let mut lines = vec![format_row(&header), format_row(&separator)];
+        lines.extend(rows.iter().map(format_row));
+        lines.join("\n") + "\n"
+    }
+
+    /// Serializes the preview as pretty-printed JSON
+    ///
+    /// # Errors
+    /// Returns an error if serialization fails
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).context("Failed to serialize import preview")
+    }
+}
+
+/// Unit tests for preview ordering and formatting
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn planned(address: &str, import_id: Option<&str>, decision: ImportDecision) -> PlannedImport {
+        PlannedImport {
+            address: address.to_string(),
+            resource_type: address.rsplit('.').nth(1).unwrap_or_default().to_string(),
+            import_id: import_id.map(str::to_string),
+            decision,
+            reason: None,
+            command: None,
+            sensitive_values: Vec::new(),
+        }
+    }
+
+    /// **TEST** - Rows are sorted by address and columns aligned without trailing spaces
+    #[test]
+    fn test_table_is_sorted_and_aligned() {
+        let preview = Preview::new(vec![
+            planned("module.storage.google_storage_bucket.example", Some("p/example"), ImportDecision::Import),
+            planned("module.kms.google_kms_key_ring.example", None, ImportDecision::Skip),
+        ]);
+
+        assert_eq!(
+            preview.to_table(),
+            "ADDRESS                                       TYPE                   IMPORT ID  DECISION\n\
+             --------------------------------------------  ---------------------  ---------  --------\n\
+             module.kms.google_kms_key_ring.example        google_kms_key_ring    -          skip\n\
+             module.storage.google_storage_bucket.example  google_storage_bucket  p/example  import\n"
+        );
+    }
+
+    /// **TEST** - JSON uses kebab-case decisions and keeps the sorted order
+    #[test]
+    fn test_json_decisions() {
+        let preview = Preview::new(vec![
+            planned("module.b.aws_vpc.main", Some("vpc-1"), ImportDecision::SkipAlreadyInState),
+            planned("module.a.aws_vpc.main", None, ImportDecision::FilteredOut),
+        ]);
+        let json: serde_json::Value = serde_json::from_str(&preview.to_json().unwrap()).unwrap();
+
+        assert_eq!(json["resources"][0]["address"], "module.a.aws_vpc.main");
+        assert_eq!(json["resources"][0]["decision"], "filtered-out");
+        assert_eq!(json["resources"][1]["decision"], "skip-already-in-state");
+        assert_eq!(json["resources"][1]["import_id"], "vpc-1");
+        assert_eq!(preview.count(ImportDecision::FilteredOut), 1);
+    }
+}
@@ -0,0 +1,255 @@
+//! # Provider Configuration Module
+//!
+//! Resources using a provider alias (`provider = google.europe`) take their defaults,
+//! such as the project and region, from that alias rather than from the default
+//! provider. Those defaults end up in the import ID, so this module resolves which
+//! provider configuration each resource of a plan uses and what it sets.
+//!
+//! ## Key Components
+//!
+//! - **ProviderConfig**: One `provider` block of the configuration and its settings
+//! - **ProviderConfigs**: Every provider block of a plan, looked up by resource
+//!
+//! ## Resolution
+//!
+//! Every resource in the plan's `configuration` names its provider block through
+//! `provider_config_key`, e.g. `google.europe` or `module.kms:google`. A module-scoped
+//! key that has no block of its own is inherited from the calling module, up to the
+//! root module. Only settings that are string constants, or references to a root
+//! input variable from a root module provider block, are resolved; anything computed
+//! is left for the builder to report as missing.
+
+use std::collections::{BTreeMap, HashMap};
+use serde_json::Value;
+use crate::address::ResourceAddress;
+use crate::importer::{PlanFile, Variables};
+
+/// One `provider` block from the plan's configuration
+///
+/// # Fields
+/// - `key`: Key of the block in `configuration.provider_config`, e.g. `google.europe`
+/// - `name`: Provider local name, e.g. `google`
+/// - `alias`: The block's alias, if any
+/// - `module_address`: Module declaring the block, None for the root module
+/// - `settings`: Resolved string settings by argument name, e.g. `project`
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct ProviderConfig {
+    /// Key of the block in `configuration.provider_config`
+    pub key: String,
+    /// Provider local name
+    pub name: String,
+    /// Alias of the block
+    pub alias: Option<String>,
+    /// Module declaring the block, None for the root module
+    pub module_address: Option<String>,
+    /// Resolved string settings by argument name
+    pub settings: BTreeMap<String, String>,
+}
+
+impl ProviderConfig {
+    /// Reads a `configuration.provider_config` entry
+    fn from_json(key: &str, config: &Value, variables: Option<&Variables>) -> Self {
+        let text = |field: &str| config.get(field).and_then(Value::as_str).map(str::to_string);
+        let module_address = text("module_address").filter(|address| !address.is_empty());
+        let mut settings = BTreeMap::new();
+        for (argument, expression) in config.get("expressions").and_then(Value::as_object).into_iter().flatten() {
+            let value = match expression.get("constant_value") {
+                Some(constant) => constant.as_str().map(str::to_string),
+                None if module_address.is_none() => root_variable_reference(expression, variables),
+                None => None,
+            };
+            if let Some(value) = value {
+                settings.insert(argument.clone(), value);
+            }
+        }
+        Self {
+            key: key.to_string(),
+            name: text("name").unwrap_or_else(|| key.split(['.', ':']).next().unwrap_or_default().to_string()),
+            alias: text("alias"),
+            module_address,
+            settings,
+        }
+    }
+
+    /// Returns the resolved value of `setting`, e.g. `project`
+    pub fn setting(&self, setting: &str) -> Option<&str> {
+        self.settings.get(setting).map(String::as_str)
+    }
+
+    /// Returns the provider reference as written in configuration, e.g. `google.europe`
+    pub fn display_name(&self) -> String {
+        match &self.alias {
+            Some(alias) => format!("{}.{}", self.name, alias),
+            None => self.name.clone(),
+        }
+    }
+}
+
+/// Resolves an expression that is exactly one `var.<name>` reference
+fn root_variable_reference(expression: &Value, variables: Option<&Variables>) -> Option<String> {
+    let references = expression.get("references").and_then(Value::as_array)?;
+    let [reference] = references.as_slice() else { return None };
+    let name = reference.as_str()?.strip_prefix("var.")?;
+    variables?.value(name).map(str::to_string)
+}
+
+/// Splits a provider config key into its module address and provider part
+///
+/// `module.kms:google.europe` gives `(Some("module.kms"), "google.europe")`.
+fn split_key(key: &str) -> (Option<&str>, &str) {
+    match key.rsplit_once(':') {
+        Some((module, provider)) => (Some(module), provider),
+        None => (None, key),
+    }
+}
+
+/// The provider blocks of a plan and the block each resource uses
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::address::ResourceAddress;
+/// use terragrunt_import_from_plan::providers::ProviderConfigs;
+///
+/// let plan = serde_json::from_value(serde_json::json!({
+///     "format_version": "1.2",
+///     "terraform_version": "1.9.0",
+///     "configuration": {
+///         "provider_config": {
+///             "google": {"name": "google", "expressions": {"project": {"constant_value": "main-project"}}},
+///             "google.europe": {"name": "google", "alias": "europe", "expressions": {"project": {"constant_value": "eu-project"}}}
+///         },
+///         "root_module": {"module_calls": {"kms": {"module": {"resources": [
+///             {"address": "google_kms_key_ring.eu", "provider_config_key": "google.europe"},
+///             {"address": "google_kms_key_ring.main", "provider_config_key": "module.kms:google"}
+///         ]}}}}
+///     }
+/// })).unwrap();
+///
+/// let providers = ProviderConfigs::from_plan(&plan);
+/// let eu: ResourceAddress = "module.kms.google_kms_key_ring.eu".parse().unwrap();
+/// assert_eq!(providers.for_resource(&eu).unwrap().setting("project"), Some("eu-project"));
+/// let main: ResourceAddress = "module.kms.google_kms_key_ring.main".parse().unwrap();
+/// assert_eq!(providers.for_resource(&main).unwrap().display_name(), "google");
+/// ```
+#[derive(Debug, Clone, Default)]
+pub struct ProviderConfigs {
+    configs: HashMap<String, ProviderConfig>,
+    resource_keys: HashMap<String, String>,
+}
+
+impl ProviderConfigs {
+    /// Reads the provider blocks and resource provider keys of `plan`'s configuration
+    ///
+    /// A plan without a configuration yields no provider configs.
+    pub fn from_plan(plan: &PlanFile) -> Self {
+        let mut providers = Self::default();
+        let Some(configuration) = &plan.configuration else { return providers };
+
+        for (key, config) in configuration.get("provider_config").and_then(Value::as_object).into_iter().flatten() {
+            providers.configs.insert(key.clone(), ProviderConfig::from_json(key, config, plan.variables.as_ref()));
+        }
+        if let Some(root_module) = configuration.get("root_module") {
+            collect_provider_keys(root_module, "", &mut providers.resource_keys);
+        }
+        providers
+    }
+
+    /// Returns the provider block with `key`, inheriting module-scoped keys
+    ///
+    /// `module.a.module.b:google` falls back to `module.a:google`, then `google`.
+    pub fn get(&self, key: &str) -> Option<&ProviderConfig> {
+        let (mut module, provider) = split_key(key);
+        loop {
+            let candidate = match module {
+                Some(module) => format!("{}:{}", module, provider),
+                None => provider.to_string(),
+            };
+            if let Some(config) = self.configs.get(&candidate) {
+                return Some(config);
+            }
+            module = module?.rsplit_once(".module.").map(|(parent, _)| parent);
+        }
+    }
+
+    /// Returns the provider block used by the resource at `address`
+    ///
+    /// # Returns
+    /// The block, or None if the configuration doesn't name one or it isn't declared
+    pub fn for_resource(&self, address: &ResourceAddress) -> Option<&ProviderConfig> {
+        self.resource_keys.get(&address.config_address()).and_then(|key| self.get(key))
+    }
+}
+
+/// Records the `provider_config_key` of every resource in a configuration module
+fn collect_provider_keys(module: &Value, scope: &str, keys: &mut HashMap<String, String>) {
+    for resource in module.get("resources").and_then(Value::as_array).into_iter().flatten() {
+        let Some(address) = resource.get("address").and_then(Value::as_str) else { continue };
+        let Some(key) = resource.get("provider_config_key").and_then(Value::as_str) else { continue };
+        keys.insert(format!("{}{}", scope, address), key.to_string());
+    }
+
+    for (name, call) in module.get("module_calls").and_then(Value::as_object).into_iter().flatten() {
+        if let Some(child) = call.get("module") {
+            collect_provider_keys(child, &format!("{}module.{}.", scope, name), keys);
+        }
+    }
+}
+
+/// Unit tests for provider config resolution
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn plan(configuration: Value, variables: Value) -> PlanFile {
+        serde_json::from_value(json!({
+            "format_version": "1.2",
+            "terraform_version": "1.9.8",
+            "variables": variables,
+            "configuration": configuration,
+        }))
+        .unwrap()
+    }
+
+    /// **TEST** - Module-scoped keys inherit the closest declared block
+    #[test]
+    fn test_module_scoped_keys_are_inherited() {
+        let providers = ProviderConfigs::from_plan(&plan(
+            json!({"provider_config": {
+                "google": {"name": "google", "expressions": {"region": {"constant_value": "us-central1"}}},
+                "module.app:google": {"name": "google", "module_address": "module.app", "expressions": {"region": {"constant_value": "europe-west1"}}}
+            }}),
+            json!({}),
+        ));
+        assert_eq!(providers.get("module.app.module.db:google").unwrap().key, "module.app:google");
+        assert_eq!(providers.get("module.net:google").unwrap().key, "google");
+        assert_eq!(providers.get("module.app:google").unwrap().setting("region"), Some("europe-west1"));
+        assert!(providers.get("module.app:google.europe").is_none());
+        assert!(providers.get("aws").is_none());
+    }
+
+    /// **TEST** - Root blocks resolve `var.` references; module blocks and computed values don't
+    #[test]
+    fn test_settings_resolve_constants_and_root_variables() {
+        let providers = ProviderConfigs::from_plan(&plan(
+            json!({"provider_config": {
+                "google.us": {"name": "google", "alias": "us", "expressions": {
+                    "project": {"references": ["var.us_project"]},
+                    "region": {"references": ["local.region"]},
+                    "zone": {"constant_value": "us-central1-a"}
+                }},
+                "module.app:google": {"name": "google", "module_address": "module.app", "expressions": {
+                    "project": {"references": ["var.us_project"]}
+                }}
+            }}),
+            json!({"us_project": {"value": "sim-us-project"}}),
+        ));
+        let us = providers.get("google.us").unwrap();
+        assert_eq!(us.display_name(), "google.us");
+        assert_eq!(us.settings, BTreeMap::from([
+            ("project".to_string(), "sim-us-project".to_string()),
+            ("zone".to_string(), "us-central1-a".to_string()),
+        ]));
+        assert!(providers.get("module.app:google").unwrap().settings.is_empty());
+    }
+}
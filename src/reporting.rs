@@ -8,6 +8,7 @@
 //! 
 //! - **ImportStats**: Tracks detailed statistics about import operations
 //! - **ImportOperation**: Represents different types of import operations
+//! - **Report**: Machine-readable per-resource results, serializable to JSON for CI
 //! - **Progress Reporting**: Real-time progress updates during import execution
 //! - **Summary Reporting**: Final summaries with detailed statistics
 //! 
@@ -17,6 +18,29 @@
 //! 2. Use print_import_progress() for real-time progress updates
 //! 3. Update statistics as operations complete
 //! 4. Print final summary using print_import_summary() or print_dry_run_summary()
+//! 5. Optionally write the accumulated Report with Report::write_json()
+//! 
+//! ## Exit Codes
+//! 
+//! The CLI exits with the `exit_code` of the aggregated Report:
+//! 
+//! - `EXIT_SUCCESS` (0): Every import succeeded, or there was nothing to import
+//! - `EXIT_IMPORT_FAILURES` (2): At least one import failed, timed out or was cancelled
+//! - `EXIT_USAGE_ERROR` (3): Invalid arguments or unreadable inputs; no report was produced
+
+use std::fs;
+use std::path::Path;
+use anyhow::{Context, Result};
+use serde::{Deserialize, Serialize};
+
+/// Exit status when every import succeeded or there was nothing to import
+pub const EXIT_SUCCESS: i32 = 0;
+
+/// Exit status when at least one import failed, timed out or was cancelled
+pub const EXIT_IMPORT_FAILURES: i32 = 2;
+
+/// Exit status for invalid arguments or input files the run couldn't start from
+pub const EXIT_USAGE_ERROR: i32 = 3;
 
 /// Import statistics for tracking the results of import operations
 /// 
@@ -27,21 +51,32 @@
 /// # Fields
 /// - `imported`: Number of resources successfully imported
 /// - `already_in_state`: Number of resources already in terraform state
-/// - `skipped`: Number of resources skipped (e.g., couldn't infer ID)
+/// - `skipped`: Number of resources skipped (e.g., no module mapping)
+/// - `unsupported`: Number of resources whose type has no import ID builder
 /// - `failed`: Number of resources that failed to import
+/// - `cancelled`: Number of imports interrupted because the run was cancelled or timed out
 /// - `imported_resources`: Detailed list of successfully imported resource addresses
+/// - `unsupported_types`: Sorted, de-duplicated resource types without a builder
 #[derive(Debug, Default, Clone)]
 pub struct ImportStats {
     /// Count of resources successfully imported
     pub imported: usize,
+    /// Count of imported resources that passed verification, included in `imported`
+    pub verified: usize,
     /// Count of resources already present in terraform state
     pub already_in_state: usize,
     /// Count of resources skipped during import process
     pub skipped: usize,
+    /// Count of resources whose type has no import ID builder
+    pub unsupported: usize,
     /// Count of resources that failed to import
     pub failed: usize,
+    /// Count of imports interrupted by cancellation or timeout
+    pub cancelled: usize,
     /// Detailed list of successfully imported resource addresses
     pub imported_resources: Vec<String>,
+    /// Resource types without an import ID builder, sorted and de-duplicated
+    pub unsupported_types: Vec<String>,
 }
 
 impl ImportStats {
@@ -83,6 +118,24 @@ impl ImportStats {
         self.imported_resources.push(resource_address);
     }
 
+    /// Increments the verified counter
+    /// 
+    /// This method should be called, in addition to `increment_imported`, when an
+    /// imported resource passed verification.
+    /// 
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::reporting::ImportStats;
+    /// 
+    /// let mut stats = ImportStats::new();
+    /// stats.increment_imported("module.vpc.aws_vpc.main".to_string());
+    /// stats.increment_verified();
+    /// assert_eq!((stats.imported, stats.verified), (1, 1));
+    /// ```
+    pub fn increment_verified(&mut self) {
+        self.verified += 1;
+    }
+
     /// Increments the skipped counter
     /// 
     /// This method should be called when a resource is skipped during the import
@@ -101,6 +154,31 @@ impl ImportStats {
         self.skipped += 1;
     }
 
+    /// Increments the unsupported counter and records the resource type
+    /// 
+    /// This method should be called when no import ID could be built for a resource
+    /// because its type has no builder and heuristic inference failed.
+    /// 
+    /// # Arguments
+    /// * `resource_type` - Terraform resource type without a builder
+    /// 
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::reporting::ImportStats;
+    /// 
+    /// let mut stats = ImportStats::new();
+    /// stats.increment_unsupported("google_foo".to_string());
+    /// stats.increment_unsupported("google_foo".to_string());
+    /// assert_eq!(stats.unsupported, 2);
+    /// assert_eq!(stats.unsupported_types, vec!["google_foo"]);
+    /// ```
+    pub fn increment_unsupported(&mut self, resource_type: String) {
+        self.unsupported += 1;
+        if let Err(position) = self.unsupported_types.binary_search(&resource_type) {
+            self.unsupported_types.insert(position, resource_type);
+        }
+    }
+
     /// Increments the failed counter
     /// 
     /// This method should be called when a resource import attempt fails due to
@@ -135,13 +213,30 @@ impl ImportStats {
         self.already_in_state += 1;
     }
 
+    /// Increments the cancelled counter
+    /// 
+    /// This method should be called when an import was killed or never started
+    /// because the run was cancelled or hit its timeout.
+    /// 
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::reporting::ImportStats;
+    /// 
+    /// let mut stats = ImportStats::new();
+    /// stats.increment_cancelled();
+    /// assert_eq!(stats.cancelled, 1);
+    /// ```
+    pub fn increment_cancelled(&mut self) {
+        self.cancelled += 1;
+    }
+
     /// Calculates the total number of resources processed across all categories
     /// 
     /// This provides a convenient way to get the total number of resources that
     /// were examined during the import process, regardless of their final status.
     /// 
     /// # Returns
-    /// Sum of imported, already_in_state, skipped, and failed counts
+    /// Sum of imported, already_in_state, skipped, unsupported, failed, and cancelled counts
     /// 
     /// # Examples
     /// ```
@@ -153,10 +248,285 @@ impl ImportStats {
     /// assert_eq!(stats.total_processed(), 2);
     /// ```
     pub fn total_processed(&self) -> usize {
-        self.imported + self.already_in_state + self.skipped + self.failed
+        self.imported + self.already_in_state + self.skipped + self.unsupported + self.failed + self.cancelled
+    }
+}
+
+/// Outcome of a single resource in a Report
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum ImportStatus {
+    /// Resource was imported
+    Success,
+    /// Resource was imported and verification confirmed it is managed with no pending create
+    Verified,
+    /// Import was attempted and failed
+    Failed,
+    /// Resource wasn't imported (no module mapping, no ID, cancelled, ...)
+    Skipped,
+    /// Resource was already present in terraform state
+    AlreadyInState,
+    /// No import ID builder exists for the resource type
+    Unsupported,
+    /// Dry-run mode: the import command was generated but not run
+    DryRun,
+    /// Import was killed or never started because the run was cancelled or timed out
+    Cancelled,
+    /// Import was killed because it exceeded the per-import timeout; counted as failed
+    TimedOut,
+}
+
+/// One resource's entry in a Report
+/// 
+/// # Fields
+/// - `address`: Full terraform resource address
+/// - `import_id`: Cloud resource ID used (or that would be used) for the import
+/// - `status`: Outcome for this resource
+/// - `error`: Failure or skip reason, if any
+/// - `duration_ms`: Time spent running the import, for attempted imports
+/// - `command`: The fully-formed import command, when one was generated
+/// - `output`: What a failed import printed, stdout then stderr, possibly truncated
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct ReportEntry {
+    /// Full terraform resource address
+    pub address: String,
+    /// Cloud resource ID used for the import
+    pub import_id: Option<String>,
+    /// Outcome for this resource
+    pub status: ImportStatus,
+    /// Failure or skip reason
+    pub error: Option<String>,
+    /// Time spent running the import in milliseconds
+    pub duration_ms: Option<u128>,
+    /// The fully-formed import command
+    pub command: Option<String>,
+    /// Captured output of a failed import
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub output: Option<String>,
+}
+
+/// Machine-readable results of an import run
+/// 
+/// Counts and overall status are kept up to date as entries are recorded, so the
+/// report can be serialized at any point. `success` is false and `exit_code` is
+/// `EXIT_IMPORT_FAILURES` if any import failed or was cancelled; unsupported resources are counted but don't
+/// affect either.
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::reporting::{ImportStatus, Report, ReportEntry, EXIT_IMPORT_FAILURES};
+/// 
+/// let mut report = Report::new();
+/// report.record(ReportEntry {
+///     address: "aws_vpc.main".to_string(),
+///     import_id: Some("vpc-12345".to_string()),
+///     status: ImportStatus::Failed,
+///     error: Some("Import failed with exit code 1".to_string()),
+///     duration_ms: Some(1200),
+///     command: None,
+///     output: None,
+/// });
+/// assert_eq!(report.failed, 1);
+/// assert!(!report.success);
+/// assert_eq!(report.exit_code, EXIT_IMPORT_FAILURES);
+/// ```
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct Report {
+    /// True if no import failed or was cancelled
+    pub success: bool,
+    /// Process exit status corresponding to `success`
+    pub exit_code: i32,
+    /// Number of resources in the report
+    pub total: usize,
+    /// Number of resources imported (or, in dry-run mode, that would be imported)
+    pub imported: usize,
+    /// Number of imported resources that passed verification, included in `imported`
+    pub verified: usize,
+    /// Number of resources already present in state
+    pub already_in_state: usize,
+    /// Number of resources skipped
+    pub skipped: usize,
+    /// Number of resources whose type has no import ID builder
+    pub unsupported: usize,
+    /// Number of failed imports, including timed-out ones
+    pub failed: usize,
+    /// Number of imports interrupted by cancellation or timeout
+    pub cancelled: usize,
+    /// Program and version that ran the imports, e.g. `terragrunt 0.54.8`, when checked
+    pub tool_version: Option<String>,
+    /// Workspace the imports ran in, when one was requested
+    pub workspace: Option<String>,
+    /// Per-resource results in processing order
+    pub resources: Vec<ReportEntry>,
+}
+
+impl Default for Report {
+    fn default() -> Self {
+        Self::new()
     }
 }
 
+impl Report {
+    /// Creates an empty, successful report
+    pub fn new() -> Self {
+        Self {
+            success: true,
+            exit_code: EXIT_SUCCESS,
+            total: 0,
+            imported: 0,
+            verified: 0,
+            already_in_state: 0,
+            skipped: 0,
+            unsupported: 0,
+            failed: 0,
+            cancelled: 0,
+            tool_version: None,
+            workspace: None,
+            resources: Vec::new(),
+        }
+    }
+
+    /// Adds a resource's result and updates the counts and overall status
+    pub fn record(&mut self, entry: ReportEntry) {
+        match entry.status {
+            ImportStatus::Success | ImportStatus::DryRun => self.imported += 1,
+            ImportStatus::Verified => {
+                self.imported += 1;
+                self.verified += 1;
+            }
+            ImportStatus::AlreadyInState => self.already_in_state += 1,
+            ImportStatus::Skipped => self.skipped += 1,
+            ImportStatus::Unsupported => self.unsupported += 1,
+            ImportStatus::Failed | ImportStatus::TimedOut => self.failed += 1,
+            ImportStatus::Cancelled => self.cancelled += 1,
+        }
+        self.total += 1;
+        self.success = self.failed == 0 && self.cancelled == 0;
+        self.exit_code = if self.success { EXIT_SUCCESS } else { EXIT_IMPORT_FAILURES };
+        self.resources.push(entry);
+    }
+
+    /// Returns the import commands that were run (or would have been run), in order
+    pub fn commands(&self) -> Vec<String> {
+        self.resources
+            .iter()
+            .filter(|entry| {
+                matches!(entry.status, ImportStatus::Success | ImportStatus::Verified | ImportStatus::Failed | ImportStatus::TimedOut | ImportStatus::DryRun)
+            })
+            .filter_map(|entry| entry.command.clone())
+            .collect()
+    }
+
+    /// Serializes the report as pretty-printed JSON
+    /// 
+    /// # Errors
+    /// Returns an error if serialization fails
+    pub fn to_json(&self) -> Result<String> {
+        serde_json::to_string_pretty(self).context("Failed to serialize import report")
+    }
+
+    /// Writes the report as JSON to `path`
+    /// 
+    /// # Errors
+    /// Returns an error if the file can't be written
+    pub fn write_json(&self, path: &Path) -> Result<()> {
+        let json = self.to_json()?;
+        fs::write(path, json)
+            .with_context(|| format!("Failed to write import report to {}", path.display()))
+    }
+
+    /// Formats the end-of-run summary table
+    /// 
+    /// The first table has one row per status group with its count: imported (including
+    /// verified and dry-run), already in state, skipped, unsupported and failed (including
+    /// timed out), plus cancelled when any import was. If anything failed, a second table
+    /// lists each failed address with the first line of its error, cut to
+    /// `SUMMARY_ERROR_WIDTH` characters. Columns are aligned with spaces.
+    /// 
+    /// # Examples
+    /// ```
+    /// use terragrunt_import_from_plan::reporting::{ImportStatus, Report, ReportEntry};
+    /// 
+    /// let mut report = Report::new();
+    /// report.record(ReportEntry {
+    ///     address: "aws_vpc.main".to_string(),
+    ///     import_id: Some("vpc-12345".to_string()),
+    ///     status: ImportStatus::Failed,
+    ///     error: Some("Import failed with exit code 1\nError: Cannot import non-existent remote object".to_string()),
+    ///     duration_ms: Some(1200),
+    ///     command: None,
+    ///     output: None,
+    /// });
+    /// let table = report.summary_table();
+    /// assert!(table.contains("failed            1\n"));
+    /// assert!(table.contains("aws_vpc.main    Import failed with exit code 1\n"));
+    /// ```
+    pub fn summary_table(&self) -> String {
+        let mut counts = vec![
+            vec!["STATUS".to_string(), "COUNT".to_string()],
+            vec!["imported".to_string(), self.imported.to_string()],
+            vec!["already in state".to_string(), self.already_in_state.to_string()],
+            vec!["skipped".to_string(), self.skipped.to_string()],
+            vec!["unsupported".to_string(), self.unsupported.to_string()],
+            vec!["failed".to_string(), self.failed.to_string()],
+        ];
+        if self.cancelled > 0 {
+            counts.push(vec!["cancelled".to_string(), self.cancelled.to_string()]);
+        }
+        let mut table = format_table(&counts);
+
+        let failures: Vec<Vec<String>> = self
+            .resources
+            .iter()
+            .filter(|entry| matches!(entry.status, ImportStatus::Failed | ImportStatus::TimedOut))
+            .map(|entry| vec![entry.address.clone(), one_line(entry.error.as_deref().unwrap_or_default(), SUMMARY_ERROR_WIDTH)])
+            .collect();
+        if !failures.is_empty() {
+            let mut rows = vec![vec!["FAILED ADDRESS".to_string(), "ERROR".to_string()]];
+            rows.extend(failures);
+            table.push('\n');
+            table.push_str(&format_table(&rows));
+        }
+        table
+    }
+}
+
+/// Longest error shown per failed address in `Report::summary_table`
+pub const SUMMARY_ERROR_WIDTH: usize = 120;
+
+/// Returns the first non-empty line of `text`, cut to `width` characters with `...`
+fn one_line(text: &str, width: usize) -> String {
+    let line = text.lines().map(str::trim).find(|line| !line.is_empty()).unwrap_or_default();
+    if line.chars().count() <= width {
+        return line.to_string();
+    }
+    let cut: String = line.chars().take(width.saturating_sub(3)).collect();
+    format!("{}...", cut.trim_end())
+}
+
+/// Formats `rows` as lines of space-aligned columns; the last column isn't padded
+fn format_table(rows: &[Vec<String>]) -> String {
+    let columns = rows.iter().map(Vec::len).max().unwrap_or(0);
+    let widths: Vec<usize> = (0..columns)
+        .map(|column| rows.iter().filter_map(|row| row.get(column)).map(|cell| cell.chars().count()).max().unwrap_or(0))
+        .collect();
+
+    let mut table = String::new();
+    for row in rows {
+        let mut line = String::new();
+        for (column, cell) in row.iter().enumerate() {
+            if column + 1 < row.len() {
+                line.push_str(&format!("{:<width$}  ", cell, width = widths[column]));
+            } else {
+                line.push_str(cell);
+            }
+        }
+        table.push_str(line.trim_end());
+        table.push('\n');
+    }
+    table
+}
+
 /// Prints a comprehensive import summary with detailed statistics
 /// 
 /// This function displays a complete summary of import operations including counts
@@ -167,8 +537,10 @@ impl ImportStats {
 /// * `stats` - ImportStats instance containing the operation results
 /// 
 /// # Output Format
-/// - Import counts by category (imported, already in state, skipped, failed)
+/// - Import counts by category (imported, already in state, skipped, unsupported, failed)
+/// - Count of cancelled imports (if any)
 /// - Detailed list of imported resource addresses (if any)
+/// - Resource types without an import ID builder (if any)
 /// 
 /// # Examples
 /// ```no_run
@@ -180,9 +552,15 @@ impl ImportStats {
 /// ```
 pub fn print_import_summary(stats: &ImportStats) {
     println!(
-        "\n✅ Import Summary\nImported:   {}\nAlready in state: {}\nSkipped:     {}\nFailed:      {}",
-        stats.imported, stats.already_in_state, stats.skipped, stats.failed
+        "\n✅ Import Summary\nImported:   {}\nAlready in state: {}\nSkipped:     {}\nUnsupported: {}\nFailed:      {}",
+        stats.imported, stats.already_in_state, stats.skipped, stats.unsupported, stats.failed
     );
+    if stats.verified > 0 {
+        println!("Verified:    {}", stats.verified);
+    }
+    if stats.cancelled > 0 {
+        println!("Cancelled:   {}", stats.cancelled);
+    }
 
     if !stats.imported_resources.is_empty() {
         println!(" 📦 Imported Resources:");
@@ -190,6 +568,13 @@ pub fn print_import_summary(stats: &ImportStats) {
             println!("{}", resource);
         }
     }
+
+    if !stats.unsupported_types.is_empty() {
+        println!(" ⚠️ Resource types without an import ID builder:");
+        for resource_type in &stats.unsupported_types {
+            println!("{}", resource_type);
+        }
+    }
 }
 
 /// Prints a compact import summary for dry-run mode
@@ -243,7 +628,10 @@ pub fn print_dry_run_summary(stats: &ImportStats) {
 /// - 📦 Importing: Resource import is being attempted
 /// - ✅ Success: Resource was successfully imported
 /// - ⚠️ Skipped: Resource was skipped with reason
+/// - ℹ️ Skipped: Resource is already in terraform state
+/// - ⚠️ Unsupported: No import ID builder for the resource type
 /// - ❌ Failed: Resource import failed with error
+/// - 🛑 Cancelled: Resource import was interrupted by cancellation or timeout
 /// - 🌿 Dry Run: Shows command that would be executed
 /// 
 /// # Examples
@@ -269,9 +657,18 @@ pub fn print_import_progress(resource_address: &str, operation: ImportOperation)
         ImportOperation::Skipped { reason } => {
             println!("⚠️ Skipped {}: {}", resource_address, reason);
         }
+        ImportOperation::AlreadyInState => {
+            println!("ℹ️ Skipped {}: already in state", resource_address);
+        }
+        ImportOperation::Unsupported { resource_type } => {
+            println!("⚠️ Unsupported {}: no import ID builder for {}", resource_address, resource_type);
+        }
         ImportOperation::Failed { error } => {
             eprintln!("❌ Error importing {}: {}", resource_address, error);
         }
+        ImportOperation::Cancelled => {
+            eprintln!("🛑 Cancelled import of {}", resource_address);
+        }
         ImportOperation::DryRun { command } => {
             println!("🌿 [DRY RUN] {}", command);
         }
@@ -288,7 +685,10 @@ pub fn print_import_progress(resource_address: &str, operation: ImportOperation)
 /// - `Importing`: Resource import is being attempted with specific ID
 /// - `Success`: Resource was successfully imported
 /// - `Skipped`: Resource was skipped with a reason
+/// - `AlreadyInState`: Resource was skipped because it is already in terraform state
+/// - `Unsupported`: Resource type has no import ID builder
 /// - `Failed`: Resource import failed with error details
+/// - `Cancelled`: Resource import was interrupted because the run was cancelled or timed out
 /// - `DryRun`: Dry-run mode showing the command that would be executed
 pub enum ImportOperation {
     /// Resource is being analyzed for import eligibility
@@ -305,11 +705,20 @@ pub enum ImportOperation {
         /// Human-readable reason why the resource was skipped
         reason: String 
     },
+    /// Resource is already managed in terraform state and won't be imported again
+    AlreadyInState,
+    /// Resource type has no import ID builder
+    Unsupported { 
+        /// Terraform resource type without a builder
+        resource_type: String 
+    },
     /// Resource import failed
     Failed { 
         /// Error message describing why the import failed
         error: String 
     },
+    /// Resource import was interrupted by cancellation or timeout
+    Cancelled,
     /// Dry-run mode showing command without execution
     DryRun { 
         /// Full terragrunt import command that would be executed
@@ -325,6 +734,89 @@ pub enum ImportOperation {
 mod tests {
     use super::*;
 
+    /// **TEST** - Verifies Report counts, overall status and JSON shape
+    #[test]
+    fn test_report_records_and_serializes() {
+        let mut report = Report::new();
+        report.record(ReportEntry {
+            address: "aws_vpc.main".to_string(),
+            import_id: Some("vpc-1".to_string()),
+            status: ImportStatus::Success,
+            error: None,
+            duration_ms: Some(10),
+            command: Some("terragrunt import aws_vpc.main vpc-1".to_string()),
+            output: None,
+        });
+        report.record(ReportEntry {
+            address: "aws_s3_bucket.logs".to_string(),
+            import_id: None,
+            status: ImportStatus::Skipped,
+            error: Some("no ID could be inferred".to_string()),
+            duration_ms: None,
+            command: None,
+            output: None,
+        });
+        assert!(report.success);
+        assert_eq!((report.total, report.imported, report.skipped), (2, 1, 1));
+        assert_eq!(report.commands(), vec!["terragrunt import aws_vpc.main vpc-1"]);
+
+        let json: serde_json::Value = serde_json::from_str(&report.to_json().unwrap()).unwrap();
+        assert_eq!(json["exit_code"], 0);
+        assert_eq!(json["resources"][1]["status"], "skipped");
+        assert_eq!(json["resources"][0]["import_id"], "vpc-1");
+    }
+
+    /// **TEST** - Verifies unsupported resources are counted without failing the report
+    #[test]
+    fn test_report_counts_unsupported() {
+        let mut report = Report::new();
+        report.record(ReportEntry {
+            address: "google_foo.bar".to_string(),
+            import_id: None,
+            status: ImportStatus::Unsupported,
+            error: Some("no import id builder for resource type 'google_foo'".to_string()),
+            duration_ms: None,
+            command: None,
+            output: None,
+        });
+        assert!(report.success);
+        assert_eq!((report.total, report.unsupported, report.skipped), (1, 1, 0));
+        assert!(report.commands().is_empty());
+
+        let json: serde_json::Value = serde_json::from_str(&report.to_json().unwrap()).unwrap();
+        assert_eq!(json["resources"][0]["status"], "unsupported");
+        assert_eq!(json["unsupported"], 1);
+    }
+
+    /// **TEST** - The summary table aligns its columns and lists failures on one line each
+    #[test]
+    fn test_summary_table() {
+        let mut report = Report::new();
+        for (address, status, error) in [
+            ("module.kms.google_kms_key_ring.example", ImportStatus::Verified, None),
+            ("module.kms.google_kms_crypto_key.example", ImportStatus::TimedOut, Some("Import timed out after 30s".to_string())),
+            ("google_storage_bucket.logs", ImportStatus::Failed, Some(format!("\nError: {}\nsecond line", "x".repeat(200)))),
+            ("google_foo.bar", ImportStatus::Unsupported, None),
+        ] {
+            report.record(ReportEntry { address: address.to_string(), import_id: None, status, error, duration_ms: None, command: None, output: None });
+        }
+
+        let expected = format!(
+            "STATUS            COUNT\n\
+             imported          1\n\
+             already in state  0\n\
+             skipped           0\n\
+             unsupported       1\n\
+             failed            2\n\
+             \n\
+             FAILED ADDRESS                            ERROR\n\
+             module.kms.google_kms_crypto_key.example  Import timed out after 30s\n\
+             google_storage_bucket.logs                Error: {}...\n",
+            "x".repeat(SUMMARY_ERROR_WIDTH - 10)
+        );
+        assert_eq!(report.summary_table(), expected);
+    }
+
     /// **TEST** - Verifies ImportStats creation with default values
     #[test]
     fn test_import_stats_creation() {
@@ -407,6 +899,7 @@ mod tests {
         print_import_progress("test.resource", ImportOperation::Importing { id: "test-id".to_string() });
         print_import_progress("test.resource", ImportOperation::Success);
         print_import_progress("test.resource", ImportOperation::Skipped { reason: "no ID found".to_string() });
+        print_import_progress("test.resource", ImportOperation::Unsupported { resource_type: "test".to_string() });
         print_import_progress("test.resource", ImportOperation::Failed { error: "network error".to_string() });
         print_import_progress("test.resource", ImportOperation::DryRun { command: "terragrunt import test.resource test-id".to_string() });
     }
@@ -243,6 +243,8 @@ impl SchemaManager {
 ///     variables: None,
 ///     planned_values: None,
 ///     provider_schemas: None,
+///     resource_changes: None,
+///     configuration: None,
 /// };
 /// let schema_map = SchemaManager::extract_schema_map_from_plan(&plan);
 /// println!("Extracted {} resource schemas", schema_map.len());
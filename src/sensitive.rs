@@ -0,0 +1,194 @@
+//! # Sensitive Value Redaction Module
+//!
+//! Terraform marks sensitive attributes in a plan with `after_sensitive` (on the
+//! resource change) and `sensitive_values` (on the planned resource). When an import ID
+//! is built from such an attribute, the real value is still needed for the import
+//! itself, but it must not end up in plaintext in logs, progress output or reports.
+//!
+//! ## Key Components
+//!
+//! - **sensitive_values**: Collects the values a sensitivity mask flags
+//! - **Redactor**: Replaces every known sensitive value in a text with `***`
+//! - **RedactingLogger**: Logger adapter that redacts each message before forwarding it
+//!
+//! ## Masks
+//!
+//! A mask mirrors the shape of the values: `true` marks the value at that position,
+//! including everything nested beneath it, as sensitive; objects and arrays of masks
+//! apply element-wise. Only non-empty strings and numbers are collected, since those
+//! are what can appear in an import ID.
+
+use serde_json::Value;
+use crate::logging::{LogLevel, Logger};
+
+/// Placeholder shown instead of a sensitive value
+pub const REDACTED: &str = "***";
+
+/// Returns the values in `values` that `mask` flags as sensitive
+///
+/// # Examples
+/// ```
+/// use serde_json::json;
+/// use terragrunt_import_from_plan::sensitive::sensitive_values;
+///
+/// let values = json!({"name": "db", "password": "hunter2", "tags": {"owner": "ops"}});
+/// let mask = json!({"password": true, "tags": {"owner": false}});
+/// assert_eq!(sensitive_values(&values, &mask), vec!["hunter2".to_string()]);
+/// ```
+pub fn sensitive_values(values: &Value, mask: &Value) -> Vec<String> {
+    let mut found = Vec::new();
+    collect(values, mask, &mut found);
+    found
+}
+
+/// Appends the flagged values of `values` to `found`
+fn collect(values: &Value, mask: &Value, found: &mut Vec<String>) {
+    match (mask, values) {
+        (Value::Bool(true), _) => collect_all(values, found),
+        (Value::Object(mask), Value::Object(values)) => {
+            for (key, mask) in mask {
+                if let Some(value) = values.get(key) {
+                    collect(value, mask, found);
+                }
+            }
+        }
+        (Value::Array(mask), Value::Array(values)) => {
+            for (value, mask) in values.iter().zip(mask) {
+                collect(value, mask, found);
+            }
+        }
+        _ => {}
+    }
+}
+
+/// Appends every string and number in `values` to `found`
+fn collect_all(values: &Value, found: &mut Vec<String>) {
+    match values {
+        Value::String(value) if !value.is_empty() => found.push(value.clone()),
+        Value::Number(value) => found.push(value.to_string()),
+        Value::Array(values) => values.iter().for_each(|value| collect_all(value, found)),
+        Value::Object(values) => values.values().for_each(|value| collect_all(value, found)),
+        _ => {}
+    }
+}
+
+/// Replaces known sensitive values in text with `REDACTED`
+///
+/// Longer values are replaced first, so a secret containing another secret is masked
+/// as a whole. The default redactor knows no values and returns text unchanged.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::sensitive::Redactor;
+///
+/// let redactor = Redactor::new(["s3cr3t".to_string()]);
+/// assert_eq!(redactor.redact("projects/p/secrets/s3cr3t"), "projects/p/secrets/***");
+/// assert!(Redactor::default().is_empty());
+/// ```
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct Redactor {
+    values: Vec<String>,
+}
+
+impl Redactor {
+    /// Creates a redactor for `values`, ignoring empty strings and duplicates
+    pub fn new<I: IntoIterator<Item = String>>(values: I) -> Self {
+        let mut redactor = Self::default();
+        redactor.extend(values);
+        redactor
+    }
+
+    /// Adds more values to redact
+    pub fn extend<I: IntoIterator<Item = String>>(&mut self, values: I) {
+        self.values.extend(values.into_iter().filter(|value| !value.is_empty()));
+        self.values.sort_by(|a, b| b.len().cmp(&a.len()).then_with(|| a.cmp(b)));
+        self.values.dedup();
+    }
+
+    /// Returns true if there is nothing to redact
+    pub fn is_empty(&self) -> bool {
+        self.values.is_empty()
+    }
+
+    /// Returns `text` with every sensitive value replaced by `REDACTED`
+    pub fn redact(&self, text: &str) -> String {
+        self.values
+            .iter()
+            .fold(text.to_string(), |text, value| text.replace(value.as_str(), REDACTED))
+    }
+}
+
+/// Logger that redacts every message before passing it to `inner`
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::logging::{LogLevel, Logger, StdLogger};
+/// use terragrunt_import_from_plan::sensitive::{RedactingLogger, Redactor};
+///
+/// let redactor = Redactor::new(["hunter2".to_string()]);
+/// let inner = StdLogger::new(LogLevel::Debug);
+/// RedactingLogger::new(&inner, &redactor).debug("Built import ID: db/hunter2");
+/// ```
+pub struct RedactingLogger<'a> {
+    inner: &'a dyn Logger,
+    redactor: &'a Redactor,
+}
+
+impl<'a> RedactingLogger<'a> {
+    /// Wraps `inner` so that values known to `redactor` never reach it
+    pub fn new(inner: &'a dyn Logger, redactor: &'a Redactor) -> Self {
+        Self { inner, redactor }
+    }
+}
+
+impl Logger for RedactingLogger<'_> {
+    fn log(&self, level: LogLevel, message: &str) {
+        self.inner.log(level, &self.redactor.redact(message));
+    }
+}
+
+/// Unit tests for sensitive value collection and redaction
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+    use std::sync::Mutex;
+
+    /// **TEST** - Masks apply recursively, and `true` covers everything beneath it
+    #[test]
+    fn test_sensitive_values_follow_mask() {
+        let values = json!({
+            "name": "db",
+            "port": 5432,
+            "credentials": {"user": "admin", "password": "hunter2"},
+            "keys": ["k1", "k2"],
+            "empty": ""
+        });
+        let mask = json!({"port": true, "credentials": {"password": true}, "keys": [false, true], "empty": true, "missing": true});
+
+        let mut found = sensitive_values(&values, &mask);
+        found.sort();
+        assert_eq!(found, ["5432", "hunter2", "k2"]);
+        assert_eq!(sensitive_values(&values, &json!(true)).len(), 6);
+        assert!(sensitive_values(&values, &json!(false)).is_empty());
+    }
+
+    /// **TEST** - Overlapping values are redacted longest first and the logger forwards redacted text
+    #[test]
+    fn test_redactor_and_logger() {
+        let redactor = Redactor::new(["abc".to_string(), "abcdef".to_string(), String::new(), "abc".to_string()]);
+        assert_eq!(redactor.redact("id/abcdef/abc"), "id/***/***");
+        assert_eq!(Redactor::default().redact("id/abcdef"), "id/abcdef");
+
+        #[derive(Default)]
+        struct MemoryLogger(Mutex<Vec<String>>);
+        impl Logger for MemoryLogger {
+            fn log(&self, _level: LogLevel, message: &str) {
+                self.0.lock().unwrap().push(message.to_string());
+            }
+        }
+        let inner = MemoryLogger::default();
+        RedactingLogger::new(&inner, &redactor).warn("import of abcdef failed");
+        assert_eq!(inner.0.lock().unwrap()[0], "import of *** failed");
+    }
+}
@@ -0,0 +1,550 @@
+//! # Terraform State Module
+//!
+//! This module inspects existing Terraform state so the importer can skip resources
+//! that are already managed. Re-running the tool after a partial failure would
+//! otherwise fail with "Resource already managed by Terraform" for every resource
+//! that was imported on the previous run.
+//!
+//! ## Key Components
+//!
+//! - **backup_state**: Pulls a module's state into a timestamped backup file
+//! - **StateCache**: Per-directory cache of pulled state, keyed by address, shared by the
+//!   already-in-state check, the fallback for values unknown in the plan and state backups
+//! - **StateError**: Failure modes when reading state
+//!
+//! An import run pulls each module directory's state at most once: remote backends can
+//! take seconds per pull, so every consumer reads the same `StateCache`.
+//!
+//! All state commands go through a `CommandRunner`, so they can be tested with a fake,
+//! and are run with the same `ImportBinary` as the imports (terragrunt by default).
+
+use std::collections::HashMap;
+use std::fs;
+use std::io;
+use std::path::{Path, PathBuf};
+use std::time::{SystemTime, UNIX_EPOCH};
+use serde_json::{Map, Value};
+use thiserror::Error;
+use crate::commands::builder::ImportBinary;
+use crate::commands::runner::CommandRunner;
+
+/// Error types for reading Terraform state
+///
+/// # Variants
+/// - `CommandFailed`: The terragrunt (or terraform) process could not be started
+/// - `NonZeroExit`: The state command ran but reported an error
+/// - `InvalidState`: `state pull` returned something that isn't state JSON
+/// - `BackupWriteFailed`: The backup file could not be written
+#[derive(Error, Debug)]
+pub enum StateError {
+    /// The terragrunt process could not be started
+    #[error("Failed to run {program} state command in {path}: {source}")]
+    CommandFailed {
+        /// Program that was run
+        program: String,
+        /// Directory the command was run in
+        path: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// terragrunt exited with a non-zero code
+    #[error("{program} state {subcommand} failed in {path} with exit code {exit_code}: {stderr}")]
+    NonZeroExit {
+        /// Program that was run
+        program: String,
+        /// State subcommand that failed (e.g. "pull")
+        subcommand: String,
+        /// Directory the command was run in
+        path: String,
+        /// Process exit code (-1 if terminated by a signal)
+        exit_code: i32,
+        /// Captured standard error
+        stderr: String,
+    },
+
+    /// `terragrunt state pull` returned output that isn't valid state JSON
+    #[error("{program} state pull in {path} returned invalid state: {source}")]
+    InvalidState {
+        /// Program that was run
+        program: String,
+        /// Directory the command was run in
+        path: String,
+        /// Underlying parse error
+        #[source]
+        source: serde_json::Error,
+    },
+
+    /// The backup file could not be written
+    #[error("Failed to write state backup {path}: {source}")]
+    BackupWriteFailed {
+        /// Backup file path
+        path: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+}
+
+/// Pulls a module's current state and writes it to a timestamped backup file
+///
+/// Runs `terragrunt state pull` in `working_directory` and writes the output to
+/// `backup_dir/<directory>-<UTC timestamp>.tfstate`, creating `backup_dir` if needed.
+/// The directory part is the working directory path with separators replaced by `_`,
+/// so modules with the same leaf name don't collide. A module without state yet (empty
+/// `state pull` output), as when adopting resources into a new module, has nothing to
+/// back up and gets no file.
+///
+/// # Arguments
+/// * `runner` - Command runner used to invoke terragrunt
+/// * `binary` - Program that manages the module's state
+/// * `working_directory` - Module directory whose state should be backed up
+/// * `backup_dir` - Directory to write the backup into
+///
+/// # Returns
+/// Path of the written backup file, or None if the module has no state yet
+///
+/// # Errors
+/// - `StateError::CommandFailed` / `StateError::NonZeroExit` if the pull fails
+/// - `StateError::BackupWriteFailed` if the backup can't be written
+///
+/// # Examples
+/// ```no_run
+/// use std::path::Path;
+/// use terragrunt_import_from_plan::commands::builder::ImportBinary;
+/// use terragrunt_import_from_plan::commands::runner::SystemCommandRunner;
+/// use terragrunt_import_from_plan::state::backup_state;
+///
+/// match backup_state(&SystemCommandRunner, ImportBinary::Terragrunt, Path::new("modules/kms"), Path::new(".state-backups")).unwrap() {
+///     Some(backup) => println!("State backed up to {}", backup.display()),
+///     None => println!("No state to back up yet"),
+/// }
+/// ```
+pub fn backup_state(
+    runner: &dyn CommandRunner,
+    binary: ImportBinary,
+    working_directory: &Path,
+    backup_dir: &Path,
+) -> Result<Option<PathBuf>, StateError> {
+    let state = run_state_pull(runner, binary, working_directory)?;
+    write_backup(&state, working_directory, backup_dir)
+}
+
+/// Writes pulled `state` to a timestamped backup file for `working_directory`, unless it's empty
+fn write_backup(state: &str, working_directory: &Path, backup_dir: &Path) -> Result<Option<PathBuf>, StateError> {
+    if state.trim().is_empty() {
+        return Ok(None);
+    }
+    let backup_file = backup_dir.join(format!(
+        "{}-{}.tfstate",
+        backup_file_stem(working_directory),
+        utc_timestamp(SystemTime::now())
+    ));
+    fs::create_dir_all(backup_dir)
+        .and_then(|_| fs::write(&backup_file, state))
+        .map_err(|source| StateError::BackupWriteFailed {
+            path: backup_file.display().to_string(),
+            source,
+        })?;
+
+    Ok(Some(backup_file))
+}
+
+/// Runs `state pull` in `working_directory` and returns its output, which is empty for
+/// a module that has no state yet
+fn run_state_pull(runner: &dyn CommandRunner, binary: ImportBinary, working_directory: &Path) -> Result<String, StateError> {
+    let program = binary.program().to_string();
+    let path = working_directory.display().to_string();
+    let output = runner
+        .run(&program, &["state", "pull"], working_directory)
+        .map_err(|source| StateError::CommandFailed { program: program.clone(), path: path.clone(), source })?;
+
+    if !output.success() {
+        return Err(StateError::NonZeroExit {
+            program,
+            subcommand: "pull".to_string(),
+            path,
+            exit_code: output.exit_code.unwrap_or(-1),
+            stderr: output.stderr.trim().to_string(),
+        });
+    }
+    Ok(output.stdout)
+}
+
+/// Indexes the attributes of every resource instance in a pulled state by address
+///
+/// Addresses are rebuilt from each resource's `module`, `mode`, `type` and `name` and
+/// each instance's `index_key`, so they match the addresses in a plan, e.g.
+/// `module.kms.google_kms_crypto_key.this["primary"]`.
+///
+/// # Errors
+/// Returns the parse error if `state` isn't JSON
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::state::parse_state_resources;
+///
+/// let state = r#"{"version": 4, "resources": [{
+///     "module": "module.storage", "mode": "managed", "type": "google_storage_bucket", "name": "logs",
+///     "instances": [{"attributes": {"name": "logs-3f9a", "project": "my-project"}}]
+/// }]}"#;
+///
+/// let resources = parse_state_resources(state).unwrap();
+/// assert_eq!(resources["module.storage.google_storage_bucket.logs"]["name"], "logs-3f9a");
+/// ```
+pub fn parse_state_resources(state: &str) -> Result<HashMap<String, Map<String, Value>>, serde_json::Error> {
+    let state: Value = serde_json::from_str(state)?;
+    let mut by_address = HashMap::new();
+
+    for resource in state["resources"].as_array().into_iter().flatten() {
+        let (Some(resource_type), Some(name)) = (resource["type"].as_str(), resource["name"].as_str()) else {
+            continue;
+        };
+        let mut address = String::new();
+        if let Some(module) = resource["module"].as_str() {
+            address.push_str(module);
+            address.push('.');
+        }
+        if resource["mode"] == "data" {
+            address.push_str("data.");
+        }
+        address.push_str(&format!("{}.{}", resource_type, name));
+
+        for instance in resource["instances"].as_array().into_iter().flatten() {
+            let Some(attributes) = instance["attributes"].as_object() else { continue };
+            let instance_address = match &instance["index_key"] {
+                Value::Null => address.clone(),
+                key => format!("{}[{}]", address, key),
+            };
+            by_address.insert(instance_address, attributes.clone());
+        }
+    }
+
+    Ok(by_address)
+}
+
+/// Turns a working directory into a file-name-safe backup prefix
+fn backup_file_stem(working_directory: &Path) -> String {
+    let stem: String = working_directory
+        .to_string_lossy()
+        .trim_matches(|c| c == '/' || c == '.')
+        .chars()
+        .map(|c| if c.is_ascii_alphanumeric() || c == '-' || c == '_' { c } else { '_' })
+        .collect();
+    if stem.is_empty() { "root".to_string() } else { stem }
+}
+
+/// Formats a time as a compact UTC timestamp with milliseconds, e.g. `20260314T091502123Z`
+fn utc_timestamp(time: SystemTime) -> String {
+    let since_epoch = time.duration_since(UNIX_EPOCH).unwrap_or_default();
+    let seconds = since_epoch.as_secs();
+    let (days, seconds_of_day) = ((seconds / 86_400) as i64, seconds % 86_400);
+
+    // Civil-from-days conversion (Howard Hinnant's algorithm)
+    let z = days + 719_468;
+    let era = z.div_euclid(146_097);
+    let day_of_era = z.rem_euclid(146_097);
+    let year_of_era = (day_of_era - day_of_era / 1_460 + day_of_era / 36_524 - day_of_era / 146_096) / 365;
+    let day_of_year = day_of_era - (365 * year_of_era + year_of_era / 4 - year_of_era / 100);
+    let mp = (5 * day_of_year + 2) / 153;
+    let day = day_of_year - (153 * mp + 2) / 5 + 1;
+    let month = if mp < 10 { mp + 3 } else { mp - 9 };
+    let year = year_of_era + era * 400 + if month <= 2 { 1 } else { 0 };
+
+    format!(
+        "{:04}{:02}{:02}T{:02}{:02}{:02}{:03}Z",
+        year,
+        month,
+        day,
+        seconds_of_day / 3_600,
+        seconds_of_day % 3_600 / 60,
+        seconds_of_day % 60,
+        since_epoch.subsec_millis()
+    )
+}
+
+/// Per-directory cache of pulled state for the duration of a run
+///
+/// Each directory's state is pulled at most once and indexed by address; the
+/// already-in-state check, the fallback for plan-unknown attributes and state backups
+/// all read the same pull. A pull or parse error is returned from the first lookup
+/// only; afterwards that directory is treated as having no resources. A module without state yet (empty `state pull` output) has no resources.
+///
+/// After an import changes a directory's state, `refresh` drops the cached pull so the
+/// next lookup sees the new state.
+///
+/// # Examples
+/// ```no_run
+/// use std::path::Path;
+/// use terragrunt_import_from_plan::commands::builder::ImportBinary;
+/// use terragrunt_import_from_plan::commands::runner::SystemCommandRunner;
+/// use terragrunt_import_from_plan::state::StateCache;
+///
+/// let mut cache = StateCache::new(&SystemCommandRunner, ImportBinary::Terragrunt);
+/// let dir = Path::new("./modules/kms");
+/// if cache.contains(dir, "google_kms_key_ring.example").unwrap_or(false) {
+///     println!("already imported");
+/// }
+/// if let Ok(Some(attributes)) = cache.attributes(dir, "google_kms_crypto_key.example") {
+///     println!("key ring: {}", attributes["key_ring"]);
+/// }
+/// ```
+pub struct StateCache<'a> {
+    runner: &'a dyn CommandRunner,
+    binary: ImportBinary,
+    by_directory: HashMap<PathBuf, PulledState>,
+}
+
+/// One directory's pulled state; `raw` is None if the pull or parse failed
+struct PulledState {
+    raw: Option<String>,
+    resources: HashMap<String, Map<String, Value>>,
+}
+
+impl<'a> StateCache<'a> {
+    /// Creates an empty cache that pulls state by running `binary` through `runner`
+    pub fn new(runner: &'a dyn CommandRunner, binary: ImportBinary) -> Self {
+        Self {
+            runner,
+            binary,
+            by_directory: HashMap::new(),
+        }
+    }
+
+    /// Returns true if `address` is already in the state of `working_directory`
+    ///
+    /// # Errors
+    /// Returns the pull or parse error the first time a directory's state can't be read
+    pub fn contains(&mut self, working_directory: &Path, address: &str) -> Result<bool, StateError> {
+        Ok(self.attributes(working_directory, address)?.is_some())
+    }
+
+    /// Returns the state attributes of `address` in `working_directory`, if it's in state
+    ///
+    /// # Errors
+    /// Returns the pull or parse error the first time a directory's state can't be read
+    pub fn attributes(&mut self, working_directory: &Path, address: &str) -> Result<Option<&Map<String, Value>>, StateError> {
+        self.load(working_directory)?;
+        Ok(self.by_directory[working_directory].resources.get(address))
+    }
+
+    /// Returns the state attributes of every resource in `working_directory`, by address
+    ///
+    /// # Errors
+    /// Returns the pull or parse error the first time a directory's state can't be read
+    pub fn resources(&mut self, working_directory: &Path) -> Result<&HashMap<String, Map<String, Value>>, StateError> {
+        self.load(working_directory)?;
+        Ok(&self.by_directory[working_directory].resources)
+    }
+
+    /// Drops the cached state of `working_directory`, so the next lookup pulls it again
+    pub fn refresh(&mut self, working_directory: &Path) {
+        self.by_directory.remove(working_directory);
+    }
+
+    /// Writes the state of `working_directory` to a timestamped file in `backup_dir`
+    ///
+    /// Uses the cached pull when there is one, so backing up doesn't pull again; see
+    /// `backup_state` for the file name. A directory whose cached pull failed is pulled again.
+    ///
+    /// # Returns
+    /// Path of the written backup file, or None if the directory has no state yet
+    ///
+    /// # Errors
+    /// Same as `backup_state`
+    pub fn backup(&mut self, working_directory: &Path, backup_dir: &Path) -> Result<Option<PathBuf>, StateError> {
+        if self.by_directory.get(working_directory).is_some_and(|pulled| pulled.raw.is_none()) {
+            self.refresh(working_directory);
+        }
+        self.load(working_directory)?;
+
+        let raw = self.by_directory[working_directory].raw.as_deref().unwrap_or_default();
+        write_backup(raw, working_directory, backup_dir)
+    }
+
+    /// Pulls and indexes the state of `working_directory` unless it's already cached
+    fn load(&mut self, working_directory: &Path) -> Result<(), StateError> {
+        if self.by_directory.contains_key(working_directory) {
+            return Ok(());
+        }
+
+        let pulled = run_state_pull(self.runner, self.binary, working_directory).and_then(|raw| {
+            if raw.trim().is_empty() {
+                return Ok(PulledState { raw: Some(raw), resources: HashMap::new() });
+            }
+            match parse_state_resources(&raw) {
+                Ok(resources) => Ok(PulledState { raw: Some(raw), resources }),
+                Err(source) => Err(StateError::InvalidState {
+                    program: self.binary.program().to_string(),
+                    path: working_directory.display().to_string(),
+                    source,
+                }),
+            }
+        });
+        let (pulled, error) = match pulled {
+            Ok(pulled) => (pulled, None),
+            Err(e) => (PulledState { raw: None, resources: HashMap::new() }, Some(e)),
+        };
+        self.by_directory.insert(working_directory.to_path_buf(), pulled);
+        match error {
+            Some(e) => Err(e),
+            None => Ok(()),
+        }
+    }
+}
+
+/// Unit tests for state inspection
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::commands::runner::CommandOutput;
+    use std::sync::atomic::{AtomicUsize, Ordering};
+
+    struct FakeRunner {
+        output: io::Result<CommandOutput>,
+        calls: AtomicUsize,
+        program: &'static str,
+    }
+
+    impl FakeRunner {
+        fn with_stdout(stdout: &str) -> Self {
+            Self {
+                output: Ok(CommandOutput { exit_code: Some(0), stdout: stdout.to_string(), stderr: String::new() }),
+                calls: AtomicUsize::new(0),
+                program: "terragrunt",
+            }
+        }
+    }
+
+    impl CommandRunner for FakeRunner {
+        fn run(&self, program: &str, args: &[&str], _working_directory: &Path) -> io::Result<CommandOutput> {
+            assert_eq!(program, self.program);
+            assert_eq!(args[0], "state");
+            self.calls.fetch_add(1, Ordering::SeqCst);
+            match &self.output {
+                Ok(output) => Ok(output.clone()),
+                Err(e) => Err(io::Error::new(e.kind(), e.to_string())),
+            }
+        }
+    }
+
+    /// **TEST** - With plain terraform, state commands and their errors name terraform
+    #[test]
+    fn test_state_commands_use_binary() {
+        let runner = FakeRunner { program: "terraform", ..FakeRunner::with_stdout(r#"{"version": 4}"#) };
+        let backup_dir = tempfile::tempdir().unwrap();
+        assert!(backup_state(&runner, ImportBinary::Terraform, Path::new("modules/vpc"), backup_dir.path()).unwrap().is_some());
+
+        let failing = FakeRunner {
+            output: Ok(CommandOutput { exit_code: Some(1), stdout: String::new(), stderr: "no state".to_string() }),
+            calls: AtomicUsize::new(0),
+            program: "terraform",
+        };
+        let err = backup_state(&failing, ImportBinary::Terraform, Path::new("modules/vpc"), backup_dir.path()).unwrap_err();
+        assert_eq!(err.to_string(), "terraform state pull failed in modules/vpc with exit code 1: no state");
+    }
+
+    /// **TEST** - State is pulled into a timestamped file under the backup directory
+    #[test]
+    fn test_backup_state_writes_file() {
+        let runner = FakeRunner::with_stdout(r#"{"version": 4, "serial": 7}"#);
+        let backup_dir = tempfile::tempdir().unwrap();
+
+        let backup = backup_state(&runner, ImportBinary::Terragrunt, Path::new("modules/kms"), backup_dir.path()).unwrap().unwrap();
+
+        assert!(backup.starts_with(backup_dir.path()));
+        let name = backup.file_name().unwrap().to_string_lossy().to_string();
+        assert!(name.starts_with("modules_kms-") && name.ends_with("Z.tfstate"), "{}", name);
+        assert_eq!(fs::read_to_string(&backup).unwrap(), r#"{"version": 4, "serial": 7}"#);
+    }
+
+    /// **TEST** - A failed pull is an error, and an empty one is nothing to back up; neither writes a file
+    #[test]
+    fn test_backup_state_failures() {
+        let backup_dir = tempfile::tempdir().unwrap();
+        let failing = FakeRunner {
+            output: Ok(CommandOutput { exit_code: Some(1), stdout: String::new(), stderr: "backend unreachable".to_string() }),
+            calls: AtomicUsize::new(0),
+            program: "terragrunt",
+        };
+        let err = backup_state(&failing, ImportBinary::Terragrunt, Path::new("modules/kms"), backup_dir.path()).unwrap_err();
+        assert!(matches!(err, StateError::NonZeroExit { ref subcommand, .. } if subcommand == "pull"));
+
+        let empty = FakeRunner::with_stdout("  \n");
+        assert!(backup_state(&empty, ImportBinary::Terragrunt, Path::new("modules/kms"), backup_dir.path()).unwrap().is_none());
+        assert_eq!(fs::read_dir(backup_dir.path()).unwrap().count(), 0);
+    }
+
+    /// **TEST** - Pulled state is indexed by full instance address
+    #[test]
+    fn test_parse_state_resources_addresses() {
+        let state = r#"{"version": 4, "resources": [
+            {"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-1"}}]},
+            {"module": "module.kms", "mode": "managed", "type": "google_kms_crypto_key", "name": "this", "instances": [
+                {"index_key": "primary", "attributes": {"key_ring": "projects/p/locations/l/keyRings/r"}},
+                {"index_key": 1, "attributes": {"key_ring": "projects/p/locations/l/keyRings/s"}}
+            ]},
+            {"mode": "data", "type": "google_project", "name": "current", "instances": [{"attributes": {}}]}
+        ]}"#;
+
+        let resources = parse_state_resources(state).unwrap();
+        let mut addresses: Vec<&str> = resources.keys().map(String::as_str).collect();
+        addresses.sort();
+        assert_eq!(addresses, [
+            "aws_vpc.main",
+            "data.google_project.current",
+            r#"module.kms.google_kms_crypto_key.this["primary"]"#,
+            "module.kms.google_kms_crypto_key.this[1]",
+        ]);
+        assert_eq!(resources["aws_vpc.main"]["id"], "vpc-1");
+    }
+
+    /// **TEST** - The cache pulls each directory once for both lookups and reports bad state once
+    #[test]
+    fn test_state_cache_pulls_once() {
+        let runner = FakeRunner::with_stdout(r#"{"resources": [{"mode": "managed", "type": "aws_vpc", "name": "main", "instances": [{"attributes": {"id": "vpc-1"}}]}]}"#);
+        let mut cache = StateCache::new(&runner, ImportBinary::Terragrunt);
+        let dir = Path::new("modules/vpc");
+
+        assert!(cache.contains(dir, "aws_vpc.main").unwrap());
+        assert_eq!(cache.attributes(dir, "aws_vpc.main").unwrap().unwrap()["id"], "vpc-1");
+        assert!(!cache.contains(dir, "aws_vpc.other").unwrap());
+        let backup_dir = tempfile::tempdir().unwrap();
+        assert!(cache.backup(dir, backup_dir.path()).unwrap().is_some());
+        assert_eq!(runner.calls.load(Ordering::SeqCst), 1);
+
+        let garbage = FakeRunner::with_stdout("not json");
+        let mut cache = StateCache::new(&garbage, ImportBinary::Terragrunt);
+        assert!(matches!(cache.attributes(dir, "aws_vpc.main").unwrap_err(), StateError::InvalidState { .. }));
+        assert!(cache.attributes(dir, "aws_vpc.main").unwrap().is_none());
+        assert!(matches!(cache.backup(dir, backup_dir.path()).unwrap_err(), StateError::InvalidState { .. }));
+        assert_eq!(garbage.calls.load(Ordering::SeqCst), 2);
+    }
+
+    /// **TEST** - Empty state has no resources and nothing to back up; refresh pulls again
+    #[test]
+    fn test_state_cache_empty_state_and_refresh() {
+        let runner = FakeRunner::with_stdout("");
+        let mut cache = StateCache::new(&runner, ImportBinary::Terragrunt);
+        let dir = Path::new("modules/vpc");
+
+        assert!(!cache.contains(dir, "aws_vpc.main").unwrap());
+        let backup_dir = tempfile::tempdir().unwrap();
+        assert!(cache.backup(dir, backup_dir.path()).unwrap().is_none());
+        assert_eq!(fs::read_dir(backup_dir.path()).unwrap().count(), 0);
+        assert_eq!(runner.calls.load(Ordering::SeqCst), 1);
+
+        cache.refresh(dir);
+        assert!(!cache.contains(dir, "aws_vpc.main").unwrap());
+        assert_eq!(runner.calls.load(Ordering::SeqCst), 2);
+    }
+
+    /// **TEST** - Timestamps are formatted as UTC calendar time
+    #[test]
+    fn test_utc_timestamp() {
+        let time = UNIX_EPOCH + std::time::Duration::from_millis(1_709_201_702_045);
+        assert_eq!(utc_timestamp(time), "20240229T101502045Z");
+        assert_eq!(utc_timestamp(UNIX_EPOCH), "19700101T000000000Z");
+    }
+}
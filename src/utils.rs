@@ -122,6 +122,40 @@ pub fn collect_resources<'a>(module: &'a PlannedModule, resources: &mut Vec<&'a
     }
 }
 
+/// Quotes a string for safe use as a single POSIX shell word
+/// 
+/// Strings made up only of characters with no special meaning to the shell are
+/// returned unchanged. Anything else is wrapped in single quotes, with embedded
+/// single quotes escaped, so that indexed addresses such as
+/// `google_kms_crypto_key.this["primary"]` survive copy-paste into a terminal.
+/// 
+/// # Arguments
+/// * `value` - The string to quote
+/// 
+/// # Returns
+/// The value, quoted if necessary
+/// 
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::utils::shell_quote;
+/// 
+/// assert_eq!(shell_quote("aws_vpc.main"), "aws_vpc.main");
+/// assert_eq!(shell_quote(r#"aws_s3_bucket.b["logs"]"#), r#"'aws_s3_bucket.b["logs"]'"#);
+/// assert_eq!(shell_quote("it's"), r#"'it'\''s'"#);
+/// ```
+pub fn shell_quote(value: &str) -> String {
+    let is_safe = !value.is_empty()
+        && value
+            .chars()
+            .all(|c| c.is_ascii_alphanumeric() || "_-./=:@%+,".contains(c));
+
+    if is_safe {
+        value.to_string()
+    } else {
+        format!("'{}'", value.replace('\'', "'\\''"))
+    }
+}
+
 /// Extracts potential ID candidate field names from provider schema JSON
 /// 
 /// Analyzes provider schema information to identify attribute names that could
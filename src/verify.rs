@@ -0,0 +1,333 @@
+//! # Import Verification Module
+//!
+//! An import can succeed and still adopt the wrong object, e.g. an ID pointing at a
+//! different bucket than the one configured. Verification re-checks each imported
+//! address after the run: it has to be in state, and a plan targeting it must not
+//! create, replace or destroy it any more.
+//!
+//! ## Key Components
+//!
+//! - **PlannedAction**: What a plan intends to do with a resource
+//! - **parse_plan_actions**: Reads the actions from human-readable `plan` output
+//! - **planned_actions**: Runs a plan targeting only the given addresses
+//! - **verify_imports**: Checks a directory's imported addresses against state and plan
+//! - **VerificationFailure**: Why an imported resource failed verification
+//! - **VerifyError**: Failure modes when running the verification plan
+//!
+//! ## Cost
+//!
+//! Verification pulls each directory's state once more and runs one plan per
+//! directory, limited to the imported addresses with `-target`. In-place updates are
+//! accepted: configuration drift is common right after an import and doesn't mean the
+//! wrong object was adopted.
+
+use std::collections::HashMap;
+use std::fmt;
+use std::io;
+use std::path::Path;
+use regex::Regex;
+use thiserror::Error;
+use crate::commands::builder::ImportBinary;
+use crate::commands::runner::CommandRunner;
+use crate::state::StateCache;
+
+/// Error types for running a verification plan
+///
+/// # Variants
+/// - `CommandFailed`: The terragrunt (or terraform) process could not be started
+/// - `NonZeroExit`: The plan ran but reported an error
+#[derive(Error, Debug)]
+pub enum VerifyError {
+    /// The plan process could not be started
+    #[error("Failed to run {program} plan in {path}: {source}")]
+    CommandFailed {
+        /// Program that was run
+        program: String,
+        /// Directory the command was run in
+        path: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// The plan exited with a non-zero code
+    #[error("{program} plan failed in {path} with exit code {exit_code}: {stderr}")]
+    NonZeroExit {
+        /// Program that was run
+        program: String,
+        /// Directory the command was run in
+        path: String,
+        /// Process exit code (-1 if terminated by a signal)
+        exit_code: i32,
+        /// Captured standard error
+        stderr: String,
+    },
+}
+
+/// What a plan intends to do with a resource
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum PlannedAction {
+    /// `will be created`
+    Create,
+    /// `must be replaced` or `will be replaced`
+    Replace,
+    /// `will be updated in-place`
+    Update,
+    /// `will be destroyed`
+    Delete,
+}
+
+impl PlannedAction {
+    /// Returns true if the action means the import didn't adopt the configured object
+    pub fn fails_verification(self) -> bool {
+        !matches!(self, PlannedAction::Update)
+    }
+}
+
+/// Why an imported resource failed verification
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum VerificationFailure {
+    /// The address isn't in the directory's state after the import
+    NotInState,
+    /// The plan still creates, replaces or destroys the resource
+    Pending(PlannedAction),
+    /// State or the plan couldn't be read
+    Unverifiable(String),
+}
+
+impl fmt::Display for VerificationFailure {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            VerificationFailure::NotInState => f.write_str("verification failed: not in state after the import"),
+            VerificationFailure::Pending(PlannedAction::Create) => f.write_str("verification failed: the plan still creates it"),
+            VerificationFailure::Pending(PlannedAction::Replace) => {
+                f.write_str("verification failed: the plan replaces it, so the imported object doesn't match the configuration")
+            }
+            VerificationFailure::Pending(PlannedAction::Delete) => {
+                f.write_str("verification failed: the plan destroys it, so the address isn't in the configuration")
+            }
+            VerificationFailure::Pending(PlannedAction::Update) => f.write_str("verification failed: the plan updates it"),
+            VerificationFailure::Unverifiable(reason) => write!(f, "could not verify the import: {}", reason),
+        }
+    }
+}
+
+/// Reads the per-resource actions from human-readable plan output
+///
+/// Only the `# <address> will be created` style headers are read; `-no-color` output
+/// is expected. Data source reads are ignored.
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::verify::{parse_plan_actions, PlannedAction};
+///
+/// let output = [
+///     "  # google_kms_key_ring.example will be updated in-place",
+///     "  ~ resource \"google_kms_key_ring\" \"example\" {",
+///     "  # module.db.google_sql_database_instance.main must be replaced",
+///     "-/+ resource \"google_sql_database_instance\" \"main\" {",
+/// ]
+/// .join("\n");
+/// let actions = parse_plan_actions(&output);
+/// assert_eq!(actions["google_kms_key_ring.example"], PlannedAction::Update);
+/// assert_eq!(actions["module.db.google_sql_database_instance.main"], PlannedAction::Replace);
+/// ```
+pub fn parse_plan_actions(output: &str) -> HashMap<String, PlannedAction> {
+    let pattern = Regex::new(r"^# (.+?) (will be created|must be replaced|will be replaced|will be updated in-place|will be destroyed)")
+        .expect("plan action pattern is valid");
+    output
+        .lines()
+        .filter_map(|line| pattern.captures(line.trim()))
+        .map(|captures| {
+            let action = match &captures[2] {
+                "will be created" => PlannedAction::Create,
+                "will be updated in-place" => PlannedAction::Update,
+                "will be destroyed" => PlannedAction::Delete,
+                _ => PlannedAction::Replace,
+            };
+            (captures[1].to_string(), action)
+        })
+        .collect()
+}
+
+/// Runs `<binary> plan` in `working_directory`, targeting only `addresses`
+///
+/// # Arguments
+/// * `runner` - Command runner used to invoke the binary
+/// * `binary` - Program that runs the plan
+/// * `working_directory` - Module directory to plan
+/// * `addresses` - Addresses passed as `-target`, relative to the directory
+///
+/// # Returns
+/// The planned action of every resource the plan changes
+///
+/// # Errors
+/// - `VerifyError::CommandFailed` if the program could not be started
+/// - `VerifyError::NonZeroExit` if the plan reported an error
+pub fn planned_actions(
+    runner: &dyn CommandRunner,
+    binary: ImportBinary,
+    working_directory: &Path,
+    addresses: &[&str],
+) -> Result<HashMap<String, PlannedAction>, VerifyError> {
+    let program = binary.program().to_string();
+    let path = working_directory.display().to_string();
+    let mut args = vec!["plan".to_string(), "-no-color".to_string(), "-input=false".to_string(), "-lock=false".to_string()];
+    args.extend(addresses.iter().map(|address| format!("-target={}", address)));
+    let args: Vec<&str> = args.iter().map(String::as_str).collect();
+
+    let output = runner
+        .run(&program, &args, working_directory)
+        .map_err(|source| VerifyError::CommandFailed { program: program.clone(), path: path.clone(), source })?;
+    if !output.success() {
+        return Err(VerifyError::NonZeroExit {
+            program,
+            path,
+            exit_code: output.exit_code.unwrap_or(-1),
+            stderr: output.stderr.trim().to_string(),
+        });
+    }
+    Ok(parse_plan_actions(&output.stdout))
+}
+
+/// Verifies the addresses imported into one module directory
+///
+/// The directory's cached state is refreshed first, so the check sees the imports.
+/// Addresses missing from state fail without a plan; the plan only runs if at least
+/// one address is in state.
+///
+/// # Arguments
+/// * `runner` - Command runner used to invoke the binary
+/// * `binary` - Program that runs the plan
+/// * `state` - State cache of the run
+/// * `working_directory` - Module directory the addresses were imported into
+/// * `addresses` - Imported addresses, relative to the directory
+///
+/// # Returns
+/// One result per address, in the order given
+pub fn verify_imports(
+    runner: &dyn CommandRunner,
+    binary: ImportBinary,
+    state: &mut StateCache,
+    working_directory: &Path,
+    addresses: &[&str],
+) -> Vec<Result<(), VerificationFailure>> {
+    state.refresh(working_directory);
+    let mut results: Vec<Result<(), VerificationFailure>> = addresses
+        .iter()
+        .map(|address| match state.contains(working_directory, address) {
+            Ok(true) => Ok(()),
+            Ok(false) => Err(VerificationFailure::NotInState),
+            Err(e) => Err(VerificationFailure::Unverifiable(e.to_string())),
+        })
+        .collect();
+
+    let in_state: Vec<&str> = addresses.iter().zip(&results).filter(|(_, result)| result.is_ok()).map(|(address, _)| *address).collect();
+    if in_state.is_empty() {
+        return results;
+    }
+    match planned_actions(runner, binary, working_directory, &in_state) {
+        Ok(actions) => {
+            for (address, result) in addresses.iter().zip(&mut results) {
+                if let (Ok(()), Some(action)) = (&result, actions.get(*address)) {
+                    if action.fails_verification() {
+                        *result = Err(VerificationFailure::Pending(*action));
+                    }
+                }
+            }
+        }
+        Err(e) => {
+            for result in results.iter_mut().filter(|result| result.is_ok()) {
+                *result = Err(VerificationFailure::Unverifiable(e.to_string()));
+            }
+        }
+    }
+    results
+}
+
+/// Unit tests for verification plans
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::Mutex;
+    use crate::commands::runner::CommandOutput;
+
+    /// Runner answering `state pull` and `plan` with fixed outputs and recording each call
+    struct FakeRunner {
+        state: String,
+        plan: CommandOutput,
+        calls: Mutex<Vec<String>>,
+    }
+
+    impl CommandRunner for FakeRunner {
+        fn run(&self, program: &str, args: &[&str], _working_directory: &Path) -> io::Result<CommandOutput> {
+            self.calls.lock().unwrap().push(format!("{} {}", program, args.join(" ")));
+            if args.first() == Some(&"state") {
+                return Ok(CommandOutput { exit_code: Some(0), stdout: self.state.clone(), stderr: String::new() });
+            }
+            Ok(self.plan.clone())
+        }
+    }
+
+    fn state(addresses: &[&str]) -> String {
+        let resources: Vec<serde_json::Value> = addresses
+            .iter()
+            .map(|address| {
+                let (resource_type, name) = address.split_once('.').unwrap();
+                serde_json::json!({"mode": "managed", "type": resource_type, "name": name, "instances": [{"attributes": {"id": name}}]})
+            })
+            .collect();
+        serde_json::json!({"version": 4, "resources": resources}).to_string()
+    }
+
+    /// **TEST** - Plan headers map to actions; attribute lines and data reads don't
+    #[test]
+    fn test_parse_plan_actions() {
+        let output = "  # aws_vpc.main will be created\n  + resource \"aws_vpc\" \"main\" {\n      # (1 unchanged attribute hidden)\n  # aws_s3_bucket.logs will be destroyed\n  # aws_iam_role.this will be replaced, as requested\n <= data \"aws_caller_identity\" \"current\" {\n";
+        let actions = parse_plan_actions(output);
+        assert_eq!(actions.len(), 3);
+        assert_eq!(actions["aws_vpc.main"], PlannedAction::Create);
+        assert_eq!(actions["aws_s3_bucket.logs"], PlannedAction::Delete);
+        assert_eq!(actions["aws_iam_role.this"], PlannedAction::Replace);
+        assert!(!PlannedAction::Update.fails_verification());
+    }
+
+    /// **TEST** - Missing, replaced and updated addresses; only in-state ones are targeted
+    #[test]
+    fn test_verify_imports() {
+        let runner = FakeRunner {
+            state: state(&["aws_vpc.main", "aws_subnet.a", "aws_subnet.b"]),
+            plan: CommandOutput {
+                exit_code: Some(0),
+                stdout: "  # aws_subnet.a must be replaced\n  # aws_subnet.b will be updated in-place\n".to_string(),
+                stderr: String::new(),
+            },
+            calls: Mutex::new(Vec::new()),
+        };
+        let mut cache = StateCache::new(&runner, ImportBinary::Terragrunt);
+        let results = verify_imports(&runner, ImportBinary::Terragrunt, &mut cache, Path::new("vpc"), &["aws_vpc.main", "aws_subnet.a", "aws_subnet.b", "aws_eip.nat"]);
+        assert_eq!(results, vec![
+            Ok(()),
+            Err(VerificationFailure::Pending(PlannedAction::Replace)),
+            Ok(()),
+            Err(VerificationFailure::NotInState),
+        ]);
+        assert_eq!(runner.calls.lock().unwrap()[1], "terragrunt plan -no-color -input=false -lock=false -target=aws_vpc.main -target=aws_subnet.a -target=aws_subnet.b");
+    }
+
+    /// **TEST** - A failing plan makes every in-state address unverifiable
+    #[test]
+    fn test_verify_imports_plan_failure() {
+        let runner = FakeRunner {
+            state: state(&["aws_vpc.main"]),
+            plan: CommandOutput { exit_code: Some(1), stdout: String::new(), stderr: "Error: No valid credential sources found\n".to_string() },
+            calls: Mutex::new(Vec::new()),
+        };
+        let mut cache = StateCache::new(&runner, ImportBinary::Terraform);
+        let results = verify_imports(&runner, ImportBinary::Terraform, &mut cache, Path::new("vpc"), &["aws_vpc.main"]);
+        assert_eq!(
+            results[0].as_ref().unwrap_err().to_string(),
+            "could not verify the import: terraform plan failed in vpc with exit code 1: Error: No valid credential sources found"
+        );
+    }
+}
@@ -0,0 +1,216 @@
+//! # Tool Version Module
+//!
+//! Terraform releases change plan JSON details and the import IDs some providers expect,
+//! so an import run records which terragrunt (or terraform) it drives and warns when
+//! that version is older than the oldest one known to work.
+//!
+//! ## Key Components
+//!
+//! - **ToolVersion**: A `major.minor.patch` version, ordered numerically
+//! - **parse_version_output**: Extracts the version from `--version` output
+//! - **minimum_version**: Oldest known-good version for an `ImportBinary`
+//! - **detect_version**: Runs `<binary> --version` through a `CommandRunner`
+//! - **VersionError**: Failure modes when detecting a version
+//!
+//! ## Recognized Output
+//!
+//! Only the line naming the tool itself is read: `Terraform v1.9.8`, `OpenTofu v1.8.0`
+//! or `terragrunt version v0.54.8` (newer terragrunt releases drop the `v`). Provider
+//! lines such as `+ provider registry.terraform.io/hashicorp/google v6.12.0` and the
+//! "out of date" notice are ignored. Pre-release suffixes (`-beta1`) are dropped.
+
+use std::fmt;
+use std::io;
+use std::path::Path;
+use std::str::FromStr;
+use regex::Regex;
+use thiserror::Error;
+use crate::commands::builder::ImportBinary;
+use crate::commands::runner::CommandRunner;
+
+/// Error types for detecting a tool version
+///
+/// # Variants
+/// - `CommandFailed`: The program could not be started
+/// - `NonZeroExit`: `--version` ran but reported an error
+/// - `Unrecognized`: The output names no version this module can read
+#[derive(Error, Debug)]
+pub enum VersionError {
+    /// The program could not be started
+    #[error("Failed to run {program} --version: {source}")]
+    CommandFailed {
+        /// Program that was run
+        program: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// `--version` exited with a non-zero code
+    #[error("{program} --version failed with exit code {exit_code}: {stderr}")]
+    NonZeroExit {
+        /// Program that was run
+        program: String,
+        /// Process exit code (-1 if terminated by a signal)
+        exit_code: i32,
+        /// Captured standard error
+        stderr: String,
+    },
+
+    /// The output doesn't contain a recognizable version line
+    #[error("could not find a version in the output of {program} --version: {output}")]
+    Unrecognized {
+        /// Program that was run
+        program: String,
+        /// First line of the output
+        output: String,
+    },
+}
+
+/// A `major.minor.patch` release version
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::version::ToolVersion;
+///
+/// let version: ToolVersion = "v1.9.8".parse().unwrap();
+/// assert_eq!(version, ToolVersion::new(1, 9, 8));
+/// assert!(version < "1.10.0".parse::<ToolVersion>().unwrap());
+/// assert_eq!(version.to_string(), "1.9.8");
+/// ```
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub struct ToolVersion {
+    /// Major version
+    pub major: u64,
+    /// Minor version
+    pub minor: u64,
+    /// Patch version
+    pub patch: u64,
+}
+
+impl ToolVersion {
+    /// Creates the version `major.minor.patch`
+    pub const fn new(major: u64, minor: u64, patch: u64) -> Self {
+        Self { major, minor, patch }
+    }
+}
+
+impl fmt::Display for ToolVersion {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "{}.{}.{}", self.major, self.minor, self.patch)
+    }
+}
+
+impl FromStr for ToolVersion {
+    type Err = String;
+
+    /// Parses `1.9.8` or `v1.9.8`, ignoring a pre-release or build suffix
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        let core = s.trim().trim_start_matches('v');
+        let core = core.split(['-', '+']).next().unwrap_or_default();
+        let parts: Vec<&str> = core.split('.').collect();
+        let [major, minor, patch] = parts.as_slice() else {
+            return Err(format!("invalid version '{}' (expected major.minor.patch)", s));
+        };
+        let number = |part: &str| part.parse::<u64>().map_err(|_| format!("invalid version '{}' (expected major.minor.patch)", s));
+        Ok(Self::new(number(major)?, number(minor)?, number(patch)?))
+    }
+}
+
+/// Oldest Terraform version known to work: plan JSON `format_version` 1.0 and the
+/// `required_version` of the simulator configurations
+pub const MIN_TERRAFORM_VERSION: ToolVersion = ToolVersion::new(1, 0, 0);
+
+/// Oldest Terragrunt version known to work: the first release supporting Terraform 1.0
+pub const MIN_TERRAGRUNT_VERSION: ToolVersion = ToolVersion::new(0, 31, 0);
+
+/// Returns the oldest known-good version of `binary`
+pub fn minimum_version(binary: ImportBinary) -> ToolVersion {
+    match binary {
+        ImportBinary::Terragrunt => MIN_TERRAGRUNT_VERSION,
+        ImportBinary::Terraform => MIN_TERRAFORM_VERSION,
+    }
+}
+
+/// Extracts the tool version from `terraform version` or `terragrunt --version` output
+///
+/// # Returns
+/// The version on the first line naming Terraform, OpenTofu or terragrunt, or None
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::version::{parse_version_output, ToolVersion};
+///
+/// let output = "Terraform v1.9.8\non linux_amd64\n+ provider registry.terraform.io/hashicorp/google v6.12.0\n";
+/// assert_eq!(parse_version_output(output), Some(ToolVersion::new(1, 9, 8)));
+/// assert_eq!(parse_version_output("terragrunt version 0.67.16"), Some(ToolVersion::new(0, 67, 16)));
+/// assert_eq!(parse_version_output("+ provider registry.terraform.io/hashicorp/aws v5.31.0"), None);
+/// ```
+pub fn parse_version_output(output: &str) -> Option<ToolVersion> {
+    let pattern = Regex::new(r"^(?:Terraform|OpenTofu|terragrunt version) (v?\d+\.\d+\.\d+\S*)").expect("version pattern is valid");
+    output
+        .lines()
+        .find_map(|line| pattern.captures(line.trim()))
+        .and_then(|captures| captures[1].parse().ok())
+}
+
+/// Runs `<binary> --version` and parses its output
+///
+/// # Arguments
+/// * `runner` - Command runner used to invoke the binary
+/// * `binary` - Program whose version is detected
+///
+/// # Errors
+/// - `VersionError::CommandFailed` if the program could not be started
+/// - `VersionError::NonZeroExit` if it reported an error
+/// - `VersionError::Unrecognized` if no version could be read from its output
+pub fn detect_version(runner: &dyn CommandRunner, binary: ImportBinary) -> Result<ToolVersion, VersionError> {
+    let program = binary.program();
+    let output = runner
+        .run(program, &["--version"], Path::new("."))
+        .map_err(|source| VersionError::CommandFailed { program: program.to_string(), source })?;
+    if !output.success() {
+        return Err(VersionError::NonZeroExit {
+            program: program.to_string(),
+            exit_code: output.exit_code.unwrap_or(-1),
+            stderr: output.stderr.trim().to_string(),
+        });
+    }
+    parse_version_output(&output.stdout).ok_or_else(|| VersionError::Unrecognized {
+        program: program.to_string(),
+        output: output.stdout.lines().next().unwrap_or_default().to_string(),
+    })
+}
+
+/// Unit tests for version parsing against recorded `--version` outputs
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::fs;
+
+    fn fixture(name: &str) -> String {
+        fs::read_to_string(format!("tests/fixtures/versions/{}", name)).unwrap()
+    }
+
+    /// **TEST** - Real outputs parse to the tool's version, never a provider's
+    #[test]
+    fn test_parse_version_output_fixtures() {
+        assert_eq!(parse_version_output(&fixture("terraform_1.9.8.txt")), Some(ToolVersion::new(1, 9, 8)));
+        assert_eq!(parse_version_output(&fixture("terraform_1.5.7_outdated.txt")), Some(ToolVersion::new(1, 5, 7)));
+        assert_eq!(parse_version_output(&fixture("terraform_0.12.31.txt")), Some(ToolVersion::new(0, 12, 31)));
+        assert_eq!(parse_version_output(&fixture("terragrunt_0.54.8.txt")), Some(ToolVersion::new(0, 54, 8)));
+        assert_eq!(parse_version_output(&fixture("terragrunt_0.67.16.txt")), Some(ToolVersion::new(0, 67, 16)));
+        assert_eq!(parse_version_output("OpenTofu v1.8.0-beta1\non linux_amd64"), Some(ToolVersion::new(1, 8, 0)));
+        assert_eq!(parse_version_output("Usage: terraform [global options] <subcommand> [args]"), None);
+    }
+
+    /// **TEST** - Versions compare numerically and old releases fall below the minimum
+    #[test]
+    fn test_versions_compare_numerically() {
+        assert!(ToolVersion::new(1, 10, 0) > ToolVersion::new(1, 9, 8));
+        assert!(ToolVersion::new(0, 12, 31) < minimum_version(ImportBinary::Terraform));
+        assert!(ToolVersion::new(0, 54, 8) >= minimum_version(ImportBinary::Terragrunt));
+        assert!("1.9".parse::<ToolVersion>().is_err());
+        assert!("1.x.0".parse::<ToolVersion>().is_err());
+    }
+}
@@ -0,0 +1,209 @@
+//! # Workspace Module
+//!
+//! A module using Terraform workspaces keeps one state per workspace. Imports go into
+//! whichever workspace is selected in the module directory, so a run meant for
+//! `staging` that finds `default` selected would import into the wrong state. This
+//! module selects the requested workspace before anything reads or changes state.
+//!
+//! ## Key Components
+//!
+//! - **parse_workspace_list**: Reads the output of `workspace list`
+//! - **list_workspaces**: Runs `<binary> workspace list` in a module directory
+//! - **select_workspace**: Checks the workspace exists, then runs `workspace select`
+//! - **WorkspaceError**: Failure modes when listing or selecting a workspace
+//!
+//! ## Missing Workspaces
+//!
+//! A workspace that doesn't exist is an error, never created: creating it would give
+//! an empty state, and the imports would land there instead of in the state the plan
+//! was made against.
+
+use std::io;
+use std::path::Path;
+use thiserror::Error;
+use crate::commands::builder::ImportBinary;
+use crate::commands::runner::CommandRunner;
+
+/// Error types for listing and selecting workspaces
+///
+/// # Variants
+/// - `CommandFailed`: The terragrunt (or terraform) process could not be started
+/// - `NonZeroExit`: The workspace command ran but reported an error
+/// - `NotFound`: The requested workspace doesn't exist in the module directory
+#[derive(Error, Debug)]
+pub enum WorkspaceError {
+    /// The workspace command could not be started
+    #[error("Failed to run {program} workspace {subcommand} in {path}: {source}")]
+    CommandFailed {
+        /// Program that was run
+        program: String,
+        /// Workspace subcommand that was run (e.g. "list", "select")
+        subcommand: String,
+        /// Directory the command was run in
+        path: String,
+        /// Underlying I/O error
+        #[source]
+        source: io::Error,
+    },
+
+    /// The workspace command exited with a non-zero code
+    #[error("{program} workspace {subcommand} failed in {path} with exit code {exit_code}: {stderr}")]
+    NonZeroExit {
+        /// Program that was run
+        program: String,
+        /// Workspace subcommand that failed
+        subcommand: String,
+        /// Directory the command was run in
+        path: String,
+        /// Process exit code (-1 if terminated by a signal)
+        exit_code: i32,
+        /// Captured standard error
+        stderr: String,
+    },
+
+    /// The requested workspace doesn't exist
+    #[error("workspace '{workspace}' does not exist in {path} (available: {})", available.join(", "))]
+    NotFound {
+        /// Requested workspace
+        workspace: String,
+        /// Module directory
+        path: String,
+        /// Workspaces that do exist
+        available: Vec<String>,
+    },
+}
+
+/// Parses the output of `terraform workspace list`
+///
+/// # Returns
+/// The workspace names in output order; the selected one is marked with `*`
+///
+/// # Examples
+/// ```
+/// use terragrunt_import_from_plan::workspace::parse_workspace_list;
+///
+/// assert_eq!(parse_workspace_list("  default\n* staging\n\n"), vec!["default", "staging"]);
+/// ```
+pub fn parse_workspace_list(output: &str) -> Vec<String> {
+    output
+        .lines()
+        .map(|line| line.trim().trim_start_matches('*').trim())
+        .filter(|name| !name.is_empty())
+        .map(str::to_string)
+        .collect()
+}
+
+/// Runs `<subcommand>` of `<binary> workspace` in `working_directory` and returns stdout
+fn run_workspace(
+    runner: &dyn CommandRunner,
+    binary: ImportBinary,
+    working_directory: &Path,
+    args: &[&str],
+) -> Result<String, WorkspaceError> {
+    let program = binary.program().to_string();
+    let subcommand = args[0].to_string();
+    let path = working_directory.display().to_string();
+    let mut command = vec!["workspace"];
+    command.extend_from_slice(args);
+
+    let output = runner
+        .run(&program, &command, working_directory)
+        .map_err(|source| WorkspaceError::CommandFailed { program: program.clone(), subcommand: subcommand.clone(), path: path.clone(), source })?;
+    if !output.success() {
+        return Err(WorkspaceError::NonZeroExit {
+            program,
+            subcommand,
+            path,
+            exit_code: output.exit_code.unwrap_or(-1),
+            stderr: output.stderr.trim().to_string(),
+        });
+    }
+    Ok(output.stdout)
+}
+
+/// Lists the workspaces of the module in `working_directory`
+///
+/// # Errors
+/// - `WorkspaceError::CommandFailed` if the program could not be started
+/// - `WorkspaceError::NonZeroExit` if it reported an error
+pub fn list_workspaces(runner: &dyn CommandRunner, binary: ImportBinary, working_directory: &Path) -> Result<Vec<String>, WorkspaceError> {
+    run_workspace(runner, binary, working_directory, &["list"]).map(|stdout| parse_workspace_list(&stdout))
+}
+
+/// Selects `workspace` in `working_directory` after checking that it exists
+///
+/// # Arguments
+/// * `runner` - Command runner used to invoke the binary
+/// * `binary` - Program that manages the module's state
+/// * `working_directory` - Module directory to select the workspace in
+/// * `workspace` - Workspace name
+/// * `check_only` - Only check that the workspace exists, e.g. in dry-run mode
+///
+/// # Errors
+/// - `WorkspaceError::NotFound` if the workspace isn't listed; nothing is selected
+/// - `WorkspaceError::CommandFailed` / `WorkspaceError::NonZeroExit` if listing or
+///   selecting fails
+pub fn select_workspace(
+    runner: &dyn CommandRunner,
+    binary: ImportBinary,
+    working_directory: &Path,
+    workspace: &str,
+    check_only: bool,
+) -> Result<(), WorkspaceError> {
+    let available = list_workspaces(runner, binary, working_directory)?;
+    if !available.iter().any(|name| name == workspace) {
+        return Err(WorkspaceError::NotFound {
+            workspace: workspace.to_string(),
+            path: working_directory.display().to_string(),
+            available,
+        });
+    }
+    if !check_only {
+        run_workspace(runner, binary, working_directory, &["select", workspace])?;
+    }
+    Ok(())
+}
+
+/// Unit tests for listing and selecting workspaces
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::Mutex;
+    use crate::commands::runner::CommandOutput;
+
+    /// Runner answering `workspace list` with fixed output and recording each call
+    struct FakeRunner {
+        list: String,
+        calls: Mutex<Vec<String>>,
+    }
+
+    impl CommandRunner for FakeRunner {
+        fn run(&self, program: &str, args: &[&str], _working_directory: &Path) -> io::Result<CommandOutput> {
+            self.calls.lock().unwrap().push(format!("{} {}", program, args.join(" ")));
+            let stdout = if args == ["workspace", "list"] { self.list.clone() } else { String::new() };
+            Ok(CommandOutput { exit_code: Some(0), stdout, stderr: String::new() })
+        }
+    }
+
+    /// **TEST** - An existing workspace is selected; in check-only mode it's only listed
+    #[test]
+    fn test_select_workspace() {
+        let runner = FakeRunner { list: "* default\n  staging\n".to_string(), calls: Mutex::new(Vec::new()) };
+        select_workspace(&runner, ImportBinary::Terragrunt, Path::new("modules/kms"), "staging", false).unwrap();
+        select_workspace(&runner, ImportBinary::Terraform, Path::new("modules/kms"), "default", true).unwrap();
+        assert_eq!(*runner.calls.lock().unwrap(), [
+            "terragrunt workspace list",
+            "terragrunt workspace select staging",
+            "terraform workspace list",
+        ]);
+    }
+
+    /// **TEST** - A missing workspace is reported with the ones that exist, and not selected
+    #[test]
+    fn test_select_missing_workspace() {
+        let runner = FakeRunner { list: "* default\n  staging\n".to_string(), calls: Mutex::new(Vec::new()) };
+        let error = select_workspace(&runner, ImportBinary::Terragrunt, Path::new("modules/kms"), "prod", false).unwrap_err();
+        assert_eq!(error.to_string(), "workspace 'prod' does not exist in modules/kms (available: default, staging)");
+        assert_eq!(runner.calls.lock().unwrap().len(), 1);
+    }
+}
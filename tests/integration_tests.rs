@@ -26,14 +26,28 @@
 //! - `out.json`: Terraform plan in JSON format
 
 use std::fs;
-use std::path::Path;
+use std::path::{Path, PathBuf};
 use std::process::Command;
 use std::sync::Once;
+use std::time::Duration;
 use tempfile::TempDir;
+use terragrunt_import_from_plan::app::{emit_import_blocks_with_runner, import_with_runner, load_builders, load_mappings, load_plan, preview_with_runner, ImportConfig};
+use terragrunt_import_from_plan::address::{parse_module_address, InstanceKey, ResourceAddress};
 use terragrunt_import_from_plan::importer::{
-    PlannedModule, Resource, ModulesFile, PlanFile,
-    validate_module_dirs, map_resources_to_modules, generate_import_commands, infer_resource_id
+    ImportDecision, PlannedModule, Resource, ModuleMeta, ModulesFile, PlanFile, PlanFormatVersion,
+    validate_module_dirs, map_resources_to_modules, generate_import_commands, infer_resource_id,
+    execute_or_print_imports, plan_imports, resolve_import_id
 };
+use terragrunt_import_from_plan::builders::ImportIdBuilderRegistry;
+use terragrunt_import_from_plan::checkpoint::{Checkpoint, CheckpointEntry};
+use terragrunt_import_from_plan::directories::DirectoryMap;
+use terragrunt_import_from_plan::mapping::{ImportIdMappings, MappingEntry, MappingFile};
+use terragrunt_import_from_plan::filter::ResourceFilter;
+use terragrunt_import_from_plan::reporting::{ImportStatus, EXIT_IMPORT_FAILURES, EXIT_SUCCESS, EXIT_USAGE_ERROR};
+use terragrunt_import_from_plan::planset::{PlanSet, UnitStatus};
+use terragrunt_import_from_plan::preview::Preview;
+use terragrunt_import_from_plan::logging::{LogLevel, Logger, SharedLogger, StdLogger};
+use terragrunt_import_from_plan::commands::{CancellationToken, CommandOutput, CommandRunner, ImportBinary, ImportCommand, ImportExecutor, ImportOptions, RetryConfig, RunOutcome, SystemCommandRunner};
 use terragrunt_import_from_plan::utils::{
     collect_resources, extract_id_candidate_fields,
     write_provider_schema, generate_fixtures
@@ -191,6 +205,7 @@ fn create_test_module() -> PlannedModule {
                 values: None,
                 sensitive_values: None,
                 depends_on: None,
+                module_address: None,
             },
             Resource {
                 address: "test.resource2".to_string(),
@@ -202,6 +217,7 @@ fn create_test_module() -> PlannedModule {
                 values: None,
                 sensitive_values: None,
                 depends_on: None,
+                module_address: None,
             },
         ]),
         child_modules: Some(vec![
@@ -217,6 +233,7 @@ fn create_test_module() -> PlannedModule {
                         values: None,
                         sensitive_values: None,
                         depends_on: None,
+                        module_address: None,
                     },
                 ]),
                 child_modules: None,
@@ -273,6 +290,7 @@ fn test_02_collect_resources_consolidation() {
                 values: None,
                 sensitive_values: None,
                 depends_on: None,
+                module_address: None,
             },
         ]),
         child_modules: Some(vec![
@@ -288,6 +306,7 @@ fn test_02_collect_resources_consolidation() {
                         values: None,
                         sensitive_values: None,
                         depends_on: None,
+                        module_address: None,
                     },
                 ]),
                 child_modules: Some(vec![
@@ -303,6 +322,7 @@ fn test_02_collect_resources_consolidation() {
                                 values: None,   
                                 sensitive_values: None,
                                 depends_on: None,
+                                module_address: None,
                             },
                         ]),
                         child_modules: None,
@@ -530,8 +550,8 @@ fn test_13_generate_import_commands() {
     let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
     let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
 
-    let mapping = map_resources_to_modules(&modules_file.modules, &plan);
-    let commands = generate_import_commands(&mapping, &plan, ".", true);
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let commands = generate_import_commands(&mapping, &plan, ".", &StdLogger::new(LogLevel::Debug));
 
     assert!(!commands.is_empty(), "No import commands generated");
     for cmd in commands {
@@ -548,12 +568,12 @@ fn test_13_generate_import_commands() {
 fn test_14_infer_resource_id() {
     let plan_data = fs::read_to_string("tests/fixtures/gcp/out.json").expect("Unable to read plan file");
     let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
-    let verbose = true;
+    let logger = StdLogger::new(LogLevel::Debug);
 
     let mut found = false;
     if let Some(planned_values) = &plan.planned_values {
         /// Internal helper function to recursively check modules for inferable IDs
-        fn check(module: &PlannedModule, found: &mut bool, verbose: bool, schema_map: &HashMap<String, Value>) {
+        fn check(module: &PlannedModule, found: &mut bool, logger: &dyn Logger, schema_map: &HashMap<String, Value>) {
             if let Some(resources) = &module.resources {
                 for resource in resources {
                     let terraform_resource = TerraformResource {
@@ -564,7 +584,7 @@ fn test_14_infer_resource_id() {
                         values: resource.values.clone(),
                     };
 
-                    if let Some(id) = infer_resource_id(&terraform_resource, None, verbose) {
+                    if let Some(id) = infer_resource_id(&terraform_resource, None, logger) {
                         println!("Inferred ID for {}: {}", resource.address, id);
                         *found = true;
                         return;
@@ -573,7 +593,7 @@ fn test_14_infer_resource_id() {
             }
             if let Some(children) = &module.child_modules {
                 for child in children {
-                    check(child, found, verbose, schema_map);
+                    check(child, found, logger, schema_map);
                 }
             }
         }
@@ -586,7 +606,7 @@ fn test_14_infer_resource_id() {
             .cloned()
             .unwrap_or_default();
 
-        check(&planned_values.root_module, &mut found, verbose, &schema_map);
+        check(&planned_values.root_module, &mut found, &logger, &schema_map);
     }
 
     assert!(found, "No resource ID could be inferred");
@@ -605,7 +625,7 @@ fn test_15_map_resources_to_modules() {
     let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
     let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
 
-    let mapping = map_resources_to_modules(&modules_file.modules, &plan);
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
 
     assert!(!mapping.is_empty(), "No resource-module mappings found");
 }
@@ -769,8 +789,8 @@ fn test_19_multi_cloud_module_root_gcp() {
     let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
     let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
 
-    let mapping = map_resources_to_modules(&modules_file.modules, &plan);
-    let commands = generate_import_commands(&mapping, &plan, "simulator/gcp/modules", true);
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let commands = generate_import_commands(&mapping, &plan, "simulator/gcp/modules", &StdLogger::new(LogLevel::Debug));
 
     // Commands should be generated and contain the GCP module path
     assert!(!commands.is_empty(), "No import commands generated for GCP modules");
@@ -790,6 +810,7 @@ fn test_19_multi_cloud_aws_directory_structure() {
         format!("{}/lambda", aws_module_root),
         format!("{}/iam", aws_module_root),
         format!("{}/vpc", aws_module_root),
+        format!("{}/ec2", aws_module_root),
     ];
 
     for path in test_module_paths {
@@ -811,12 +832,2644 @@ fn test_19_multi_cloud_module_root_aws() {
     let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid AWS modules JSON");
     let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid AWS plan JSON");
 
-    let mapping = map_resources_to_modules(&modules_file.modules, &plan);
-    let commands = generate_import_commands(&mapping, &plan, "simulator/aws/modules", true);
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let commands = generate_import_commands(&mapping, &plan, "simulator/aws/modules", &StdLogger::new(LogLevel::Debug));
 
     // Commands should be generated and contain the AWS module path
     assert!(!commands.is_empty(), "No import commands generated for AWS modules");
     for cmd in &commands {
         assert!(cmd.contains("simulator/aws/modules"), "Command does not contain AWS module path: {}", cmd);
     }
-}
\ No newline at end of file
+}
+/// **TEST** - Verifies plans from each supported format version parse identically
+/// 
+/// Loads one fixture per plan `format_version` and checks that the resource
+/// change addresses come out the same, and that older layouts are normalized
+/// so downstream code never has to branch on the version itself.
+#[test]
+fn test_20_plan_format_versions() {
+    let expected = vec![
+        "module.kms.google_kms_key_ring.example",
+        "module.kms.google_kms_crypto_key.example",
+        "module.storage.google_storage_bucket.example",
+    ];
+
+    for (fixture, version) in [
+        ("v0_1", PlanFormatVersion::V0_1),
+        ("v1_0", PlanFormatVersion::V1_0),
+        ("v1_1", PlanFormatVersion::V1_1),
+        ("v1_2", PlanFormatVersion::V1_2),
+    ] {
+        let path = format!("tests/fixtures/plan_formats/{}.json", fixture);
+        let plan = load_plan(&path).unwrap_or_else(|e| panic!("Failed to load {}: {:#}", path, e));
+
+        assert_eq!(PlanFormatVersion::parse(&plan.format_version).unwrap(), version);
+        assert_eq!(plan.resource_change_addresses(), expected, "Unexpected addresses in {}", fixture);
+
+        for change in plan.resource_changes.as_ref().unwrap() {
+            assert!(change.change.after_unknown.as_ref().unwrap().is_object(),
+                "after_unknown not normalized for {} in {}", change.address, fixture);
+            assert!(change.change.after_sensitive.is_some(),
+                "after_sensitive not normalized for {} in {}", change.address, fixture);
+        }
+    }
+}
+
+/// **TEST** - Verifies unknown plan format versions are rejected with a clear error
+#[test]
+fn test_20_plan_format_version_unsupported() {
+    let result = load_plan("tests/fixtures/plan_formats/unsupported.json");
+    assert!(result.is_err());
+
+    let error_string = format!("{:#}", result.unwrap_err());
+    assert!(error_string.contains("unsupported plan format version: 2.0"), "Unexpected error: {}", error_string);
+}
+
+/// **TEST** - Verifies dry-run mode returns the commands without executing them
+/// 
+/// Uses a working directory that doesn't exist, so any real execution attempt
+/// would be reported as a failure. Indexed addresses must be shell-quoted.
+#[test]
+fn test_21_dry_run_returns_commands() {
+    let commands = vec![
+        ImportCommand {
+            working_directory: PathBuf::from("/nonexistent/kms"),
+            resource_address: r#"module.kms.google_kms_crypto_key.this["primary"]"#.to_string(),
+            resource_id: "projects/p/locations/europe-west1/keyRings/ring/cryptoKeys/primary".to_string(),
+            resource_type: "google_kms_crypto_key".to_string(),
+            module_name: "kms".to_string(),
+            binary: ImportBinary::Terragrunt,
+            extra_args: Vec::new(),
+        },
+        ImportCommand {
+            working_directory: PathBuf::from("/nonexistent/kms"),
+            resource_address: "module.kms.google_kms_key_ring.example".to_string(),
+            resource_id: "projects/p/locations/europe-west1/keyRings/ring".to_string(),
+            resource_type: "google_kms_key_ring".to_string(),
+            module_name: "kms".to_string(),
+            binary: ImportBinary::Terragrunt,
+            extra_args: Vec::new(),
+        },
+    ];
+
+    let options = ImportOptions { dry_run: true, ..Default::default() };
+    let result = ImportExecutor.execute_imports(&commands, &options);
+
+    assert!(result.failed.is_empty(), "Dry run must not execute commands");
+    assert!(result.successful.is_empty(), "Dry run must not execute commands");
+    assert_eq!(result.dry_run.len(), 2);
+    assert_eq!(result.commands, vec![
+        r#"terragrunt import -config-dir=/nonexistent/kms 'module.kms.google_kms_crypto_key.this["primary"]' projects/p/locations/europe-west1/keyRings/ring/cryptoKeys/primary"#,
+        "terragrunt import -config-dir=/nonexistent/kms module.kms.google_kms_key_ring.example projects/p/locations/europe-west1/keyRings/ring",
+    ]);
+}
+
+/// **TEST** - Verifies the legacy workflow returns quoted commands in dry-run mode
+#[test]
+fn test_21_execute_or_print_imports_dry_run() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let plan_data = fs::read_to_string("tests/fixtures/gcp/out.json").expect("Unable to read plan file");
+
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let commands = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner).expect("Import run failed").commands();
+
+    assert!(!commands.is_empty(), "No commands returned from dry run");
+    let indexed = commands.iter()
+        .find(|cmd| cmd.contains(r#"required_services["run.googleapis.com"]"#))
+        .expect("Indexed resource missing from dry run output");
+    assert!(indexed.contains(r#"'module.enable_apis.google_project_service.required_services["run.googleapis.com"]'"#),
+        "Indexed address not quoted: {}", indexed);
+}
+
+/// **TEST** - Registered builders produce provider-format import IDs
+/// 
+/// The KMS key ring builder should emit the full resource path, and a crypto key
+/// whose `key_ring` is unknown at plan time should be skipped rather than guessed.
+#[test]
+fn test_22_import_id_builders_in_dry_run() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let plan_data = fs::read_to_string("tests/fixtures/gcp/out.json").expect("Unable to read plan file");
+
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let commands = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner).expect("Import run failed").commands();
+
+    let key_ring = commands.iter()
+        .find(|cmd| cmd.contains("module.kms.google_kms_key_ring.example"))
+        .expect("Key ring missing from dry run output");
+    assert!(key_ring.ends_with("projects/your-gcp-project-id/locations/europe-west1/keyRings/sim-keyring"),
+        "Unexpected key ring import ID: {}", key_ring);
+
+    assert!(commands.iter().all(|cmd| !cmd.contains("module.kms.google_kms_crypto_key.example")),
+        "Crypto key with unknown key_ring should not be imported");
+}
+
+/// Command runner that reports the same state, holding `addresses`, for every directory
+struct FakeStateRunner {
+    addresses: Vec<&'static str>,
+}
+
+impl CommandRunner for FakeStateRunner {
+    fn run(&self, _program: &str, args: &[&str], _working_directory: &Path) -> std::io::Result<CommandOutput> {
+        assert_eq!(args, ["state", "pull"], "Only state pulls are expected during a dry run");
+        let resources: Vec<Value> = self.addresses.iter().map(|address| {
+            let address: ResourceAddress = address.parse().unwrap();
+            let index_key = match &address.key {
+                Some(InstanceKey::Index(index)) => json!(index),
+                Some(InstanceKey::Key(key)) => json!(key),
+                None => Value::Null,
+            };
+            json!({
+                "module": address.module_address(),
+                "mode": "managed",
+                "type": address.resource_type,
+                "name": address.name,
+                "instances": [{"index_key": index_key, "attributes": {}}]
+            })
+        }).collect();
+        Ok(CommandOutput {
+            exit_code: Some(0),
+            stdout: json!({"version": 4, "resources": resources}).to_string(),
+            stderr: String::new(),
+        })
+    }
+}
+
+/// **TEST** - Resources already present in state are not imported again
+/// 
+/// Simulates a re-run after a partial failure: the key ring is already in state,
+/// so no command should be generated for it while other resources still are.
+#[test]
+fn test_23_skip_resources_already_in_state() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let plan_data = fs::read_to_string("tests/fixtures/gcp/out.json").expect("Unable to read plan file");
+
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let runner = FakeStateRunner { addresses: vec!["module.kms.google_kms_key_ring.example"] };
+    let commands = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &runner).expect("Import run failed").commands();
+
+    assert!(!commands.is_empty(), "Resources not in state should still be imported");
+    assert!(commands.iter().all(|cmd| !cmd.contains("module.kms.google_kms_key_ring.example")),
+        "Resource already in state should be skipped");
+}
+
+/// **TEST** - Indexed and nested module addresses map to their modules.json keys
+/// 
+/// `for_each` on a module call yields module addresses such as `module.kms["primary"]`,
+/// and nested modules are keyed `parent.child` in modules.json. Both must resolve to
+/// the right module, and instance keys must survive into the generated command.
+#[test]
+fn test_24_indexed_and_nested_module_addresses() {
+    let plan: PlanFile = serde_json::from_value(json!({
+        "format_version": "1.2",
+        "terraform_version": "1.9.0",
+        "planned_values": {
+            "root_module": {
+                "child_modules": [
+                    {
+                        "address": "module.kms[\"primary\"]",
+                        "resources": [{
+                            "address": "module.kms[\"primary\"].google_kms_key_ring.this[0]",
+                            "mode": "managed",
+                            "type": "google_kms_key_ring",
+                            "name": "this",
+                            "values": { "name": "ring", "project": "p", "location": "l" }
+                        }]
+                    },
+                    {
+                        "address": "module.app",
+                        "child_modules": [{
+                            "address": "module.app.module.db",
+                            "resources": [{
+                                "address": "module.app.module.db.google_storage_bucket.this[\"logs\"]",
+                                "mode": "managed",
+                                "type": "google_storage_bucket",
+                                "name": "this",
+                                "values": { "name": "logs-bucket" }
+                            }]
+                        }]
+                    }
+                ]
+            }
+        }
+    })).expect("Invalid plan JSON");
+    let modules = vec![
+        ModuleMeta { key: "kms".to_string(), source: "./modules/kms".to_string(), dir: "modules/kms".to_string() },
+        ModuleMeta { key: "app.db".to_string(), source: "./modules/db".to_string(), dir: "modules/db".to_string() },
+    ];
+
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    assert_eq!(mapping[r#"module.kms["primary"].google_kms_key_ring.this[0]"#].key, "kms");
+    assert_eq!(mapping[r#"module.app.module.db.google_storage_bucket.this["logs"]"#].key, "app.db");
+
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let commands = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "modules", &SystemCommandRunner).expect("Import run failed").commands();
+
+    assert_eq!(commands.len(), 2, "Both instances should produce commands: {:?}", commands);
+    assert!(commands.iter().any(|cmd| cmd.contains(r#"'module.kms["primary"].google_kms_key_ring.this[0]' projects/p/locations/l/keyRings/ring"#)));
+    assert!(commands.iter().any(|cmd| cmd.contains(r#"'module.app.module.db.google_storage_bucket.this["logs"]' logs-bucket"#)));
+}
+
+/// Builds an import command whose working directory does not exist, so executing it fails
+/// immediately without invoking terragrunt
+fn failing_command(module: &str, name: &str) -> ImportCommand {
+    ImportCommand {
+        working_directory: PathBuf::from(format!("/nonexistent/{}", module)),
+        resource_address: format!("module.{}.google_storage_bucket.{}", module, name),
+        resource_id: name.to_string(),
+        resource_type: "google_storage_bucket".to_string(),
+        module_name: module.to_string(),
+        binary: ImportBinary::Terragrunt,
+        extra_args: Vec::new(),
+    }
+}
+
+/// **TEST** - Worker pool results are collected in input order
+/// 
+/// Failures in one worker must not stop the others when fail-fast is off.
+#[test]
+fn test_25_parallel_imports_deterministic_results() {
+    let commands: Vec<ImportCommand> = (0..8)
+        .map(|i| failing_command(&format!("m{}", i % 3), &format!("b{}", i)))
+        .collect();
+
+    let options = ImportOptions { workers: 4, ..Default::default() };
+    let result = ImportExecutor.execute_imports(&commands, &options);
+
+    let addresses: Vec<&str> = result.failed.iter().map(|r| r.address()).collect();
+    let expected: Vec<&str> = commands.iter().map(|c| c.resource_address.as_str()).collect();
+    assert_eq!(addresses, expected, "Results must follow input order");
+    assert!(result.cancelled.is_empty(), "No command should be cancelled without fail-fast");
+    assert_eq!(result.total_executed, 8);
+}
+
+/// **TEST** - With fail-fast, commands not yet started after a failure are cancelled
+#[test]
+fn test_25_fail_fast_cancels_remaining_imports() {
+    let commands = vec![
+        failing_command("kms", "first"),
+        failing_command("kms", "second"),
+        failing_command("storage", "third"),
+    ];
+
+    let options = ImportOptions { fail_fast: true, ..Default::default() };
+    let result = ImportExecutor.execute_imports(&commands, &options);
+
+    assert_eq!(result.failed.len(), 1);
+    assert_eq!(result.failed[0].address(), "module.kms.google_storage_bucket.first");
+    let cancelled: Vec<&str> = result.cancelled.iter().map(|r| r.address()).collect();
+    assert_eq!(cancelled, vec!["module.kms.google_storage_bucket.second", "module.storage.google_storage_bucket.third"]);
+}
+
+/// **TEST** - The JSON report lists every resource with totals and overall status
+#[test]
+fn test_26_json_report() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let plan_data = fs::read_to_string("tests/fixtures/gcp/out.json").expect("Unable to read plan file");
+
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan: PlanFile = serde_json::from_str(&plan_data).expect("Invalid plan JSON");
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner).expect("Import run failed");
+
+    let temp_dir = TempDir::new().expect("Failed to create temp dir");
+    let report_path = temp_dir.path().join("report.json");
+    report.write_json(&report_path).expect("Failed to write report");
+
+    let json: Value = serde_json::from_str(&fs::read_to_string(&report_path).unwrap()).expect("Report is not valid JSON");
+    assert_eq!(json["success"], true);
+    assert_eq!(json["exit_code"], 0);
+    assert_eq!(json["failed"], 0);
+    let resources = json["resources"].as_array().expect("resources must be an array");
+    assert_eq!(json["total"].as_u64().unwrap() as usize, resources.len());
+
+    let key_ring = resources.iter()
+        .find(|r| r["address"] == "module.kms.google_kms_key_ring.example")
+        .expect("Key ring missing from report");
+    assert_eq!(key_ring["status"], "dry_run");
+    assert_eq!(key_ring["import_id"], "projects/your-gcp-project-id/locations/europe-west1/keyRings/sim-keyring");
+
+    let crypto_key = resources.iter()
+        .find(|r| r["address"] == "module.kms.google_kms_crypto_key.example")
+        .expect("Crypto key missing from report");
+    assert_eq!(crypto_key["status"], "skipped");
+    assert!(crypto_key["error"].as_str().unwrap().contains("key_ring"));
+}
+
+/// **TEST** - Mapping file IDs override builders, exact entries before globs
+/// 
+/// The key ring has a registered builder but must use the mapped ID. The storage
+/// bucket matches both a glob and an exact entry, and the exact entry must win even
+/// though the glob appears first. Other buckets fall through to the glob.
+#[test]
+fn test_27_mapping_file_precedence() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+
+    let mappings = load_mappings("tests/fixtures/mappings/gcp.json", &plan, &StdLogger::default()).expect("Failed to load mappings");
+    assert_eq!(
+        mappings.lookup("module.storage.google_storage_bucket.example"),
+        Some("storage-bucket-override")
+    );
+    assert_eq!(mappings.lookup("module.cloud_functions.google_storage_bucket.source"), Some("any-bucket"));
+    assert_eq!(mappings.lookup("module.kms.google_kms_crypto_key.example"), None);
+
+    let addresses: Vec<String> = vec!["module.kms.google_kms_key_ring.example".to_string()];
+    assert_eq!(
+        mappings.unused_entries(&addresses),
+        vec!["module.*.google_storage_bucket.*", "module.removed.google_pubsub_topic.old", "module.storage.google_storage_bucket.example"]
+    );
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    // The glob gives every cloud_functions bucket the same ID
+    let options = ImportOptions { dry_run: true, skip_state_check: true, allow_duplicate_ids: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let commands = execute_or_print_imports(&mapping, &plan, &mappings, &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner).expect("Import run failed").commands();
+
+    let command_for = |address: &str| commands.iter()
+        .find(|cmd| cmd.contains(&format!(" {} ", address)))
+        .unwrap_or_else(|| panic!("No command for {}", address))
+        .clone();
+    assert!(command_for("module.kms.google_kms_key_ring.example")
+        .ends_with("projects/legacy-project/locations/europe-west1/keyRings/legacy-ring"));
+    assert!(command_for("module.storage.google_storage_bucket.example").ends_with(" storage-bucket-override"));
+    assert!(command_for("module.cloud_functions.google_storage_bucket.source").ends_with(" any-bucket"));
+}
+
+/// Command runner whose `terragrunt state pull` always fails, recording every call
+struct FailingBackupRunner {
+    calls: std::sync::Mutex<Vec<String>>,
+}
+
+impl CommandRunner for FailingBackupRunner {
+    fn run(&self, _program: &str, args: &[&str], working_directory: &Path) -> std::io::Result<CommandOutput> {
+        self.calls.lock().unwrap().push(format!("{} in {}", args.join(" "), working_directory.display()));
+        Ok(CommandOutput {
+            exit_code: Some(1),
+            stdout: String::new(),
+            stderr: "Error: Failed to load state: AccessDenied".to_string(),
+        })
+    }
+}
+
+/// **TEST** - A failed state backup aborts the run before any import
+/// 
+/// With `state_backup_dir` set, the first module's `state pull` fails. The run must
+/// return an error after that single call, leaving the backup directory empty.
+#[test]
+fn test_28_state_backup_failure_aborts_imports() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+
+    let backup_dir = tempfile::tempdir().expect("Failed to create temp dir");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions {
+        skip_state_check: true,
+        state_backup_dir: Some(backup_dir.path().to_path_buf()),
+        ..Default::default()
+    };
+    let builders = ImportIdBuilderRegistry::default();
+    let runner = FailingBackupRunner { calls: std::sync::Mutex::new(Vec::new()) };
+
+    let result = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &runner);
+
+    let err = result.expect_err("Backup failure should abort the run");
+    assert!(err.to_string().contains("state backup failed"), "Unexpected error: {}", err);
+    let calls = runner.calls.lock().unwrap();
+    assert_eq!(calls.len(), 1, "Run should stop at the first failed backup: {:?}", calls);
+    assert!(calls[0].starts_with("state pull in "));
+    assert_eq!(fs::read_dir(backup_dir.path()).unwrap().count(), 0);
+}
+
+/// **TEST** - Dry runs never back up state
+#[test]
+fn test_28_state_backup_skipped_in_dry_run() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+
+    let backup_dir = tempfile::tempdir().expect("Failed to create temp dir");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions {
+        dry_run: true,
+        skip_state_check: true,
+        state_backup_dir: Some(backup_dir.path().to_path_buf()),
+        ..Default::default()
+    };
+    let builders = ImportIdBuilderRegistry::default();
+    let runner = FailingBackupRunner { calls: std::sync::Mutex::new(Vec::new()) };
+
+    let commands = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &runner).expect("Dry run should not back up state").commands();
+
+    assert!(!commands.is_empty());
+    assert!(runner.calls.lock().unwrap().is_empty());
+}
+
+/// **TEST** - Include/exclude patterns limit which resources get import commands
+/// 
+/// `module.kms.*` selects only the KMS module; excluding the crypto key type wins
+/// over the include; `google_kms_*` matches full addresses and so selects nothing
+/// in a plan where every resource lives in a module.
+#[test]
+fn test_29_include_exclude_filters() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let builders = ImportIdBuilderRegistry::default();
+
+    let addresses_for = |include: &[&str], exclude: &[&str]| -> Vec<String> {
+        let to_strings = |patterns: &[&str]| patterns.iter().map(|p| p.to_string()).collect::<Vec<_>>();
+        let options = ImportOptions {
+            dry_run: true,
+            skip_state_check: true,
+            filter: ResourceFilter::new(&to_strings(include), &to_strings(exclude)).expect("Invalid filter"),
+            ..Default::default()
+        };
+        let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner).expect("Import run failed");
+        report.resources.iter().map(|entry| entry.address.clone()).collect()
+    };
+
+    let all = addresses_for(&[], &[]);
+    let kms = addresses_for(&["module.kms.*"], &[]);
+    assert!(!kms.is_empty() && kms.len() < all.len());
+    assert!(kms.iter().all(|address| address.starts_with("module.kms.")), "{:?}", kms);
+    assert!(kms.contains(&"module.kms.google_kms_crypto_key.example".to_string()));
+
+    let without_keys = addresses_for(&["module.kms.*"], &["*.google_kms_crypto_key.*"]);
+    assert_eq!(without_keys.len(), kms.len() - 1);
+    assert!(!without_keys.contains(&"module.kms.google_kms_crypto_key.example".to_string()));
+
+    assert!(addresses_for(&["google_kms_*"], &[]).is_empty());
+    assert_eq!(addresses_for(&["*google_kms_*"], &[]).len(), kms.len());
+}
+
+/// **TEST** - Resource types without an import ID builder are reported as unsupported
+/// 
+/// A type with no builder and no inferable ID attribute must not stop the supported
+/// resource from being imported, and must appear in the report with its own status.
+#[test]
+fn test_30_unsupported_resource_types_reported() {
+    let plan: PlanFile = serde_json::from_value(json!({
+        "format_version": "1.2",
+        "terraform_version": "1.9.0",
+        "planned_values": {
+            "root_module": {
+                "child_modules": [{
+                    "address": "module.app",
+                    "resources": [
+                        {
+                            "address": "module.app.google_storage_bucket.logs",
+                            "mode": "managed",
+                            "type": "google_storage_bucket",
+                            "name": "logs",
+                            "values": { "name": "logs-bucket", "project": "p" }
+                        },
+                        {
+                            "address": "module.app.google_widget.thing",
+                            "mode": "managed",
+                            "type": "google_widget",
+                            "name": "thing",
+                            "values": { "size": 3 }
+                        }
+                    ]
+                }]
+            }
+        }
+    })).expect("Invalid plan JSON");
+    let modules = vec![
+        ModuleMeta { key: "app".to_string(), source: "./modules/app".to_string(), dir: "modules/app".to_string() },
+    ];
+
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "modules", &SystemCommandRunner).expect("Import run failed");
+
+    assert_eq!(report.commands().len(), 1);
+    assert_eq!((report.imported, report.unsupported), (1, 1));
+    assert!(report.success, "Unsupported resources alone must not fail the run");
+
+    let entry = report.resources.iter().find(|entry| entry.address == "module.app.google_widget.thing").unwrap();
+    assert_eq!(entry.status, ImportStatus::Unsupported);
+    assert!(entry.error.as_deref().unwrap().contains("no import id builder for resource type 'google_widget'"));
+}
+
+/// **TEST** - Only resources planned for a pure create are queued for import
+/// 
+/// The fixture mixes create, update, replace (both orders), no-op and delete actions
+/// for otherwise identical buckets; only the create must produce a command. The
+/// replacements abort the run unless they are skipped.
+#[test]
+fn test_31_only_create_actions_imported() {
+    let plan = load_plan("tests/fixtures/plan_actions/mixed.json").expect("Failed to load plan");
+    let modules = vec![
+        ModuleMeta { key: "storage".to_string(), source: "./modules/storage".to_string(), dir: "modules/storage".to_string() },
+    ];
+
+    let creates = plan.create_only_addresses().expect("Plan has resource changes");
+    assert_eq!(creates.len(), 1);
+
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let err = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "modules", &SystemCommandRunner)
+        .expect_err("Replacements were imported");
+    assert!(err.to_string().contains("the plan replaces module.storage.google_storage_bucket.replaced, module.storage.google_storage_bucket.replaced_cbd;"), "{}", err);
+
+    let options = ImportOptions { skip_replacements: true, ..options };
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "modules", &SystemCommandRunner).expect("Import run failed");
+
+    let imported: Vec<&str> = report.resources.iter()
+        .filter(|entry| entry.status != ImportStatus::Skipped)
+        .map(|entry| entry.address.as_str())
+        .collect();
+    assert_eq!(imported, vec!["module.storage.google_storage_bucket.created"]);
+    assert_eq!(report.commands().len(), 1);
+
+    let commands = generate_import_commands(&mapping, &plan, "modules", &StdLogger::default());
+    assert_eq!(commands.len(), 1);
+    assert!(commands[0].contains("module.storage.google_storage_bucket.created"));
+}
+
+/// **TEST** - A plan set imports each unit in its own working directory
+/// 
+/// One unit's plan is corrupt: it must be reported as failed while the other unit
+/// still runs, unless fail-fast is enabled, in which case later units are skipped.
+#[test]
+fn test_32_plan_set_runs_units_independently() {
+    let plans = tempfile::tempdir().expect("Failed to create temp dir");
+    fs::create_dir_all(plans.path().join("prod")).unwrap();
+    fs::write(plans.path().join("prod/broken.json"), "{ not json").unwrap();
+    fs::copy("tests/fixtures/plan_actions/mixed.json", plans.path().join("prod/storage.json")).unwrap();
+
+    let plan_set = PlanSet::from_dir(plans.path(), Path::new("live")).expect("Failed to read plan set");
+    assert_eq!(plan_set.units.len(), 2);
+
+    let builders = ImportIdBuilderRegistry::default();
+    let options = ImportOptions { dry_run: true, skip_state_check: true, skip_replacements: true, ..Default::default() };
+    let report = plan_set.run(&ImportIdMappings::new(), &builders, &options, false, &SystemCommandRunner);
+
+    assert!(!report.success);
+    assert_eq!((report.failed, report.skipped), (1, 0));
+    assert_eq!(report.units[0].unit, "prod/broken");
+    assert!(report.units[0].error.as_deref().unwrap().contains("Failed to parse plan JSON"));
+
+    let storage = report.units[1].report.as_ref().expect("Storage unit should have run");
+    assert_eq!(storage.commands().len(), 1);
+    assert!(storage.commands()[0].starts_with("terragrunt import -config-dir=live/prod/storage "), "{:?}", storage.commands());
+
+    let fail_fast = ImportOptions { fail_fast: true, ..options };
+    let report = plan_set.run(&ImportIdMappings::new(), &builders, &fail_fast, false, &SystemCommandRunner);
+    assert_eq!((report.failed, report.skipped), (1, 1));
+    assert_eq!(report.units[1].status, UnitStatus::Skipped);
+}
+
+/// Logger that keeps every message so tests can assert on diagnostics
+#[derive(Default)]
+struct RecordingLogger {
+    messages: std::sync::Mutex<Vec<(LogLevel, String)>>,
+}
+
+impl Logger for RecordingLogger {
+    fn log(&self, level: LogLevel, message: &str) {
+        self.messages.lock().unwrap().push((level, message.to_string()));
+    }
+}
+
+impl RecordingLogger {
+    fn debug_messages(&self) -> Vec<String> {
+        self.messages.lock().unwrap().iter()
+            .filter(|(level, _)| *level == LogLevel::Debug)
+            .map(|(_, message)| message.clone())
+            .collect()
+    }
+}
+
+/// **TEST** - An injected logger receives parsed addresses, built IDs and executed commands
+#[test]
+fn test_33_injected_logger_receives_debug_output() {
+    let plan = load_plan("tests/fixtures/plan_actions/mixed.json").expect("Failed to load plan");
+    let modules = vec![
+        ModuleMeta { key: "storage".to_string(), source: "./modules/storage".to_string(), dir: "modules/storage".to_string() },
+    ];
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let options = ImportOptions {
+        dry_run: true,
+        skip_state_check: true,
+        skip_replacements: true,
+        logger: SharedLogger::from_arc(logger.clone()),
+        ..Default::default()
+    };
+    let builders = ImportIdBuilderRegistry::default();
+    execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "modules", &SystemCommandRunner).expect("Import run failed");
+
+    let debug = logger.debug_messages();
+    assert!(debug.contains(&"Parsed address module.storage.google_storage_bucket.created: module=module.storage type=google_storage_bucket name=created key=<none>".to_string()), "{:?}", debug);
+    assert!(debug.contains(&"Built import ID for module.storage.google_storage_bucket.created: sim-project/created-bucket".to_string()), "{:?}", debug);
+
+    let options = ImportOptions { dry_run: false, ..options };
+    let _ = ImportExecutor.run_command(&failing_command("kms", "logged"), &options, &SystemCommandRunner);
+    assert!(logger.debug_messages().iter().any(|message| message.starts_with("Executing in /nonexistent/kms: terragrunt import")));
+}
+
+/// **TEST** - AWS resources in the fixture plan get IDs from the AWS builders
+/// 
+/// The bucket and role are named in the plan; the security group's ID is only
+/// assigned by AWS, so it must be skipped with a clear reason instead of guessed.
+#[test]
+fn test_34_aws_builders_on_fixture_plan() {
+    let modules_data = fs::read_to_string("tests/fixtures/aws/modules.json").expect("Unable to read AWS modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid AWS modules JSON");
+    let plan = load_plan("tests/fixtures/aws/out.json").expect("Failed to load AWS plan");
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let builders = ImportIdBuilderRegistry::default();
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/aws/modules", &SystemCommandRunner).expect("Import run failed");
+
+    let entry = |address: &str| report.resources.iter()
+        .find(|entry| entry.address == address)
+        .unwrap_or_else(|| panic!("No report entry for {}", address));
+    assert_eq!(entry("module.s3.aws_s3_bucket.example").import_id.as_deref(), Some("sim-s3-bucket-example-12345"));
+    assert_eq!(entry("module.iam.aws_iam_role.example").import_id.as_deref(), Some("sim-iam-role"));
+
+    let security_group = entry("module.vpc.aws_security_group.example");
+    assert_eq!(security_group.status, ImportStatus::Skipped);
+    assert!(security_group.error.as_deref().unwrap().contains("'id' is missing or unknown at plan time"));
+}
+
+/// **TEST** - With ID validation enabled, a malformed mapped ID fails without running a command
+/// 
+/// The mapped crypto key ID lacks the key ring path, so it doesn't match the
+/// builder's format; well-formed IDs in the same plan are still imported.
+#[test]
+fn test_35_validate_ids_rejects_malformed_ids() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let mappings = ImportIdMappings::from_file(MappingFile {
+        mappings: vec![MappingEntry {
+            address: "module.kms.google_kms_crypto_key.example".to_string(),
+            id: "sim-keyring/sim-key".to_string(),
+        }],
+        ..Default::default()
+    }).expect("Invalid mappings");
+    let builders = ImportIdBuilderRegistry::default();
+    let run = |validate_ids: bool| {
+        let options = ImportOptions { dry_run: true, skip_state_check: true, validate_ids, ..Default::default() };
+        execute_or_print_imports(&mapping, &plan, &mappings, &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner).expect("Import run failed")
+    };
+
+    let report = run(true);
+    let crypto_key = report.resources.iter()
+        .find(|entry| entry.address == "module.kms.google_kms_crypto_key.example")
+        .expect("No report entry for crypto key");
+    assert_eq!(crypto_key.status, ImportStatus::Failed);
+    assert_eq!(crypto_key.error.as_deref(), Some("invalid import id for google_kms_crypto_key: expected 8 segments, got 2"));
+    assert!(crypto_key.command.is_none());
+    assert!(report.resources.iter().any(|entry| entry.address == "module.kms.google_kms_key_ring.example" && entry.command.is_some()));
+
+    let unvalidated = run(false);
+    assert!(unvalidated.commands().iter().any(|command| command.contains("sim-keyring/sim-key")));
+}
+
+/// **TEST** - The chosen binary determines the exact program, arguments and printed command
+/// 
+/// Both binaries run `import <address> <id>` with the module directory as working
+/// directory; only the printed form names the directory, with the flag each binary
+/// understands in the position it requires.
+#[test]
+fn test_36_import_argv_per_binary() {
+    let command = |binary: ImportBinary| ImportCommand {
+        working_directory: PathBuf::from("live/prod/vpc"),
+        resource_address: r#"aws_subnet.private["a"]"#.to_string(),
+        resource_id: "subnet-0abc".to_string(),
+        resource_type: "aws_subnet".to_string(),
+        module_name: "vpc".to_string(),
+        binary,
+        extra_args: Vec::new(),
+    };
+
+    let terragrunt = command(ImportBinary::Terragrunt);
+    assert_eq!(terragrunt.binary.program(), "terragrunt");
+    assert_eq!(terragrunt.args(), ["import", r#"aws_subnet.private["a"]"#, "subnet-0abc"]);
+    assert_eq!(terragrunt.command_string(), r#"terragrunt import -config-dir=live/prod/vpc 'aws_subnet.private["a"]' subnet-0abc"#);
+
+    let terraform = command(ImportBinary::Terraform);
+    assert_eq!(terraform.binary.program(), "terraform");
+    assert_eq!(terraform.args(), ["import", r#"aws_subnet.private["a"]"#, "subnet-0abc"]);
+    assert_eq!(terraform.command_string(), r#"terraform -chdir=live/prod/vpc import 'aws_subnet.private["a"]' subnet-0abc"#);
+    assert_eq!(terraform.working_directory, PathBuf::from("live/prod/vpc"));
+
+    assert_eq!("terraform".parse::<ImportBinary>(), Ok(ImportBinary::Terraform));
+    assert!("tofu".parse::<ImportBinary>().unwrap_err().contains("unknown binary 'tofu'"));
+
+    // State checks in a dry run go through the same binary
+    let modules = vec![
+        ModuleMeta { key: "storage".to_string(), source: "./modules/storage".to_string(), dir: "modules/storage".to_string() },
+    ];
+    let plan = load_plan("tests/fixtures/plan_actions/mixed.json").expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    let runner = RecordingRunner::default();
+    let options = ImportOptions { dry_run: true, binary: ImportBinary::Terraform, skip_replacements: true, ..Default::default() };
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, false, "modules", &runner).expect("Import run failed");
+
+    assert_eq!(runner.programs.lock().unwrap().as_slice(), ["terraform state pull"]);
+    assert!(report.commands().iter().all(|command| command.starts_with("terraform -chdir=modules/modules/storage import ")), "{:?}", report.commands());
+}
+
+/// Command runner that records each program and arguments and reports empty state
+#[derive(Default)]
+struct RecordingRunner {
+    programs: std::sync::Mutex<Vec<String>>,
+}
+
+impl CommandRunner for RecordingRunner {
+    fn run(&self, program: &str, args: &[&str], _working_directory: &Path) -> std::io::Result<CommandOutput> {
+        self.programs.lock().unwrap().push(format!("{} {}", program, args.join(" ")));
+        Ok(CommandOutput { exit_code: Some(0), stdout: String::new(), stderr: String::new() })
+    }
+}
+
+/// Command runner serving a fixed `state pull` document and counting the pulls
+#[derive(Default)]
+struct PulledStateRunner {
+    state: String,
+    pulls: std::sync::atomic::AtomicUsize,
+}
+
+impl CommandRunner for PulledStateRunner {
+    fn run(&self, _program: &str, args: &[&str], _working_directory: &Path) -> std::io::Result<CommandOutput> {
+        if args == ["state", "pull"] {
+            self.pulls.fetch_add(1, std::sync::atomic::Ordering::SeqCst);
+        }
+        let stdout = if args == ["state", "pull"] { self.state.clone() } else { String::new() };
+        Ok(CommandOutput { exit_code: Some(0), stdout, stderr: String::new() })
+    }
+}
+
+/// **TEST** - Attributes unknown in the plan are filled in from pulled state when enabled
+/// 
+/// The crypto key's `key_ring` is only known after apply, so without the fallback it
+/// is skipped; with it, the value comes from state and the resolution is logged.
+#[test]
+fn test_37_unknown_attributes_resolved_from_state() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let runner = PulledStateRunner {
+        state: json!({
+            "version": 4,
+            "resources": [{
+                "module": "module.kms",
+                "mode": "managed",
+                "type": "google_kms_crypto_key",
+                "name": "example",
+                "instances": [{"attributes": {"key_ring": "projects/sim-project/locations/europe-west1/keyRings/sim-keyring", "name": "sim-key"}}]
+            }]
+        }).to_string(),
+        ..Default::default()
+    };
+    let builders = ImportIdBuilderRegistry::default();
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let run = |resolve_unknown_from_state: bool| {
+        let options = ImportOptions {
+            dry_run: true,
+            resolve_unknown_from_state,
+            logger: SharedLogger::from_arc(logger.clone()),
+            ..Default::default()
+        };
+        let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &runner).expect("Import run failed");
+        report.resources.into_iter()
+            .find(|entry| entry.address == "module.kms.google_kms_crypto_key.example")
+            .expect("No report entry for crypto key")
+    };
+
+    let without = run(false);
+    assert_eq!(without.status, ImportStatus::Skipped);
+
+    let with = run(true);
+    assert_eq!(with.import_id.as_deref(), Some("projects/sim-project/locations/europe-west1/keyRings/sim-keyring/cryptoKeys/sim-key"));
+    let messages = logger.messages.lock().unwrap();
+    assert!(messages.iter().any(|(level, message)| *level == LogLevel::Info
+        && message.contains("Resolved key_ring of module.kms.google_kms_crypto_key.example from state (unknown in plan)")), "{:?}", messages);
+}
+
+/// **TEST** - The library entry point runs the whole flow and returns the report
+/// 
+/// Inputs, filters and the runner are all supplied programmatically, with no CLI
+/// involved; the report can be asserted on directly.
+#[test]
+fn test_38_library_import_entry_point() {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules");
+    config.mapping_path = Some(PathBuf::from("tests/fixtures/mappings/gcp.json"));
+    config.options.dry_run = true;
+    config.options.filter = ResourceFilter::new(&["module.kms.*".to_string()], &[]).unwrap();
+
+    let report = import_with_runner(&config, &RecordingRunner::default()).expect("Import run failed");
+
+    assert!(report.success);
+    assert!(!report.resources.is_empty());
+    assert!(report.resources.iter().all(|entry| entry.address.starts_with("module.kms.")));
+    let key_ring = report.resources.iter()
+        .find(|entry| entry.address == "module.kms.google_kms_key_ring.example")
+        .expect("No report entry for key ring");
+    assert_eq!(key_ring.import_id.as_deref(), Some("projects/legacy-project/locations/europe-west1/keyRings/legacy-ring"));
+    assert_eq!(key_ring.status, ImportStatus::DryRun);
+
+    let missing = ImportConfig::new("tests/fixtures/gcp/missing.json", "tests/fixtures/gcp/modules.json");
+    let err = import_with_runner(&missing, &RecordingRunner::default()).unwrap_err();
+    assert!(format!("{:#}", err).contains("Failed to read plan file"), "{:#}", err);
+}
+
+/// **INTEGRATION TEST** - A cancelled run reports its imports as cancelled, not failed
+/// 
+/// The token is cancelled before the run starts (as a zero `--timeout` would), so no
+/// import process is spawned and every planned import ends up with status "cancelled".
+#[test]
+fn test_39_cancelled_run_marks_imports_cancelled() {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules");
+    config.mapping_path = Some(PathBuf::from("tests/fixtures/mappings/gcp.json"));
+    config.options.skip_state_check = true;
+    config.options.filter = ResourceFilter::new(&["module.kms.*".to_string()], &[]).unwrap();
+    config.options.cancellation = CancellationToken::with_timeout(Duration::ZERO);
+
+    let report = import_with_runner(&config, &RecordingRunner::default()).expect("Import run failed");
+
+    assert!(!report.success);
+    assert_eq!(report.exit_code, EXIT_IMPORT_FAILURES);
+    assert_eq!(report.failed, 0);
+    assert!(report.cancelled > 0);
+    let key_ring = report.resources.iter()
+        .find(|entry| entry.address == "module.kms.google_kms_key_ring.example")
+        .expect("No report entry for key ring");
+    assert_eq!(key_ring.status, ImportStatus::Cancelled);
+    assert!(key_ring.error.as_deref().unwrap_or_default().contains("cancelled"));
+    assert_eq!(key_ring.duration_ms, None);
+
+    let json: Value = serde_json::from_str(&report.to_json().unwrap()).unwrap();
+    let statuses: Vec<&Value> = json["resources"].as_array().unwrap().iter().map(|entry| &entry["status"]).collect();
+    assert!(statuses.contains(&&json!("cancelled")));
+}
+
+/// **TEST** - Two buckets computing the same name abort the run, naming both addresses
+/// 
+/// The storage module's bucket is renamed to the cloud_functions bucket's name, so both
+/// resolve to the same `google_storage_bucket` import ID. With `allow_duplicate_ids`
+/// both imports are generated anyway.
+#[test]
+fn test_40_duplicate_import_ids_are_rejected() {
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let mut plan_json: Value = serde_json::from_str(&fs::read_to_string("tests/fixtures/gcp/out.json").unwrap()).unwrap();
+    for module in plan_json["planned_values"]["root_module"]["child_modules"].as_array_mut().unwrap() {
+        for resource in module["resources"].as_array_mut().unwrap() {
+            if resource["address"] == "module.storage.google_storage_bucket.example" {
+                resource["values"]["name"] = json!("sim-cloud-function-bucket");
+            }
+        }
+    }
+    let temp_dir = TempDir::new().unwrap();
+    let plan_path = temp_dir.path().join("plan.json");
+    fs::write(&plan_path, plan_json.to_string()).unwrap();
+    let plan = load_plan(plan_path.to_str().unwrap()).expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let builders = ImportIdBuilderRegistry::default();
+    let filter = ResourceFilter::new(&["*.google_storage_bucket.*".to_string()], &[]).unwrap();
+    let run = |allow_duplicate_ids: bool| {
+        let options = ImportOptions {
+            dry_run: true,
+            skip_state_check: true,
+            allow_duplicate_ids,
+            filter: filter.clone(),
+            ..Default::default()
+        };
+        execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "simulator/gcp/modules", &SystemCommandRunner)
+    };
+
+    let err = run(false).expect_err("Duplicate import IDs were accepted");
+    let message = err.to_string();
+    assert!(message.contains("module.cloud_functions.google_storage_bucket.example"), "{}", message);
+    assert!(message.contains("module.storage.google_storage_bucket.example"), "{}", message);
+    assert!(message.contains("google_storage_bucket import id 'sim-cloud-function-bucket'"), "{}", message);
+    assert!(message.contains("--allow-duplicate-ids"), "{}", message);
+
+    let report = run(true).expect("Import run failed");
+    let duplicates = report.commands().into_iter().filter(|command| command.ends_with(" sim-cloud-function-bucket")).count();
+    assert_eq!(duplicates, 2);
+}
+
+/// **TEST** - A preview lists every planned create with its decision and runs no import
+/// 
+/// Uses the state pre-check (only `state pull` is allowed by the runner) and the
+/// filter, so already-in-state, filtered-out, skipped and imported rows all appear.
+/// Two previews of the same inputs render identically.
+#[test]
+fn test_41_preview_decisions() {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules");
+    config.options.filter = ResourceFilter::new(&[], &["module.storage.*".to_string()]).unwrap();
+    let runner = FakeStateRunner { addresses: vec!["module.kms.google_kms_key_ring.example"] };
+
+    let preview = preview_with_runner(&config, &runner).expect("Preview failed");
+    let decision_of = |address: &str| preview.resources.iter()
+        .find(|entry| entry.address == address)
+        .unwrap_or_else(|| panic!("No preview row for {}", address))
+        .decision;
+
+    assert_eq!(decision_of("module.kms.google_kms_key_ring.example"), ImportDecision::SkipAlreadyInState);
+    assert_eq!(decision_of("module.storage.google_storage_bucket.example"), ImportDecision::FilteredOut);
+    assert_eq!(decision_of("module.kms.google_kms_crypto_key.example"), ImportDecision::Skip);
+    assert_eq!(decision_of("module.cloud_functions.google_storage_bucket.source"), ImportDecision::Import);
+    assert!(preview.resources.windows(2).all(|pair| pair[0].address <= pair[1].address));
+
+    let table = preview.to_table();
+    assert!(table.starts_with("ADDRESS"));
+    assert!(table.lines().any(|line| line.starts_with("module.kms.google_kms_key_ring.example ") && line.ends_with("skip-already-in-state")), "{}", table);
+    assert_eq!(table, preview_with_runner(&config, &runner).unwrap().to_table());
+
+    let json: Value = serde_json::from_str(&preview.to_json().unwrap()).unwrap();
+    let bucket = json["resources"].as_array().unwrap().iter()
+        .find(|entry| entry["address"] == "module.cloud_functions.google_storage_bucket.source")
+        .unwrap();
+    assert_eq!(bucket["decision"], "import");
+    assert_eq!(bucket["resource_type"], "google_storage_bucket");
+    assert!(bucket["command"].as_str().unwrap().starts_with("terragrunt import "));
+}
+
+/// **TEST HELPER** - Puts a fake `terragrunt` script first on a PATH for CLI tests
+/// 
+/// The script appends its arguments to `$FAKE_TERRAGRUNT_LOG`, runs `case_arm` (a
+/// shell case arm matched against its first two arguments), fails `providers` and
+/// succeeds at everything else.
+/// 
+/// # Returns
+/// The PATH value to run the CLI with
+#[cfg(unix)]
+fn install_fake_terragrunt(temp_dir: &TempDir, case_arm: &str) -> String {
+    use std::os::unix::fs::PermissionsExt;
+
+    let bin_dir = temp_dir.path().join("bin");
+    fs::create_dir(&bin_dir).unwrap();
+    let fake_terragrunt = bin_dir.join("terragrunt");
+    fs::write(&fake_terragrunt, format!("#!/bin/sh\n\
+        echo \"$*\" >> \"$FAKE_TERRAGRUNT_LOG\"\n\
+        case \"$1 $2\" in\n\
+        {}\n\
+        providers*) exit 1 ;;\n\
+        esac\n\
+        exit 0\n", case_arm)).unwrap();
+    fs::set_permissions(&fake_terragrunt, fs::Permissions::from_mode(0o755)).unwrap();
+    format!("{}:{}", bin_dir.display(), std::env::var("PATH").unwrap_or_default())
+}
+
+/// Command runner deciding each import's outcome with a closure and answering every other command with empty success
+/// 
+/// The closure gets the import's address and the addresses of every import started so
+/// far, this one included.
+struct ImportRunner<F> {
+    outcome: F,
+    imports: std::sync::Mutex<Vec<String>>,
+}
+
+impl<F: Fn(&str, &[String]) -> RunOutcome + Sync> ImportRunner<F> {
+    fn new(outcome: F) -> Self {
+        Self { outcome, imports: Default::default() }
+    }
+
+    fn attempts(&self, address: &str) -> usize {
+        self.imports.lock().unwrap().iter().filter(|import| *import == address).count()
+    }
+}
+
+impl<F: Fn(&str, &[String]) -> RunOutcome + Sync> CommandRunner for ImportRunner<F> {
+    fn run(&self, _program: &str, _args: &[&str], _working_directory: &Path) -> std::io::Result<CommandOutput> {
+        Ok(CommandOutput { exit_code: Some(0), stdout: String::new(), stderr: String::new() })
+    }
+
+    fn run_until(
+        &self,
+        program: &str,
+        args: &[&str],
+        working_directory: &Path,
+        _cancellation: &CancellationToken,
+        _timeout: Option<Duration>,
+    ) -> std::io::Result<RunOutcome> {
+        if args.first() != Some(&"import") {
+            return self.run(program, args, working_directory).map(RunOutcome::Finished);
+        }
+        let address = args[args.len() - 2];
+        let mut imports = self.imports.lock().unwrap();
+        imports.push(address.to_string());
+        Ok((self.outcome)(address, &imports))
+    }
+}
+
+/// Runs the key ring and cloud functions bucket imports of the gcp fixture through `runner`
+fn import_gcp_pair(runner: &dyn CommandRunner, options: ImportOptions) -> terragrunt_import_from_plan::reporting::Report {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp");
+    config.options = ImportOptions {
+        skip_state_check: true,
+        filter: ResourceFilter::new(&["module.kms.google_kms_key_ring.example".to_string(), "module.cloud_functions.google_storage_bucket.source".to_string()], &[]).unwrap(),
+        ..options
+    };
+    import_with_runner(&config, runner).expect("Import run failed")
+}
+
+/// **TEST** - A wedged import is killed at the per-import timeout while the run continues
+/// 
+/// The runner times out every key ring import and fails the bucket's first import with
+/// a transient error. With two attempts allowed, the key ring is tried twice and
+/// reported as timed out, while the bucket's retry succeeds.
+#[test]
+fn test_42_per_import_timeout_kills_slow_import() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let runner = ImportRunner::new(|address: &str, imports: &[String]| match address {
+        "module.kms.google_kms_key_ring.example" => RunOutcome::TimedOut,
+        _ if imports.iter().filter(|import| *import == address).count() == 1 => RunOutcome::Finished(CommandOutput { exit_code: Some(1), stdout: String::new(), stderr: "Error 503: Service Unavailable".to_string() }),
+        _ => RunOutcome::Finished(CommandOutput { exit_code: Some(0), stdout: String::new(), stderr: String::new() }),
+    });
+    let retry = RetryConfig { max_attempts: 2, base_delay: Duration::ZERO, ..RetryConfig::with_default_retryable_errors() };
+    let options = ImportOptions { retry, per_import_timeout: Some(Duration::from_secs(1)), ..Default::default() };
+
+    let report = import_gcp_pair(&runner, options);
+
+    let status_of = |address: &str| report.resources.iter()
+        .find(|entry| entry.address == address)
+        .unwrap_or_else(|| panic!("No report entry for {}", address));
+    assert_eq!(status_of(key_ring).status, ImportStatus::TimedOut);
+    assert_eq!(status_of(key_ring).error.as_deref(), Some("Import timed out after 1s after 2 attempts"));
+    assert_eq!(status_of(bucket).status, ImportStatus::Success);
+    assert_eq!(report.failed, 1);
+    assert_eq!(runner.attempts(key_ring), 2);
+    assert_eq!(runner.attempts(bucket), 2);
+}
+
+/// **TEST** - With a module prefix, imports run in the module root with relative addresses
+/// 
+/// The plan comes from the root module while terragrunt runs in the kms module
+/// directory, whose state addresses the key ring without `module.kms.`. Resources of
+/// other modules are skipped, and the preview keeps the plan addresses.
+#[test]
+fn test_43_strip_module_prefix() {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules/kms");
+    config.options.strip_module_prefix = parse_module_address("module.kms").unwrap();
+    let entry_for = |preview: &Preview, address: &str| preview.resources.iter()
+        .find(|entry| entry.address == address)
+        .unwrap_or_else(|| panic!("No preview row for {}", address))
+        .clone();
+
+    let preview = preview_with_runner(&config, &FakeStateRunner { addresses: vec![] }).expect("Preview failed");
+    let key_ring = entry_for(&preview, "module.kms.google_kms_key_ring.example");
+    assert_eq!(key_ring.decision, ImportDecision::Import);
+    let command = key_ring.command.unwrap();
+    assert!(command.starts_with("terragrunt import -config-dir=simulator/gcp/modules/kms google_kms_key_ring.example "), "{}", command);
+
+    let bucket = entry_for(&preview, "module.storage.google_storage_bucket.example");
+    assert_eq!(bucket.decision, ImportDecision::Skip);
+    assert_eq!(bucket.reason.as_deref(), Some("not inside module.kms"));
+
+    let preview = preview_with_runner(&config, &FakeStateRunner { addresses: vec!["google_kms_key_ring.example"] }).expect("Preview failed");
+    assert_eq!(entry_for(&preview, "module.kms.google_kms_key_ring.example").decision, ImportDecision::SkipAlreadyInState);
+}
+
+/// **TEST** - The CLI exit status distinguishes success, failed imports and usage errors
+/// 
+/// The fake terragrunt fails the bucket import. By default the run stops there and
+/// the key ring after it is skipped; with `--continue-on-error` the key ring is imported.
+/// Either way the exit status is 2. A dry run exits 0 and a missing `--plan` exits 3.
+#[cfg(unix)]
+#[test]
+fn test_44_exit_codes_and_continue_on_error() {
+    let temp_dir = TempDir::new().unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "\"import module.cloud_functions.google_storage_bucket.source\") echo 'Error: permission denied' >&2; exit 1 ;;");
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let report_path = temp_dir.path().join("report.json");
+    let run = |extra_args: &[&str]| {
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+            .args(["--module-root", "simulator/gcp", "--skip-state-check"])
+            .args(["--include", "module.kms.google_kms_key_ring.example", "--include", "module.cloud_functions.google_storage_bucket.source"])
+            .args(extra_args)
+            .arg("--working-directory").arg(temp_dir.path())
+            .arg("--report-json").arg(&report_path)
+            .env("PATH", &path)
+            .env("FAKE_TERRAGRUNT_LOG", &log_path)
+            .output()
+            .expect("Failed to run CLI");
+        let report: Value = serde_json::from_str(&fs::read_to_string(&report_path).unwrap_or_default()).unwrap_or(Value::Null);
+        (output.status.code(), report)
+    };
+    let key_ring_status = |report: &Value| report["resources"].as_array().unwrap().iter()
+        .find(|entry| entry["address"] == "module.kms.google_kms_key_ring.example")
+        .map(|entry| entry["status"].clone())
+        .unwrap();
+
+    let (code, report) = run(&[]);
+    assert_eq!(code, Some(EXIT_IMPORT_FAILURES));
+    assert_eq!(report["failed"], 1);
+    assert_eq!(key_ring_status(&report), "skipped");
+
+    let (code, report) = run(&["--continue-on-error"]);
+    assert_eq!(code, Some(EXIT_IMPORT_FAILURES));
+    assert_eq!(report["exit_code"], EXIT_IMPORT_FAILURES);
+    assert_eq!(key_ring_status(&report), "success");
+
+    let (code, report) = run(&["--dry-run"]);
+    assert_eq!(code, Some(EXIT_SUCCESS));
+    assert_eq!(report["success"], true);
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--modules", "tests/fixtures/gcp/modules.json"])
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR));
+    assert!(String::from_utf8_lossy(&output.stderr).contains("--plan argument is required"));
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--fail-fast", "--continue-on-error"])
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR));
+}
+
+/// **TEST** - Import blocks are written for exactly the resources a run would import
+/// 
+/// Resources already in state get no block, and nothing but `state pull` is run.
+#[test]
+fn test_45_emit_import_blocks() {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules");
+    let runner = FakeStateRunner { addresses: vec!["module.kms.google_kms_key_ring.example"] };
+    let temp_dir = TempDir::new().unwrap();
+    let path = temp_dir.path().join("imports.tf");
+
+    let summary = emit_import_blocks_with_runner(&config, &path, &runner).expect("Emitting import blocks failed");
+    let count = summary.written;
+    let hcl = fs::read_to_string(&path).unwrap();
+
+    let preview = preview_with_runner(&config, &runner).unwrap();
+    assert_eq!(count, preview.count(ImportDecision::Import));
+    assert_eq!(summary.unsupported, preview.count(ImportDecision::Unsupported));
+    assert_eq!(hcl.matches("import {\n").count(), count);
+    assert!(hcl.contains("import {\n  to = module.cloud_functions.google_storage_bucket.source\n  id = \""), "{}", hcl);
+    assert!(!hcl.contains("module.kms.google_kms_key_ring.example"), "{}", hcl);
+}
+
+/// **TEST** - State is pulled once per module for the state check and the unknown-attribute fallback
+/// 
+/// The kms module's state holds the key ring, which is skipped, and the crypto key's
+/// `key_ring`, which is unknown in the plan. Both lookups are served by one pull.
+#[test]
+fn test_46_state_pulled_once_per_run() {
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules");
+    config.options.dry_run = true;
+    config.options.resolve_unknown_from_state = true;
+    config.options.filter = ResourceFilter::new(&["module.kms.*".to_string()], &[]).unwrap();
+    let runner = PulledStateRunner {
+        state: json!({
+            "version": 4,
+            "resources": [
+                {"module": "module.kms", "mode": "managed", "type": "google_kms_key_ring", "name": "example", "instances": [{"attributes": {"name": "sim-keyring"}}]},
+                {"module": "module.kms", "mode": "managed", "type": "google_kms_crypto_key", "name": "example",
+                 "instances": [{"attributes": {"key_ring": "projects/sim-project/locations/europe-west1/keyRings/sim-keyring", "name": "sim-key"}}]}
+            ]
+        }).to_string(),
+        ..Default::default()
+    };
+
+    let report = import_with_runner(&config, &runner).expect("Import run failed");
+
+    assert!(report.total >= 2, "{:?}", report.resources);
+    let status_of = |address: &str| report.resources.iter().find(|entry| entry.address == address).map(|entry| entry.status);
+    assert_eq!(status_of("module.kms.google_kms_key_ring.example"), Some(ImportStatus::AlreadyInState));
+    assert_eq!(status_of("module.kms.google_kms_crypto_key.example"), Some(ImportStatus::AlreadyInState));
+    assert_eq!(runner.pulls.load(std::sync::atomic::Ordering::SeqCst), 1);
+}
+
+/// **TEST** - A sensitive bucket name is used for the import but masked everywhere it is shown
+/// 
+/// The storage module's bucket name is marked in `after_sensitive`. The import command
+/// carries the real name, while debug logs, the report and the preview only show `***`.
+#[test]
+fn test_47_sensitive_import_id_is_redacted() {
+    let secret = "s3cr3t-bucket-name";
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let mut plan_json: Value = serde_json::from_str(&fs::read_to_string("tests/fixtures/gcp/out.json").unwrap()).unwrap();
+    for module in plan_json["planned_values"]["root_module"]["child_modules"].as_array_mut().unwrap() {
+        for resource in module["resources"].as_array_mut().unwrap() {
+            if resource["address"] == "module.storage.google_storage_bucket.example" {
+                resource["values"]["name"] = json!(secret);
+            }
+        }
+    }
+    for change in plan_json["resource_changes"].as_array_mut().unwrap() {
+        if change["address"] == "module.storage.google_storage_bucket.example" {
+            change["change"]["after"]["name"] = json!(secret);
+            change["change"]["after_sensitive"] = json!({"name": true});
+        }
+    }
+    let temp_dir = TempDir::new().unwrap();
+    let plan_path = temp_dir.path().join("plan.json");
+    fs::write(&plan_path, plan_json.to_string()).unwrap();
+    let plan = load_plan(plan_path.to_str().unwrap()).expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let builders = ImportIdBuilderRegistry::default();
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let options = ImportOptions {
+        dry_run: true,
+        skip_state_check: true,
+        filter: ResourceFilter::new(&["module.storage.google_storage_bucket.example".to_string()], &[]).unwrap(),
+        logger: SharedLogger::from_arc(logger.clone()),
+        ..Default::default()
+    };
+
+    let planned = plan_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, "simulator/gcp/modules", &SystemCommandRunner)
+        .expect("Planning failed");
+    let entry = planned.iter().find(|entry| entry.decision == ImportDecision::Import).expect("Bucket not planned for import");
+    assert!(entry.command.as_ref().unwrap().resource_id.contains(secret), "{:?}", entry.command);
+    assert_eq!(entry.sensitive_values, vec![secret.to_string()]);
+    let preview = Preview::new(planned);
+    assert!(!preview.to_json().unwrap().contains(secret));
+    assert!(preview.to_table().contains("***"), "{}", preview.to_table());
+
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, true, "simulator/gcp/modules", &SystemCommandRunner)
+        .expect("Import run failed");
+    let bucket = &report.resources[0];
+    assert_eq!(bucket.status, ImportStatus::DryRun);
+    assert!(bucket.import_id.as_deref().unwrap().contains("***"), "{:?}", bucket);
+    assert!(!bucket.command.as_deref().unwrap().contains(secret), "{:?}", bucket);
+    let debug = logger.debug_messages();
+    assert!(debug.iter().any(|message| message.starts_with("Built import ID for module.storage.google_storage_bucket.example") && message.contains("***")), "{:?}", debug);
+    assert!(logger.messages.lock().unwrap().iter().all(|(_, message)| !message.contains(secret)));
+}
+
+/// **TEST** - The coverage subcommand lists the plan's types and fails `--strict` on missing builders
+#[test]
+fn test_48_coverage_subcommand() {
+    let run = |extra: &[&str]| {
+        Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .args(["coverage", "--plan", "tests/fixtures/gcp/out.json", "--format", "json"])
+            .args(extra)
+            .output()
+            .expect("Failed to run CLI")
+    };
+
+    let output = run(&[]);
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+    let coverage: Value = serde_json::from_slice(&output.stdout).expect("Coverage is not JSON");
+    let types = coverage["resource_types"].as_array().unwrap();
+    let has_builder = |resource_type: &str| types.iter().find(|entry| entry["resource_type"] == resource_type).map(|entry| entry["has_builder"].clone());
+    assert_eq!(has_builder("google_kms_key_ring"), Some(json!(true)));
+    assert_eq!(has_builder("google_pubsub_topic"), Some(json!(false)));
+    let names: Vec<&str> = types.iter().map(|entry| entry["resource_type"].as_str().unwrap()).collect();
+    assert!(names.windows(2).all(|pair| pair[0] < pair[1]), "{:?}", names);
+
+    let output = run(&["--strict"]);
+    assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES));
+    assert!(String::from_utf8_lossy(&output.stderr).contains("google_pubsub_topic"));
+}
+
+/// **TEST** - Azure resources in the fixture plan get full resource IDs from the Azure builders
+/// 
+/// The plan sets the `subscription_id` variable, so the storage account and key vault are
+/// imported by their ARM IDs.
+#[test]
+fn test_49_azure_builders_use_plan_subscription() {
+    let mut config = ImportConfig::new("tests/fixtures/azure/out.json", "tests/fixtures/azure/modules.json");
+    config.module_root = PathBuf::from("simulator/azure");
+    config.options.dry_run = true;
+    config.options.skip_state_check = true;
+
+    let report = import_with_runner(&config, &RecordingRunner::default()).expect("Import run failed");
+
+    let id_of = |address: &str| report.resources.iter().find(|entry| entry.address == address).and_then(|entry| entry.import_id.clone());
+    let subscription = "/subscriptions/00000000-0000-0000-0000-000000000000";
+    assert_eq!(
+        id_of("module.storage.azurerm_storage_account.example"),
+        Some(format!("{}/resourceGroups/example-rg/providers/Microsoft.Storage/storageAccounts/examplestorageacct123", subscription))
+    );
+    assert_eq!(
+        id_of("module.key_vault.azurerm_key_vault.example"),
+        Some(format!("{}/resourceGroups/example-rg/providers/Microsoft.KeyVault/vaults/example-keyvault-123", subscription))
+    );
+    assert!(report.commands().iter().any(|command| command.starts_with("terragrunt import -config-dir=simulator/azure/modules/storage module.storage.azurerm_storage_account.example")), "{:?}", report.commands());
+}
+
+/// **TEST** - Without a plan subscription, the Azure builders take it from state or report it missing
+#[test]
+fn test_50_azure_subscription_from_state_or_missing() {
+    let mut plan_json: Value = serde_json::from_str(&fs::read_to_string("tests/fixtures/azure/out.json").unwrap()).unwrap();
+    plan_json["variables"].as_object_mut().unwrap().remove("subscription_id");
+    let temp_dir = TempDir::new().unwrap();
+    let plan_path = temp_dir.path().join("plan.json");
+    fs::write(&plan_path, plan_json.to_string()).unwrap();
+
+    let mut config = ImportConfig::new(plan_path.to_str().unwrap(), "tests/fixtures/azure/modules.json");
+    config.module_root = PathBuf::from("simulator/azure");
+    config.options.dry_run = true;
+    config.options.skip_state_check = true;
+    config.options.filter = ResourceFilter::new(&["*.azurerm_storage_account.*".to_string()], &[]).unwrap();
+
+    let report = import_with_runner(&config, &RecordingRunner::default()).expect("Import run failed");
+    let account = &report.resources[0];
+    assert_eq!(account.status, ImportStatus::Skipped);
+    assert!(account.error.as_deref().unwrap().contains("'subscription_id'"), "{:?}", account);
+
+    config.options.resolve_unknown_from_state = true;
+    let runner = PulledStateRunner {
+        state: json!({
+            "version": 4,
+            "resources": [{
+                "mode": "managed",
+                "type": "azurerm_resource_group",
+                "name": "main",
+                "instances": [{"attributes": {"id": "/subscriptions/22222222-2222-2222-2222-222222222222/resourceGroups/example-rg", "name": "example-rg"}}]
+            }]
+        }).to_string(),
+        ..Default::default()
+    };
+    let report = import_with_runner(&config, &runner).expect("Import run failed");
+    assert_eq!(
+        report.resources[0].import_id.as_deref(),
+        Some("/subscriptions/22222222-2222-2222-2222-222222222222/resourceGroups/example-rg/providers/Microsoft.Storage/storageAccounts/examplestorageacct123")
+    );
+}
+
+/// **TEST** - A crypto key listed before its key ring is imported after it
+/// 
+/// The fixture's configuration has the crypto key reference `google_kms_key_ring.example`.
+/// When the reference is turned into a cycle, the plan order is kept and a warning logged.
+#[test]
+fn test_51_imports_ordered_by_dependency() {
+    let modules = vec![ModuleMeta { key: "kms".to_string(), source: "./modules/kms".to_string(), dir: "modules/kms".to_string() }];
+    let run = |plan: &PlanFile, logger: std::sync::Arc<RecordingLogger>| {
+        let mapping = map_resources_to_modules(&modules, plan, &StdLogger::default());
+        let options = ImportOptions {
+            dry_run: true,
+            skip_state_check: true,
+            logger: SharedLogger::from_arc(logger),
+            ..Default::default()
+        };
+        let report = execute_or_print_imports(&mapping, plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, false, "simulator/gcp", &SystemCommandRunner)
+            .expect("Import run failed");
+        report.resources.iter().map(|entry| entry.address.clone()).collect::<Vec<_>>()
+    };
+    let key_ring = "module.kms.google_kms_key_ring.example".to_string();
+    let crypto_key = "module.kms.google_kms_crypto_key.example".to_string();
+
+    let plan = load_plan("tests/fixtures/dependencies/kms.json").expect("Failed to load plan");
+    assert_eq!(plan.planned_values.as_ref().unwrap().root_module.child_modules.as_ref().unwrap()[0].resources.as_ref().unwrap()[0].address, crypto_key);
+    assert_eq!(run(&plan, Default::default()), vec![key_ring.clone(), crypto_key.clone()]);
+
+    let mut plan_json: Value = serde_json::from_str(&fs::read_to_string("tests/fixtures/dependencies/kms.json").unwrap()).unwrap();
+    plan_json["configuration"]["root_module"]["module_calls"]["kms"]["module"]["resources"][1]["depends_on"] = json!(["google_kms_crypto_key.example"]);
+    let plan: PlanFile = serde_json::from_value(plan_json).unwrap();
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    assert_eq!(run(&plan, logger.clone()), vec![crypto_key.clone(), key_ring.clone()]);
+    let messages = logger.messages.lock().unwrap();
+    assert!(messages.iter().any(|(level, message)| *level == LogLevel::Warn && message.contains("dependency cycle between")), "{:?}", messages);
+}
+
+/// **TEST** - Extra arguments reach every import after the subcommand and before the address
+/// 
+/// Runs the CLI against a fake `terragrunt` with two `--extra-arg`s and checks the argv
+/// it was called with, then checks the same position for terraform's argv and both
+/// printed forms.
+#[cfg(unix)]
+#[test]
+fn test_52_extra_args_passed_to_imports() {
+    let temp_dir = TempDir::new().unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "");
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--module-root", "simulator/gcp", "--skip-state-check", "--include", "module.kms.google_kms_key_ring.example"])
+        .args(["--extra-arg", "-lock-timeout=60s", "--extra-arg=-var-file=prod.tfvars"])
+        .arg("--working-directory").arg(temp_dir.path())
+        .env("PATH", &path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let log = fs::read_to_string(&log_path).unwrap();
+    let imports: Vec<&str> = log.lines().filter(|line| line.starts_with("import ")).collect();
+    assert_eq!(imports.len(), 1, "{}", log);
+    assert!(imports[0].starts_with("import -lock-timeout=60s -var-file=prod.tfvars module.kms.google_kms_key_ring.example "), "{}", log);
+
+    let command = |binary| ImportCommand {
+        working_directory: PathBuf::from("live/prod/vpc"),
+        resource_address: "aws_vpc.main".to_string(),
+        resource_id: "vpc-1".to_string(),
+        resource_type: "aws_vpc".to_string(),
+        module_name: "vpc".to_string(),
+        binary,
+        extra_args: vec!["-lock-timeout=60s".to_string(), "-var-file=prod vars.tfvars".to_string()],
+    };
+    let terraform = command(ImportBinary::Terraform);
+    assert_eq!(terraform.args(), ["import", "-lock-timeout=60s", "-var-file=prod vars.tfvars", "aws_vpc.main", "vpc-1"]);
+    assert_eq!(terraform.command_string(), "terraform -chdir=live/prod/vpc import -lock-timeout=60s '-var-file=prod vars.tfvars' aws_vpc.main vpc-1");
+    assert_eq!(
+        command(ImportBinary::Terragrunt).command_string(),
+        "terragrunt import -config-dir=live/prod/vpc -lock-timeout=60s '-var-file=prod vars.tfvars' aws_vpc.main vpc-1"
+    );
+}
+
+/// **TEST** - An interrupted run resumes from its checkpoint without redoing finished imports
+/// 
+/// The first run fails the bucket import, so only the key ring is checkpointed. A stale
+/// entry for an address that isn't in the plan is then added. Resuming imports just the
+/// bucket, warns about the stale entry and appends the bucket to the same checkpoint.
+#[cfg(unix)]
+#[test]
+fn test_53_checkpoint_and_resume() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let checkpoint_dir = TempDir::new().unwrap();
+    let checkpoint_path = checkpoint_dir.path().join("checkpoint.ndjson");
+    let run = |case_arm: &str, checkpoint_args: &[&str]| {
+        let temp_dir = TempDir::new().unwrap();
+        let path = install_fake_terragrunt(&temp_dir, case_arm);
+        let log_path = temp_dir.path().join("terragrunt.log");
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+            .args(["--module-root", "simulator/gcp", "--skip-state-check", "--continue-on-error"])
+            .args(["--include", key_ring, "--include", bucket])
+            .args(checkpoint_args)
+            .arg(&checkpoint_path)
+            .arg("--working-directory").arg(temp_dir.path())
+            .env("PATH", &path)
+            .env("FAKE_TERRAGRUNT_LOG", &log_path)
+            .output()
+            .expect("Failed to run CLI");
+        let imported: Vec<String> = fs::read_to_string(&log_path).unwrap_or_default().lines()
+            .filter(|line| line.starts_with("import "))
+            .map(|line| line.split(' ').nth(1).unwrap().to_string())
+            .collect();
+        (output, imported)
+    };
+    let recorded = || Checkpoint::load(&checkpoint_path).unwrap().entries().to_vec();
+
+    let (output, imported) = run(&format!("\"import {}\") exit 1 ;;", bucket), &["--checkpoint"]);
+    assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES));
+    assert_eq!(imported, vec![bucket, key_ring]);
+    assert_eq!(recorded(), vec![CheckpointEntry { address: key_ring.to_string(), status: ImportStatus::Success }]);
+
+    let mut file = fs::OpenOptions::new().append(true).open(&checkpoint_path).unwrap();
+    std::io::Write::write_all(&mut file, b"{\"address\":\"module.gone.google_storage_bucket.old\",\"status\":\"success\"}\n").unwrap();
+
+    let (output, imported) = run("", &["--resume"]);
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS));
+    assert_eq!(imported, vec![bucket]);
+    let stderr = String::from_utf8_lossy(&output.stderr);
+    assert!(stderr.contains("Ignoring checkpoint entry for module.gone.google_storage_bucket.old"), "{}", stderr);
+    assert_eq!(recorded().last(), Some(&CheckpointEntry { address: bucket.to_string(), status: ImportStatus::Success }));
+    assert_eq!(recorded().len(), 3);
+}
+
+/// Command runner serving a plan for `gcloud storage cat` and recording every command
+struct RemotePlanRunner {
+    plan: String,
+    commands: std::sync::Mutex<Vec<String>>,
+}
+
+impl CommandRunner for RemotePlanRunner {
+    fn run(&self, program: &str, args: &[&str], _working_directory: &Path) -> std::io::Result<CommandOutput> {
+        self.commands.lock().unwrap().push(format!("{} {}", program, args.join(" ")));
+        let stdout = if program == "gcloud" { self.plan.clone() } else { String::new() };
+        Ok(CommandOutput { exit_code: Some(0), stdout, stderr: String::new() })
+    }
+}
+
+/// **TEST** - Plans are fetched from GCS through the runner, and `file://` reads locally
+/// 
+/// A `gs://` plan path is downloaded with `gcloud storage cat` before it is parsed and
+/// previews the same imports as the local fixture. Unknown schemes are rejected.
+#[test]
+fn test_54_remote_plan_input() {
+    let local = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let from_file_url = load_plan(format!("file://{}", fs::canonicalize("tests/fixtures/gcp/out.json").unwrap().display())).expect("Failed to load file:// plan");
+    assert_eq!(from_file_url.resource_change_addresses(), local.resource_change_addresses());
+
+    let runner = RemotePlanRunner { plan: fs::read_to_string("tests/fixtures/gcp/out.json").unwrap(), commands: Default::default() };
+    let mut config = ImportConfig::new("gs://ci-plans/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp/modules");
+    config.options.skip_state_check = true;
+    let remote = preview_with_runner(&config, &runner).expect("Preview of remote plan failed");
+
+    config.plan_path = PathBuf::from("tests/fixtures/gcp/out.json");
+    let expected = preview_with_runner(&config, &runner).expect("Preview of local plan failed");
+    assert_eq!(remote.resources, expected.resources);
+    assert_eq!(*runner.commands.lock().unwrap(), ["gcloud storage cat gs://ci-plans/gcp/out.json"]);
+
+    let error = load_plan("s3://ci-plans/gcp/out.json").unwrap_err();
+    assert!(format!("{:#}", error).contains("scheme 's3' is not one of gs, http, https or file"), "{:#}", error);
+}
+
+/// **TEST** - `--max-errors` stops dispatching imports once the threshold is reached
+/// 
+/// The runner fails the first three imports. With a threshold of two, only two imports
+/// are attempted and every other resource is reported as skipped, both with a single
+/// worker and with a pool, where imports already running may still finish.
+#[test]
+fn test_55_max_errors_stops_early() {
+    let run = |workers: usize| {
+        let runner = ImportRunner::new(|_: &str, imports: &[String]| {
+            let exit_code = if imports.len() <= 3 { 1 } else { 0 };
+            RunOutcome::Finished(CommandOutput { exit_code: Some(exit_code), stdout: String::new(), stderr: String::new() })
+        });
+        let logger = std::sync::Arc::new(RecordingLogger::default());
+        let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+        config.module_root = PathBuf::from("simulator/gcp");
+        config.options = ImportOptions {
+            skip_state_check: true,
+            max_errors: Some(2),
+            workers,
+            logger: SharedLogger::from_arc(logger.clone()),
+            ..Default::default()
+        };
+        let report = import_with_runner(&config, &runner).expect("Import run failed");
+        let attempted = runner.imports.lock().unwrap().len();
+        (report, attempted, logger)
+    };
+    let max_errors_skips = |report: &terragrunt_import_from_plan::reporting::Report| report.resources.iter()
+        .filter(|entry| entry.error.as_deref() == Some("not started because too many imports failed (max-errors)"))
+        .count();
+
+    let (report, attempted, logger) = run(1);
+    assert_eq!(report.exit_code, EXIT_IMPORT_FAILURES);
+    assert_eq!(attempted, 2);
+    assert_eq!(report.failed, 2);
+    assert_eq!(report.imported, 0);
+    assert!(max_errors_skips(&report) > 0);
+    let warnings: Vec<String> = logger.messages.lock().unwrap().iter()
+        .filter(|(level, _)| *level == LogLevel::Warn)
+        .map(|(_, message)| message.clone())
+        .collect();
+    assert!(warnings.iter().any(|warning| warning.contains("2 failed (--max-errors 2), 0 succeeded so far")), "{:?}", warnings);
+
+    let (report, attempted, _) = run(3);
+    let total_imports = report.resources.iter().filter(|entry| entry.command.is_some()).count();
+    assert!(attempted < total_imports, "{} of {} imports attempted", attempted, total_imports);
+    assert!(report.failed >= 2);
+    assert!(max_errors_skips(&report) > 0);
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json", "--max-errors", "0"])
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR));
+}
+
+/// Command runner answering `--version` with a recorded output and everything else with empty success
+struct VersionRunner {
+    version_output: String,
+}
+
+impl CommandRunner for VersionRunner {
+    fn run(&self, _program: &str, args: &[&str], _working_directory: &Path) -> std::io::Result<CommandOutput> {
+        let stdout = if args == ["--version"] { self.version_output.clone() } else { String::new() };
+        Ok(CommandOutput { exit_code: Some(0), stdout, stderr: String::new() })
+    }
+}
+
+/// **TEST** - The binary's version is recorded in the report and old versions are warned about
+#[test]
+fn test_56_tool_version_checked_and_reported() {
+    let modules = vec![ModuleMeta { key: "kms".to_string(), source: "./modules/kms".to_string(), dir: "modules/kms".to_string() }];
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    let fixture = |name: &str| fs::read_to_string(format!("tests/fixtures/versions/{}", name)).unwrap();
+    let run = |binary: ImportBinary, version_output: String| {
+        let logger = std::sync::Arc::new(RecordingLogger::default());
+        let options = ImportOptions {
+            dry_run: true,
+            skip_state_check: true,
+            binary,
+            check_version: true,
+            logger: SharedLogger::from_arc(logger.clone()),
+            ..Default::default()
+        };
+        let runner = VersionRunner { version_output };
+        let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, false, "simulator/gcp", &runner)
+            .expect("Import run failed");
+        let warnings: Vec<String> = logger.messages.lock().unwrap().iter()
+            .filter(|(level, _)| *level == LogLevel::Warn)
+            .map(|(_, message)| message.clone())
+            .collect();
+        (report, warnings)
+    };
+
+    let (report, warnings) = run(ImportBinary::Terragrunt, fixture("terragrunt_0.54.8.txt"));
+    assert_eq!(report.tool_version.as_deref(), Some("terragrunt 0.54.8"));
+    assert!(warnings.is_empty(), "{:?}", warnings);
+    assert!(report.to_json().unwrap().contains("\"tool_version\": \"terragrunt 0.54.8\""));
+
+    let (report, warnings) = run(ImportBinary::Terraform, fixture("terraform_0.12.31.txt"));
+    assert_eq!(report.tool_version.as_deref(), Some("terraform 0.12.31"));
+    assert!(warnings.iter().any(|warning| warning.contains("terraform 0.12.31 is older than 1.0.0")), "{:?}", warnings);
+
+    let (report, warnings) = run(ImportBinary::Terraform, "Usage: terraform [global options] <subcommand> [args]".to_string());
+    assert_eq!(report.tool_version, None);
+    assert!(warnings.iter().any(|warning| warning.contains("Could not determine the terraform version")), "{:?}", warnings);
+}
+
+/// **TEST** - Key rings using different provider aliases get their alias's project
+/// 
+/// The fixture has two key rings with the same name in `module.kms`, one using
+/// `google.europe` and one `google.us`. Neither sets `project`, so each import ID takes
+/// the project of its alias (a root variable) and keeps its own location.
+#[test]
+fn test_57_provider_aliases_resolve_project() {
+    let modules = vec![ModuleMeta { key: "kms".to_string(), source: "./modules/kms".to_string(), dir: "modules/kms".to_string() }];
+    let plan = load_plan("tests/fixtures/providers/aliases.json").expect("Failed to load plan");
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+    let report = execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, false, "simulator/gcp", &SystemCommandRunner)
+        .expect("Import run failed");
+
+    let import_ids: Vec<(&str, Option<&str>)> = report.resources.iter().map(|entry| (entry.address.as_str(), entry.import_id.as_deref())).collect();
+    assert_eq!(import_ids, vec![
+        ("module.kms.google_kms_key_ring.europe", Some("projects/sim-eu-project/locations/europe-west1/keyRings/sim-keyring")),
+        ("module.kms.google_kms_key_ring.us", Some("projects/sim-us-project/locations/us-central1/keyRings/sim-keyring")),
+    ]);
+}
+
+/// **TEST** - `--verify` re-checks imports against state and a targeted plan
+/// 
+/// Runs the CLI against a fake `terragrunt` whose state has both imported resources and
+/// whose plan still replaces the bucket. The key ring is reported as verified and the
+/// bucket as failed; each module gets one plan targeting only its imported address.
+#[cfg(unix)]
+#[test]
+fn test_58_verify_imports() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let state = json!({"version": 4, "resources": [
+        {"module": "module.kms", "mode": "managed", "type": "google_kms_key_ring", "name": "example", "instances": [{"attributes": {"id": "ring"}}]},
+        {"module": "module.cloud_functions", "mode": "managed", "type": "google_storage_bucket", "name": "source", "instances": [{"attributes": {"id": "bucket"}}]}
+    ]});
+    let temp_dir = TempDir::new().unwrap();
+    let path = install_fake_terragrunt(&temp_dir, &format!(
+        "\"state pull\") echo '{}' ;;\n\"plan -no-color\") echo '  # {} must be replaced' ;;",
+        state, bucket
+    ));
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let report_path = temp_dir.path().join("report.json");
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--module-root", "simulator/gcp", "--skip-state-check", "--continue-on-error", "--verify"])
+        .args(["--include", key_ring, "--include", bucket])
+        .arg("--working-directory").arg(temp_dir.path())
+        .arg("--report-json").arg(&report_path)
+        .env("PATH", path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let report: Value = serde_json::from_str(&fs::read_to_string(&report_path).unwrap()).unwrap();
+    let status = |address: &str| report["resources"].as_array().unwrap().iter().find(|entry| entry["address"] == address).unwrap().clone();
+    assert_eq!(status(key_ring)["status"], "verified");
+    assert_eq!(status(bucket)["status"], "failed");
+    assert!(status(bucket)["error"].as_str().unwrap().contains("the plan replaces it"), "{}", status(bucket));
+    assert_eq!((report["imported"].as_u64(), report["verified"].as_u64()), (Some(1), Some(1)));
+
+    let log = fs::read_to_string(&log_path).unwrap();
+    let plans: Vec<&str> = log.lines().filter(|line| line.starts_with("plan ")).collect();
+    assert_eq!(plans, vec![
+        format!("plan -no-color -input=false -lock=false -target={}", bucket),
+        format!("plan -no-color -input=false -lock=false -target={}", key_ring),
+    ]);
+}
+
+/// **TEST** - `--workspace` is selected in every module before the first import
+/// 
+/// Runs the CLI against a fake `terragrunt` listing `default` and `staging`. With
+/// `--workspace staging` every `workspace select` comes before any import and the report
+/// names the workspace; with `--workspace prod` the run fails before importing anything.
+#[cfg(unix)]
+#[test]
+fn test_59_workspace_selected_before_imports() {
+    let run = |workspace: &str| {
+        let temp_dir = TempDir::new().unwrap();
+        let path = install_fake_terragrunt(&temp_dir, "\"workspace list\") printf '* default\\n  staging\\n' ;;");
+        let log_path = temp_dir.path().join("terragrunt.log");
+        let report_path = temp_dir.path().join("report.json");
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+            .args(["--module-root", "simulator/gcp", "--skip-state-check"])
+            .args(["--include", "module.kms.*", "--workspace", workspace])
+            .arg("--working-directory").arg(temp_dir.path())
+            .arg("--report-json").arg(&report_path)
+            .env("PATH", path)
+            .env("FAKE_TERRAGRUNT_LOG", &log_path)
+            .output()
+            .expect("Failed to run CLI");
+        let log: Vec<String> = fs::read_to_string(&log_path).unwrap_or_default().lines().map(str::to_string).collect();
+        let report: Option<Value> = fs::read_to_string(&report_path).ok().map(|report| serde_json::from_str(&report).unwrap());
+        (output, log, report)
+    };
+
+    let (output, log, report) = run("staging");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+    let first_import = log.iter().position(|line| line.starts_with("import ")).expect("no import was run");
+    let selects: Vec<usize> = log.iter().enumerate().filter(|(_, line)| *line == "workspace select staging").map(|(index, _)| index).collect();
+    assert!(!selects.is_empty(), "{:?}", log);
+    assert!(selects.iter().all(|index| *index < first_import), "{:?}", log);
+    assert_eq!(report.unwrap()["workspace"], "staging");
+
+    let (output, log, report) = run("prod");
+    assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR));
+    let stderr = String::from_utf8_lossy(&output.stderr);
+    assert!(stderr.contains("workspace 'prod' does not exist"), "{}", stderr);
+    assert!(log.iter().any(|line| line == "workspace list"), "{:?}", log);
+    assert!(!log.iter().any(|line| line.starts_with("import ") || line.starts_with("workspace select")), "{:?}", log);
+    assert!(report.is_none());
+}
+
+/// **TEST** - The CLI ends with a summary table unless `--quiet` is given
+/// 
+/// Runs against a fake `terragrunt` failing the bucket import, so the table lists the
+/// bucket with its error under the status counts.
+#[cfg(unix)]
+#[test]
+fn test_60_summary_table_and_quiet() {
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let run = |extra_args: &[&str]| {
+        let temp_dir = TempDir::new().unwrap();
+        let path = install_fake_terragrunt(&temp_dir, &format!("\"import {}\") echo 'Error: bucket not found' >&2; exit 1 ;;", bucket));
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+            .args(["--module-root", "simulator/gcp", "--skip-state-check", "--continue-on-error"])
+            .args(["--include", "module.kms.google_kms_key_ring.example", "--include", bucket])
+            .args(extra_args)
+            .arg("--working-directory").arg(temp_dir.path())
+            .env("PATH", path)
+            .env("FAKE_TERRAGRUNT_LOG", temp_dir.path().join("terragrunt.log"))
+            .output()
+            .expect("Failed to run CLI");
+        assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES));
+        String::from_utf8_lossy(&output.stdout).to_string()
+    };
+
+    let stdout = run(&[]);
+    assert!(stdout.contains("STATUS            COUNT\nimported          1\n"), "{}", stdout);
+    assert!(stdout.contains("failed            1\n"), "{}", stdout);
+    let failure = stdout.lines().skip_while(|line| !line.starts_with("FAILED ADDRESS")).nth(1).unwrap_or_default().to_string();
+    assert!(failure.starts_with(&format!("{}  ", bucket)) && failure.contains("exit code 1"), "{}", stdout);
+
+    let stdout = run(&["--quiet"]);
+    assert!(!stdout.contains("STATUS            COUNT"), "{}", stdout);
+}
+
+/// **TEST** - Several plans are merged into one run, keeping the first of each address
+/// 
+/// Both fixtures plan `module.kms.google_kms_key_ring.example`, in different locations:
+/// the merged run plans it once, with the first plan's import ID, and warns about the
+/// conflict. The CLI expands a glob to the same two plans.
+#[cfg(unix)]
+#[test]
+fn test_61_merge_plan_files() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let mut config = ImportConfig::new("tests/fixtures/merge/kms.json", "tests/fixtures/gcp/modules.json");
+    config.additional_plan_paths = vec![PathBuf::from("tests/fixtures/merge/kms_storage.json")];
+    config.module_root = PathBuf::from("simulator/gcp");
+    config.options = ImportOptions {
+        dry_run: true,
+        skip_state_check: true,
+        logger: SharedLogger::from_arc(logger.clone()),
+        ..Default::default()
+    };
+
+    let preview = preview_with_runner(&config, &SystemCommandRunner).expect("Preview failed");
+    let mut addresses: Vec<&str> = preview.resources.iter().map(|entry| entry.address.as_str()).collect();
+    addresses.sort();
+    assert_eq!(addresses, [
+        "module.cloud_functions.google_storage_bucket.source",
+        "module.kms.google_kms_crypto_key.example",
+        key_ring,
+    ]);
+    let key_ring_id = preview.resources.iter().find(|entry| entry.address == key_ring).and_then(|entry| entry.import_id.clone());
+    assert_eq!(key_ring_id.as_deref(), Some("projects/sim-project/locations/europe-west1/keyRings/sim-keyring"));
+
+    let warnings: Vec<String> = logger.messages.lock().unwrap().iter()
+        .filter(|(level, _)| *level == LogLevel::Warn)
+        .map(|(_, message)| message.clone())
+        .collect();
+    assert_eq!(warnings, [format!(
+        "{} is in both tests/fixtures/merge/kms.json and tests/fixtures/merge/kms_storage.json with different import IDs \
+         (projects/sim-project/locations/europe-west1/keyRings/sim-keyring vs projects/sim-project/locations/us-central1/keyRings/sim-keyring); \
+         using the one from tests/fixtures/merge/kms.json",
+        key_ring
+    )]);
+
+    let temp_dir = TempDir::new().unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "");
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/merge/*.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--module-root", "simulator/gcp", "--skip-state-check", "--dry-run"])
+        .arg("--working-directory").arg(temp_dir.path())
+        .env("PATH", path)
+        .env("FAKE_TERRAGRUNT_LOG", temp_dir.path().join("terragrunt.log"))
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+    let stderr = String::from_utf8_lossy(&output.stderr);
+    assert!(stderr.contains(&format!("{} is in both", key_ring)), "{}", stderr);
+    let stdout = String::from_utf8_lossy(&output.stdout);
+    assert_eq!(stdout.matches("keyRings/sim-keyring'").count() + stdout.matches("keyRings/sim-keyring\n").count(), 1, "{}", stdout);
+}
+
+/// **TEST** - Mapping file IDs expand environment variables, with defaults
+/// 
+/// `MAPPING_PROJECT` is required by the fixture while `MAPPING_REGION` defaults to
+/// `europe-west1`; without `MAPPING_PROJECT` the run fails before any import.
+#[cfg(unix)]
+#[test]
+fn test_62_mapping_environment_variables() {
+    let run = |project: Option<&str>| {
+        let temp_dir = TempDir::new().unwrap();
+        let path = install_fake_terragrunt(&temp_dir, "");
+        let mut command = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"));
+        command
+            .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+            .args(["--mapping", "tests/fixtures/mappings/env.json", "--module-root", "simulator/gcp", "--skip-state-check", "--dry-run"])
+            .args(["--include", "module.kms.google_kms_key_ring.example", "--include", "module.cloud_functions.google_storage_bucket.source"])
+            .arg("--working-directory").arg(temp_dir.path())
+            .env("PATH", path)
+            .env("FAKE_TERRAGRUNT_LOG", temp_dir.path().join("terragrunt.log"))
+            .env_remove("MAPPING_PROJECT")
+            .env_remove("MAPPING_REGION");
+        if let Some(project) = project {
+            command.env("MAPPING_PROJECT", project);
+        }
+        command.output().expect("Failed to run CLI")
+    };
+
+    let output = run(Some("legacy-project"));
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+    let stdout = String::from_utf8_lossy(&output.stdout);
+    assert!(stdout.contains("projects/legacy-project/locations/europe-west1/keyRings/legacy-ring"), "{}", stdout);
+    assert!(stdout.contains("legacy-project-function-source"), "{}", stdout);
+
+    let output = run(None);
+    assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR));
+    let stderr = String::from_utf8_lossy(&output.stderr);
+    assert!(stderr.contains("environment variable MAPPING_PROJECT is not set"), "{}", stderr);
+    assert!(stderr.contains("tests/fixtures/mappings/env.json"), "{}", stderr);
+}
+
+/// **TEST** - Planned replacements abort the run, or are skipped with a warning
+/// 
+/// The fixture creates a key ring and replaces a bucket whose location changed.
+/// Importing the bucket would adopt the object Terraform is about to delete.
+#[test]
+fn test_63_replacements_are_refused() {
+    let plan = load_plan("tests/fixtures/plan_actions/replace.json").expect("Failed to load plan");
+    assert_eq!(plan.replacements().iter().map(|rc| rc.address.as_str()).collect::<Vec<_>>(), ["module.storage.google_storage_bucket.moved"]);
+    let modules = vec![
+        ModuleMeta { key: "kms".to_string(), source: "./modules/kms".to_string(), dir: "modules/kms".to_string() },
+        ModuleMeta { key: "storage".to_string(), source: "./modules/storage".to_string(), dir: "modules/storage".to_string() },
+    ];
+    let mapping = map_resources_to_modules(&modules, &plan, &StdLogger::default());
+    let builders = ImportIdBuilderRegistry::default();
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let run = |skip_replacements: bool, filter: ResourceFilter| {
+        let options = ImportOptions {
+            dry_run: true,
+            skip_state_check: true,
+            skip_replacements,
+            filter,
+            logger: SharedLogger::from_arc(logger.clone()),
+            ..Default::default()
+        };
+        execute_or_print_imports(&mapping, &plan, &ImportIdMappings::new(), &builders, &options, false, "modules", &SystemCommandRunner)
+    };
+
+    let err = run(false, ResourceFilter::default()).expect_err("The replacement was imported");
+    assert_eq!(
+        err.to_string(),
+        "aborting before any imports: the plan replaces module.storage.google_storage_bucket.moved; \
+         importing would adopt objects Terraform is about to destroy (pass --skip-replacements to skip them)"
+    );
+
+    // Excluding the replacement lets the rest of the plan import as before
+    let kms_only = ResourceFilter::new(&["module.kms.*".to_string()], &[]).unwrap();
+    let report = run(false, kms_only).expect("Import run failed");
+    assert_eq!(report.commands().len(), 1);
+
+    let report = run(true, ResourceFilter::default()).expect("Import run failed");
+    let bucket = report.resources.iter().find(|entry| entry.address == "module.storage.google_storage_bucket.moved").expect("Replacement missing from report");
+    assert_eq!(bucket.status, ImportStatus::Skipped);
+    assert!(bucket.error.as_deref().unwrap_or_default().contains("the plan replaces it"), "{:?}", bucket);
+    assert_eq!(report.commands().len(), 1);
+    assert!(report.commands()[0].contains("module.kms.google_kms_key_ring.example"));
+    let warnings: Vec<String> = logger.messages.lock().unwrap().iter()
+        .filter(|(level, _)| *level == LogLevel::Warn)
+        .map(|(_, message)| message.clone())
+        .collect();
+    assert!(warnings.iter().any(|warning| warning.starts_with("⚠️ Skipping module.storage.google_storage_bucket.moved: the plan replaces it")), "{:?}", warnings);
+}
+
+/// **TEST** - Directory rules route each resource's import to its own working directory
+/// 
+/// The key ring follows a `module.kms` rule, the bucket an exact-address rule from the
+/// mapping file, and the topic, matching no rule, keeps its `modules.json` directory.
+/// The CLI run checks the fake `terragrunt` really runs each import in that directory.
+#[cfg(unix)]
+#[test]
+fn test_64_directory_rules_set_working_directories() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let topic = "module.pubsub.google_pubsub_topic.example";
+
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let directories = DirectoryMap::new(vec![
+        "module.kms=units/kms".parse().unwrap(),
+        format!("{}=units/functions", bucket).parse().unwrap(),
+    ]);
+    let mut mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    directories.apply(&plan, &mut mapping);
+    let filter = ResourceFilter::new(&[key_ring.to_string(), bucket.to_string(), topic.to_string()], &[]).unwrap();
+    let options = ImportOptions { dry_run: true, skip_state_check: true, filter, ..Default::default() };
+    let planned = plan_imports(&mapping, &plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, "live", &SystemCommandRunner)
+        .expect("Planning failed");
+    let directory_of = |address: &str| planned.iter()
+        .find(|entry| entry.address == address)
+        .and_then(|entry| entry.command.as_ref())
+        .map(|command| command.working_directory.clone())
+        .unwrap_or_else(|| panic!("No command for {}", address));
+    assert_eq!(directory_of(key_ring), PathBuf::from("live/units/kms"));
+    assert_eq!(directory_of(bucket), PathBuf::from("live/units/functions"));
+    assert_eq!(directory_of(topic), PathBuf::from("live/modules/pubsub"));
+
+    let temp_dir = TempDir::new().unwrap();
+    let root = temp_dir.path().join("live");
+    for dir in ["units/kms", "units/functions", "modules/pubsub"] {
+        fs::create_dir_all(root.join(dir)).unwrap();
+    }
+    let mapping_path = temp_dir.path().join("mapping.json");
+    fs::write(&mapping_path, json!({"directories": [{"address": bucket, "dir": "units/functions"}]}).to_string()).unwrap();
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let path = install_fake_terragrunt(&temp_dir, "\"import \"*) echo \"$2 $(pwd)\" >> \"$FAKE_TERRAGRUNT_LOG.dirs\" ;;");
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--skip-state-check", "--include", key_ring, "--include", bucket, "--include", topic])
+        .args(["--dir-map", "module.kms=units/kms"])
+        .arg("--mapping").arg(&mapping_path)
+        .arg("--module-root").arg(&root)
+        .arg("--working-directory").arg(temp_dir.path())
+        .env("PATH", path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let root = root.canonicalize().unwrap();
+    let mut dirs: Vec<String> = fs::read_to_string(format!("{}.dirs", log_path.display())).unwrap().lines().map(str::to_string).collect();
+    dirs.sort();
+    assert_eq!(dirs, [
+        format!("{} {}", bucket, root.join("units/functions").display()),
+        format!("{} {}", key_ring, root.join("units/kms").display()),
+        format!("{} {}", topic, root.join("modules/pubsub").display()),
+    ]);
+}
+
+/// **TEST** - A failed import's captured output ends up in the report and the error log
+/// 
+/// The runner fails the key ring import with output on both streams. The report entry's
+/// error is only the exit code line, while its output keeps the end of the combined
+/// output, cut to `max_output_bytes` with a marker; the error log gets the same output.
+#[test]
+fn test_65_failed_import_output_is_reported() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let runner = ImportRunner::new(|_: &str, _: &[String]| RunOutcome::Finished(CommandOutput {
+        exit_code: Some(1),
+        stdout: "Acquiring state lock. This may take a few moments...\n".to_string(),
+        stderr: "Error: key ring not found\n".to_string(),
+    }));
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let mut config = ImportConfig::new("tests/fixtures/gcp/out.json", "tests/fixtures/gcp/modules.json");
+    config.module_root = PathBuf::from("simulator/gcp");
+    config.options = ImportOptions {
+        skip_state_check: true,
+        filter: ResourceFilter::new(&[key_ring.to_string()], &[]).unwrap(),
+        max_output_bytes: Some(40),
+        logger: SharedLogger::from_arc(logger.clone()),
+        ..Default::default()
+    };
+
+    let report = import_with_runner(&config, &runner).expect("Import run failed");
+    assert_eq!(report.exit_code, EXIT_IMPORT_FAILURES);
+    let entry = report.resources.iter()
+        .find(|entry| entry.address == key_ring)
+        .expect("No report entry for the key ring");
+    assert_eq!(entry.status, ImportStatus::Failed);
+    assert_eq!(entry.error.as_deref(), Some("Import failed with exit code 1"));
+    let captured = entry.output.as_deref().expect("No captured output in the report");
+    assert!(captured.starts_with("[... "), "{}", captured);
+    assert!(captured.ends_with("moments...\nError: key ring not found"), "{}", captured);
+
+    let errors: Vec<String> = logger.messages.lock().unwrap().iter()
+        .filter(|(level, _)| *level == LogLevel::Error)
+        .map(|(_, message)| message.clone())
+        .collect();
+    assert_eq!(errors, [format!("{} failed, captured output:\n{}", key_ring, captured)]);
+}
+
+/// **TEST** - The import script is valid bash that runs the imports a run would make
+/// 
+/// `bash -n` checks the syntax. Running the script against a fake `terragrunt` then
+/// shows the indexed API service address arrives as one argument, quotes included,
+/// in the enable_apis module directory.
+#[cfg(unix)]
+#[test]
+fn test_66_emit_import_script() {
+    use std::os::unix::fs::PermissionsExt;
+
+    let service = "module.enable_apis.google_project_service.required_services[\"bigquery.googleapis.com\"]";
+    let temp_dir = TempDir::new().unwrap();
+    let root = temp_dir.path().join("live");
+    fs::create_dir_all(root.join("modules/enable_apis")).unwrap();
+    let script_path = temp_dir.path().join("import.sh");
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--skip-state-check", "--include", "*required_services*bigquery.googleapis.com*"])
+        .arg("--module-root").arg(&root)
+        .arg("--emit-script").arg(&script_path)
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let script = fs::read_to_string(&script_path).unwrap();
+    assert!(script.starts_with("#!/usr/bin/env bash\n"), "{}", script);
+    assert!(script.contains("\nset -euo pipefail\n"), "{}", script);
+    assert!(script.contains(&format!("terragrunt import '{}' ", service)), "{}", script);
+    assert_eq!(fs::metadata(&script_path).unwrap().permissions().mode() & 0o111, 0o111);
+    let syntax = Command::new("bash").arg("-n").arg(&script_path).output().unwrap();
+    assert!(syntax.status.success(), "{}", String::from_utf8_lossy(&syntax.stderr));
+
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let path = install_fake_terragrunt(&temp_dir, "\"import \"*) printf '%s|%s\\n' \"$2\" \"$(pwd)\" >> \"$FAKE_TERRAGRUNT_LOG.args\" ;;");
+    let run = Command::new(&script_path)
+        .env("PATH", path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .unwrap();
+    assert!(run.status.success(), "{}", String::from_utf8_lossy(&run.stderr));
+    let imported = fs::read_to_string(format!("{}.args", log_path.display())).unwrap();
+    assert_eq!(imported, format!("{}|{}\n", service, root.join("modules/enable_apis").canonicalize().unwrap().display()));
+}
+
+/// **TEST** - Template builders from a config file add resource types and replace built-ins
+/// 
+/// The pub/sub types have no built-in builder, and the storage bucket template replaces
+/// the built-in one. `self_link` is only known after apply, so bucket IDs can't be built
+/// from the template; a mapping file ID still takes precedence over it.
+#[test]
+fn test_67_template_builders_from_config() {
+    let topic = "module.pubsub.google_pubsub_topic.example";
+    let subscription = "module.pubsub.google_pubsub_subscription.example";
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let mapped_bucket = "module.storage.google_storage_bucket.example";
+
+    let mut builders = ImportIdBuilderRegistry::default();
+    assert!(!builders.supports("google_pubsub_topic"));
+    let count = load_builders("tests/fixtures/builders/templates.json", &mut builders).expect("Loading builders failed");
+    assert_eq!(count, 3);
+    assert!(builders.supports("google_pubsub_topic"));
+
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let modules_data = fs::read_to_string("tests/fixtures/gcp/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+    let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+    let mappings = ImportIdMappings::from_file(MappingFile {
+        mappings: vec![MappingEntry { address: mapped_bucket.to_string(), id: "mapped/bucket".to_string() }],
+        ..Default::default()
+    })
+    .unwrap();
+    let filter = ResourceFilter::new(&[topic, subscription, bucket, mapped_bucket].map(str::to_string), &[]).unwrap();
+    let options = ImportOptions { dry_run: true, skip_state_check: true, filter, ..Default::default() };
+    let planned = plan_imports(&mapping, &plan, &mappings, &builders, &options, "live", &SystemCommandRunner)
+        .expect("Planning failed");
+    let entry_of = |address: &str| planned.iter()
+        .find(|entry| entry.address == address)
+        .unwrap_or_else(|| panic!("No decision for {}", address));
+
+    assert_eq!(entry_of(topic).import_id.as_deref(), Some("projects/your-gcp-project-id/topics/sim-topic"));
+    assert_eq!(entry_of(subscription).import_id.as_deref(), Some("projects/your-gcp-project-id/subscriptions/sim-subscription"));
+    assert_eq!(entry_of(mapped_bucket).import_id.as_deref(), Some("mapped/bucket"));
+    let bucket = entry_of(bucket);
+    assert_ne!(bucket.decision, ImportDecision::Import);
+    assert!(bucket.reason.as_deref().unwrap_or_default().contains("required attribute 'self_link'"), "{:?}", bucket);
+
+    let error = load_builders("tests/fixtures/builders/invalid.json", &mut ImportIdBuilderRegistry::default()).unwrap_err();
+    assert_eq!(format!("{:#}", error), format!(
+        "Invalid builder for google_pubsub_topic in file: tests/fixtures/builders/invalid.json: unterminated placeholder in template '{}'",
+        "projects/{project/topics/{name}"
+    ));
+}
+
+/// **TEST** - Resources of nested modules are imported at their fully-qualified address
+/// 
+/// `nested/plan.json` is written the way Terraform writes it; `nested/relative.json`
+/// has the same two-level nested module, but with addresses relative to the parent
+/// module. Both give the same import targets and directories, and the parent module's
+/// same-named key ring keeps its own address.
+#[test]
+fn test_68_nested_module_addresses_are_fully_qualified() {
+    let modules_data = fs::read_to_string("tests/fixtures/nested/modules.json").expect("Unable to read modules file");
+    let modules_file: ModulesFile = serde_json::from_str(&modules_data).expect("Invalid modules JSON");
+
+    for fixture in ["tests/fixtures/nested/plan.json", "tests/fixtures/nested/relative.json"] {
+        let plan = load_plan(fixture).expect("Failed to load plan");
+        let mut resources = Vec::new();
+        collect_resources(&plan.planned_values.as_ref().unwrap().root_module, &mut resources);
+        let module_addresses: Vec<(&str, Option<&str>)> = resources.iter()
+            .map(|resource| (resource.address.as_str(), resource.module_address.as_deref()))
+            .collect();
+        assert_eq!(module_addresses, [
+            ("module.platform.google_kms_key_ring.this", Some("module.platform")),
+            ("module.platform.module.kms.google_kms_key_ring.this", Some("module.platform.module.kms")),
+            ("module.platform.module.kms.google_kms_crypto_key.this[\"app\"]", Some("module.platform.module.kms")),
+        ], "{}", fixture);
+
+        let mapping = map_resources_to_modules(&modules_file.modules, &plan, &StdLogger::default());
+        let options = ImportOptions { dry_run: true, skip_state_check: true, ..Default::default() };
+        let planned = plan_imports(&mapping, &plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, "live", &SystemCommandRunner)
+            .expect("Planning failed");
+        let mut targets: Vec<(String, String, PathBuf)> = planned.iter()
+            .filter_map(|entry| entry.command.as_ref())
+            .map(|command| (command.resource_address.clone(), command.resource_id.clone(), command.working_directory.clone()))
+            .collect();
+        targets.sort();
+        assert_eq!(targets, [
+            (
+                "module.platform.google_kms_key_ring.this".to_string(),
+                "projects/nested-project/locations/europe-west1/keyRings/platform".to_string(),
+                PathBuf::from("live/modules/platform"),
+            ),
+            (
+                "module.platform.module.kms.google_kms_crypto_key.this[\"app\"]".to_string(),
+                "projects/nested-project/locations/europe-west1/keyRings/platform-kms/cryptoKeys/app".to_string(),
+                PathBuf::from("live/modules/platform/kms"),
+            ),
+            (
+                "module.platform.module.kms.google_kms_key_ring.this".to_string(),
+                "projects/nested-project/locations/europe-west1/keyRings/platform-kms".to_string(),
+                PathBuf::from("live/modules/platform/kms"),
+            ),
+        ], "{}", fixture);
+    }
+}
+
+/// **TEST** - With --preflight, a working directory without terragrunt.hcl stops the run
+/// 
+/// The kms unit has a `terragrunt.hcl`, but the cloud functions directory is a plain
+/// terraform module. The run aborts with the directory named before terragrunt is run
+/// for anything but the provider schema setup; with --skip-terragrunt-config-check its
+/// `main.tf` is enough.
+#[cfg(unix)]
+#[test]
+fn test_69_preflight_checks_working_directories() {
+    let temp_dir = TempDir::new().unwrap();
+    let root = temp_dir.path().join("live");
+    fs::create_dir_all(root.join("modules/kms")).unwrap();
+    fs::write(root.join("modules/kms/terragrunt.hcl"), "").unwrap();
+    fs::create_dir_all(root.join("modules/cloud_functions")).unwrap();
+    fs::write(root.join("modules/cloud_functions/main.tf"), "").unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "");
+    let log_path = temp_dir.path().join("terragrunt.log");
+
+    let run = |extra_args: &[&str]| Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--skip-state-check", "--preflight"])
+        .args(["--include", "module.kms.google_kms_key_ring.example", "--include", "module.cloud_functions.google_storage_bucket.source"])
+        .args(extra_args)
+        .arg("--module-root").arg(&root)
+        .arg("--working-directory").arg(temp_dir.path())
+        .env("PATH", &path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .expect("Failed to run CLI");
+
+    let output = run(&[]);
+    assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR));
+    let stderr = String::from_utf8_lossy(&output.stderr);
+    assert!(stderr.contains(&format!(
+        "aborting before any imports: working directory {} has no terragrunt.hcl",
+        root.join("modules/cloud_functions").display()
+    )), "{}", stderr);
+    let log = fs::read_to_string(&log_path).unwrap_or_default();
+    assert!(log.lines().all(|line| line == "init" || line.starts_with("providers ")), "terragrunt ran: {}", log);
+
+    let output = run(&["--skip-terragrunt-config-check"]);
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+    let imports = fs::read_to_string(&log_path).unwrap().lines().filter(|line| line.starts_with("import ")).count();
+    assert_eq!(imports, 2);
+}
+
+/// **TEST** - Flags that replace importing are refused with `--plan-dir` instead of ignored
+/// 
+/// Plan sets only import, so `--emit-import-blocks` and `--emit-script` must stop the
+/// run before anything reads or changes state rather than let the units be imported.
+#[cfg(unix)]
+#[test]
+fn test_70_emit_flags_conflict_with_plan_dir() {
+    let temp_dir = TempDir::new().unwrap();
+    let plans = temp_dir.path().join("plans");
+    fs::create_dir_all(plans.join("prod")).unwrap();
+    fs::copy("tests/fixtures/plan_actions/mixed.json", plans.join("prod/storage.json")).unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "");
+    let log_path = temp_dir.path().join("terragrunt.log");
+
+    for flag in ["--emit-import-blocks", "--emit-script"] {
+        let output_path = temp_dir.path().join("emitted");
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .arg("--plan-dir").arg(&plans)
+            .arg("--units-root").arg(temp_dir.path().join("live"))
+            .arg(flag).arg(&output_path)
+            .env("PATH", &path)
+            .env("FAKE_TERRAGRUNT_LOG", &log_path)
+            .output()
+            .expect("Failed to run CLI");
+        assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR), "{}", flag);
+        assert!(String::from_utf8_lossy(&output.stderr).contains("cannot be used with"), "{}", String::from_utf8_lossy(&output.stderr));
+        assert!(!output_path.exists(), "{}", flag);
+        assert!(!log_path.exists(), "terragrunt ran with {}: {}", flag, fs::read_to_string(&log_path).unwrap_or_default());
+    }
+}
+
+/// **TEST** - With `--workspace`, the import script is checked against and imports into that workspace
+/// 
+/// The workspace is selected in the module before its state is pulled for the state
+/// check, and the script selects it again before its import.
+#[cfg(unix)]
+#[test]
+fn test_71_emit_import_script_selects_workspace() {
+    let temp_dir = TempDir::new().unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "\"workspace list\") printf '* default\\n  staging\\n' ;;");
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let script_path = temp_dir.path().join("import.sh");
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--module-root", "simulator/gcp", "--include", "module.kms.google_kms_key_ring.example", "--workspace", "staging"])
+        .arg("--emit-script").arg(&script_path)
+        .env("PATH", path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let log: Vec<String> = fs::read_to_string(&log_path).unwrap().lines().map(str::to_string).collect();
+    let select = log.iter().position(|line| line == "workspace select staging").expect("workspace was not selected");
+    let pull = log.iter().position(|line| line == "state pull").expect("state was not checked");
+    assert!(select < pull, "{:?}", log);
+    assert!(!log.iter().any(|line| line.starts_with("import ")), "{:?}", log);
+
+    let script = fs::read_to_string(&script_path).unwrap();
+    assert!(
+        script.contains("(cd simulator/gcp/modules/kms && terragrunt workspace select staging && terragrunt import module.kms.google_kms_key_ring.example "),
+        "{}",
+        script
+    );
+}
+
+/// **TEST** - With `--verify`, only verified imports are checkpointed
+/// 
+/// The fake `terragrunt`'s plan still replaces the bucket, so its import fails
+/// verification. The checkpoint records the key ring as verified and leaves the bucket
+/// out, so resuming imports the bucket again.
+#[cfg(unix)]
+#[test]
+fn test_72_checkpoint_records_verified_imports_only() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let bucket = "module.cloud_functions.google_storage_bucket.source";
+    let state = json!({"version": 4, "resources": [
+        {"module": "module.kms", "mode": "managed", "type": "google_kms_key_ring", "name": "example", "instances": [{"attributes": {"id": "ring"}}]},
+        {"module": "module.cloud_functions", "mode": "managed", "type": "google_storage_bucket", "name": "source", "instances": [{"attributes": {"id": "bucket"}}]}
+    ]});
+    let checkpoint_dir = TempDir::new().unwrap();
+    let checkpoint_path = checkpoint_dir.path().join("checkpoint.ndjson");
+    let run = |checkpoint_flag: &str| {
+        let temp_dir = TempDir::new().unwrap();
+        let path = install_fake_terragrunt(&temp_dir, &format!(
+            "\"state pull\") echo '{}' ;;\n\"plan -no-color\") echo '  # {} must be replaced' ;;",
+            state, bucket
+        ));
+        let log_path = temp_dir.path().join("terragrunt.log");
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+            .args(["--module-root", "simulator/gcp", "--skip-state-check", "--continue-on-error", "--verify"])
+            .args(["--include", key_ring, "--include", bucket])
+            .arg(checkpoint_flag).arg(&checkpoint_path)
+            .arg("--working-directory").arg(temp_dir.path())
+            .env("PATH", &path)
+            .env("FAKE_TERRAGRUNT_LOG", &log_path)
+            .output()
+            .expect("Failed to run CLI");
+        let imported: Vec<String> = fs::read_to_string(&log_path).unwrap_or_default().lines()
+            .filter(|line| line.starts_with("import "))
+            .map(|line| line.split(' ').nth(1).unwrap().to_string())
+            .collect();
+        (output, imported)
+    };
+
+    let (output, imported) = run("--checkpoint");
+    assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES), "{}", String::from_utf8_lossy(&output.stderr));
+    assert_eq!(imported, vec![bucket, key_ring]);
+    let recorded = Checkpoint::load(&checkpoint_path).unwrap().entries().to_vec();
+    assert_eq!(recorded, vec![CheckpointEntry { address: key_ring.to_string(), status: ImportStatus::Verified }]);
+
+    let (_, imported) = run("--resume");
+    assert_eq!(imported, vec![bucket]);
+}
+
+/// **TEST** - `--state-backup-dir` doesn't stop imports into a module that has no state yet
+/// 
+/// The fake `terragrunt state pull` prints nothing, as for a module adopting its first
+/// resources. The run logs that there is nothing to back up, writes no backup and imports.
+#[cfg(unix)]
+#[test]
+fn test_73_state_backup_of_empty_state_is_skipped() {
+    let key_ring = "module.kms.google_kms_key_ring.example";
+    let temp_dir = TempDir::new().unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "");
+    let log_path = temp_dir.path().join("terragrunt.log");
+    let backup_dir = temp_dir.path().join("backups");
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--module-root", "simulator/gcp", "--include", key_ring])
+        .arg("--state-backup-dir").arg(&backup_dir)
+        .arg("--working-directory").arg(temp_dir.path())
+        .env("PATH", path)
+        .env("FAKE_TERRAGRUNT_LOG", &log_path)
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let stdout = String::from_utf8_lossy(&output.stdout);
+    assert!(stdout.contains("No state to back up in simulator/gcp/modules/kms yet"), "{}", stdout);
+    assert_eq!(fs::read_dir(&backup_dir).map(|entries| entries.count()).unwrap_or(0), 0);
+    let log = fs::read_to_string(&log_path).unwrap();
+    assert!(log.lines().any(|line| line.starts_with(&format!("import {} ", key_ring))), "{}", log);
+}
+
+/// **TEST** - `preview` and `coverage` take `--builders` like an import run does
+/// 
+/// With the template file the pub/sub types count as covered, so `coverage --strict`
+/// no longer lists them, and the preview imports the topic with the template's ID.
+#[test]
+fn test_74_preview_and_coverage_use_template_builders() {
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["coverage", "--plan", "tests/fixtures/gcp/out.json", "--format", "json", "--strict"])
+        .args(["--builders", "tests/fixtures/builders/templates.json"])
+        .output()
+        .expect("Failed to run CLI");
+    let coverage: Value = serde_json::from_slice(&output.stdout).expect("Coverage is not JSON");
+    let has_builder = |resource_type: &str| coverage["resource_types"].as_array().unwrap().iter()
+        .find(|entry| entry["resource_type"] == resource_type)
+        .map(|entry| entry["has_builder"].clone());
+    assert_eq!(has_builder("google_pubsub_topic"), Some(json!(true)));
+    assert_eq!(has_builder("google_pubsub_subscription"), Some(json!(true)));
+    assert!(!String::from_utf8_lossy(&output.stderr).contains("google_pubsub"), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .args(["preview", "--plan", "tests/fixtures/gcp/out.json", "--modules", "tests/fixtures/gcp/modules.json"])
+        .args(["--skip-state-check", "--format", "json", "--include", "module.pubsub.google_pubsub_topic.example"])
+        .args(["--builders", "tests/fixtures/builders/templates.json"])
+        .output()
+        .expect("Failed to run CLI");
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+    let preview: Value = serde_json::from_slice(&output.stdout).expect("Preview is not JSON");
+    let topic = preview["resources"].as_array().unwrap().iter()
+        .find(|entry| entry["address"] == "module.pubsub.google_pubsub_topic.example")
+        .expect("No preview row for the topic");
+    assert_eq!(topic["decision"], "import");
+    assert_eq!(topic["import_id"], "projects/your-gcp-project-id/topics/sim-topic");
+}
+
+/// **TEST** - `--strict` fails a `--plan-dir` run with unsupported resources, as it does a single plan
+/// 
+/// The widget's type has no builder and no attribute to infer an ID from; the same dry
+/// run exits 0 without `--strict`.
+#[test]
+fn test_75_strict_plan_set_fails_on_unsupported_resources() {
+    let temp_dir = TempDir::new().unwrap();
+    let plans = temp_dir.path().join("plans");
+    fs::create_dir_all(plans.join("prod")).unwrap();
+    let plan = json!({
+        "format_version": "1.2",
+        "terraform_version": "1.9.0",
+        "planned_values": {"root_module": {"resources": [
+            {"address": "example_widget.main", "mode": "managed", "type": "example_widget", "name": "main", "values": {"size": 3}}
+        ]}},
+        "resource_changes": [
+            {"address": "example_widget.main", "mode": "managed", "type": "example_widget", "name": "main", "change": {"actions": ["create"]}}
+        ]
+    });
+    fs::write(plans.join("prod/widgets.json"), plan.to_string()).unwrap();
+
+    let run = |extra_args: &[&str]| Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+        .arg("--plan-dir").arg(&plans)
+        .arg("--units-root").arg(temp_dir.path().join("live"))
+        .args(["--dry-run", "--skip-state-check"])
+        .args(extra_args)
+        .output()
+        .expect("Failed to run CLI");
+
+    let output = run(&[]);
+    assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}", String::from_utf8_lossy(&output.stderr));
+
+    let output = run(&["--strict"]);
+    assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES));
+    let stderr = String::from_utf8_lossy(&output.stderr);
+    assert!(stderr.contains("1 resource(s) have no import ID builder (--strict)"), "{}", stderr);
+}
+
+/// **TEST** - Flags that resolve module directories are refused with `--plan-dir` instead of ignored
+/// 
+/// Plan sets run each unit in its directory under `--units-root`, so `--module-root` and
+/// `--dir-map` would silently have no effect.
+#[cfg(unix)]
+#[test]
+fn test_76_directory_flags_conflict_with_plan_dir() {
+    let temp_dir = TempDir::new().unwrap();
+    let plans = temp_dir.path().join("plans");
+    fs::create_dir_all(plans.join("prod")).unwrap();
+    fs::copy("tests/fixtures/plan_actions/mixed.json", plans.join("prod/storage.json")).unwrap();
+    let path = install_fake_terragrunt(&temp_dir, "");
+    let log_path = temp_dir.path().join("terragrunt.log");
+
+    for flags in [["--module-root", "simulator/gcp"], ["--dir-map", "module.kms=units/kms"]] {
+        let output = Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .arg("--plan-dir").arg(&plans)
+            .arg("--units-root").arg(temp_dir.path().join("live"))
+            .args(flags)
+            .env("PATH", &path)
+            .env("FAKE_TERRAGRUNT_LOG", &log_path)
+            .output()
+            .expect("Failed to run CLI");
+        assert_eq!(output.status.code(), Some(EXIT_USAGE_ERROR), "{}", flags[0]);
+        assert!(String::from_utf8_lossy(&output.stderr).contains("cannot be used with"), "{}", String::from_utf8_lossy(&output.stderr));
+        assert!(!log_path.exists(), "terragrunt ran with {}: {}", flags[0], fs::read_to_string(&log_path).unwrap_or_default());
+    }
+}
+
+/// **TEST** - `--strict` fails emitting import blocks or a script when resources have no builder
+/// 
+/// The widget's type has no builder and no attribute to infer an ID from, so it gets no
+/// block or command; the file is still written, but `--strict` must not report success.
+#[test]
+fn test_77_strict_applies_to_emitted_files() {
+    let temp_dir = TempDir::new().unwrap();
+    let plan = json!({
+        "format_version": "1.2",
+        "terraform_version": "1.9.0",
+        "planned_values": {"root_module": {"child_modules": [{"address": "module.widgets", "resources": [
+            {"address": "module.widgets.example_widget.main", "mode": "managed", "type": "example_widget", "name": "main", "values": {"size": 3}}
+        ]}]}},
+        "resource_changes": [
+            {"address": "module.widgets.example_widget.main", "module_address": "module.widgets", "mode": "managed", "type": "example_widget", "name": "main", "change": {"actions": ["create"]}}
+        ]
+    });
+    let plan_path = temp_dir.path().join("plan.json");
+    fs::write(&plan_path, plan.to_string()).unwrap();
+    let modules_path = temp_dir.path().join("modules.json");
+    fs::write(&modules_path, r#"{"Modules":[{"Key":"widgets","Source":"./widgets","Dir":"widgets"}]}"#).unwrap();
+    fs::create_dir(temp_dir.path().join("widgets")).unwrap();
+
+    for flag in ["--emit-import-blocks", "--emit-script"] {
+        let output_path = temp_dir.path().join("emitted");
+        let run = |extra_args: &[&str]| Command::new(env!("CARGO_BIN_EXE_terragrunt_import_from_plan"))
+            .arg("--plan").arg(&plan_path)
+            .arg("--modules").arg(&modules_path)
+            .arg("--module-root").arg(temp_dir.path())
+            .arg("--skip-state-check")
+            .arg(flag).arg(&output_path)
+            .args(extra_args)
+            .output()
+            .expect("Failed to run CLI");
+
+        let output = run(&[]);
+        assert_eq!(output.status.code(), Some(EXIT_SUCCESS), "{}: {}", flag, String::from_utf8_lossy(&output.stderr));
+
+        let output = run(&["--strict"]);
+        assert_eq!(output.status.code(), Some(EXIT_IMPORT_FAILURES), "{}", flag);
+        let stderr = String::from_utf8_lossy(&output.stderr);
+        assert!(stderr.contains("1 resource(s) have no import ID builder (--strict)"), "{}: {}", flag, stderr);
+        assert!(output_path.exists(), "{}", flag);
+    }
+}
+
+/// **TEST** - Mapping, module and inference diagnostics go to the injected logger
+/// 
+/// A stale mapping entry and a module missing from modules.json are warnings; the mapped
+/// ID and ranked inference candidates are debug messages, so they respect the log level.
+#[test]
+fn test_78_diagnostics_go_to_logger() {
+    let plan = load_plan("tests/fixtures/gcp/out.json").expect("Failed to load plan");
+    let logger = RecordingLogger::default();
+
+    let mappings = load_mappings("tests/fixtures/mappings/gcp.json", &plan, &logger).expect("Failed to load mappings");
+    let mapping = map_resources_to_modules(&[], &plan, &logger);
+    assert!(mapping.is_empty());
+    let warnings: Vec<String> = logger.messages.lock().unwrap().iter()
+        .filter(|(level, _)| *level == LogLevel::Warn)
+        .map(|(_, message)| message.clone())
+        .collect();
+    assert!(warnings.contains(&"Mapping 'module.removed.google_pubsub_topic.old' does not match any resource in the plan".to_string()), "{:?}", warnings);
+    assert!(warnings.iter().any(|warning| warning.starts_with("Unmatched module address 'module.kms'")), "{:?}", warnings);
+
+    let bucket = TerraformResource {
+        address: "module.storage.google_storage_bucket.example".to_string(),
+        mode: "managed".to_string(),
+        r#type: "google_storage_bucket".to_string(),
+        name: "example".to_string(),
+        values: Some(json!({"name": "bucket"})),
+    };
+    let widget = TerraformResource {
+        address: "example_widget.main".to_string(),
+        r#type: "example_widget".to_string(),
+        values: Some(json!({"name": "widget"})),
+        ..bucket.clone()
+    };
+    let builders = ImportIdBuilderRegistry::default();
+    assert_eq!(resolve_import_id(&bucket, &mappings, &builders, &logger).unwrap(), "storage-bucket-override");
+    assert_eq!(resolve_import_id(&widget, &mappings, &builders, &logger).unwrap(), "widget");
+    let debug = logger.debug_messages();
+    assert!(debug.contains(&"🗺️ Using mapped import ID for module.storage.google_storage_bucket.example: storage-bucket-override".to_string()), "{:?}", debug);
+    assert!(debug.contains(&"🔍 [example_widget.main] Ranked ID candidates: [\"name\"]".to_string()), "{:?}", debug);
+}
+
+/// **TEST** - A later 1.x format version is read with the 1.2 layout and a warning
+#[test]
+fn test_79_newer_minor_plan_format_version() {
+    assert_eq!(PlanFormatVersion::parse("1.3").unwrap(), PlanFormatVersion::V1_2);
+    assert!(PlanFormatVersion::is_newer_than_known("1.3"));
+    assert!(!PlanFormatVersion::is_newer_than_known("1.2"));
+    assert!(PlanFormatVersion::parse("0.3").is_err());
+    assert!(PlanFormatVersion::parse("1.x").is_err());
+
+    let mut plan_json: Value = serde_json::from_str(&fs::read_to_string("tests/fixtures/plan_formats/v1_2.json").unwrap()).unwrap();
+    plan_json["format_version"] = json!("1.3");
+    let temp_dir = TempDir::new().unwrap();
+    let plan_path = temp_dir.path().join("plan.json");
+    fs::write(&plan_path, plan_json.to_string()).unwrap();
+    let plan = load_plan(&plan_path).expect("Failed to load a 1.3 plan");
+    assert_eq!(plan.resource_change_addresses().len(), 3);
+
+    let logger = std::sync::Arc::new(RecordingLogger::default());
+    let options = ImportOptions {
+        dry_run: true,
+        skip_state_check: true,
+        logger: SharedLogger::from_arc(logger.clone()),
+        ..Default::default()
+    };
+    plan_imports(&HashMap::new(), &plan, &ImportIdMappings::new(), &ImportIdBuilderRegistry::default(), &options, ".", &SystemCommandRunner)
+        .expect("Planning failed");
+    let messages = logger.messages.lock().unwrap();
+    assert!(messages.iter().any(|(level, message)| *level == LogLevel::Warn && message.contains("Plan format version 1.3 is newer than 1.2")), "{:?}", messages);
+}